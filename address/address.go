@@ -0,0 +1,264 @@
+// Package address decodes Bitcoin addresses into the output scripts they stand for: base58check
+// P2PKH and P2SH addresses, bech32 segwit v0 addresses (BIP173) and bech32m addresses of later
+// witness versions, such as taproot (BIP350).
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Address types, named as Bitcoin Core names the scripts they stand for.
+const (
+	TypePubKeyHash        = "pubkeyhash"            // P2PKH
+	TypeScriptHash        = "scripthash"            // P2SH
+	TypeWitnessKeyHash    = "witness_v0_keyhash"    // P2WPKH
+	TypeWitnessScriptHash = "witness_v0_scripthash" // P2WSH
+	TypeTaproot           = "witness_v1_taproot"    // P2TR
+	TypeWitnessUnknown    = "witness_unknown"       // Witness versions without defined semantics
+)
+
+// Network holds the address prefixes of a chain.
+type Network struct {
+	Name       string // Chain name, as returned by 'getblockchaininfo'
+	PubKeyHash byte   // Version byte of P2PKH addresses
+	ScriptHash byte   // Version byte of P2SH addresses
+	HRP        string // Human-readable part of segwit addresses
+}
+
+var (
+	// MainNet holds the address prefixes of the main network.
+	MainNet = Network{Name: "main", PubKeyHash: 0x00, ScriptHash: 0x05, HRP: "bc"}
+
+	// TestNet holds the address prefixes of testnet3, testnet4 and signet.
+	TestNet = Network{Name: "test", PubKeyHash: 0x6f, ScriptHash: 0xc4, HRP: "tb"}
+
+	// RegTest holds the address prefixes of regtest, whose base58 addresses are the same as testnet's.
+	RegTest = Network{Name: "regtest", PubKeyHash: 0x6f, ScriptHash: 0xc4, HRP: "bcrt"}
+)
+
+// Address is a decoded Bitcoin address.
+type Address struct {
+	Address string  // Address, as given
+	Network Network // Network of the address (TestNet for base58 testnet and regtest addresses)
+	Type    string  // Type of the address (see the Type* constants)
+	Script  []byte  // Output script (scriptPubKey) paying to the address
+}
+
+// Decode decodes an address of any network.
+//
+// Parameters:
+// - address (string): A base58check or bech32/bech32m address.
+//
+// Returns:
+// - *Address: The decoded address, with the output script paying to it.
+// - error: An error if the address is malformed or has an invalid checksum.
+//
+// Example:
+//
+//	decoded, err := address.Decode("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+//	fmt.Printf("%x", decoded.Script) // 0014751e76e8199196d454941c45d1b3a323f1433bd6
+func Decode(address string) (*Address, error) {
+	lower := strings.ToLower(address)
+	for _, network := range []Network{MainNet, TestNet, RegTest} {
+		if strings.HasPrefix(lower, network.HRP+"1") {
+			return decodeSegwit(address, network)
+		}
+	}
+	return decodeBase58(address)
+}
+
+// decodeSegwit decodes a bech32 or bech32m segwit address of a network.
+func decodeSegwit(address string, network Network) (*Address, error) {
+	hrp, data, encoding, err := bech32Decode(address)
+	if err != nil {
+		return nil, failure.Of("invalid address '%s': %v", address, err.Error())
+	}
+	if hrp != network.HRP || len(data) == 0 {
+		return nil, failure.Of("invalid address '%s': missing witness version", address)
+	}
+
+	version := data[0]
+	program, ok := convertBits(data[1:], 5, 8, false)
+	switch {
+	case version > 16:
+		return nil, failure.Of("invalid address '%s': invalid witness version %d", address, version)
+	case !ok || len(program) < 2 || len(program) > 40:
+		return nil, failure.Of("invalid address '%s': invalid witness program", address)
+	case version == 0 && len(program) != 20 && len(program) != 32:
+		return nil, failure.Of("invalid address '%s': witness v0 programs must be 20 or 32 bytes", address)
+	case version == 0 && encoding != bech32Const:
+		return nil, failure.Of("invalid address '%s': witness v0 addresses must use bech32", address)
+	case version != 0 && encoding != bech32mConst:
+		return nil, failure.Of("invalid address '%s': witness v%d addresses must use bech32m", address, version)
+	}
+
+	decoded := &Address{Address: address, Network: network, Type: TypeWitnessUnknown}
+	switch {
+	case version == 0 && len(program) == 20:
+		decoded.Type = TypeWitnessKeyHash
+	case version == 0:
+		decoded.Type = TypeWitnessScriptHash
+	case version == 1 && len(program) == 32:
+		decoded.Type = TypeTaproot
+	}
+
+	// OP_0, or OP_1 to OP_16, followed by a push of the program
+	opcode := byte(0x00)
+	if version > 0 {
+		opcode = 0x50 + version
+	}
+	decoded.Script = append([]byte{opcode, byte(len(program))}, program...)
+
+	return decoded, nil
+}
+
+// decodeBase58 decodes a base58check P2PKH or P2SH address.
+func decodeBase58(address string) (*Address, error) {
+	data, ok := base58Decode(address)
+	if !ok || len(data) != 25 {
+		return nil, failure.Of("invalid address '%s': not a base58 or bech32 address", address)
+	}
+
+	payload, checksum := data[:21], data[21:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, failure.Of("invalid address '%s': invalid checksum", address)
+	}
+
+	version, hash := payload[0], payload[1:]
+	for _, network := range []Network{MainNet, TestNet} {
+		switch version {
+		case network.PubKeyHash:
+			script := append([]byte{0x76, 0xa9, 0x14}, hash...)
+			return &Address{Address: address, Network: network, Type: TypePubKeyHash, Script: append(script, 0x88, 0xac)}, nil
+		case network.ScriptHash:
+			script := append([]byte{0xa9, 0x14}, hash...)
+			return &Address{Address: address, Network: network, Type: TypeScriptHash, Script: append(script, 0x87)}, nil
+		}
+	}
+	return nil, failure.Of("invalid address '%s': unknown version byte %d", address, version)
+}
+
+// base58Alphabet is the alphabet of base58 encoding, without 0, O, I and l.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Decode decodes a base58 string, each leading '1' standing for a zero byte.
+func base58Decode(s string) ([]byte, bool) {
+	value := new(big.Int)
+	for _, c := range s {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, false
+		}
+		value.Mul(value, big.NewInt(58))
+		value.Add(value, big.NewInt(int64(digit)))
+	}
+
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	return append(make([]byte, zeros), value.Bytes()...), true
+}
+
+// Checksum constants of bech32 (BIP173) and bech32m (BIP350).
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// bech32Charset is the alphabet of bech32 data.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Decode decodes a bech32 or bech32m string, returning its human-readable part, its 5-bit
+// data without checksum and the checksum constant it's valid for.
+func bech32Decode(s string) (string, []byte, int, error) {
+	if len(s) > 90 {
+		return "", nil, 0, failure.Of("too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, failure.Of("mixed case")
+	}
+	s = strings.ToLower(s)
+
+	separator := strings.LastIndexByte(s, '1')
+	if separator < 1 || separator+7 > len(s) {
+		return "", nil, 0, failure.Of("invalid separator position")
+	}
+
+	hrp := s[:separator]
+	data := []byte{}
+	for _, c := range s[separator+1:] {
+		value := strings.IndexRune(bech32Charset, c)
+		if value < 0 {
+			return "", nil, 0, failure.Of("invalid character '%c'", c)
+		}
+		data = append(data, byte(value))
+	}
+
+	encoding := bech32Polymod(append(bech32ExpandHRP(hrp), data...))
+	if encoding != bech32Const && encoding != bech32mConst {
+		return "", nil, 0, failure.Of("invalid checksum")
+	}
+
+	return hrp, data[:len(data)-6], encoding, nil
+}
+
+// bech32Polymod computes the bech32 checksum polynomial of values.
+func bech32Polymod(values []byte) int {
+	generator := []int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	checksum := 1
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ int(value)
+		for i := range 5 {
+			if (top>>i)&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+// bech32ExpandHRP expands the human-readable part for checksum computation.
+func bech32ExpandHRP(hrp string) []byte {
+	expanded := []byte{}
+	for _, c := range []byte(hrp) {
+		expanded = append(expanded, c>>5)
+	}
+	expanded = append(expanded, 0)
+	for _, c := range []byte(hrp) {
+		expanded = append(expanded, c&31)
+	}
+	return expanded
+}
+
+// convertBits regroups bits of data from groups of from bits to groups of to bits.
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, bool) {
+	accumulator, bits := 0, uint(0)
+	mask := 1<<to - 1
+
+	converted := []byte{}
+	for _, value := range data {
+		if int(value)>>from != 0 {
+			return nil, false
+		}
+		accumulator = accumulator<<from | int(value)
+		bits += from
+		for bits >= to {
+			bits -= to
+			converted = append(converted, byte(accumulator>>bits&mask))
+		}
+	}
+
+	if pad && bits > 0 {
+		converted = append(converted, byte(accumulator<<(to-bits)&mask))
+	} else if !pad && (bits >= from || accumulator<<(to-bits)&mask != 0) {
+		return nil, false
+	}
+	return converted, true
+}
@@ -0,0 +1,61 @@
+package address_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/avila-r/bitclient/address"
+)
+
+func Test_Decode(t *testing.T) {
+	cases := []struct {
+		Address string
+		Network string
+		Type    string
+		Script  string
+	}{
+		{Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", Network: "main", Type: address.TypePubKeyHash, Script: "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac"},
+		{Address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", Network: "main", Type: address.TypeScriptHash, Script: "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87"},
+		{Address: "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", Network: "main", Type: address.TypeWitnessKeyHash, Script: "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{Address: "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", Network: "test", Type: address.TypeWitnessScriptHash, Script: "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{Address: "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", Network: "main", Type: address.TypeWitnessUnknown, Script: "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{Address: "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", Network: "main", Type: address.TypeTaproot, Script: "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			decoded, err := address.Decode(test.Address)
+			if err != nil {
+				t.Fatalf("Failed to decode address: %v", err)
+			}
+			if decoded.Network.Name != test.Network || decoded.Type != test.Type {
+				t.Errorf("Expected a %s address of %s, got a %s address of %s", test.Type, test.Network, decoded.Type, decoded.Network.Name)
+			}
+			if script := hex.EncodeToString(decoded.Script); script != test.Script {
+				t.Errorf("Expected script %s, got %s", test.Script, script)
+			}
+		})
+	}
+}
+
+func Test_DecodeInvalid(t *testing.T) {
+	cases := []struct{ Address string }{
+		{Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb"},                             // Invalid checksum
+		{Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7Div0Na"},                             // Invalid base58 character
+		{Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},                     // Invalid bech32 checksum
+		{Address: "bc1qW508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},                     // Mixed case
+		{Address: "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd"}, // Taproot address with a bech32 checksum
+		{Address: "tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47"}, // Witness v0 address with a bech32m checksum
+		{Address: "bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du"},                          // Witness v2 address with a bech32 checksum
+		{Address: ""},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			if _, err := address.Decode(test.Address); err == nil {
+				t.Errorf("Expected address %q to be rejected", test.Address)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Package banlist moves the ban lists of nodes around: it exports them as JSON or CSV, and imports
+// or synchronizes them by applying the difference between two lists, preserving expiry times.
+package banlist
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/network"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// Entry is a banned IP or subnet, as listed by 'listbanned'.
+type Entry struct {
+	Address     string `json:"address"`      // Banned subnet (e.g. "192.0.2.33/32")
+	BanCreated  int64  `json:"ban_created"`  // UNIX time the ban was created at
+	BannedUntil int64  `json:"banned_until"` // UNIX time the ban expires at
+}
+
+// Operation is a change to a ban list.
+type Operation string
+
+const (
+	OperationAdd    Operation = "+" // Ban a subnet
+	OperationUpdate Operation = "~" // Change the expiry time of a ban
+	OperationRemove Operation = "-" // Unban a subnet
+)
+
+// Change is a change applied to a ban list to make it match another one.
+type Change struct {
+	Operation Operation
+	Entry     Entry // Ban to apply, or to remove
+	Previous  int64 // Expiry time of the ban being updated
+}
+
+// String formats a change as a line of a diff, e.g. "+ 192.0.2.33/32 until 2024-01-01T00:00:00Z".
+func (c Change) String() string {
+	until := func(t int64) string { return time.Unix(t, 0).UTC().Format(time.RFC3339) }
+
+	switch c.Operation {
+	case OperationUpdate:
+		return fmt.Sprintf("%s %s until %s (was %s)", c.Operation, c.Entry.Address, until(c.Entry.BannedUntil), until(c.Previous))
+	case OperationRemove:
+		return fmt.Sprintf("%s %s", c.Operation, c.Entry.Address)
+	}
+	return fmt.Sprintf("%s %s until %s", c.Operation, c.Entry.Address, until(c.Entry.BannedUntil))
+}
+
+// List retrieves the ban list of the node a client is connected to, sorted by address.
+//
+// Parameters:
+// - client (*rpc.RPCClient): The client of the node.
+//
+// Returns:
+// - []Entry: The banned subnets.
+// - error: An error if the list can't be retrieved.
+func List(client *rpc.RPCClient) ([]Entry, error) {
+	if client == nil {
+		return nil, failure.Of("no rpc client is configured")
+	}
+
+	request := rpc.Request{
+		ID:      rpc.Identifier,
+		Version: rpc.Version2,
+		Method:  network.MethodListBanned,
+		Params:  rpc.NoParams,
+	}
+	response, err := rpc.ArrayResult(client.Do(request))
+	if err != nil {
+		return nil, failure.Of("failed to list banned subnets: %v", err.Error())
+	}
+
+	entries := []Entry{}
+	if err := response.Bind(&entries); err != nil {
+		return nil, failure.Of("failed to read banned subnets: %v", err.Error())
+	}
+
+	sort(entries)
+	return entries, nil
+}
+
+// Diff returns the changes making a target ban list match a source one: the source's bans missing
+// from the target are added, and the ones expiring later are extended. Only when prune is set are
+// the target's bans shortened to the source's expiry times, and the ones missing from the source
+// removed. Bans expired at now are ignored.
+//
+// Parameters:
+// - source ([]Entry): The ban list to match.
+// - target ([]Entry): The ban list to change.
+// - now (time.Time): The time expiry times are compared to.
+// - prune (bool): Whether bans missing from the source are removed, and longer ones shortened.
+//
+// Returns:
+// - []Change: The changes, sorted by address.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient network blacklist sync --from node1 --to node2 --dry-run
+//
+//   - Using Go:
+//     changes := banlist.Diff(source, target, time.Now(), false)
+func Diff(source, target []Entry, now time.Time, prune bool) []Change {
+	current := map[string]Entry{}
+	for _, entry := range target {
+		current[normalize(entry.Address)] = entry
+	}
+
+	changes := []Change{}
+	wanted := map[string]bool{}
+	for _, entry := range source {
+		if entry.BannedUntil <= now.Unix() {
+			continue
+		}
+		address := normalize(entry.Address)
+		entry.Address = address
+		wanted[address] = true
+
+		existing, ok := current[address]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Operation: OperationAdd, Entry: entry})
+		case entry.BannedUntil > existing.BannedUntil, prune && entry.BannedUntil != existing.BannedUntil:
+			changes = append(changes, Change{Operation: OperationUpdate, Entry: entry, Previous: existing.BannedUntil})
+		}
+	}
+
+	if prune {
+		for address, entry := range current {
+			if !wanted[address] {
+				entry.Address = address
+				changes = append(changes, Change{Operation: OperationRemove, Entry: entry})
+			}
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int { return strings.Compare(a.Entry.Address, b.Entry.Address) })
+	return changes
+}
+
+// Apply applies changes to the ban list of the node a client is connected to, with absolute expiry
+// times. Bitcoin Core can't change the expiry time of a ban, so updated bans are removed, then added
+// again, the previous ban being restored if adding it fails. Every change is reported with the error
+// it failed with, if any, and the next ones are applied.
+//
+// Parameters:
+// - client (*rpc.RPCClient): The client of the node.
+// - changes ([]Change): The changes, as returned by Diff.
+// - report (func(Change, error)): Called with every change applied.
+//
+// Returns:
+// - int: The number of changes that failed.
+func Apply(client *rpc.RPCClient, changes []Change, report func(Change, error)) int {
+	failed := 0
+	for _, change := range changes {
+		var err error
+		switch change.Operation {
+		case OperationAdd:
+			err = add(client, change.Entry)
+		case OperationUpdate:
+			err = update(client, change)
+		case OperationRemove:
+			err = remove(client, change.Entry.Address)
+		default:
+			err = failure.Of("unknown operation %q", change.Operation)
+		}
+
+		if err != nil {
+			failed++
+		}
+		report(change, err)
+	}
+	return failed
+}
+
+// update changes the expiry time of a ban by removing it, then adding it again, restoring the
+// previous ban if adding it fails so that the subnet isn't left unbanned.
+func update(client *rpc.RPCClient, change Change) error {
+	if err := remove(client, change.Entry.Address); err != nil {
+		return err
+	}
+
+	err := add(client, change.Entry)
+	if err == nil {
+		return nil
+	}
+
+	previous := change.Entry
+	previous.BannedUntil = change.Previous
+	if restored := add(client, previous); restored != nil {
+		return failure.Of("%v, and failed to restore the previous ban: %v", err.Error(), restored.Error())
+	}
+	return err
+}
+
+// add bans a subnet until its absolute expiry time.
+func add(client *rpc.RPCClient, entry Entry) error {
+	if err := network.SetBanWith(client, network.Ban{Target: entry.Address, Time: int(entry.BannedUntil), Absolute: true}); err != nil {
+		return failure.Of("failed to ban %s: %v", entry.Address, err.Error())
+	}
+	return nil
+}
+
+// remove unbans a subnet.
+func remove(client *rpc.RPCClient, address string) error {
+	if err := network.UnbanWith(client, address); err != nil {
+		return failure.Of("failed to unban %s: %v", address, err.Error())
+	}
+	return nil
+}
+
+// sort sorts entries by address.
+func sort(entries []Entry) {
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.Address, b.Address) })
+}
@@ -0,0 +1,232 @@
+package banlist_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/banlist"
+	"github.com/avila-r/bitclient/network"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+// now is the time ban lists are compared at.
+var now = time.Unix(1800000000, 0)
+
+func Test_Format(t *testing.T) {
+	entries := []banlist.Entry{
+		{Address: "192.0.2.0/24", BanCreated: 1799990000, BannedUntil: 1800086400},
+		{Address: "2001:db8::1/128", BanCreated: 1799990000, BannedUntil: 1900000000},
+	}
+
+	for _, format := range []banlist.Format{banlist.FormatJSON, banlist.FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var b bytes.Buffer
+			if err := banlist.Write(&b, entries, format); err != nil {
+				t.Fatalf("Failed to write ban list: %v", err)
+			}
+			if format == banlist.FormatCSV && !strings.HasPrefix(b.String(), "address,ban_created,banned_until\n192.0.2.0/24,1799990000,1800086400\n") {
+				t.Errorf("Unexpected CSV ban list: %q", b.String())
+			}
+
+			read, err := banlist.Read(&b)
+			if err != nil {
+				t.Fatalf("Failed to read ban list: %v", err)
+			}
+			if fmt.Sprint(read) != fmt.Sprint(entries) {
+				t.Errorf("Expected ban list %v, got %v", entries, read)
+			}
+		})
+	}
+}
+
+func Test_Read(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected []banlist.Entry
+		Valid    bool
+	}{
+		// Addresses are normalized, and listbanned's output can be imported as is
+		{
+			Input:    ` [{"address":"198.51.100.7","banned_until":1800086400,"ban_duration":86400,"time_remaining":86400},{"address":"192.0.2.1/24","banned_until":1800000001}]`,
+			Expected: []banlist.Entry{{Address: "192.0.2.0/24", BannedUntil: 1800000001}, {Address: "198.51.100.7/32", BannedUntil: 1800086400}},
+			Valid:    true,
+		},
+		// CSV columns are given by the header, and ban_created is optional
+		{
+			Input:    "banned_until,address\n1800086400,2001:db8::1\n",
+			Expected: []banlist.Entry{{Address: "2001:db8::1/128", BannedUntil: 1800086400}},
+			Valid:    true,
+		},
+		{Input: "", Expected: []banlist.Entry{}, Valid: true},
+		{Input: `[{"address":"example.com","banned_until":1800086400}]`, Valid: false},
+		{Input: `[{"address":"192.0.2.1"}]`, Valid: false},
+		{Input: "address\n192.0.2.1\n", Valid: false},
+		{Input: "address,banned_until\n192.0.2.1,tomorrow\n", Valid: false},
+		{Input: `[{"address":`, Valid: false},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			entries, err := banlist.Read(strings.NewReader(test.Input))
+			if (err == nil) != test.Valid {
+				t.Fatalf("Expected ban list to be valid: %v, got error %v", test.Valid, err)
+			}
+			if test.Valid && fmt.Sprint(entries) != fmt.Sprint(test.Expected) {
+				t.Errorf("Expected ban list %v, got %v", test.Expected, entries)
+			}
+		})
+	}
+}
+
+func Test_Diff(t *testing.T) {
+	source := []banlist.Entry{
+		{Address: "192.0.2.1", BannedUntil: 1800086400},       // Missing from the target
+		{Address: "198.51.100.0/24", BannedUntil: 1900000000}, // Expiring later than in the target
+		{Address: "203.0.113.5/32", BannedUntil: 1800086400},  // Same in both
+		{Address: "203.0.113.6/32", BannedUntil: 1700000000},  // Expired
+		{Address: "203.0.113.7/32", BannedUntil: 1800086400},  // Expiring earlier than in the target
+	}
+	target := []banlist.Entry{
+		{Address: "198.51.100.0/24", BannedUntil: 1800086400},
+		{Address: "203.0.113.5/32", BannedUntil: 1800086400},
+		{Address: "203.0.113.7/32", BannedUntil: 1900000000},
+		{Address: "203.0.113.9/32", BannedUntil: 1800086400}, // Missing from the source
+	}
+
+	cases := []struct {
+		Prune    bool
+		Expected []string
+	}{
+		{
+			Prune:    false,
+			Expected: []string{"+ 192.0.2.1/32 until 2027-01-16T08:00:00Z", "~ 198.51.100.0/24 until 2030-03-17T17:46:40Z (was 2027-01-16T08:00:00Z)"},
+		},
+		{
+			Prune:    true,
+			Expected: []string{"+ 192.0.2.1/32 until 2027-01-16T08:00:00Z", "~ 198.51.100.0/24 until 2030-03-17T17:46:40Z (was 2027-01-16T08:00:00Z)", "~ 203.0.113.7/32 until 2027-01-16T08:00:00Z (was 2030-03-17T17:46:40Z)", "- 203.0.113.9/32"},
+		},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			got := []string{}
+			for _, change := range banlist.Diff(source, target, now, test.Prune) {
+				got = append(got, change.String())
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.Expected) {
+				t.Errorf("Expected changes %q, got %q", test.Expected, got)
+			}
+		})
+	}
+}
+
+func Test_Sync(t *testing.T) {
+	from, to := rpctest.NewServer(), rpctest.NewServer()
+	defer from.Close()
+	defer to.Close()
+
+	until := time.Now().Add(48 * time.Hour).Unix()
+	source, target := from.Client(), to.Client()
+	for _, ban := range []rpc.Params{{"192.0.2.1", "add", until, true}, {"198.51.100.0/24", "add", until, true}} {
+		if _, err := source.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: network.MethodSetBan, Params: ban}); err != nil {
+			t.Fatalf("Failed to ban: %v", err)
+		}
+	}
+	if _, err := target.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: network.MethodSetBan, Params: rpc.Params{"198.51.100.0/24", "add", 3600}}); err != nil {
+		t.Fatalf("Failed to ban: %v", err)
+	}
+
+	entries, err := banlist.List(source)
+	if err != nil {
+		t.Fatalf("Failed to list bans: %v", err)
+	}
+	current, err := banlist.List(target)
+	if err != nil {
+		t.Fatalf("Failed to list bans: %v", err)
+	}
+
+	changes := banlist.Diff(entries, current, time.Now(), false)
+	if len(changes) != 2 || changes[0].Operation != banlist.OperationAdd || changes[1].Operation != banlist.OperationUpdate {
+		t.Fatalf("Unexpected changes: %v", changes)
+	}
+
+	reported := 0
+	failed := banlist.Apply(target, changes, func(change banlist.Change, err error) {
+		reported++
+		if err != nil {
+			t.Errorf("Failed to apply %v: %v", change, err)
+		}
+	})
+	if failed != 0 || reported != 2 {
+		t.Errorf("Expected 2 changes applied, got %d reported and %d failed", reported, failed)
+	}
+
+	// Expiry times are preserved
+	synced, _ := banlist.List(target)
+	if fmt.Sprint(addresses(synced)) != fmt.Sprint(addresses(entries)) || synced[0].BannedUntil != until || synced[1].BannedUntil != until {
+		t.Errorf("Expected ban lists to match, got %+v and %+v", entries, synced)
+	}
+	if changes := banlist.Diff(entries, synced, time.Now(), true); len(changes) != 0 {
+		t.Errorf("Expected no change once synchronized, got %v", changes)
+	}
+
+	// Failed changes are counted, and the next ones applied
+	changes = []banlist.Change{
+		{Operation: banlist.OperationRemove, Entry: banlist.Entry{Address: "203.0.113.1/32"}},
+		{Operation: banlist.OperationRemove, Entry: banlist.Entry{Address: "192.0.2.1/32"}},
+	}
+	errors := []error{}
+	if failed := banlist.Apply(target, changes, func(change banlist.Change, err error) { errors = append(errors, err) }); failed != 1 || errors[0] == nil || errors[1] != nil {
+		t.Errorf("Expected only the first change to fail, got %v", errors)
+	}
+
+	to.Close()
+	if _, err := banlist.List(target); err == nil {
+		t.Errorf("Expected listing bans to fail when the node can't be reached")
+	}
+}
+
+func Test_ApplyRestore(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	// The node refuses the new expiry time
+	calls := []string{}
+	server.Handle(network.MethodSetBan, func(params []json.RawMessage) (any, error) {
+		call := fmt.Sprintf("%s %s", params[1], params[0])
+		if len(params) > 2 {
+			call += " " + string(params[2])
+		}
+		calls = append(calls, call)
+		if len(params) > 2 && string(params[2]) == "1900000000" {
+			return nil, &rpctest.Error{Code: rpctest.RPCMiscError, Message: "Error: refused"}
+		}
+		return nil, nil
+	})
+
+	changes := []banlist.Change{{Operation: banlist.OperationUpdate, Entry: banlist.Entry{Address: "192.0.2.1/32", BannedUntil: 1900000000}, Previous: 1800086400}}
+	var failure error
+	if failed := banlist.Apply(server.Client(), changes, func(change banlist.Change, err error) { failure = err }); failed != 1 || failure == nil {
+		t.Fatalf("Expected the update to fail, got %v", failure)
+	}
+
+	// The ban is restored with its previous expiry time
+	expected := []string{`"remove" "192.0.2.1/32"`, `"add" "192.0.2.1/32" 1900000000`, `"add" "192.0.2.1/32" 1800086400`}
+	if fmt.Sprint(calls) != fmt.Sprint(expected) {
+		t.Errorf("Expected calls %q, got %q", expected, calls)
+	}
+}
+
+// addresses returns the addresses of entries.
+func addresses(entries []banlist.Entry) []string {
+	result := []string{}
+	for _, entry := range entries {
+		result = append(result, entry.Address)
+	}
+	return result
+}
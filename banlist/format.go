@@ -0,0 +1,178 @@
+package banlist
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Format is the format ban lists are exported and imported in.
+type Format string
+
+const (
+	FormatJSON Format = "json" // Array of entries, as listed by 'listbanned'
+	FormatCSV  Format = "csv"  // Header, then one line per entry: address,ban_created,banned_until
+)
+
+// header is the header of CSV ban lists.
+var header = []string{"address", "ban_created", "banned_until"}
+
+// ParseFormat parses a format name: json or csv, case-insensitively.
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case FormatJSON, FormatCSV:
+		return format, nil
+	}
+	return FormatJSON, failure.Of("invalid format %q: must be json or csv", value)
+}
+
+// Write writes a ban list in a format.
+//
+// Parameters:
+// - w (io.Writer): Where the list is written.
+// - entries ([]Entry): The banned subnets.
+// - format (Format): The format of the list.
+//
+// Returns:
+// - error: An error if the list can't be written.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient network blacklist export --format csv > bans.csv
+//
+//   - Using Go:
+//     err := banlist.Write(os.Stdout, entries, banlist.FormatCSV)
+func Write(w io.Writer, entries []Entry, format Format) error {
+	if format == FormatCSV {
+		writer := csv.NewWriter(w)
+		_ = writer.Write(header)
+		for _, entry := range entries {
+			_ = writer.Write([]string{entry.Address, strconv.FormatInt(entry.BanCreated, 10), strconv.FormatInt(entry.BannedUntil, 10)})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return failure.Of("failed to write ban list: %v", err.Error())
+		}
+		return nil
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(entries); err != nil {
+		return failure.Of("failed to write ban list: %v", err.Error())
+	}
+	return nil
+}
+
+// Read reads a ban list written by Write, in JSON or CSV, which is detected from its first character.
+// Addresses are validated and normalized to subnets (e.g. "192.0.2.33" to "192.0.2.33/32").
+//
+// Parameters:
+// - r (io.Reader): Where the list is read from.
+//
+// Returns:
+// - []Entry: The banned subnets, sorted by address.
+// - error: An error if the list is malformed.
+func Read(r io.Reader) ([]Entry, error) {
+	reader := bufio.NewReader(r)
+	entries := []Entry{}
+
+	first, err := firstRune(reader)
+	switch {
+	case err == io.EOF:
+		return entries, nil
+	case err != nil:
+		return nil, failure.Of("failed to read ban list: %v", err.Error())
+	case first == '[':
+		if err := json.NewDecoder(reader).Decode(&entries); err != nil {
+			return nil, failure.Of("failed to parse ban list as JSON: %v", err.Error())
+		}
+	default:
+		if entries, err = readCSV(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, entry := range entries {
+		if entries[i].Address = normalize(entry.Address); entries[i].Address == "" {
+			return nil, failure.Of("invalid banned subnet %q", entry.Address)
+		}
+		if entry.BannedUntil <= 0 {
+			return nil, failure.Of("missing expiry time of banned subnet %q", entry.Address)
+		}
+	}
+
+	sort(entries)
+	return entries, nil
+}
+
+// readCSV reads the entries of a CSV ban list, whose columns are given by its header.
+func readCSV(r io.Reader) ([]Entry, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, failure.Of("failed to parse ban list as CSV: %v", err.Error())
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"address", "banned_until"} {
+		if _, ok := columns[name]; !ok {
+			return nil, failure.Of("missing %q column in CSV ban list", name)
+		}
+	}
+
+	entries := []Entry{}
+	for line, record := range records[1:] {
+		entry := Entry{Address: strings.TrimSpace(record[columns["address"]])}
+
+		fields := map[string]*int64{"banned_until": &entry.BannedUntil, "ban_created": &entry.BanCreated}
+		for name, field := range fields {
+			i, ok := columns[name]
+			if !ok || strings.TrimSpace(record[i]) == "" {
+				continue
+			}
+			if *field, err = strconv.ParseInt(strings.TrimSpace(record[i]), 10, 64); err != nil {
+				return nil, failure.Of("invalid %s on line %d: %v", name, line+2, err.Error())
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// firstRune returns the first non-space character of a reader, without consuming it.
+func firstRune(r *bufio.Reader) (rune, error) {
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if !strings.ContainsRune(" \t\r\n\ufeff", c) {
+			return c, r.UnreadRune()
+		}
+	}
+}
+
+// normalize returns the subnet of an IP or subnet, as Bitcoin Core lists them (e.g. "192.0.2.33/32"),
+// or "" if it's neither.
+func normalize(address string) string {
+	if ip := net.ParseIP(address); ip != nil {
+		if ip.To4() != nil {
+			return ip.String() + "/32"
+		}
+		return ip.String() + "/128"
+	}
+	if _, subnet, err := net.ParseCIDR(address); err == nil {
+		return subnet.String()
+	}
+	return ""
+}
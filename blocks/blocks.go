@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/filters"
 	"github.com/avila-r/bitclient/rpc"
 )
 
@@ -114,6 +115,7 @@ func IsBlockHashInvalid(blockhash string) bool {
 // - The verbosity level is validated using `VerbosityFrom` to ensure it is within the range 0–3.
 // - Ensure the RPC client is properly configured and connected to the Bitcoin node before calling this function.
 // - The node must be synchronized with the blockchain to provide accurate block information.
+// - When REST is set, the block is read through the REST interface; verbosity 2 then includes previous outputs, like verbosity 3.
 //
 // Verbosity Levels:
 // - VerbositySerializedHexData (0): Serialized, hex-encoded block data.
@@ -136,6 +138,10 @@ func GetBlock(block string, verbosity int) (*rpc.Response, error) {
 		return nil, err
 	}
 
+	if REST != nil {
+		return restBlock(block, verbosity)
+	}
+
 	request := rpc.Request{
 		ID:      rpc.Identifier,
 		Version: rpc.Version2,
@@ -150,11 +156,13 @@ func GetBlock(block string, verbosity int) (*rpc.Response, error) {
 //
 // This function sends a JSON-RPC request to the Bitcoin client using the "getblockfilter" procedure call.
 // The response contains the filter and filter header, which are hex-encoded. Compact block filters
-// are used for light client applications and can be extended using the "filtertype" parameter.
+// are used for light client applications; the filter type defaults to "basic".
 //
 // Parameters:
 //   - block (string or numeric, required): The block hash or height of the target block.
 //     The function accepts either a block hash (64-character hex string) or a numeric block height.
+//   - filtertype (string, optional): The type of the filter. Defaults to "basic", the only type
+//     defined by BIP 158.
 //
 // Returns:
 // - *rpc.Json: The JSON-RPC response containing the compact block filter and header.
@@ -164,6 +172,7 @@ func GetBlock(block string, verbosity int) (*rpc.Response, error) {
 //   - Ensure that compact block filters are enabled on the Bitcoin node by starting it with the
 //     `-blockfilterindex=basic` or `-blockfilterindex` flag.
 //   - A warning message will be included in the response if the block filter index is not active.
+//   - Use GetDecodedBlockFilter to decode the filter and match scripts against it locally.
 //
 // Example Usage:
 //
@@ -206,7 +215,7 @@ func GetBlock(block string, verbosity int) (*rpc.Response, error) {
 //	  "filter": "0123456789abcdef",
 //	  "header": "fedcba9876543210"
 //	}
-func GetBlockFilter(block string) (*rpc.Json, error) {
+func GetBlockFilter(block string, filtertype ...string) (*rpc.Json, error) {
 	if IsBlockHashInvalid(block) {
 		height, _ := strconv.Atoi(block)
 		hash, err := GetBlockHash(height)
@@ -217,11 +226,16 @@ func GetBlockFilter(block string) (*rpc.Json, error) {
 		}
 	}
 
+	t := filters.Basic.Name
+	if len(filtertype) > 0 && filtertype[0] != "" {
+		t = filtertype[0]
+	}
+
 	request := rpc.Request{
 		ID:      rpc.Identifier,
 		Version: rpc.Version2,
 		Method:  MethodGetBlockFilter,
-		Params:  rpc.Params{block, "extended"},
+		Params:  rpc.Params{block, t},
 	}
 
 	result, err := rpc.Client.Do(request)
@@ -273,6 +287,7 @@ func GetBlockFilter(block string) (*rpc.Json, error) {
 // Notes:
 // - The `height` parameter must be a non-negative integer representing the block's position in the blockchain.
 // - The genesis block is at height 0.
+// - When REST is set, the hash is read through the REST interface ('/rest/blockhashbyheight/').
 //
 // Result:
 // - `hex` (string): The block hash at the specified height, encoded as a hex string.
@@ -285,6 +300,10 @@ func GetBlockFilter(block string) (*rpc.Json, error) {
 //	  "id": "curltest"
 //	}
 func GetBlockHash(height int) (string, error) {
+	if REST != nil {
+		return restBlockHash(height)
+	}
+
 	request := rpc.Request{
 		ID:      rpc.Identifier,
 		Version: rpc.Version2,
@@ -323,6 +342,7 @@ func GetBlockHash(height int) (string, error) {
 //
 // Notes:
 // - The `blockhash` parameter must be a valid 64-character hex string representing the block hash.
+// - When REST is set, the header is read through the REST interface, serving blocks of the active chain only.
 // - The verbosity parameter, if provided, determines the level of detail in the response:
 //   - `true`: Returns a JSON object with block header details.
 //   - `false`: Returns hex-encoded block header data (default is `true`).
@@ -389,6 +409,10 @@ func GetBlockHeader(block string, verbose ...bool) (*rpc.Response, error) {
 		verbosity = verbose[0]
 	}
 
+	if REST != nil {
+		return restBlockHeader(block, verbosity)
+	}
+
 	request := rpc.Request{
 		ID:      rpc.Identifier,
 		Version: rpc.Version2,
@@ -2,28 +2,25 @@ package blocks_test
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
-	"github.com/avila-r/env"
-
 	"github.com/avila-r/bitclient/blocks"
-	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
 )
 
-var (
-	RequiredEnvs = []string{
-		"RPC_URL",
-		"RPC_AUTH_TYPE",
-		"RPC_AUTH_LABEL",
-	}
-)
+// server is the fake bitcoind every test runs against.
+var server *rpctest.Server
 
-func init() {
-	for _, key := range RequiredEnvs {
-		if env.Get(key) == "" {
-			logger.Fatalf("Client isn't available. %v variables must be provided", RequiredEnvs)
-		}
-	}
+func TestMain(m *testing.M) {
+	server = rpctest.NewServer()
+	rpc.Client = server.Client()
+
+	code := m.Run()
+
+	server.Close()
+	os.Exit(code)
 }
 
 func Test_GetBlockchainInfo(t *testing.T) {
@@ -90,7 +87,7 @@ func Test_GetBlock(t *testing.T) {
 		{Verbosity: 5, ExpectSuccess: false},
 	}
 
-	blockhash := "00000000c937983704a73af28acdec37b049d214adbda81d7e2a3dd146f6ed09"
+	blockhash := server.Chain.Block(100).Hash
 	for i, test := range tests {
 		t.Run(fmt.Sprintf("case_%d_verbosity_%d", i, test.Verbosity), func(t *testing.T) {
 			result, err := blocks.GetBlock(blockhash, test.Verbosity)
@@ -117,24 +114,38 @@ func Test_GetBlock(t *testing.T) {
 }
 
 func Test_GetBlockFilter(t *testing.T) {
-	blockhash := "00000000c937983704a73af28acdec37b049d214adbda81d7e2a3dd146f6ed09"
+	blockhash := server.Chain.Block(100).Hash
 
-	_, err := blocks.GetBlockFilter(blockhash)
+	result, err := blocks.GetBlockFilter(blockhash)
 	if err != nil {
-		t.Logf("Non-critical failure: failed to get block filter - %v", err)
-		// t.Errorf("Failed to get block filter - %v", err)
+		t.Fatalf("Failed to get block filter - %v", err)
+	}
+	if _, ok := (*result)["filter"].(string); !ok {
+		t.Errorf("Expected a hex-encoded filter, got %v", result)
+	}
+
+	if _, err := blocks.GetBlockFilter(blockhash, "extended"); err == nil {
+		t.Errorf("Expected an unknown filter type to be rejected")
 	}
 }
 
 func Test_GetBlockHash(t *testing.T) {
-	height := 1000
-	if _, err := blocks.GetBlockHash(height); err != nil {
+	height := 100
+	hash, err := blocks.GetBlockHash(height)
+	if err != nil {
 		t.Errorf("Failed to get block hash: %v", err)
 	}
+	if expected := server.Chain.Block(height).Hash; hash != expected {
+		t.Errorf("Expected block hash %s, got %s", expected, hash)
+	}
+
+	if _, err := blocks.GetBlockHash(server.Chain.Height() + 1); err == nil {
+		t.Errorf("Expected failure for a height above the tip")
+	}
 }
 
 func Test_GetBlockHeader(t *testing.T) {
-	blockhash := "00000000c937983704a73af28acdec37b049d214adbda81d7e2a3dd146f6ed09"
+	blockhash := server.Chain.Block(100).Hash
 
 	verbose, err := blocks.GetBlockHeader(blockhash)
 	if err != nil {
@@ -156,10 +167,42 @@ func Test_GetBlockHeader(t *testing.T) {
 }
 
 func Test_GetBlockStats(t *testing.T) {
-	blockhash := "00000000c937983704a73af28acdec37b049d214adbda81d7e2a3dd146f6ed09"
+	blockhash := server.Chain.Block(100).Hash
 
 	_, err := blocks.GetBlockStats(blockhash)
 	if err != nil {
 		t.Errorf("Failed to get block stats: %v", err)
 	}
 }
+
+func Test_GetDecodedBlock(t *testing.T) {
+	expected := server.Chain.Block(150)
+
+	cases := []struct{ Block string }{
+		{Block: expected.Hash},
+		{Block: "150"},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			block, err := blocks.GetDecodedBlock(test.Block)
+			if err != nil {
+				t.Fatalf("Failed to get decoded block: %v", err)
+			}
+			if block.Hash().String() != expected.Hash {
+				t.Errorf("Expected block %s, got %s", expected.Hash, block.Hash())
+			}
+			if block.Transactions[0].TxID().String() != expected.TxIDs[0] {
+				t.Errorf("Expected coinbase %s, got %s", expected.TxIDs[0], block.Transactions[0].TxID())
+			}
+
+			header, err := blocks.GetDecodedBlockHeader(test.Block)
+			if err != nil {
+				t.Fatalf("Failed to get decoded block header: %v", err)
+			}
+			if header.Hash().String() != expected.Hash {
+				t.Errorf("Expected block header %s, got %s", expected.Hash, header.Hash())
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package blocks
+
+import (
+	"strings"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// GetDecodedBlock retrieves a block in its compact serialized form and decodes it client-side.
+//
+// This function requests the block with VerbositySerializedHexData, which is much smaller than the
+// JSON verbosity levels, decodes it through the wire package and verifies that its header commits to
+// its transactions: the merkle root (and, for segwit blocks, the witness commitment) is computed locally.
+//
+// Parameters:
+//   - block (string or numeric, required): The block hash or height of the target block.
+//
+// Returns:
+// - *wire.Block: The decoded block, with txids, wtxids and the block hash computable locally.
+// - error: An error if the request fails, the block can't be decoded or its merkle root doesn't match.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks get 00000000c937983704a73af28acdec37b049d214adbda81d7e2a3dd146f6ed09 --decode
+//
+//   - Using Go:
+//     block, err := blocks.GetDecodedBlock("840000")
+//     for _, tx := range block.Transactions {
+//     fmt.Println(tx.TxID(), tx.VSize())
+//     }
+func GetDecodedBlock(block string) (*wire.Block, error) {
+	response, err := GetBlock(block, int(VerbositySerializedHexData))
+	if err != nil {
+		return nil, err
+	}
+
+	data := ""
+	if err := response.Bind(&data); err != nil {
+		return nil, failure.Of("failed to parse block hex: %v", err.Error())
+	}
+
+	decoded, err := wire.DecodeBlockHex(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsBlockHashInvalid(block) && !strings.EqualFold(decoded.Hash().String(), block) {
+		return nil, failure.Of("node returned block %s instead of %s", decoded.Hash(), block)
+	}
+
+	if err := decoded.CheckMerkleRoot(); err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
+
+// GetDecodedBlockHeader retrieves a block header in its serialized form and decodes it client-side.
+//
+// Parameters:
+//   - block (string or numeric, required): The block hash or height of the target block.
+//
+// Returns:
+// - *wire.Header: The decoded header, whose hash is computed locally.
+// - error: An error if the request fails, the header can't be decoded or doesn't hash to the requested block.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks header 00000000c937983704a73af28acdec37b049d214adbda81d7e2a3dd146f6ed09 --decode
+func GetDecodedBlockHeader(block string) (*wire.Header, error) {
+	response, err := GetBlockHeader(block, false)
+	if err != nil {
+		return nil, err
+	}
+
+	data := ""
+	if err := response.Bind(&data); err != nil {
+		return nil, failure.Of("failed to parse block header hex: %v", err.Error())
+	}
+
+	decoded, err := wire.DecodeHeaderHex(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsBlockHashInvalid(block) && !strings.EqualFold(decoded.Hash().String(), block) {
+		return nil, failure.Of("node returned block header %s instead of %s", decoded.Hash(), block)
+	}
+
+	return decoded, nil
+}
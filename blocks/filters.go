@@ -0,0 +1,166 @@
+package blocks
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/filters"
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// RuleFilterHeader is the rule checked by VerifyFilterHeaders: a filter header must commit to its
+// filter and to the previous block's filter header.
+const RuleFilterHeader = "bad-filter-header"
+
+// GetDecodedBlockFilter retrieves a block's basic compact block filter and decodes it client-side.
+//
+// The filter is keyed by the block hash, so that scripts can be matched against it locally
+// through Match or MatchAny, without downloading the block.
+//
+// Parameters:
+//   - block (string or numeric, required): The block hash or height of the target block.
+//
+// Returns:
+// - *filters.Filter: The decoded filter.
+// - wire.Hash: The filter header returned by the node.
+// - error: An error if the request fails or the filter can't be decoded.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks filter 840000 --script 0014751e76e8199196d454941c45d1b3a323f1433bd6
+//
+//   - Using Go:
+//     filter, _, err := blocks.GetDecodedBlockFilter("840000")
+//     if filter.Match(script) {
+//     block, err := blocks.GetDecodedBlock("840000")
+//     }
+func GetDecodedBlockFilter(block string) (*filters.Filter, wire.Hash, error) {
+	if IsBlockHashInvalid(block) {
+		height, err := strconv.Atoi(block)
+		if err != nil {
+			return nil, wire.Hash{}, failure.Of("block must be a valid block hash or a numeric height")
+		}
+		if block, err = GetBlockHash(height); err != nil {
+			return nil, wire.Hash{}, err
+		}
+	}
+
+	hash, err := wire.HashFrom(block)
+	if err != nil {
+		return nil, wire.Hash{}, err
+	}
+
+	response, err := GetBlockFilter(block, filters.Basic.Name)
+	if err != nil {
+		return nil, wire.Hash{}, err
+	}
+
+	filterhex, _ := (*response)["filter"].(string)
+	data, err := hex.DecodeString(filterhex)
+	if err != nil {
+		return nil, wire.Hash{}, failure.Of("failed to parse block filter hex: %v", err.Error())
+	}
+
+	headerhex, _ := (*response)["header"].(string)
+	header, err := wire.HashFrom(headerhex)
+	if err != nil {
+		return nil, wire.Hash{}, failure.Of("failed to parse block filter header: %v", err.Error())
+	}
+
+	filter, err := filters.New(filters.Basic, hash, data)
+	if err != nil {
+		return nil, wire.Hash{}, err
+	}
+
+	return filter, header, nil
+}
+
+// MatchBlockFilter reports whether any of the scripts may be involved in a block, created by
+// one of its outputs or spent by one of its inputs, according to the block's basic filter.
+//
+// Parameters:
+//   - block (string or numeric, required): The block hash or height of the target block.
+//   - scripts ([][]byte): The scriptPubKeys to match.
+//
+// Returns:
+//   - bool: True if any script matches. False positives happen for about 1 in 784931 scripts,
+//     while a script involved in the block always matches.
+//   - error: An error if the filter can't be retrieved or decoded.
+func MatchBlockFilter(block string, scripts ...[]byte) (bool, error) {
+	filter, _, err := GetDecodedBlockFilter(block)
+	if err != nil {
+		return false, err
+	}
+	return filter.MatchAny(scripts), nil
+}
+
+// VerifyFilterHeaders verifies the chain of basic filter headers of the active chain between two heights.
+//
+// Each filter is fetched through GetDecodedBlockFilter and its header is computed locally, from the
+// filter hash and the previous header. The header below the range (zero for the genesis block) is
+// trusted as the anchor of the chain.
+//
+// Parameters:
+// - from (int): The height of the first filter header to verify.
+// - to (int): The height of the last filter header to verify.
+//
+// Returns:
+//   - error: A *Violation of RuleFilterHeader for the first header that doesn't commit to its filter,
+//     or an error if the heights are invalid or the filters can't be retrieved. Nil when every header is valid.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks verify-filters --from 840000 --to 842000
+//
+//   - Using Go:
+//     err := blocks.VerifyFilterHeaders(840000, 842000)
+//
+// Notes:
+//   - Filters aren't checked against the content of their blocks, only against the header chain.
+func VerifyFilterHeaders(from, to int) error {
+	if from < 0 || to < from {
+		return failure.Of("invalid range %d-%d: heights must be non-negative, with from not above to", from, to)
+	}
+
+	previous := wire.Hash{}
+	if from > 0 {
+		_, header, err := GetDecodedBlockFilter(strconv.Itoa(from - 1))
+		if err != nil {
+			return failure.Of("failed to get block filter at height %d: %v", from-1, err.Error())
+		}
+		previous = header
+	}
+
+	for height := from; height <= to; height++ {
+		hash, err := GetBlockHash(height)
+		if err != nil {
+			return failure.Of("failed to get block hash at height %d: %v", height, err.Error())
+		}
+
+		filter, header, err := GetDecodedBlockFilter(hash)
+		if err != nil {
+			return failure.Of("failed to get block filter at height %d: %v", height, err.Error())
+		}
+
+		if expected := filter.Header(previous); header != expected {
+			return &Violation{
+				Height: height,
+				Hash:   hash,
+				Rule:   RuleFilterHeader,
+				Reason: fmt.Sprintf("filter header %s doesn't match the expected %s", header, expected),
+			}
+		}
+		previous = header
+
+		if (height-from+1)%1000 == 0 {
+			logger.Debugf("verified filter headers %d-%d", from, height)
+		}
+	}
+
+	return nil
+}
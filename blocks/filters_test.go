@@ -0,0 +1,118 @@
+package blocks_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/filters"
+	"github.com/avila-r/bitclient/rpctest"
+	"github.com/avila-r/bitclient/wire"
+)
+
+func Test_GetDecodedBlockFilter(t *testing.T) {
+	expected := server.Chain.Block(150)
+
+	for _, block := range []string{expected.Hash, "150"} {
+		filter, header, err := blocks.GetDecodedBlockFilter(block)
+		if err != nil {
+			t.Fatalf("Failed to get decoded block filter: %v", err)
+		}
+		if filter.Block.String() != expected.Hash {
+			t.Errorf("Expected the filter of block %s, got %s", expected.Hash, filter.Block)
+		}
+		if filter.N() != 1 {
+			t.Errorf("Expected a single element (the OP_TRUE output), got %d", filter.N())
+		}
+		if header.IsZero() {
+			t.Errorf("Expected a filter header")
+		}
+	}
+
+	if _, _, err := blocks.GetDecodedBlockFilter("not a block"); err == nil {
+		t.Errorf("Expected an invalid block to be rejected")
+	}
+}
+
+func Test_MatchBlockFilter(t *testing.T) {
+	// Fixture coinbases pay to OP_TRUE
+	if match, err := blocks.MatchBlockFilter("100", []byte{0x51}); err != nil || !match {
+		t.Errorf("Expected OP_TRUE to match block 100: %v", err)
+	}
+	if match, err := blocks.MatchBlockFilter("100", []byte{0x00, 0x14}, []byte{0x52}); err != nil || match {
+		t.Errorf("Expected other scripts not to match block 100: %v", err)
+	}
+
+	rpctest.Use(t, rpctest.WithoutBlockFilterIndex())
+
+	if _, err := blocks.MatchBlockFilter("100", []byte{0x51}); err == nil {
+		t.Errorf("Expected a failure without the block filter index")
+	}
+}
+
+func Test_VerifyFilterHeaders(t *testing.T) {
+	if err := blocks.VerifyFilterHeaders(0, server.Chain.Height()); err != nil {
+		t.Errorf("Failed to verify fixture filter headers: %v", err)
+	}
+	if err := blocks.VerifyFilterHeaders(120, 150); err != nil {
+		t.Errorf("Failed to verify filter headers from height 120: %v", err)
+	}
+	if err := blocks.VerifyFilterHeaders(150, 120); err == nil {
+		t.Errorf("Expected an inverted range to be rejected")
+	}
+
+	// Serve a filter with an extra element at height 31, keeping the honest headers
+	chain := rpctest.NewChain(40)
+	tampered := chain.Block(31).Hash
+	fake := rpctest.Use(t, rpctest.WithChain(chain))
+
+	served, headers := map[string][]byte{}, map[string]wire.Hash{}
+	header := wire.Hash{}
+	for height := 0; height <= chain.Height(); height++ {
+		block := chain.Block(height)
+		served[block.Hash] = basicFilter(t, block)
+		header = filters.Header(wire.Hash256(served[block.Hash]), header)
+		headers[block.Hash] = header
+	}
+	served[tampered] = filters.Build(filters.Basic, mustHash(t, tampered), [][]byte{{0x51}, {0x52}})
+
+	fake.Handle(blocks.MethodGetBlockFilter, func(params []json.RawMessage) (any, error) {
+		hash := ""
+		if err := json.Unmarshal(params[0], &hash); err != nil {
+			return nil, err
+		}
+		return map[string]any{"filter": hex.EncodeToString(served[hash]), "header": headers[hash].String()}, nil
+	})
+
+	if err := blocks.VerifyFilterHeaders(0, 30); err != nil {
+		t.Errorf("Failed to verify the filter headers below the tampered one: %v", err)
+	}
+	if err := blocks.VerifyFilterHeaders(32, 40); err != nil {
+		t.Errorf("Failed to verify the filter headers above the tampered one: %v", err)
+	}
+
+	violation := &blocks.Violation{}
+	if err := blocks.VerifyFilterHeaders(20, 40); !errors.As(err, &violation) || violation.Rule != blocks.RuleFilterHeader || violation.Height != 31 {
+		t.Errorf("Expected a %s violation at height 31, got %v", blocks.RuleFilterHeader, err)
+	}
+}
+
+// basicFilter builds the basic filter of a fixture block, which spends no outputs.
+func basicFilter(t *testing.T, block *rpctest.Block) []byte {
+	decoded, err := wire.DecodeBlock(block.Serialize())
+	if err != nil {
+		t.Fatalf("Failed to decode block %d: %v", block.Height, err)
+	}
+	return filters.Build(filters.Basic, decoded.Hash(), filters.BasicElements(decoded, nil))
+}
+
+// mustHash parses a block hash.
+func mustHash(t *testing.T, s string) wire.Hash {
+	hash, err := wire.HashFrom(s)
+	if err != nil {
+		t.Fatalf("Failed to parse hash %s: %v", s, err)
+	}
+	return hash
+}
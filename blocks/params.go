@@ -0,0 +1,71 @@
+package blocks
+
+import (
+	"math/big"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// ChainParams holds the consensus parameters needed to verify the headers of a chain,
+// as defined in Bitcoin Core's src/kernel/chainparams.cpp.
+type ChainParams struct {
+	Name               string   // Chain name, as returned by 'getblockchaininfo' (main, test, testnet4, signet, regtest)
+	PowLimit           *big.Int // Easiest proof-of-work target allowed
+	TargetSpacing      int64    // Expected number of seconds between blocks
+	TargetTimespan     int64    // Expected number of seconds of a difficulty adjustment period
+	AllowMinDifficulty bool     // Whether blocks may use the easiest target after 20 minutes without blocks
+	NoRetargeting      bool     // Whether the target never changes at difficulty adjustments
+	EnforceBIP94       bool     // Whether BIP94 applies (retargets from the period's first block, time warp protection)
+}
+
+// Limits of the proof-of-work target of each chain.
+var (
+	mainPowLimit    = limit("00000000ffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	signetPowLimit  = limit("00000377ae000000000000000000000000000000000000000000000000000000")
+	regtestPowLimit = limit("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+)
+
+var (
+	// MainNet holds the consensus parameters of the main network.
+	MainNet = ChainParams{Name: "main", PowLimit: mainPowLimit, TargetSpacing: 600, TargetTimespan: 14 * 24 * 60 * 60}
+
+	// TestNet3 holds the consensus parameters of testnet3, allowing min-difficulty blocks.
+	TestNet3 = ChainParams{Name: "test", PowLimit: mainPowLimit, TargetSpacing: 600, TargetTimespan: 14 * 24 * 60 * 60, AllowMinDifficulty: true}
+
+	// TestNet4 holds the consensus parameters of testnet4, allowing min-difficulty blocks under BIP94 rules.
+	TestNet4 = ChainParams{Name: "testnet4", PowLimit: mainPowLimit, TargetSpacing: 600, TargetTimespan: 14 * 24 * 60 * 60, AllowMinDifficulty: true, EnforceBIP94: true}
+
+	// SigNet holds the consensus parameters of the default signet. Block signatures (the signet challenge) aren't verified.
+	SigNet = ChainParams{Name: "signet", PowLimit: signetPowLimit, TargetSpacing: 600, TargetTimespan: 14 * 24 * 60 * 60}
+
+	// RegTest holds the consensus parameters of regtest, whose target never changes.
+	RegTest = ChainParams{Name: "regtest", PowLimit: regtestPowLimit, TargetSpacing: 600, TargetTimespan: 14 * 24 * 60 * 60, AllowMinDifficulty: true, NoRetargeting: true}
+)
+
+// ParamsFrom returns the consensus parameters of a chain, given its name as returned by 'getblockchaininfo'.
+//
+// Parameters:
+// - chain (string): The chain name: main, test, testnet4, signet or regtest.
+//
+// Returns:
+// - ChainParams: The chain's consensus parameters.
+// - error: An error if the chain is unknown.
+func ParamsFrom(chain string) (ChainParams, error) {
+	for _, params := range []ChainParams{MainNet, TestNet3, TestNet4, SigNet, RegTest} {
+		if params.Name == chain {
+			return params, nil
+		}
+	}
+	return ChainParams{}, failure.Of("unknown chain '%s', valid chains are main, test, testnet4, signet and regtest", chain)
+}
+
+// Interval returns the number of blocks between difficulty adjustments (2016 on every chain).
+func (p ChainParams) Interval() int {
+	return int(p.TargetTimespan / p.TargetSpacing)
+}
+
+// limit parses a hex-encoded proof-of-work limit.
+func limit(s string) *big.Int {
+	value, _ := new(big.Int).SetString(s, 16)
+	return value
+}
@@ -0,0 +1,98 @@
+package blocks
+
+import (
+	"encoding/json"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/rest"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// REST is the client of the node's REST interface. When set, GetBlock, GetBlockHash and GetBlockHeader
+// read through it instead of JSON-RPC, which is much faster for bulk reads.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks get {blockhash} --rest
+//
+//   - Using Go:
+//     blocks.REST, err = rest.FromRPC(rpc.Client)
+var REST *rest.Client
+
+// restBlock retrieves a block through the REST interface, wrapping it like a 'getblock' response.
+//
+// Verbosity 1 is served by '/rest/block/notxdetails/'. Verbosities 2 and 3 are both served by
+// '/rest/block/', which always includes the previous outputs of the transactions' inputs.
+func restBlock(hash string, verbosity int) (*rpc.Response, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch BlockInfoVerbosity(verbosity) {
+	case VerbositySerializedHexData:
+		data, err = REST.Block(hash, rest.FormatHex)
+	case VerbosityBasicBlockInfo:
+		data, err = REST.BlockNoTxDetails(hash, rest.FormatJSON)
+	default:
+		data, err = REST.Block(hash, rest.FormatJSON)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return restResponse(data, verbosity == int(VerbositySerializedHexData))
+}
+
+// restBlockHeader retrieves a block header through the REST interface, wrapping it like a 'getblockheader' response.
+func restBlockHeader(hash string, verbose bool) (*rpc.Response, error) {
+	if !verbose {
+		data, err := REST.Headers(hash, 1, rest.FormatHex)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return nil, failure.Of("block %s isn't part of the active chain", hash)
+		}
+		return restResponse(data, true)
+	}
+
+	data, err := REST.Headers(hash, 1, rest.FormatJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []json.RawMessage{}
+	if err := json.Unmarshal(data, &headers); err != nil {
+		return nil, failure.Of("failed to parse headers: %v", err.Error())
+	}
+	if len(headers) == 0 {
+		return nil, failure.Of("block %s isn't part of the active chain", hash)
+	}
+
+	return restResponse(headers[0], false)
+}
+
+// restBlockHash retrieves the hash of the active chain's block at a height through the REST interface.
+func restBlockHash(height int) (string, error) {
+	data, err := REST.BlockHashByHeight(height, rest.FormatHex)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// restResponse wraps a REST response body into an RPC response, encoding it as string if it's hex.
+func restResponse(data []byte, hex bool) (*rpc.Response, error) {
+	result := json.RawMessage(data)
+	if hex {
+		encoded, err := json.Marshal(string(data))
+		if err != nil {
+			return nil, failure.Of("failed to process result: %v", err.Error())
+		}
+		result = encoded
+	}
+
+	return &rpc.Response{ID: rpc.Identifier, Result: result}, nil
+}
@@ -0,0 +1,79 @@
+package blocks_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/rest"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// result decodes the result of a response, for comparisons regardless of formatting.
+func result(t *testing.T, response *rpc.Response) any {
+	var value any
+	if err := json.Unmarshal(response.Result, &value); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	return value
+}
+
+func Test_REST(t *testing.T) {
+	client, err := rest.FromRPC(rpc.Client)
+	if err != nil {
+		t.Fatalf("Failed to create rest client: %v", err)
+	}
+
+	block := server.Chain.Block(120)
+
+	cases := []struct {
+		Get func() (*rpc.Response, error)
+	}{
+		{Get: func() (*rpc.Response, error) { return blocks.GetBlock(block.Hash, 0) }},
+		{Get: func() (*rpc.Response, error) { return blocks.GetBlock(block.Hash, 1) }},
+		{Get: func() (*rpc.Response, error) { return blocks.GetBlock(block.Hash, 3) }},
+		{Get: func() (*rpc.Response, error) { return blocks.GetBlockHeader(block.Hash) }},
+		{Get: func() (*rpc.Response, error) { return blocks.GetBlockHeader(block.Hash, false) }},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			expected, err := c.Get()
+			if err != nil {
+				t.Fatalf("Failed to get through rpc: %v", err)
+			}
+
+			blocks.REST = client
+			defer func() { blocks.REST = nil }()
+
+			calls, paths := len(server.Requests()), len(server.Paths())
+
+			response, err := c.Get()
+			if err != nil {
+				t.Fatalf("Failed to get through rest: %v", err)
+			}
+
+			if !reflect.DeepEqual(result(t, expected), result(t, response)) {
+				t.Errorf("REST result doesn't match the RPC one:\n%s\n%s", expected.Result, response.Result)
+			}
+			if len(server.Requests()) != calls || len(server.Paths()) == paths {
+				t.Errorf("Expected the request to go through rest only")
+			}
+		})
+	}
+
+	blocks.REST = client
+	defer func() { blocks.REST = nil }()
+
+	hash, err := blocks.GetBlockHash(120)
+	if err != nil || hash != block.Hash {
+		t.Errorf("Expected block hash %s, got %s (%v)", block.Hash, hash, err)
+	}
+
+	// Heights are resolved through rest as well
+	if _, err := blocks.GetBlock("120", 1); err != nil {
+		t.Errorf("Failed to get block by height: %v", err)
+	}
+}
@@ -0,0 +1,398 @@
+package blocks
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/logger"
+)
+
+// MatchType defines whether a match found by Scan pays to or spends from a script.
+type MatchType string
+
+const (
+	// MatchOutput reports an output paying to a scanned script.
+	MatchOutput MatchType = "output"
+
+	// MatchInput reports an input spending an output of a scanned script.
+	MatchInput MatchType = "input"
+)
+
+// Match is an output paying to, or an input spending from, a script looked for by Scan.
+type Match struct {
+	Type     MatchType `json:"type"`               // Whether the transaction pays to or spends from the script
+	Height   int       `json:"height"`             // Height of the block
+	Block    string    `json:"blockhash"`          // Hash of the block
+	TxID     string    `json:"txid"`               // Id of the transaction
+	Index    int       `json:"index"`              // Index of the output (vout) or input (vin) in the transaction
+	Value    int64     `json:"value"`              // Amount paid or spent, in satoshis
+	Script   string    `json:"script"`             // Hex-encoded script matched
+	Outpoint string    `json:"outpoint,omitempty"` // Output spent by an input, as txid:vout
+}
+
+// ScanOptions configures the range, scripts and concurrency of Scan.
+type ScanOptions struct {
+	// Scripts are the output scripts (scriptPubKeys) to look for.
+	Scripts [][]byte
+
+	// From and To are the heights of the first and last blocks to scan. A negative To stands for
+	// the tip, or for the last block of the scan a checkpoint resumes, so that a scan up to the tip
+	// resumes up to the same block however many blocks were mined since.
+	From, To int
+
+	// Workers is the number of blocks checked concurrently (default: 4).
+	Workers int
+
+	// Checkpoint is the path of a file recording the scan's progress. When it exists, the scan
+	// resumes from it; it's removed once the scan completes.
+	Checkpoint string
+
+	// Interval is the number of blocks between checkpoint saves (default: 100).
+	Interval int
+}
+
+// ScanResult summarizes a scan.
+type ScanResult struct {
+	From    int `json:"from"`    // Height of the first block of the scan
+	To      int `json:"to"`      // Height of the last block of the scan
+	Next    int `json:"next"`    // Height of the first block not scanned yet, above To once the scan completes
+	Scanned int `json:"scanned"` // Number of blocks whose filter was checked, including previous runs
+	Fetched int `json:"fetched"` // Number of blocks whose filter matched, fetched in full
+	Matches int `json:"matches"` // Number of matches found
+}
+
+// checkpoint is the progress of a scan, saved as JSON to resume it.
+type checkpoint struct {
+	Scripts []string `json:"scripts"` // Hex-encoded scripts, sorted
+	Updated int64    `json:"updated"` // Time of the last save
+	ScanResult
+}
+
+// scanned is the outcome of scanning a block.
+type scanned struct {
+	height  int
+	fetched bool
+	matches []Match
+	err     error
+}
+
+// Scan looks for outputs paying to, and inputs spending from, any of the given scripts in a range
+// of blocks, using BIP158 compact block filters.
+//
+// Each block's basic filter is retrieved and matched locally; only blocks whose filter matches are
+// fetched in full (with their prevouts, through GetBlock verbosity 3), to find the transactions
+// involving the scripts. Blocks are checked concurrently by a bounded pool of workers, while matches
+// are reported in block order. Progress is saved to the checkpoint file, if any, so that an
+// interrupted scan continues where it stopped when called again with the same options.
+//
+// Parameters:
+// - ctx (context.Context): Interrupts the scan when done, saving its progress.
+// - options (ScanOptions): The scripts, range, concurrency and checkpoint of the scan.
+// - found (func(Match)): Called for each match, in block order.
+//
+// Returns:
+//   - *ScanResult: The progress of the scan, also returned when it's interrupted.
+//   - error: An error if the options are invalid, a block can't be scanned or the context is done.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks scan --address bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4 --from 800000 --to 900000 --checkpoint scan.json
+//
+//   - Using Go:
+//     options := blocks.ScanOptions{Scripts: [][]byte{script}, From: 800000, To: 900000, Checkpoint: "scan.json"}
+//     result, err := blocks.Scan(ctx, options, func(match blocks.Match) {
+//     fmt.Println(match.Type, match.TxID, match.Value)
+//     })
+//
+// Notes:
+//   - Requires bitcoind to run with -blockfilterindex, and Bitcoin Core 23.0 or later for prevouts.
+//   - Matches are reported once: a resumed scan doesn't report the matches found before its checkpoint.
+func Scan(ctx context.Context, options ScanOptions, found func(Match)) (*ScanResult, error) {
+	if options.From < 0 || (options.To >= 0 && options.To < options.From) {
+		return nil, failure.Of("invalid range %d-%d: heights must be non-negative, with from not above to", options.From, options.To)
+	}
+	if len(options.Scripts) == 0 {
+		return nil, failure.Of("at least one script is required")
+	}
+	if options.Workers <= 0 {
+		options.Workers = 4
+	}
+	if options.Interval <= 0 {
+		options.Interval = 100
+	}
+
+	progress := &checkpoint{ScanResult: ScanResult{From: options.From, To: options.To, Next: options.From}}
+	for _, script := range options.Scripts {
+		progress.Scripts = append(progress.Scripts, hex.EncodeToString(script))
+	}
+	slices.Sort(progress.Scripts)
+
+	if err := progress.load(options.Checkpoint); err != nil {
+		return nil, err
+	}
+	if progress.To < 0 {
+		response, err := GetBlockCount()
+		if err != nil {
+			return nil, failure.Of("failed to get the height of the tip: %v", err.Error())
+		}
+		if err := response.Bind(&progress.To); err != nil {
+			return nil, failure.Of("failed to parse the height of the tip: %v", err.Error())
+		}
+		if progress.To < options.From {
+			return nil, failure.Of("invalid range %d-%d: the tip is below the first block", options.From, progress.To)
+		}
+	}
+	options.To = progress.To
+	if progress.Next > options.From {
+		logger.Debugf("resuming scan from height %d", progress.Next)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Workers take heights in order, and report them through results in any order
+	heights, results := make(chan int), make(chan scanned)
+	go func() {
+		defer close(heights)
+		for height := progress.Next; height <= options.To; height++ {
+			select {
+			case heights <- height:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range options.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for height := range heights {
+				select {
+				case results <- scan(height, options.Scripts):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results are buffered until every block below them is reported, and dropped once interrupted
+	pending := map[int]scanned{}
+	var failed error
+	for result := range results {
+		if result.err != nil {
+			if failed == nil {
+				failed = failure.Of("failed to scan block %d: %v", result.height, result.err.Error())
+			}
+			cancel()
+			continue
+		}
+
+		pending[result.height] = result
+		for next, ok := pending[progress.Next]; ok && failed == nil && ctx.Err() == nil; next, ok = pending[progress.Next] {
+			delete(pending, progress.Next)
+			for _, match := range next.matches {
+				found(match)
+			}
+
+			progress.Next++
+			progress.Scanned++
+			progress.Matches += len(next.matches)
+			if next.fetched {
+				progress.Fetched++
+			}
+
+			if progress.Scanned%options.Interval == 0 {
+				if err := progress.save(options.Checkpoint); err != nil {
+					failed = err
+					cancel()
+				}
+			}
+		}
+	}
+
+	if failed == nil && progress.Next <= options.To {
+		failed = ctx.Err()
+	}
+	if failed != nil {
+		if err := progress.save(options.Checkpoint); err != nil {
+			logger.Errorf("failed to save scan checkpoint: %v", err.Error())
+		}
+		return &progress.ScanResult, failed
+	}
+
+	if options.Checkpoint != "" {
+		if err := os.Remove(options.Checkpoint); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return &progress.ScanResult, failure.Of("failed to remove scan checkpoint: %v", err.Error())
+		}
+	}
+	return &progress.ScanResult, nil
+}
+
+// scan matches a block's filter against the scripts, and looks for the transactions involving
+// them if the filter matches.
+func scan(height int, scripts [][]byte) scanned {
+	result := scanned{height: height}
+
+	hash, err := GetBlockHash(height)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	filter, _, err := GetDecodedBlockFilter(hash)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	if !filter.MatchAny(scripts) {
+		return result
+	}
+
+	result.fetched = true
+	result.matches, result.err = matches(height, hash, scripts)
+	return result
+}
+
+// matches fetches a block with its prevouts, returning its outputs paying to and inputs spending from the scripts.
+func matches(height int, hash string, scripts [][]byte) ([]Match, error) {
+	response, err := GetBlock(hash, int(VerbosityFullBlockInfoWithPrevout))
+	if err != nil {
+		return nil, err
+	}
+
+	type script struct {
+		Hex string `json:"hex"`
+	}
+	block := struct {
+		Tx []struct {
+			TxID string `json:"txid"`
+			Vin  []struct {
+				TxID    string `json:"txid"`
+				Vout    int    `json:"vout"`
+				Prevout *struct {
+					Value        float64 `json:"value"`
+					ScriptPubKey script  `json:"scriptPubKey"`
+				} `json:"prevout"`
+			} `json:"vin"`
+			Vout []struct {
+				Value        float64 `json:"value"`
+				N            int     `json:"n"`
+				ScriptPubKey script  `json:"scriptPubKey"`
+			} `json:"vout"`
+		} `json:"tx"`
+	}{}
+	if err := response.Bind(&block); err != nil {
+		return nil, failure.Of("failed to parse block %s: %v", hash, err.Error())
+	}
+
+	involves := func(s script) bool {
+		data, err := hex.DecodeString(s.Hex)
+		return err == nil && slices.ContainsFunc(scripts, func(script []byte) bool { return bytes.Equal(script, data) })
+	}
+	satoshis := func(btc float64) int64 {
+		return int64(math.Round(btc * 1e8))
+	}
+
+	found := []Match{}
+	for _, tx := range block.Tx {
+		for i, input := range tx.Vin {
+			if input.Prevout != nil && involves(input.Prevout.ScriptPubKey) {
+				found = append(found, Match{
+					Type:     MatchInput,
+					Height:   height,
+					Block:    hash,
+					TxID:     tx.TxID,
+					Index:    i,
+					Value:    satoshis(input.Prevout.Value),
+					Script:   input.Prevout.ScriptPubKey.Hex,
+					Outpoint: input.TxID + ":" + strconv.Itoa(input.Vout),
+				})
+			}
+		}
+		for _, output := range tx.Vout {
+			if involves(output.ScriptPubKey) {
+				found = append(found, Match{
+					Type:   MatchOutput,
+					Height: height,
+					Block:  hash,
+					TxID:   tx.TxID,
+					Index:  output.N,
+					Value:  satoshis(output.Value),
+					Script: output.ScriptPubKey.Hex,
+				})
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// load reads a scan's progress from a checkpoint file, if it exists. A scan up to the tip resumes
+// up to the last block of the checkpoint.
+func (c *checkpoint) load(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return failure.Of("failed to read scan checkpoint: %v", err.Error())
+	}
+
+	saved := checkpoint{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return failure.Of("failed to parse scan checkpoint %s: %v", path, err.Error())
+	}
+	if !slices.Equal(saved.Scripts, c.Scripts) || saved.From != c.From || (c.To >= 0 && saved.To != c.To) {
+		return failure.Of("checkpoint %s belongs to another scan (heights %d-%d), remove it to start over", path, saved.From, saved.To)
+	}
+	if saved.Next < saved.From || saved.Next > saved.To+1 {
+		return failure.Of("checkpoint %s resumes from height %d, outside of the scan", path, saved.Next)
+	}
+
+	*c = saved
+	return nil
+}
+
+// save writes a scan's progress to a checkpoint file, replacing it atomically.
+func (c *checkpoint) save(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	c.Updated = time.Now().Unix()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return failure.Of("failed to serialize scan checkpoint: %v", err.Error())
+	}
+
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, data, 0o644); err != nil {
+		return failure.Of("failed to write scan checkpoint: %v", err.Error())
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		return failure.Of("failed to write scan checkpoint: %v", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package blocks_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+// scanFixture mines a chain with a payment to script at height 31, spent at height 33.
+func scanFixture(script []byte) *rpctest.Chain {
+	chain := rpctest.NewChain(30)
+	funding := chain.Block(1).TxIDs[0]
+
+	paid := chain.MineWith(1, func(b *rpctest.Block) {
+		b.Transactions = append(b.Transactions, rpctest.Spend(funding, 0, rpctest.Output{Value: 10_0000_0000, Script: script}, rpctest.Output{Value: 39_0000_0000, Script: []byte{0x51}}))
+	})
+	chain.Mine(1)
+	chain.MineWith(1, func(b *rpctest.Block) {
+		b.Transactions = append(b.Transactions, rpctest.Spend(paid[0].TxIDs[1], 0, rpctest.Output{Value: 9_9999_0000, Script: []byte{0x51}}))
+	})
+	chain.Mine(7)
+
+	return chain
+}
+
+func Test_Scan(t *testing.T) {
+	script := []byte{0x00, 0x14, 0x75, 0x1e, 0x76, 0xe8, 0x19, 0x91, 0x96, 0xd4, 0x54, 0x94, 0x1c, 0x45, 0xd1, 0xb3, 0xa3, 0x23, 0xf1, 0x43, 0x3b, 0xd6}
+	chain := scanFixture(script)
+
+	fake := rpctest.Use(t, rpctest.WithChain(chain))
+
+	matches := []blocks.Match{}
+	result, err := blocks.Scan(context.Background(), blocks.ScanOptions{Scripts: [][]byte{script}, From: 0, To: 40, Workers: 8}, func(match blocks.Match) {
+		matches = append(matches, match)
+	})
+	if err != nil {
+		t.Fatalf("Failed to scan blocks: %v", err)
+	}
+
+	if result.Scanned != 41 || result.Fetched != 2 || result.Matches != 2 || result.Next != 41 {
+		t.Errorf("Unexpected scan result: %+v", result)
+	}
+	if calls := fake.Calls(blocks.MethodGetBlock); calls != 2 {
+		t.Errorf("Expected only the 2 matching blocks to be fetched, got %v", calls)
+	}
+
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %+v", matches)
+	}
+	output, input := matches[0], matches[1]
+	if output.Type != blocks.MatchOutput || output.Height != 31 || output.TxID != chain.Block(31).TxIDs[1] || output.Index != 0 || output.Value != 10_0000_0000 {
+		t.Errorf("Unexpected output match: %+v", output)
+	}
+	if input.Type != blocks.MatchInput || input.Height != 33 || input.Outpoint != output.TxID+":0" || input.Value != 10_0000_0000 {
+		t.Errorf("Unexpected input match: %+v", input)
+	}
+
+	if _, err := blocks.Scan(context.Background(), blocks.ScanOptions{From: 0, To: 40}, func(blocks.Match) {}); err == nil {
+		t.Errorf("Expected a scan without scripts to be rejected")
+	}
+}
+
+func Test_ScanCheckpoint(t *testing.T) {
+	script := []byte{0x51, 0x20, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f, 0x20}
+	chain := scanFixture(script)
+
+	rpctest.Use(t, rpctest.WithChain(chain))
+
+	path := filepath.Join(t.TempDir(), "scan.json")
+	options := blocks.ScanOptions{Scripts: [][]byte{script}, From: 10, To: 40, Workers: 2, Checkpoint: path, Interval: 1}
+
+	// Interrupt the scan at the first match
+	ctx, cancel := context.WithCancel(context.Background())
+	matches := []blocks.Match{}
+	result, err := blocks.Scan(ctx, options, func(match blocks.Match) {
+		matches = append(matches, match)
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the scan to be interrupted, got %v", err)
+	}
+	if len(matches) != 1 || result.Next <= 31 || result.Next > 33 {
+		t.Fatalf("Expected the scan to stop after the first match, got %+v and %+v", matches, result)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected a checkpoint: %v", err)
+	}
+
+	// Another scan can't resume from the checkpoint
+	if _, err := blocks.Scan(context.Background(), blocks.ScanOptions{Scripts: [][]byte{script}, From: 0, To: 40, Checkpoint: path}, func(blocks.Match) {}); err == nil {
+		t.Errorf("Expected a checkpoint of another scan to be rejected")
+	}
+
+	// Resuming reports the remaining match only
+	result, err = blocks.Scan(context.Background(), options, func(match blocks.Match) {
+		matches = append(matches, match)
+	})
+	if err != nil {
+		t.Fatalf("Failed to resume scan: %v", err)
+	}
+	if len(matches) != 2 || matches[1].Type != blocks.MatchInput || matches[1].Height != 33 {
+		t.Errorf("Expected the resumed scan to find the input at height 33, got %+v", matches)
+	}
+	if result.Scanned != 31 || result.Matches != 2 || result.Next != 41 {
+		t.Errorf("Unexpected resumed scan result: %+v", result)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the checkpoint to be removed once the scan completes")
+	}
+}
+
+func Test_ScanCheckpointTip(t *testing.T) {
+	script := []byte{0x51, 0x20, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f, 0x20}
+	chain := scanFixture(script)
+
+	rpctest.Use(t, rpctest.WithChain(chain))
+
+	// Scan up to the tip, interrupted at the first match
+	path := filepath.Join(t.TempDir(), "scan.json")
+	options := blocks.ScanOptions{Scripts: [][]byte{script}, From: 10, To: -1, Workers: 2, Checkpoint: path, Interval: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := blocks.Scan(ctx, options, func(blocks.Match) { cancel() })
+	if !errors.Is(err, context.Canceled) || result.To != 40 {
+		t.Fatalf("Expected the scan up to the tip at 40 to be interrupted, got %+v and %v", result, err)
+	}
+
+	// Blocks mined since don't keep the same options from resuming it, up to the same block
+	chain.Mine(5)
+	result, err = blocks.Scan(context.Background(), options, func(blocks.Match) {})
+	if err != nil {
+		t.Fatalf("Failed to resume scan: %v", err)
+	}
+	if result.To != 40 || result.Next != 41 || result.Scanned != 31 {
+		t.Errorf("Expected the resumed scan to end at 40, got %+v", result)
+	}
+
+	// Without a checkpoint, the scan goes up to the new tip
+	result, err = blocks.Scan(context.Background(), options, func(blocks.Match) {})
+	if err != nil || result.To != 45 {
+		t.Errorf("Expected a new scan to end at the tip at 45, got %+v and %v", result, err)
+	}
+}
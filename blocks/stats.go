@@ -0,0 +1,438 @@
+package blocks
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/export"
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// StatsColumns are the statistics returned by 'getblockstats', in the order they are exported.
+// The feerate_percentiles array is flattened into a column per percentile.
+var StatsColumns = []export.Column{
+	{Name: "height", Kind: export.KindInt},
+	{Name: "blockhash", Kind: export.KindString},
+	{Name: "time", Kind: export.KindInt},
+	{Name: "mediantime", Kind: export.KindInt},
+	{Name: "txs", Kind: export.KindInt},
+	{Name: "ins", Kind: export.KindInt},
+	{Name: "outs", Kind: export.KindInt},
+	{Name: "subsidy", Kind: export.KindInt},
+	{Name: "totalfee", Kind: export.KindInt},
+	{Name: "total_out", Kind: export.KindInt},
+	{Name: "total_size", Kind: export.KindInt},
+	{Name: "total_weight", Kind: export.KindInt},
+	{Name: "avgfee", Kind: export.KindInt},
+	{Name: "avgfeerate", Kind: export.KindInt},
+	{Name: "avgtxsize", Kind: export.KindInt},
+	{Name: "minfee", Kind: export.KindInt},
+	{Name: "maxfee", Kind: export.KindInt},
+	{Name: "medianfee", Kind: export.KindInt},
+	{Name: "minfeerate", Kind: export.KindInt},
+	{Name: "maxfeerate", Kind: export.KindInt},
+	{Name: "feerate_percentiles_10", Kind: export.KindInt},
+	{Name: "feerate_percentiles_25", Kind: export.KindInt},
+	{Name: "feerate_percentiles_50", Kind: export.KindInt},
+	{Name: "feerate_percentiles_75", Kind: export.KindInt},
+	{Name: "feerate_percentiles_90", Kind: export.KindInt},
+	{Name: "mintxsize", Kind: export.KindInt},
+	{Name: "maxtxsize", Kind: export.KindInt},
+	{Name: "mediantxsize", Kind: export.KindInt},
+	{Name: "swtxs", Kind: export.KindInt},
+	{Name: "swtotal_size", Kind: export.KindInt},
+	{Name: "swtotal_weight", Kind: export.KindInt},
+	{Name: "utxo_increase", Kind: export.KindInt},
+	{Name: "utxo_size_inc", Kind: export.KindInt},
+	{Name: "utxo_increase_actual", Kind: export.KindInt},
+	{Name: "utxo_size_inc_actual", Kind: export.KindInt},
+}
+
+// percentiles are the fee rate percentiles of the feerate_percentiles statistic, in order.
+var percentiles = []string{"10", "25", "50", "75", "90"}
+
+// aggregated are the statistics needed to compute StatsAggregates.
+var aggregated = []string{"txs", "totalfee", "total_weight", "swtxs", "feerate_percentiles"}
+
+// StatsOptions configures the range, statistics and concurrency of GetBlockStatsRange.
+type StatsOptions struct {
+	// From and To are the heights of the first and last blocks of the range.
+	From, To int
+
+	// Since and Until narrow the range down to the blocks whose median time past is within them,
+	// when they're set.
+	Since, Until time.Time
+
+	// Stats are the statistics to retrieve, all of them if empty.
+	Stats []string
+
+	// Workers is the number of blocks whose statistics are fetched concurrently (default: 4).
+	Workers int
+
+	// Aggregate computes StatsAggregates over the range, retrieving the statistics they need
+	// besides the selected ones.
+	Aggregate bool
+}
+
+// StatsResult summarizes the statistics retrieved by GetBlockStatsRange.
+type StatsResult struct {
+	From       int              `json:"from"`                 // Height of the first block of the range
+	To         int              `json:"to"`                   // Height of the last block of the range
+	Blocks     int              `json:"blocks"`               // Number of blocks whose statistics were retrieved
+	Aggregates *StatsAggregates `json:"aggregates,omitempty"` // Aggregates over the range, when requested
+}
+
+// StatsAggregates are statistics of a range of blocks. Coinbase transactions are excluded from
+// every aggregate, as they are from the fee statistics of 'getblockstats'.
+type StatsAggregates struct {
+	Txs           int64   `json:"txs"`            // Number of transactions
+	TotalFees     int64   `json:"total_fees"`     // Sum of the fees, in satoshis
+	MeanFeeRate   float64 `json:"mean_feerate"`   // Total fees over total virtual size, in sat/vB
+	MedianFeeRate float64 `json:"median_feerate"` // Median of the blocks' median fee rates, in sat/vB, ignoring empty blocks
+	SegwitShare   float64 `json:"segwit_share"`   // Fraction of the transactions spending segwit outputs
+}
+
+// fetched is the outcome of retrieving a block's statistics.
+type fetched struct {
+	height int
+	stats  rpc.Json
+	err    error
+}
+
+// GetBlockStatsRange retrieves the statistics of a range of blocks, by height or time window.
+//
+// Statistics are fetched concurrently by a bounded pool of workers, each block by height through
+// 'getblockstats', while they are reported in block order. A time window is resolved to heights by
+// binary search over the blocks' median time past, which never decreases along the chain, unlike
+// their timestamps.
+//
+// Parameters:
+// - ctx (context.Context): Interrupts the retrieval when done.
+// - options (StatsOptions): The range, statistics, concurrency and aggregation of the retrieval.
+// - found (func(rpc.Json) error): Called for each block's statistics, in block order; an error stops the retrieval.
+//
+// Returns:
+//   - *StatsResult: The range retrieved, with its aggregates when requested. Also returned on errors,
+//     with the blocks reported so far.
+//   - error: An error if the options are invalid, a block's statistics can't be retrieved or the context is done.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks stats --from 840000 --to 850000 --output stats.parquet --aggregate
+//
+//   - Using Go:
+//     options := blocks.StatsOptions{From: 840000, To: 850000, Stats: []string{"height", "avgfeerate"}, Aggregate: true}
+//     result, err := blocks.GetBlockStatsRange(ctx, options, func(stats rpc.Json) error {
+//     fmt.Println(stats["height"], stats["avgfeerate"])
+//     return nil
+//     })
+//
+// Notes:
+//   - Blocks must not be pruned, as their statistics are computed from their undo data.
+//   - When Aggregate is set, the statistics it needs are reported along with the selected ones.
+func GetBlockStatsRange(ctx context.Context, options StatsOptions, found func(rpc.Json) error) (*StatsResult, error) {
+	if options.From < 0 || options.To < options.From {
+		return nil, failure.Of("invalid range %d-%d: heights must be non-negative, with from not above to", options.From, options.To)
+	}
+	if !options.Since.IsZero() && !options.Until.IsZero() && options.Until.Before(options.Since) {
+		return nil, failure.Of("invalid time window: until (%v) is before since (%v)", options.Until, options.Since)
+	}
+	if _, err := StatsColumnsOf(options.Stats); err != nil {
+		return nil, err
+	}
+	if options.Workers <= 0 {
+		options.Workers = 4
+	}
+
+	result := &StatsResult{From: options.From, To: options.To}
+	if err := result.window(options.Since, options.Until); err != nil {
+		return nil, err
+	}
+
+	stats := slices.Clone(options.Stats)
+	if len(stats) > 0 && options.Aggregate {
+		for _, stat := range aggregated {
+			if !slices.Contains(stats, stat) {
+				stats = append(stats, stat)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Workers take heights in order, and report them through results in any order
+	heights, results := make(chan int), make(chan fetched)
+	go func() {
+		defer close(heights)
+		for height := result.From; height <= result.To; height++ {
+			select {
+			case heights <- height:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range options.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for height := range heights {
+				response, err := blockStats(height, stats)
+				select {
+				case results <- fetched{height: height, stats: response, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results are buffered until every block below them is reported
+	aggregates := &aggregator{}
+	pending, next := map[int]fetched{}, result.From
+	var failed error
+	for response := range results {
+		if response.err != nil {
+			if failed == nil {
+				failed = failure.Of("failed to get block stats at height %d: %v", response.height, response.err.Error())
+			}
+			cancel()
+			continue
+		}
+
+		pending[response.height] = response
+		for current, ok := pending[next]; ok && failed == nil && ctx.Err() == nil; current, ok = pending[next] {
+			delete(pending, next)
+			if err := found(current.stats); err != nil {
+				failed = err
+				cancel()
+				break
+			}
+			if options.Aggregate {
+				aggregates.add(current.stats)
+			}
+
+			next++
+			result.Blocks++
+			if result.Blocks%1000 == 0 {
+				logger.Debugf("retrieved block stats %d-%d", result.From, next-1)
+			}
+		}
+	}
+
+	if failed == nil && next <= result.To {
+		failed = ctx.Err()
+	}
+	if options.Aggregate {
+		result.Aggregates = aggregates.result()
+	}
+	return result, failed
+}
+
+// StatsColumnsOf returns the columns exporting the given statistics, in the order of StatsColumns.
+//
+// Parameters:
+// - stats ([]string): The statistics, as named by 'getblockstats'. Every column is returned if empty.
+//
+// Returns:
+// - []export.Column: The columns of the statistics.
+// - error: An error if a statistic is unknown.
+func StatsColumnsOf(stats []string) ([]export.Column, error) {
+	if len(stats) == 0 {
+		return StatsColumns, nil
+	}
+
+	selected := map[string]bool{}
+	for _, stat := range stats {
+		if stat == "feerate_percentiles" {
+			for _, percentile := range percentiles {
+				selected[stat+"_"+percentile] = true
+			}
+			continue
+		}
+		if !slices.ContainsFunc(StatsColumns, func(column export.Column) bool { return column.Name == stat }) {
+			return nil, failure.Of("unknown block statistic '%s'", stat)
+		}
+		selected[stat] = true
+	}
+
+	columns := []export.Column{}
+	for _, column := range StatsColumns {
+		if selected[column.Name] {
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
+
+// StatsRecord converts a block's statistics into a record of the given columns, ready to be exported.
+// Statistics missing from the block are exported as zero values.
+//
+// Parameters:
+// - stats (rpc.Json): The statistics of a block, as returned by 'getblockstats'.
+// - columns ([]export.Column): The columns of the record, from StatsColumnsOf.
+//
+// Returns:
+// - []any: The record, with a value per column.
+func StatsRecord(stats rpc.Json, columns []export.Column) []any {
+	record := make([]any, 0, len(columns))
+	for _, column := range columns {
+		value := stats[column.Name]
+		for i, percentile := range percentiles {
+			if column.Name == "feerate_percentiles_"+percentile {
+				if values, ok := stats["feerate_percentiles"].([]any); ok && i < len(values) {
+					value = values[i]
+				}
+			}
+		}
+
+		switch column.Kind {
+		case export.KindInt:
+			number, _ := value.(float64)
+			record = append(record, int64(number))
+		case export.KindFloat:
+			number, _ := value.(float64)
+			record = append(record, number)
+		default:
+			text, _ := value.(string)
+			record = append(record, text)
+		}
+	}
+	return record
+}
+
+// blockStats retrieves the statistics of the block at a height, by height to avoid resolving its hash.
+func blockStats(height int, stats []string) (rpc.Json, error) {
+	params := rpc.Params{height}
+	if len(stats) > 0 {
+		params = append(params, stats)
+	}
+
+	request := rpc.Request{
+		ID:      rpc.Identifier,
+		Version: rpc.Version2,
+		Method:  MethodGetBlockStats,
+		Params:  params,
+	}
+
+	response, err := rpc.JsonResult(rpc.Client.Do(request))
+	if err != nil {
+		return nil, err
+	}
+	return *response, nil
+}
+
+// window narrows the range down to the blocks whose median time past is between since and until.
+func (r *StatsResult) window(since, until time.Time) error {
+	if since.IsZero() && until.IsZero() {
+		return nil
+	}
+
+	// Median times are fetched lazily and memoized, as both bounds search the same range
+	times := map[int]int64{}
+	var failed error
+	after := func(t time.Time) int {
+		return r.From + sort.Search(r.To-r.From+1, func(i int) bool {
+			height := r.From + i
+			if _, ok := times[height]; !ok && failed == nil {
+				times[height], failed = medianTime(height)
+			}
+			return times[height] >= t.Unix()
+		})
+	}
+
+	from, to := r.From, r.To
+	if !since.IsZero() {
+		from = after(since)
+	}
+	if !until.IsZero() {
+		to = after(until.Add(time.Second)) - 1
+	}
+	if failed != nil {
+		return failure.Of("failed to resolve time window: %v", failed.Error())
+	}
+
+	logger.Debugf("resolved time window %v-%v to heights %d-%d", since, until, from, to)
+	r.From, r.To = from, to
+	return nil
+}
+
+// medianTime retrieves the median time past of the block at a height.
+func medianTime(height int) (int64, error) {
+	hash, err := GetBlockHash(height)
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := GetBlockHeader(hash, true)
+	if err != nil {
+		return 0, err
+	}
+
+	header := struct {
+		MedianTime int64 `json:"mediantime"`
+	}{}
+	if err := response.Bind(&header); err != nil {
+		return 0, failure.Of("failed to parse block header: %v", err.Error())
+	}
+	return header.MedianTime, nil
+}
+
+// aggregator accumulates the statistics of blocks into StatsAggregates.
+type aggregator struct {
+	txs, fees, weight, segwit int64
+	feerates                  []float64 // Median fee rate of each non-empty block
+}
+
+func (a *aggregator) add(stats rpc.Json) {
+	number := func(name string) int64 {
+		value, _ := stats[name].(float64)
+		return int64(value)
+	}
+
+	txs := number("txs") - 1 // Without the coinbase
+	if txs <= 0 {
+		return
+	}
+
+	a.txs += txs
+	a.fees += number("totalfee")
+	a.weight += number("total_weight")
+	a.segwit += number("swtxs")
+	if values, ok := stats["feerate_percentiles"].([]any); ok && len(values) == len(percentiles) {
+		median, _ := values[2].(float64)
+		a.feerates = append(a.feerates, median)
+	}
+}
+
+func (a *aggregator) result() *StatsAggregates {
+	aggregates := &StatsAggregates{Txs: a.txs, TotalFees: a.fees}
+	if a.weight > 0 {
+		aggregates.MeanFeeRate = float64(a.fees) * 4 / float64(a.weight)
+	}
+	if a.txs > 0 {
+		aggregates.SegwitShare = float64(a.segwit) / float64(a.txs)
+	}
+
+	if n := len(a.feerates); n > 0 {
+		slices.Sort(a.feerates)
+		aggregates.MedianFeeRate = a.feerates[n/2]
+		if n%2 == 0 {
+			aggregates.MedianFeeRate = (a.feerates[n/2-1] + a.feerates[n/2]) / 2
+		}
+	}
+	return aggregates
+}
@@ -0,0 +1,171 @@
+package blocks_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/export"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+func Test_GetBlockStatsRange(t *testing.T) {
+	chain := scanFixture([]byte{0x51})
+
+	fake := rpctest.Use(t, rpctest.WithChain(chain))
+
+	options := blocks.StatsOptions{From: 0, To: 40, Stats: []string{"height", "avgfeerate"}, Workers: 8, Aggregate: true}
+	reported := []rpc.Json{}
+	result, err := blocks.GetBlockStatsRange(context.Background(), options, func(stats rpc.Json) error {
+		reported = append(reported, stats)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to get block stats range: %v", err)
+	}
+
+	if result.From != 0 || result.To != 40 || result.Blocks != 41 || len(reported) != 41 {
+		t.Fatalf("Unexpected stats result: %+v, with %d blocks reported", result, len(reported))
+	}
+	for i, stats := range reported {
+		if stats["height"] != float64(i) {
+			t.Errorf("Expected block %d to be reported in order, got height %v", i, stats["height"])
+		}
+		if _, ok := stats["totalfee"]; !ok {
+			t.Errorf("Expected the statistics needed by aggregates to be retrieved for block %d", i)
+		}
+	}
+	if calls := fake.Calls(blocks.MethodGetBlockHash); calls != 0 {
+		t.Errorf("Expected blocks to be fetched by height, got %d getblockhash calls", calls)
+	}
+
+	weight := reported[31]["total_weight"].(float64) + reported[33]["total_weight"].(float64)
+	fees := int64(1_0000_0000 + 1_0000) // Fees paid at heights 31 and 33
+	medians := []float64{
+		reported[31]["feerate_percentiles"].([]any)[2].(float64),
+		reported[33]["feerate_percentiles"].([]any)[2].(float64),
+	}
+
+	aggregates := result.Aggregates
+	if aggregates == nil {
+		t.Fatalf("Expected aggregates to be computed")
+	}
+	if aggregates.Txs != 2 || aggregates.TotalFees != fees || aggregates.SegwitShare != 0 {
+		t.Errorf("Unexpected aggregates: %+v", aggregates)
+	}
+	if expected := float64(fees) * 4 / weight; aggregates.MeanFeeRate != expected {
+		t.Errorf("Expected mean fee rate %v, got %v", expected, aggregates.MeanFeeRate)
+	}
+	if expected := (medians[0] + medians[1]) / 2; aggregates.MedianFeeRate != expected {
+		t.Errorf("Expected median fee rate %v, got %v", expected, aggregates.MedianFeeRate)
+	}
+}
+
+func Test_GetBlockStatsRangeWindow(t *testing.T) {
+	rpctest.Use(t)
+
+	mediantime := func(height int) time.Time {
+		stats, err := blocks.GetBlockStats(fmt.Sprint(height), "mediantime")
+		if err != nil {
+			t.Fatalf("Failed to get block stats: %v", err)
+		}
+		return time.Unix(int64((*stats)["mediantime"].(float64)), 0)
+	}
+
+	cases := []struct {
+		Since, Until time.Time
+		From, To     int
+	}{
+		{Since: mediantime(100), Until: mediantime(120), From: 100, To: 120},
+		{Since: mediantime(100).Add(time.Second), Until: mediantime(120).Add(-time.Second), From: 101, To: 119},
+		{Since: mediantime(190), From: 190, To: 200},
+		{Until: mediantime(15), From: 0, To: 15},
+		{Since: mediantime(200).Add(time.Hour), From: 201, To: 200},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			heights := []int{}
+			options := blocks.StatsOptions{From: 0, To: 200, Since: test.Since, Until: test.Until, Stats: []string{"height"}}
+			result, err := blocks.GetBlockStatsRange(context.Background(), options, func(stats rpc.Json) error {
+				heights = append(heights, int(stats["height"].(float64)))
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Failed to get block stats range: %v", err)
+			}
+
+			if result.From != test.From || result.To != test.To || len(heights) != test.To-test.From+1 {
+				t.Errorf("Expected heights %d-%d, got %+v with %d blocks reported", test.From, test.To, result, len(heights))
+			}
+			if len(heights) > 0 && (heights[0] != test.From || heights[len(heights)-1] != test.To) {
+				t.Errorf("Expected heights %d-%d to be reported, got %v", test.From, test.To, heights)
+			}
+		})
+	}
+}
+
+func Test_GetBlockStatsRangeErrors(t *testing.T) {
+	rpctest.Use(t)
+
+	ignore := func(rpc.Json) error { return nil }
+
+	if _, err := blocks.GetBlockStatsRange(context.Background(), blocks.StatsOptions{From: 10, To: 5}, ignore); err == nil {
+		t.Errorf("Expected an inverted range to be rejected")
+	}
+	if _, err := blocks.GetBlockStatsRange(context.Background(), blocks.StatsOptions{To: 5, Stats: []string{"unknown"}}, ignore); err == nil {
+		t.Errorf("Expected an unknown statistic to be rejected")
+	}
+	if _, err := blocks.GetBlockStatsRange(context.Background(), blocks.StatsOptions{To: 300}, ignore); err == nil {
+		t.Errorf("Expected a range above the tip to fail")
+	}
+
+	stop := errors.New("stop")
+	result, err := blocks.GetBlockStatsRange(context.Background(), blocks.StatsOptions{To: 200}, func(stats rpc.Json) error {
+		if stats["height"] == float64(50) {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || result.Blocks != 50 {
+		t.Errorf("Expected the retrieval to stop at height 50, got %+v (%v)", result, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := blocks.GetBlockStatsRange(ctx, blocks.StatsOptions{To: 200}, ignore); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a done context to interrupt the retrieval, got %v", err)
+	}
+}
+
+func Test_StatsRecord(t *testing.T) {
+	columns, err := blocks.StatsColumnsOf([]string{"feerate_percentiles", "blockhash", "height"})
+	if err != nil {
+		t.Fatalf("Failed to get stats columns: %v", err)
+	}
+
+	names := []string{}
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+	expected := "[height blockhash feerate_percentiles_10 feerate_percentiles_25 feerate_percentiles_50 feerate_percentiles_75 feerate_percentiles_90]"
+	if fmt.Sprint(names) != expected {
+		t.Errorf("Expected columns %s, got %v", expected, names)
+	}
+	if columns[1].Kind != export.KindString {
+		t.Errorf("Expected blockhash to be a string column")
+	}
+
+	stats := rpc.Json{"height": float64(840000), "blockhash": "0000", "feerate_percentiles": []any{1.0, 2.0, 3.0, 4.0, 5.0}}
+	record := blocks.StatsRecord(stats, columns)
+	if fmt.Sprint(record) != "[840000 0000 1 2 3 4 5]" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+	if _, ok := record[0].(int64); !ok {
+		t.Errorf("Expected integer statistics as int64, got %T", record[0])
+	}
+}
@@ -0,0 +1,269 @@
+package blocks
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// Rules checked by VerifyHeaders, named after Bitcoin Core's rejection reasons.
+const (
+	RuleHighHash   = "high-hash"            // The hash doesn't meet the target, or the target exceeds the limit
+	RulePrevBlock  = "bad-prevblk"          // The header doesn't link to the previous block
+	RuleDiffBits   = "bad-diffbits"         // The bits don't match the difficulty adjustment rules
+	RuleTimeTooOld = "time-too-old"         // The timestamp isn't above the median time past
+	RuleTimeTooNew = "time-too-new"         // The timestamp is more than 2 hours in the future
+	RuleTimeWarp   = "time-timewarp-attack" // The first block of a period predates its parent by over 10 minutes (BIP94)
+)
+
+const (
+	maxFutureTime   = 2 * 60 * 60 // Seconds a timestamp may be ahead of the local clock
+	maxTimeWarp     = 10 * 60     // Seconds the first block of a period may predate its parent (BIP94)
+	medianTimeSpan  = 11          // Number of blocks whose median time is the median time past
+	retainedHeaders = 2 * 2016    // Number of headers kept in memory behind the verified one
+)
+
+// Violation is a consensus rule broken by a block header, as reported by VerifyHeaders.
+type Violation struct {
+	Height int    `json:"height"` // Height of the header
+	Hash   string `json:"hash"`   // Hash of the header
+	Rule   string `json:"rule"`   // Rule broken (see the Rule* constants)
+	Reason string `json:"reason"` // Human-readable details
+}
+
+// Error implements the error interface.
+func (v *Violation) Error() string {
+	return fmt.Sprintf("header %d (%s) violates %s: %s", v.Height, v.Hash, v.Rule, v.Reason)
+}
+
+// VerifyHeaders verifies, rather than trusts, the headers of the active chain between two heights.
+//
+// Headers are fetched through GetBlockHeader in their serialized form and hashed locally. Each one is
+// checked against the consensus rules of the chain: its hash must meet the target decoded from its bits,
+// it must link to the previous header, its bits must follow the difficulty adjustment rules (2016-block
+// retargets, testnet min-difficulty blocks, BIP94 on testnet4, no retargeting on regtest) and its
+// timestamp must be above the median time past and not too far in the future.
+//
+// Parameters:
+//   - from (int): The height of the first header to verify.
+//   - to (int): The height of the last header to verify.
+//   - params (...ChainParams): The chain's consensus parameters. When omitted, they're selected from
+//     the chain reported by 'getblockchaininfo'.
+//
+// Returns:
+//   - error: A *Violation for the first header breaking a rule, or an error if the heights are invalid
+//     or the headers can't be retrieved. Nil when every header is valid.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks verify-headers --from 840000 --to 842000
+//
+//   - Using Go:
+//     err := blocks.VerifyHeaders(840000, 842000)
+//     violation := &blocks.Violation{}
+//     if errors.As(err, &violation) {
+//     fmt.Println(violation.Height, violation.Rule)
+//     }
+//
+// Notes:
+//   - Verifying a header needs up to 2016 headers below it (for retargets and the median time past),
+//     which are fetched but not verified themselves.
+//   - Signet block signatures aren't verified.
+func VerifyHeaders(from, to int, params ...ChainParams) error {
+	if from < 0 || to < from {
+		return failure.Of("invalid range %d-%d: heights must be non-negative, with from not above to", from, to)
+	}
+
+	var chain ChainParams
+	if len(params) > 0 {
+		chain = params[0]
+	} else {
+		info, err := GetBlockchainInfo()
+		if err != nil {
+			return err
+		}
+		name, _ := (*info)["chain"].(string)
+		if chain, err = ParamsFrom(name); err != nil {
+			return err
+		}
+	}
+
+	v := &verifier{params: chain, headers: map[int]*wire.Header{}, now: time.Now().Unix()}
+	for height := from; height <= to; height++ {
+		if err := v.verify(height); err != nil {
+			return err
+		}
+
+		delete(v.headers, height-retainedHeaders)
+		if (height-from+1)%chain.Interval() == 0 {
+			logger.Debugf("verified headers %d-%d", from, height)
+		}
+	}
+
+	return nil
+}
+
+// verifier checks headers against the consensus rules of a chain, caching the headers it fetches.
+type verifier struct {
+	params  ChainParams
+	headers map[int]*wire.Header // Fetched headers, indexed by height
+	now     int64                // Local time, used to reject headers from the future
+}
+
+// verify checks the header at a height, given its ancestors.
+func (v *verifier) verify(height int) error {
+	header, err := v.header(height)
+	if err != nil {
+		return err
+	}
+
+	violation := func(rule string, reason string, args ...any) error {
+		return &Violation{Height: height, Hash: header.Hash().String(), Rule: rule, Reason: fmt.Sprintf(reason, args...)}
+	}
+
+	if err := header.CheckProofOfWork(v.params.PowLimit); err != nil {
+		return violation(RuleHighHash, "%v", err.Error())
+	}
+
+	if int64(header.Time) > v.now+maxFutureTime {
+		return violation(RuleTimeTooNew, "timestamp %d is more than 2 hours ahead of the local clock", header.Time)
+	}
+
+	if height == 0 {
+		return nil
+	}
+
+	previous, err := v.header(height - 1)
+	if err != nil {
+		return err
+	}
+
+	if hash := previous.Hash(); header.PreviousBlock != hash {
+		return violation(RulePrevBlock, "links to %s instead of block %d (%s)", header.PreviousBlock, height-1, hash)
+	}
+
+	expected, err := v.nextBits(height, header)
+	if err != nil {
+		return err
+	}
+	if header.Bits != expected {
+		return violation(RuleDiffBits, "bits %08x don't match the expected %08x", header.Bits, expected)
+	}
+
+	median, err := v.medianTime(height - 1)
+	if err != nil {
+		return err
+	}
+	if int64(header.Time) <= median {
+		return violation(RuleTimeTooOld, "timestamp %d isn't above the median time past %d", header.Time, median)
+	}
+
+	if v.params.EnforceBIP94 && height%v.params.Interval() == 0 && int64(header.Time) < int64(previous.Time)-maxTimeWarp {
+		return violation(RuleTimeWarp, "timestamp %d predates the previous block's %d by more than 10 minutes", header.Time, previous.Time)
+	}
+
+	return nil
+}
+
+// nextBits computes the bits required for the header at a height, as Bitcoin Core's GetNextWorkRequired does.
+func (v *verifier) nextBits(height int, header *wire.Header) (uint32, error) {
+	interval := v.params.Interval()
+	limit := wire.TargetToCompact(v.params.PowLimit)
+
+	last, err := v.header(height - 1)
+	if err != nil {
+		return 0, err
+	}
+
+	if height%interval != 0 {
+		if !v.params.AllowMinDifficulty {
+			return last.Bits, nil
+		}
+
+		// Min-difficulty blocks are allowed 20 minutes after the previous block
+		if int64(header.Time) > int64(last.Time)+v.params.TargetSpacing*2 {
+			return limit, nil
+		}
+
+		// Otherwise, the last target that isn't a min-difficulty one applies
+		for h := height - 1; h > 0 && h%interval != 0 && last.Bits == limit; h-- {
+			if last, err = v.header(h - 1); err != nil {
+				return 0, err
+			}
+		}
+		return last.Bits, nil
+	}
+
+	if v.params.NoRetargeting {
+		return last.Bits, nil
+	}
+
+	first, err := v.header(height - interval)
+	if err != nil {
+		return 0, err
+	}
+
+	timespan := int64(last.Time) - int64(first.Time)
+	timespan = max(timespan, v.params.TargetTimespan/4)
+	timespan = min(timespan, v.params.TargetTimespan*4)
+
+	// BIP94 retargets from the period's first block, so that min-difficulty blocks can't lower the target
+	base := last.Bits
+	if v.params.EnforceBIP94 {
+		base = first.Bits
+	}
+
+	target, err := wire.CompactToTarget(base)
+	if err != nil {
+		return 0, err
+	}
+	target.Mul(target, big.NewInt(timespan))
+	target.Div(target, big.NewInt(v.params.TargetTimespan))
+	if target.Cmp(v.params.PowLimit) > 0 {
+		target.Set(v.params.PowLimit)
+	}
+
+	return wire.TargetToCompact(target), nil
+}
+
+// medianTime computes the median time past of the block at a height: the median timestamp of the
+// block and its 10 parents.
+func (v *verifier) medianTime(height int) (int64, error) {
+	times := []int64{}
+	for h := height; h >= 0 && h > height-medianTimeSpan; h-- {
+		header, err := v.header(h)
+		if err != nil {
+			return 0, err
+		}
+		times = append(times, int64(header.Time))
+	}
+
+	slices.Sort(times)
+	return times[len(times)/2], nil
+}
+
+// header returns the header of the active chain at a height, fetching it if needed.
+func (v *verifier) header(height int) (*wire.Header, error) {
+	if header, ok := v.headers[height]; ok {
+		return header, nil
+	}
+
+	hash, err := GetBlockHash(height)
+	if err != nil {
+		return nil, failure.Of("failed to get block hash at height %d: %v", height, err.Error())
+	}
+
+	header, err := GetDecodedBlockHeader(hash)
+	if err != nil {
+		return nil, failure.Of("failed to get block header at height %d: %v", height, err.Error())
+	}
+
+	v.headers[height] = header
+	return header, nil
+}
@@ -0,0 +1,84 @@
+package blocks_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/rpctest"
+	"github.com/avila-r/bitclient/wire"
+)
+
+func Test_VerifyHeaders(t *testing.T) {
+	if err := blocks.VerifyHeaders(0, server.Chain.Height()); err != nil {
+		t.Errorf("Failed to verify fixture headers: %v", err)
+	}
+
+	if err := blocks.VerifyHeaders(150, 120); err == nil {
+		t.Errorf("Expected an inverted range to be rejected")
+	}
+
+	// Regtest targets exceed the mainnet proof-of-work limit
+	violation := &blocks.Violation{}
+	if err := blocks.VerifyHeaders(0, 10, blocks.MainNet); !errors.As(err, &violation) || violation.Rule != blocks.RuleHighHash || violation.Height != 0 {
+		t.Errorf("Expected a %s violation at height 0, got %v", blocks.RuleHighHash, err)
+	}
+}
+
+func Test_VerifyHeadersViolations(t *testing.T) {
+	cases := []struct {
+		Modify func(*rpctest.Block)
+		Rule   string
+	}{
+		{Modify: func(b *rpctest.Block) { b.Bits = 0x1f7fffff }, Rule: blocks.RuleDiffBits},
+		{Modify: func(b *rpctest.Block) { b.Time -= 10 * rpctest.BlockInterval }, Rule: blocks.RuleTimeTooOld},
+		{Modify: func(b *rpctest.Block) { b.Time += 1 << 31 }, Rule: blocks.RuleTimeTooNew},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			chain := rpctest.NewChain(30)
+			chain.MineWith(1, test.Modify)
+			chain.Mine(5)
+
+			rpctest.Use(t, rpctest.WithChain(chain))
+
+			if err := blocks.VerifyHeaders(0, 30); err != nil {
+				t.Errorf("Failed to verify the headers below the invalid one: %v", err)
+			}
+
+			violation := &blocks.Violation{}
+			err := blocks.VerifyHeaders(20, 36)
+			if !errors.As(err, &violation) {
+				t.Fatalf("Expected a violation, got %v", err)
+			}
+			if violation.Rule != test.Rule || violation.Height != 31 || violation.Hash != chain.Block(31).Hash {
+				t.Errorf("Expected a %s violation at height 31, got %v", test.Rule, violation)
+			}
+		})
+	}
+}
+
+func Test_VerifyHeadersRetarget(t *testing.T) {
+	// Retarget every 4 blocks, expecting blocks every 800 seconds while the fixture mines one every 600
+	params := blocks.ChainParams{Name: "retarget", PowLimit: blocks.RegTest.PowLimit, TargetSpacing: 800, TargetTimespan: 4 * 800}
+	target := new(big.Int).Div(new(big.Int).Mul(params.PowLimit, big.NewInt(3*600)), big.NewInt(params.TargetTimespan))
+	bits := wire.TargetToCompact(target)
+
+	chain := rpctest.NewChain(3)
+	chain.MineWith(4, func(b *rpctest.Block) { b.Bits = bits })
+	chain.Mine(1) // Keeps the easiest target at the next retarget
+
+	rpctest.Use(t, rpctest.WithChain(chain))
+
+	if err := blocks.VerifyHeaders(0, 7, params); err != nil {
+		t.Errorf("Failed to verify retargeted headers: %v", err)
+	}
+
+	violation := &blocks.Violation{}
+	if err := blocks.VerifyHeaders(0, 8, params); !errors.As(err, &violation) || violation.Rule != blocks.RuleDiffBits || violation.Height != 8 {
+		t.Errorf("Expected a %s violation at height 8, got %v", blocks.RuleDiffBits, err)
+	}
+}
@@ -0,0 +1,355 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// EventType defines the kind of change reported by Watch.
+type EventType string
+
+const (
+	// EventConnected reports a block connected to the tip of the active chain.
+	EventConnected EventType = "connected"
+
+	// EventDisconnected reports a block disconnected from the active chain by a reorg.
+	EventDisconnected EventType = "disconnected"
+
+	// EventError reports a failure while watching. Watch keeps retrying after it.
+	EventError EventType = "error"
+)
+
+// Event is a change of the active chain's tip, as reported by Watch.
+type Event struct {
+	Type         EventType `json:"type"`                        // Kind of change
+	Hash         string    `json:"hash,omitempty"`              // Hash of the block
+	Height       int       `json:"height"`                      // Height of the block
+	PreviousHash string    `json:"previousblockhash,omitempty"` // Hash of the block's parent
+	Time         int64     `json:"time,omitempty"`              // Block timestamp
+	Error        string    `json:"error,omitempty"`             // Failure message, for error events
+}
+
+// WatchOptions configures how Watch follows the active chain.
+type WatchOptions struct {
+	// Poll disables long-polls, only polling 'getbestblockhash' every Interval.
+	Poll bool
+
+	// Interval is the polling interval, also used as first retry delay after failures (default: 1s).
+	// The delay doubles after each consecutive failure, up to Timeout.
+	Interval time.Duration
+
+	// Timeout is the timeout of each 'waitfornewblock'/'waitforblockheight' long-poll (default: 60s).
+	Timeout time.Duration
+
+	// Depth is the deepest reorg that can be followed (default: 100 blocks).
+	Depth int
+}
+
+// header holds the fields of a block header needed to follow the active chain.
+type header struct {
+	Hash         string `json:"hash"`
+	Height       int    `json:"height"`
+	PreviousHash string `json:"previousblockhash"`
+	Time         int64  `json:"time"`
+}
+
+// event builds an event of the given type for the block.
+func (h header) event(t EventType) Event {
+	return Event{Type: t, Hash: h.Hash, Height: h.Height, PreviousHash: h.PreviousHash, Time: h.Time}
+}
+
+// watcher follows the active chain, remembering its last blocks to detect reorgs.
+type watcher struct {
+	ctx      context.Context
+	options  WatchOptions
+	events   chan Event
+	method   rpc.Method // Long-poll method in use, empty when polling
+	chain    []header   // Last blocks of the active chain, lowest first
+	failures int        // Consecutive failures, backing off the retries
+}
+
+// Watch follows the tip of the active chain, sending an event for every block connected to it.
+//
+// New tips are awaited through 'waitfornewblock' long-polls. When the node doesn't support them
+// (or forbids them through -rpcwhitelist), Watch falls back to 'waitforblockheight' and then to
+// polling 'getbestblockhash'. Other failures are retried with the same method, backing off.
+// Reorgs are detected by walking back the new tip's 'previousblockhash' links until a known block
+// is found: the blocks above it are reported as disconnected (tip first), followed by the new
+// branch as connected (lowest first).
+//
+// Parameters:
+// - ctx (context.Context): Stops watching when done, closing the events channel.
+// - options (WatchOptions): Polling and reorg settings; zero values fall back to the defaults.
+//
+// Returns:
+// - <-chan Event: The events, starting with the next tip change. Failures are sent as EventError events.
+// - error: An error if the current tip can't be retrieved.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks watch
+//
+//   - Using Go:
+//     events, err := blocks.Watch(ctx, blocks.WatchOptions{})
+//     for event := range events {
+//     fmt.Println(event.Type, event.Height, event.Hash)
+//     }
+//
+// Notes:
+//   - Since 'waitfornewblock' only returns on changes after it's called, a tip change happening
+//     between two long-polls is reported when the next long-poll times out.
+func Watch(ctx context.Context, options WatchOptions) (<-chan Event, error) {
+	if options.Interval <= 0 {
+		options.Interval = time.Second
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = time.Minute
+	}
+	if options.Depth <= 0 {
+		options.Depth = 100
+	}
+
+	w := &watcher{ctx: ctx, options: options, events: make(chan Event)}
+	if !options.Poll {
+		w.method = MethodWaitForNewBlock
+	}
+
+	hash, err := w.best()
+	if err != nil {
+		return nil, err
+	}
+	tip, err := w.header(hash)
+	if err != nil {
+		return nil, err
+	}
+	w.chain = []header{tip}
+
+	go w.run()
+
+	return w.events, nil
+}
+
+// run waits for tip changes until the context is done.
+func (w *watcher) run() {
+	defer close(w.events)
+
+	for w.ctx.Err() == nil {
+		hash, err := w.wait()
+		if err == nil && hash != w.tip().Hash {
+			err = w.update(hash)
+		}
+
+		if err == nil {
+			w.failures = 0
+			continue
+		}
+
+		if w.ctx.Err() == nil {
+			w.send(Event{Type: EventError, Height: w.tip().Height, Error: err.Error()})
+			w.sleep(w.backoff())
+			w.failures++
+		}
+	}
+}
+
+// wait blocks until the tip may have changed, returning the hash of the current tip.
+func (w *watcher) wait() (string, error) {
+	timeout := w.options.Timeout.Milliseconds()
+
+	switch w.method {
+	case MethodWaitForNewBlock:
+		response, err := w.call(MethodWaitForNewBlock, rpc.Params{timeout})
+		if err == nil || !unsupported(err) {
+			return w.result(response, err)
+		}
+		logger.Debugf("waitfornewblock failed, falling back to waitforblockheight: %v", err.Error())
+		w.method = MethodWaitForBlockHeight
+
+	case MethodWaitForBlockHeight:
+		response, err := w.call(MethodWaitForBlockHeight, rpc.Params{w.tip().Height + 1, timeout})
+		if err == nil || !unsupported(err) {
+			return w.result(response, err)
+		}
+		logger.Debugf("waitforblockheight failed, falling back to polling: %v", err.Error())
+		w.method = ""
+	}
+
+	if w.method == "" {
+		w.sleep(w.options.Interval)
+	}
+	return w.best()
+}
+
+// unsupported reports whether a long-poll failed because the node doesn't support the method
+// or forbids it through -rpcwhitelist (HTTP 403), rather than transiently.
+func unsupported(err error) bool {
+	failed := &rpc.Error{}
+	return errors.As(err, &failed) && (failed.Code == rpc.CodeMethodNotFound || failed.Status == http.StatusForbidden)
+}
+
+// backoff returns the delay before retrying after the current run of failures: Interval, doubled
+// after each consecutive failure, up to Timeout.
+func (w *watcher) backoff() time.Duration {
+	delay := w.options.Interval
+	for i := 0; i < w.failures && delay < w.options.Timeout; i++ {
+		delay *= 2
+	}
+	return min(delay, w.options.Timeout)
+}
+
+// update follows the active chain up to a new tip, sending the disconnected and connected blocks.
+func (w *watcher) update(hash string) error {
+	current, err := w.header(hash)
+	if err != nil {
+		return err
+	}
+
+	// Walk back the new branch until a block of the known chain is found
+	branch := []header{current}
+	for !w.known(current) {
+		// Remember older blocks of the known chain, in case the branch forks below them
+		for current.Height <= w.chain[0].Height && w.chain[0].PreviousHash != "" {
+			if len(w.chain) >= w.options.Depth {
+				tip := branch[0]
+				w.chain = []header{tip}
+				return failure.Of("reorg deeper than %d blocks, resuming from %s (height %d)", w.options.Depth, tip.Hash, tip.Height)
+			}
+
+			parent, err := w.header(w.chain[0].PreviousHash)
+			if err != nil {
+				return err
+			}
+			w.chain = append([]header{parent}, w.chain...)
+		}
+
+		if current.PreviousHash == "" {
+			return failure.Of("block %s doesn't share any ancestor with the known chain", hash)
+		}
+
+		if current, err = w.header(current.PreviousHash); err != nil {
+			return err
+		}
+		branch = append(branch, current)
+	}
+
+	// The last block of the branch is the common ancestor
+	fork := len(w.chain) - 1 - (w.tip().Height - current.Height)
+	for i := len(w.chain) - 1; i > fork; i-- {
+		if !w.send(w.chain[i].event(EventDisconnected)) {
+			return nil
+		}
+	}
+	w.chain = w.chain[:fork+1]
+
+	for i := len(branch) - 2; i >= 0; i-- {
+		w.chain = append(w.chain, branch[i])
+		if !w.send(branch[i].event(EventConnected)) {
+			return nil
+		}
+	}
+
+	if len(w.chain) > w.options.Depth {
+		w.chain = w.chain[len(w.chain)-w.options.Depth:]
+	}
+
+	return nil
+}
+
+// known reports whether a block belongs to the known chain.
+func (w *watcher) known(h header) bool {
+	i := h.Height - w.chain[0].Height
+	return i >= 0 && i < len(w.chain) && w.chain[i].Hash == h.Hash
+}
+
+// tip returns the tip of the known chain.
+func (w *watcher) tip() header {
+	return w.chain[len(w.chain)-1]
+}
+
+// send sends an event, returning false if the context is done first.
+func (w *watcher) send(event Event) bool {
+	select {
+	case w.events <- event:
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+// sleep waits for d or until the context is done.
+func (w *watcher) sleep(d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-w.ctx.Done():
+	}
+}
+
+// call sends a request, aborting it when the context is done.
+func (w *watcher) call(method rpc.Method, params rpc.Params) (*rpc.Response, error) {
+	if rpc.Client == nil {
+		return nil, failure.Of("rpc client isn't available")
+	}
+
+	request := rpc.Request{
+		ID:      rpc.Identifier,
+		Version: rpc.Version2,
+		Method:  method,
+		Params:  params,
+	}
+
+	return rpc.Client.DoContext(w.ctx, request)
+}
+
+// best returns the hash of the active chain's tip.
+func (w *watcher) best() (string, error) {
+	response, err := w.call(MethodGetBestBlockHash, rpc.NoParams)
+	if err != nil {
+		return "", err
+	}
+
+	hash := ""
+	if err := response.Bind(&hash); err != nil {
+		return "", failure.Of("failed to parse best block hash: %v", err.Error())
+	}
+	return hash, nil
+}
+
+// hash extracts the tip's hash from a 'waitfornewblock'/'waitforblockheight' response.
+func (w *watcher) hash(response *rpc.Response) (string, error) {
+	result := struct {
+		Hash string `json:"hash"`
+	}{}
+	if err := response.Bind(&result); err != nil {
+		return "", failure.Of("failed to parse long-poll result: %v", err.Error())
+	}
+	return result.Hash, nil
+}
+
+// result extracts the tip's hash from a long-poll response, or returns the error it failed with.
+func (w *watcher) result(response *rpc.Response, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return w.hash(response)
+}
+
+// header retrieves the header of a block.
+func (w *watcher) header(hash string) (header, error) {
+	response, err := w.call(MethodGetBlockHeader, rpc.Params{hash, true})
+	if err != nil {
+		return header{}, err
+	}
+
+	h := header{}
+	if err := response.Bind(&h); err != nil {
+		return header{}, failure.Of("failed to parse block header: %v", err.Error())
+	}
+	return h, nil
+}
@@ -0,0 +1,157 @@
+package blocks_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+// next receives the next event, failing the test if none arrives in time.
+func next(t *testing.T, events <-chan blocks.Event) blocks.Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatalf("Events channel closed unexpectedly")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for an event")
+	}
+	return blocks.Event{}
+}
+
+// forbid answers the requests for a method with HTTP 403, as nodes do for methods left out of
+// their -rpcwhitelist.
+func forbid(method rpc.Method) rpc.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return rpc.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			if strings.Contains(string(body), `"method":"`+string(method)+`"`) {
+				return &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	cases := []struct {
+		Unavailable rpc.Method // Long-poll method made unavailable, if any
+		Forbidden   rpc.Method // Long-poll method forbidden by -rpcwhitelist, if any
+		Poll        bool
+	}{
+		{},
+		{Unavailable: blocks.MethodWaitForNewBlock},
+		{Forbidden: blocks.MethodWaitForNewBlock},
+		{Poll: true},
+	}
+
+	for i, test := range cases {
+		name := fmt.Sprintf("case %v", i)
+		t.Run(name, func(t *testing.T) {
+			server := rpctest.Use(t, rpctest.WithChain(rpctest.NewChain(20)))
+
+			if test.Unavailable != "" {
+				server.Fail(test.Unavailable, rpctest.RPCMethodNotFound, "Method not found")
+			}
+			if test.Forbidden != "" {
+				rpc.Client.Use(forbid(test.Forbidden))
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			events, err := blocks.Watch(ctx, blocks.WatchOptions{
+				Poll:     test.Poll,
+				Interval: 10 * time.Millisecond,
+				Timeout:  50 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("Failed to watch blocks: %v", err)
+			}
+
+			// New blocks are connected in order
+			for _, block := range server.Chain.Mine(2) {
+				event := next(t, events)
+				if event.Type != blocks.EventConnected || event.Hash != block.Hash || event.Height != block.Height {
+					t.Errorf("Expected block %v to be connected, got %+v", block.Height, event)
+				}
+			}
+
+			// Reorgs disconnect the stale blocks, tip first, then connect the new branch
+			disconnected, connected := server.Chain.Reorg(2, 3)
+			for j := len(disconnected) - 1; j >= 0; j-- {
+				event := next(t, events)
+				if event.Type != blocks.EventDisconnected || event.Hash != disconnected[j].Hash {
+					t.Errorf("Expected block %v to be disconnected, got %+v", disconnected[j].Height, event)
+				}
+			}
+			for _, block := range connected {
+				event := next(t, events)
+				if event.Type != blocks.EventConnected || event.Hash != block.Hash {
+					t.Errorf("Expected block %v to be connected, got %+v", block.Height, event)
+				}
+			}
+
+			if test.Forbidden != "" && server.Calls(blocks.MethodWaitForBlockHeight) == 0 {
+				t.Errorf("Expected the watcher to fall back to %s", blocks.MethodWaitForBlockHeight)
+			}
+
+			cancel()
+			for range events {
+				// Drain until the watcher stops
+			}
+		})
+	}
+}
+
+func Test_WatchRetry(t *testing.T) {
+	server := rpctest.Use(t, rpctest.WithChain(rpctest.NewChain(20)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := blocks.Watch(ctx, blocks.WatchOptions{Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to watch blocks: %v", err)
+	}
+
+	// Transient failures are reported and retried, without falling back from 'waitfornewblock'
+	server.Fail(blocks.MethodWaitForNewBlock, rpctest.RPCClientInInitialDownload, "Loading block index...")
+	for range 3 {
+		if event := next(t, events); event.Type != blocks.EventError {
+			t.Fatalf("Expected an error event, got %+v", event)
+		}
+	}
+	server.Recover(blocks.MethodWaitForNewBlock)
+
+	block := server.Chain.Mine(1)[0]
+	for event := next(t, events); event.Type != blocks.EventConnected; event = next(t, events) {
+		if event.Type != blocks.EventError {
+			t.Fatalf("Expected block %v to be connected, got %+v", block.Height, event)
+		}
+	}
+	if calls := server.Calls(blocks.MethodWaitForBlockHeight); calls != 0 {
+		t.Errorf("Expected no fallback to waitforblockheight, got %d calls", calls)
+	}
+
+	cancel()
+	for range events {
+		// Drain until the watcher stops
+	}
+}
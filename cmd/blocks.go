@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/avila-r/bitclient/config"
@@ -19,42 +21,83 @@ var (
 var (
 	// bitclient blocks get
 	BlocksGet = &cobra.Command{
-		Use:   config.Get().Commands.Blocks.Get.Use,
-		Short: config.Get().Commands.Blocks.Get.ShortDescription,
-		Long:  config.Get().Commands.Blocks.Get.LongDescription,
-		Run:   handler.Blocks.Get,
+		Use:               config.Get().Commands.Blocks.Get.Use,
+		Short:             config.Get().Commands.Blocks.Get.ShortDescription,
+		Long:              config.Get().Commands.Blocks.Get.LongDescription,
+		Run:               handler.Blocks.Get,
+		ValidArgsFunction: handler.Complete.Block,
 	}
 
 	// bitclient blocks filter
 	BlocksFilter = &cobra.Command{
-		Use:   config.Get().Commands.Blocks.Filter.Use,
-		Short: config.Get().Commands.Blocks.Filter.ShortDescription,
-		Long:  config.Get().Commands.Blocks.Filter.LongDescription,
-		Run:   handler.Blocks.Filter,
+		Use:               config.Get().Commands.Blocks.Filter.Use,
+		Short:             config.Get().Commands.Blocks.Filter.ShortDescription,
+		Long:              config.Get().Commands.Blocks.Filter.LongDescription,
+		Run:               handler.Blocks.Filter,
+		ValidArgsFunction: handler.Complete.Block,
 	}
 
 	// bitclient blocks hash
 	BlocksHash = &cobra.Command{
-		Use:   config.Get().Commands.Blocks.Hash.Use,
-		Short: config.Get().Commands.Blocks.Hash.ShortDescription,
-		Long:  config.Get().Commands.Blocks.Hash.LongDescription,
-		Run:   handler.Blocks.Hash,
+		Use:               config.Get().Commands.Blocks.Hash.Use,
+		Short:             config.Get().Commands.Blocks.Hash.ShortDescription,
+		Long:              config.Get().Commands.Blocks.Hash.LongDescription,
+		Run:               handler.Blocks.Hash,
+		ValidArgsFunction: handler.Complete.Block,
 	}
 
 	// bitclient blocks header
 	BlocksHeader = &cobra.Command{
-		Use:   config.Get().Commands.Blocks.Header.Use,
-		Short: config.Get().Commands.Blocks.Header.ShortDescription,
-		Long:  config.Get().Commands.Blocks.Header.LongDescription,
-		Run:   handler.Blocks.Header,
+		Use:               config.Get().Commands.Blocks.Header.Use,
+		Short:             config.Get().Commands.Blocks.Header.ShortDescription,
+		Long:              config.Get().Commands.Blocks.Header.LongDescription,
+		Run:               handler.Blocks.Header,
+		ValidArgsFunction: handler.Complete.Block,
+	}
+
+	// bitclient blocks scan
+	BlocksScan = &cobra.Command{
+		Use:   config.Get().Commands.Blocks.Scan.Use,
+		Short: config.Get().Commands.Blocks.Scan.ShortDescription,
+		Long:  config.Get().Commands.Blocks.Scan.LongDescription,
+		Args:  cobra.NoArgs,
+		Run:   handler.Blocks.Scan,
 	}
 
 	// bitclient blocks stats
 	BlocksStats = &cobra.Command{
-		Use:   config.Get().Commands.Blocks.Stats.Use,
-		Short: config.Get().Commands.Blocks.Stats.ShortDescription,
-		Long:  config.Get().Commands.Blocks.Stats.LongDescription,
-		Run:   handler.Blocks.Stats,
+		Use:               config.Get().Commands.Blocks.Stats.Use,
+		Short:             config.Get().Commands.Blocks.Stats.ShortDescription,
+		Long:              config.Get().Commands.Blocks.Stats.LongDescription,
+		Run:               handler.Blocks.Stats,
+		ValidArgsFunction: handler.Complete.Block,
+	}
+
+	// bitclient blocks verify-headers
+	BlocksVerifyHeaders = &cobra.Command{
+		Use:   config.Get().Commands.Blocks.VerifyHeaders.Use,
+		Short: config.Get().Commands.Blocks.VerifyHeaders.ShortDescription,
+		Long:  config.Get().Commands.Blocks.VerifyHeaders.LongDescription,
+		Args:  cobra.NoArgs,
+		Run:   handler.Blocks.VerifyHeaders,
+	}
+
+	// bitclient blocks verify-filters
+	BlocksVerifyFilters = &cobra.Command{
+		Use:   config.Get().Commands.Blocks.VerifyFilters.Use,
+		Short: config.Get().Commands.Blocks.VerifyFilters.ShortDescription,
+		Long:  config.Get().Commands.Blocks.VerifyFilters.LongDescription,
+		Args:  cobra.NoArgs,
+		Run:   handler.Blocks.VerifyFilters,
+	}
+
+	// bitclient blocks watch
+	BlocksWatch = &cobra.Command{
+		Use:   config.Get().Commands.Blocks.Watch.Use,
+		Short: config.Get().Commands.Blocks.Watch.ShortDescription,
+		Long:  config.Get().Commands.Blocks.Watch.LongDescription,
+		Args:  cobra.NoArgs,
+		Run:   handler.Blocks.Watch,
 	}
 )
 
@@ -63,6 +106,10 @@ func init() {
 	// Flags
 	{
 		Blocks.PersistentFlags().StringP("block", "b", "", "Specify the block if has a target block (optional)")
+		Blocks.PersistentFlags().Bool("rest", false, "Read blocks, headers and hashes through the node's REST interface (requires bitcoind -rest)")
+		Blocks.RegisterFlagCompletionFunc("block", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return handler.Complete.Block(cmd, nil, toComplete)
+		})
 		Blocks.Flags().IntP("verbosity", "v", 1, "Set full response's verbosity level (0-3, default: 0)")
 	}
 
@@ -77,19 +124,68 @@ func init() {
 			BlocksGet.Flags().Bool("hash", false, "Get blockhash")
 			BlocksGet.Flags().Bool("hex", false, "Set to return the block header in hexadecimal encoding")
 			BlocksGet.Flags().IntP("verbosity", "v", 1, "Set full response's verbosity level (0-3, default: 0)")
+			BlocksGet.Flags().Bool("decode", false, "Fetch the serialized block and decode it locally, verifying its merkle root")
 		}
 
 		Blocks.AddCommand(BlocksHeader) // bitclient blocks header
 		{
 			BlocksHeader.Flags().Bool("hex", false, "Set to return the block header in hexadecimal encoding")
+			BlocksHeader.Flags().Bool("decode", false, "Fetch the serialized block header and decode it locally")
 		}
 
 		Blocks.AddCommand(BlocksFilter) // bitclient blocks filter
-		Blocks.AddCommand(BlocksHash)   // bitclient blocks hash
+		{
+			BlocksFilter.Flags().String("type", "basic", "Type of the filter")
+			BlocksFilter.Flags().StringSlice("script", []string{}, "Hex-encoded scriptPubKey to match against the filter locally (basic filters only)")
+		}
+
+		Blocks.AddCommand(BlocksHash) // bitclient blocks hash
+
+		Blocks.AddCommand(BlocksScan) // bitclient blocks scan
+		{
+			BlocksScan.Flags().StringSliceP("address", "a", []string{}, "Address to look for")
+			BlocksScan.Flags().StringSlice("script", []string{}, "Hex-encoded scriptPubKey to look for")
+			BlocksScan.Flags().Int("from", 0, "Height of the first block to scan")
+			BlocksScan.Flags().Int("to", -1, "Height of the last block to scan (default: the tip, or the checkpoint's last block when resuming)")
+			BlocksScan.Flags().Int("workers", 4, "Number of blocks checked concurrently")
+			BlocksScan.Flags().String("checkpoint", "", "File recording the scan's progress, resuming from it if it exists")
+		}
 
 		Blocks.AddCommand(BlocksStats) // bitclient blocks stats
 		{
 			BlocksStats.Flags().StringSliceP("stat", "s", []string{}, "A specific statistic to retrieve.")
+			BlocksStats.RegisterFlagCompletionFunc("stat", handler.Complete.Stat)
+			BlocksStats.Flags().Int("from", 0, "Height of the first block of a range")
+			BlocksStats.Flags().Int("to", -1, "Height of the last block of a range (default: the tip)")
+			BlocksStats.Flags().String("range", "", "Heights of a range as FROM-TO, e.g. 800000-800143 (the tip when TO is omitted)")
+			BlocksStats.MarkFlagsMutuallyExclusive("range", "from")
+			BlocksStats.MarkFlagsMutuallyExclusive("range", "to")
+			BlocksStats.Flags().String("since", "", "Start of a time window, as a date, an RFC 3339 time or a unix timestamp")
+			BlocksStats.Flags().String("until", "", "End of a time window, as a date, an RFC 3339 time or a unix timestamp")
+			BlocksStats.Flags().Int("workers", 4, "Number of blocks whose stats are fetched concurrently")
+			BlocksStats.Flags().StringP("output", "o", "", "File to export the stats of a range to (default: the standard output)")
+			BlocksStats.Flags().String("format", "", "Export format: csv, jsonl or parquet (default: from the output extension, or jsonl)")
+			BlocksStats.Flags().Bool("aggregate", false, "Print fee and segwit aggregates of the range once exported (to stderr when exporting to stdout)")
+		}
+
+		Blocks.AddCommand(BlocksWatch) // bitclient blocks watch
+		{
+			BlocksWatch.Flags().Bool("poll", false, "Poll getbestblockhash instead of using long-polls")
+			BlocksWatch.Flags().Duration("interval", time.Second, "Polling interval, also used as retry delay after failures")
+			BlocksWatch.Flags().Duration("timeout", time.Minute, "Timeout of each long-poll")
+			BlocksWatch.Flags().Int("depth", 100, "Deepest reorg that can be followed, in blocks")
+		}
+
+		Blocks.AddCommand(BlocksVerifyHeaders) // bitclient blocks verify-headers
+		{
+			BlocksVerifyHeaders.Flags().Int("from", 0, "Height of the first header to verify")
+			BlocksVerifyHeaders.Flags().Int("to", -1, "Height of the last header to verify (default: the tip)")
+		}
+
+		Blocks.AddCommand(BlocksVerifyFilters) // bitclient blocks verify-filters
+		{
+			BlocksVerifyFilters.Flags().Int("from", 0, "Height of the first filter header to verify")
+			BlocksVerifyFilters.Flags().Int("to", -1, "Height of the last filter header to verify (default: the tip)")
 		}
 	}
 }
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
+)
+
+// bitclient completion
+var Completion = &cobra.Command{
+	Use:       config.Get().Commands.Completion.Use,
+	Short:     config.Get().Commands.Completion.ShortDescription,
+	Long:      config.Get().Commands.Completion.LongDescription,
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Run:       handler.Completion,
+}
+
+func init() {
+	// Replaces cobra's default completion command
+	Root.CompletionOptions.DisableDefaultCmd = true
+	Root.AddCommand(Completion)
+}
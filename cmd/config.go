@@ -4,15 +4,14 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
 )
 
 var Config = &cobra.Command{
 	Use:   config.Get().Commands.Config.Use,
 	Short: config.Get().Commands.Config.ShortDescription,
 	Long:  config.Get().Commands.Config.LongDescription,
-	Run: func(_ *cobra.Command, _ []string) {
-		config.Get().Log()
-	},
+	Run:   handler.Config,
 }
 
 func init() {
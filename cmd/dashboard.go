@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
+)
+
+// bitclient dashboard
+var Dashboard = &cobra.Command{
+	Use:   config.Get().Commands.Dashboard.Use,
+	Short: config.Get().Commands.Dashboard.ShortDescription,
+	Long:  config.Get().Commands.Dashboard.LongDescription,
+	Args:  cobra.NoArgs,
+	Run:   handler.Dashboard,
+}
+
+func init() {
+	Root.AddCommand(Dashboard)
+	// Flags
+	{
+		Dashboard.Flags().Duration("interval", 2*time.Second, "Time between refreshes")
+		Dashboard.Flags().Int("blocks", 6, "Number of latest blocks listed")
+		Dashboard.Flags().Duration("ban-time", 24*time.Hour, "Duration of the bans of peers")
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
+)
+
+// bitclient exporter
+var Exporter = &cobra.Command{
+	Use:   config.Get().Commands.Exporter.Use,
+	Short: config.Get().Commands.Exporter.ShortDescription,
+	Long:  config.Get().Commands.Exporter.LongDescription,
+	Args:  cobra.NoArgs,
+	Run:   handler.Exporter,
+}
+
+func init() {
+	Root.AddCommand(Exporter)
+	// Flags
+	{
+		Exporter.Flags().String("listen", ":9332", "Address the metrics are served on, at /metrics")
+		Exporter.Flags().Duration("interval", 15*time.Second, "Time between two polls of the node")
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
+)
+
+// bitclient monitor
+var (
+	Monitor = &cobra.Command{
+		Use:   config.Get().Commands.Monitor.Use,
+		Short: config.Get().Commands.Monitor.ShortDescription,
+		Long:  config.Get().Commands.Monitor.LongDescription,
+	}
+)
+
+var (
+	// bitclient monitor chaintips
+	MonitorChainTips = &cobra.Command{
+		Use:   config.Get().Commands.Monitor.ChainTips.Use,
+		Short: config.Get().Commands.Monitor.ChainTips.ShortDescription,
+		Long:  config.Get().Commands.Monitor.ChainTips.LongDescription,
+		Args:  cobra.NoArgs,
+		Run:   handler.Monitor.ChainTips,
+	}
+)
+
+func init() {
+	Root.AddCommand(Monitor) // bitclient monitor
+
+	// Subcommands
+	Monitor.AddCommand(
+		MonitorChainTips,
+	)
+
+	// Subcommands' flags
+	{
+		MonitorChainTips.Flags().Duration("interval", 10*time.Second, "Time between two polls of the chain tips")
+		MonitorChainTips.Flags().Int("depth", 0, "Only alert on reorgs disconnecting more than this many blocks")
+		MonitorChainTips.Flags().StringArray("exec", []string{}, "Shell command run on each alert, reading it as JSON from its standard input")
+		MonitorChainTips.Flags().StringArray("webhook", []string{}, "URL each alert is posted to as JSON")
+		MonitorChainTips.Flags().StringArray("file", []string{}, "File each alert is appended to as a JSON line")
+	}
+}
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/guard"
 	"github.com/avila-r/bitclient/handler"
 )
 
@@ -75,10 +79,11 @@ var (
 
 	// bitclient network unban
 	NetworkUnban = &cobra.Command{
-		Use:   config.Get().Commands.Network.Unban.Use,
-		Short: config.Get().Commands.Network.Unban.ShortDescription,
-		Long:  config.Get().Commands.Network.Unban.LongDescription,
-		Run:   handler.Network.Unban,
+		Use:               config.Get().Commands.Network.Unban.Use,
+		Short:             config.Get().Commands.Network.Unban.ShortDescription,
+		Long:              config.Get().Commands.Network.Unban.LongDescription,
+		Run:               handler.Network.Unban,
+		ValidArgsFunction: handler.Complete.Banned,
 	}
 
 	// bitclient network blacklist
@@ -88,6 +93,46 @@ var (
 		Long:  config.Get().Commands.Network.Blacklist.LongDescription,
 		Run:   handler.Network.Blacklist,
 	}
+
+	// bitclient network blacklist list
+	NetworkBlacklistList = &cobra.Command{
+		Use:   config.Get().Commands.Network.Blacklist.List.Use,
+		Short: config.Get().Commands.Network.Blacklist.List.ShortDescription,
+		Long:  config.Get().Commands.Network.Blacklist.List.LongDescription,
+		Run:   handler.Network.Blacklist,
+	}
+
+	// bitclient network blacklist export
+	NetworkBlacklistExport = &cobra.Command{
+		Use:   config.Get().Commands.Network.Blacklist.Export.Use,
+		Short: config.Get().Commands.Network.Blacklist.Export.ShortDescription,
+		Long:  config.Get().Commands.Network.Blacklist.Export.LongDescription,
+		Run:   handler.Network.BlacklistExport,
+	}
+
+	// bitclient network blacklist import
+	NetworkBlacklistImport = &cobra.Command{
+		Use:   config.Get().Commands.Network.Blacklist.Import.Use,
+		Short: config.Get().Commands.Network.Blacklist.Import.ShortDescription,
+		Long:  config.Get().Commands.Network.Blacklist.Import.LongDescription,
+		Run:   handler.Network.BlacklistImport,
+	}
+
+	// bitclient network blacklist sync
+	NetworkBlacklistSync = &cobra.Command{
+		Use:   config.Get().Commands.Network.Blacklist.Sync.Use,
+		Short: config.Get().Commands.Network.Blacklist.Sync.ShortDescription,
+		Long:  config.Get().Commands.Network.Blacklist.Sync.LongDescription,
+		Run:   handler.Network.BlacklistSync,
+	}
+
+	// bitclient network guard
+	NetworkGuard = &cobra.Command{
+		Use:   config.Get().Commands.Network.Guard.Use,
+		Short: config.Get().Commands.Network.Guard.ShortDescription,
+		Long:  config.Get().Commands.Network.Guard.LongDescription,
+		Run:   handler.Network.Guard,
+	}
 )
 
 func init() {
@@ -107,5 +152,44 @@ func init() {
 		NetworkBan,
 		NetworkUnban,
 		NetworkBlacklist,
+		NetworkGuard,
+	)
+	NetworkBlacklist.AddCommand(
+		NetworkBlacklistList,
+		NetworkBlacklistExport,
+		NetworkBlacklistImport,
+		NetworkBlacklistSync,
 	)
+
+	// Subcommands' flags
+	{
+		NetworkBan.Flags().Int("time", 0, "Ban duration in seconds (default: 24 hours)")
+		NetworkBan.Flags().Bool("absolute", false, "Set to interpret --time as the UNIX timestamp the ban ends at")
+
+		NetworkGuard.Flags().Duration("max-ping", 0, "Maximum ping time of peers (e.g. 2s)")
+		NetworkGuard.Flags().String("min-version", "", "Minimum version of Bitcoin Core peers (e.g. 25.0)")
+		NetworkGuard.Flags().Bool("no-services", false, "Set to judge peers advertising no service")
+		NetworkGuard.Flags().Duration("stale", 0, "Time after which peers that never relayed a block are judged (e.g. 30m)")
+		NetworkGuard.Flags().Int("max-per-subnet", 0, "Maximum number of peers from the same /16 (IPv4) or /32 (IPv6)")
+		NetworkGuard.Flags().Int("max-per-asn", 0, "Maximum number of peers from the same autonomous system (requires -asmap)")
+		NetworkGuard.Flags().StringSlice("ban", []string{}, "Rules whose breakers are banned rather than disconnected: "+strings.Join(guard.Rules, ", "))
+		NetworkGuard.Flags().Duration("ban-time", 0, "Ban duration (default: 24 hours)")
+		NetworkGuard.Flags().Bool("inbound-only", false, "Set to judge inbound peers only")
+		NetworkGuard.Flags().StringArray("protect", []string{}, "IP or subnet whose peers are never judged (repeatable)")
+		NetworkGuard.Flags().Duration("interval", time.Minute, "Time between two evaluations of the peers")
+		NetworkGuard.Flags().Bool("dry-run", false, "Set to print the decisions without disconnecting or banning any peer")
+		NetworkGuard.Flags().Bool("once", false, "Set to evaluate the peers once and exit")
+		NetworkGuard.Flags().String("audit-log", "", "File every decision is appended to as a JSON line")
+
+		NetworkBlacklistExport.Flags().String("format", "json", "Format of the ban list: json or csv")
+		NetworkBlacklistExport.Flags().StringP("output", "o", "", "File the ban list is written to (default: standard output)")
+
+		NetworkBlacklistImport.Flags().Bool("prune", false, "Set to also unban the subnets missing from the file, and shorten longer bans")
+		NetworkBlacklistImport.Flags().Bool("dry-run", false, "Set to print the changes without applying them")
+
+		NetworkBlacklistSync.Flags().String("from", "", "Profile of the node whose ban list is copied (default: the default profile)")
+		NetworkBlacklistSync.Flags().String("to", "", "Profile of the node whose ban list is changed (default: the default profile)")
+		NetworkBlacklistSync.Flags().Bool("prune", false, "Set to also unban the subnets the source node doesn't ban, and shorten longer bans")
+		NetworkBlacklistSync.Flags().Bool("dry-run", false, "Set to print the diff without applying it")
+	}
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
+)
+
+// bitclient nodes
+var (
+	Nodes = &cobra.Command{
+		Use:   config.Get().Commands.Nodes.Use,
+		Short: config.Get().Commands.Nodes.ShortDescription,
+		Long:  config.Get().Commands.Nodes.LongDescription,
+	}
+)
+
+var (
+	// bitclient nodes disconnect
+	NodesDisconnect = &cobra.Command{
+		Use:               config.Get().Commands.Nodes.Disconnect.Use,
+		Short:             config.Get().Commands.Nodes.Disconnect.ShortDescription,
+		Long:              config.Get().Commands.Nodes.Disconnect.LongDescription,
+		Args:              cobra.MaximumNArgs(1),
+		Run:               handler.Nodes.Disconnect,
+		ValidArgsFunction: handler.Complete.Peer,
+	}
+)
+
+func init() {
+	Root.AddCommand(Nodes) // bitclient nodes
+
+	// Subcommands
+	Nodes.AddCommand(
+		NodesDisconnect,
+	)
+}
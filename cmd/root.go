@@ -1,23 +1,49 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
 	"github.com/avila-r/bitclient/logger"
-	// "github.com/avila-r/bitclient/handlers"
 )
 
 var Root = &cobra.Command{
-	Use:   config.Get().Main.Use,
-	Short: config.Get().Main.ShortDescription,
-	Long:  config.Get().Main.LongDescription,
-	// Currently disable interactive mode
-	// Run:   handlers.Root,
+	Use:               config.Get().Main.Use,
+	Short:             config.Get().Main.ShortDescription,
+	Long:              config.Get().Main.LongDescription,
+	Args:              cobra.NoArgs,
+	Run:               handler.Root,
+	PersistentPreRun:  handler.Connect,
+	PersistentPostRun: handler.Disconnect,
+}
+
+func init() {
+	// Flags
+	{
+		Root.PersistentFlags().String("profile", "", "Connection profile to use, read from the .env.<profile> file")
+		Root.PersistentFlags().String("datadir", "", "Bitcoin Core data directory, used to locate bitcoin.conf and the cookie file")
+		Root.PersistentFlags().String("conf", "", "Path to bitcoin.conf (relative paths are resolved against --datadir)")
+		Root.PersistentFlags().String("chain", "", "Chain section of bitcoin.conf to use (main, test, testnet4, signet, regtest)")
+		Root.PersistentFlags().String("record", "", "Record every RPC request and response to a JSONL cassette file")
+		Root.PersistentFlags().String("replay", "", "Serve RPC responses from a JSONL cassette file instead of reaching the node")
+		Root.MarkFlagsMutuallyExclusive("record", "replay")
+		Root.PersistentFlags().String("log-level", "", "Minimum level of the messages logged: debug, info, warn or error (default from LOG_LEVEL, or info)")
+		Root.PersistentFlags().String("log-format", "", "Format of the messages logged: text or json (default from LOG_FORMAT, or text)")
+		Root.PersistentFlags().String("log-file", "", "File the messages are appended to, instead of stderr (default from LOG_FILE)")
+		Root.PersistentFlags().Bool("trace", false, "Print a timing summary of the RPC requests to stderr after the command")
+		Root.PersistentFlags().String("otlp-endpoint", "", "OTLP/HTTP collector the RPC requests are exported to as traces (e.g. http://localhost:4318)")
+	}
 }
 
 func Execute() {
 	if err := Root.Execute(); err != nil {
 		logger.Fatalf("failed to run bitclient cmd: %v", err.Error())
 	}
+
+	if err := handler.Failed(); err != nil {
+		os.Exit(1)
+	}
 }
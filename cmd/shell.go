@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
+)
+
+// bitclient shell
+var Shell = &cobra.Command{
+	Use:   config.Get().Commands.Shell.Use,
+	Short: config.Get().Commands.Shell.ShortDescription,
+	Long:  config.Get().Commands.Shell.LongDescription,
+	Args:  cobra.NoArgs,
+	Run:   handler.Shell,
+}
+
+func init() {
+	Root.AddCommand(Shell)
+	// Flags
+	{
+		Shell.Flags().String("history", "", "Path of the history file (default: ~/.local/state/bitclient/history)")
+		Shell.Flags().Bool("no-history", false, "Don't read nor save the history")
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/handler"
+)
+
+// bitclient zmq
+var (
+	Zmq = &cobra.Command{
+		Use:   config.Get().Commands.Zmq.Use,
+		Short: config.Get().Commands.Zmq.ShortDescription,
+		Long:  config.Get().Commands.Zmq.LongDescription,
+	}
+)
+
+var (
+	// bitclient zmq listen
+	ZmqListen = &cobra.Command{
+		Use:   config.Get().Commands.Zmq.Listen.Use,
+		Short: config.Get().Commands.Zmq.Listen.ShortDescription,
+		Long:  config.Get().Commands.Zmq.Listen.LongDescription,
+		Args:  cobra.NoArgs,
+		Run:   handler.Zmq.Listen,
+	}
+)
+
+func init() {
+	Root.AddCommand(Zmq) // bitclient zmq
+
+	// Subcommands
+	{
+		Zmq.AddCommand(ZmqListen) // bitclient zmq listen
+		// Flags
+		{
+			ZmqListen.Flags().StringSliceP("topic", "t", []string{}, "Topics to subscribe to (rawblock, hashblock, rawtx, hashtx, sequence), default: all enabled ones")
+			ZmqListen.Flags().StringP("endpoint", "e", "", "Publisher endpoint (e.g. tcp://127.0.0.1:28332), instead of discovering it through getzmqnotifications")
+			ZmqListen.Flags().Duration("retry", time.Second, "Delay before reconnecting after a failure")
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Chain identifies the Bitcoin network a node is running on.
+type Chain string
+
+// Constants representing the networks supported by Bitcoin Core.
+const (
+	ChainMain     Chain = "main"     // Main network
+	ChainTest     Chain = "test"     // Test network (testnet3)
+	ChainTestnet4 Chain = "testnet4" // Test network (testnet4)
+	ChainSignet   Chain = "signet"   // Signet network
+	ChainRegtest  Chain = "regtest"  // Regression test network
+)
+
+// Chains lists every supported chain, in the order they're usually presented.
+var Chains = []Chain{ChainMain, ChainTest, ChainTestnet4, ChainSignet, ChainRegtest}
+
+// ChainFrom converts a string to the corresponding Chain value.
+//
+// Besides the canonical names, "mainnet" and "testnet" are accepted as aliases
+// for "main" and "test". An error is returned for unknown chains.
+func ChainFrom(s string) (Chain, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "main", "mainnet":
+		return ChainMain, nil
+	case "test", "testnet", "testnet3":
+		return ChainTest, nil
+	case "testnet4":
+		return ChainTestnet4, nil
+	case "signet":
+		return ChainSignet, nil
+	case "regtest":
+		return ChainRegtest, nil
+	}
+	return "", fmt.Errorf("invalid chain '%s' (valid chains are: %v)", s, Chains)
+}
+
+// Directory returns the chain-specific subdirectory of the data directory,
+// where Bitcoin Core keeps its blocks, chainstate and the RPC cookie file.
+func (c Chain) Directory() string {
+	switch c {
+	case ChainTest:
+		return "testnet3"
+	case ChainTestnet4:
+		return "testnet4"
+	case ChainSignet:
+		return "signet"
+	case ChainRegtest:
+		return "regtest"
+	}
+	return ""
+}
+
+// RPCPort returns the default RPC port used by Bitcoin Core for the chain.
+func (c Chain) RPCPort() int {
+	switch c {
+	case ChainTest:
+		return 18332
+	case ChainTestnet4:
+		return 48332
+	case ChainSignet:
+		return 38332
+	case ChainRegtest:
+		return 18443
+	}
+	return 8332
+}
+
+// networkOnly holds the options that Bitcoin Core only honours from the
+// network section when a chain other than main is selected.
+var networkOnly = map[string]bool{
+	"addnode":    true,
+	"bind":       true,
+	"connect":    true,
+	"port":       true,
+	"rpcbind":    true,
+	"rpcport":    true,
+	"wallet":     true,
+	"whitebind":  true,
+	"onlynet":    true,
+	"rpcallowip": true,
+	"walletdir":  true,
+}
+
+// BitcoinConf represents a parsed bitcoin.conf file, including the files
+// pulled in through 'includeconf' and the section of the selected chain.
+type BitcoinConf struct {
+	Path    string // Path to the main configuration file
+	DataDir string // Data directory the configuration refers to
+	Chain   Chain  // Chain whose section is used to resolve options
+
+	global   map[string][]string            // Options set outside of any section
+	sections map[string]map[string][]string // Options set under [main], [test], [signet], ...
+}
+
+// DefaultDataDir returns the platform-specific default data directory used by Bitcoin Core.
+func DefaultDataDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		if appdata := os.Getenv("APPDATA"); appdata != "" {
+			return filepath.Join(appdata, "Bitcoin")
+		}
+		return filepath.Join(home, "AppData", "Roaming", "Bitcoin")
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "Bitcoin")
+	}
+	return filepath.Join(home, ".bitcoin")
+}
+
+// ReadBitcoinConf reads and parses a bitcoin.conf file.
+//
+// Parameters:
+//   - datadir (string): The node's data directory. Defaults to DefaultDataDir when empty.
+//   - path (string): Path to the configuration file. Defaults to "bitcoin.conf"; relative
+//     paths are resolved against the data directory, just like Bitcoin Core's -conf.
+//   - chain (string): The chain whose section should be used (main, test, testnet4, signet
+//     or regtest). When empty, it's taken from 'chain', 'testnet', 'testnet4', 'signet' or
+//     'regtest' inside the file, falling back to main.
+//
+// Notes:
+//   - A missing file is read as an empty one, unless its path was given.
+//   - A 'datadir' option inside the file overrides the data directory, unless one was given.
+//   - Files referenced through 'includeconf' (globally or in the selected chain section) are
+//     read once; nested 'includeconf' options are ignored, as Bitcoin Core does.
+func ReadBitcoinConf(datadir, path, chain string) (*BitcoinConf, error) {
+	explicit := datadir != ""
+	if !explicit {
+		datadir = DefaultDataDir()
+	}
+	datadir = expand(datadir)
+
+	required := path != ""
+	if !required {
+		path = "bitcoin.conf"
+	}
+	path = expand(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(datadir, path)
+	}
+
+	conf := &BitcoinConf{
+		Path:     path,
+		DataDir:  datadir,
+		global:   map[string][]string{},
+		sections: map[string]map[string][]string{},
+	}
+
+	// Like Bitcoin Core, only a file given explicitly must exist: nodes configured through their
+	// command line, authenticated by cookie, may have none
+	if _, err := os.Stat(path); required || !errors.Is(err, os.ErrNotExist) {
+		if err := conf.parse(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir, ok := last(conf.global["datadir"]); ok && !explicit {
+		conf.DataDir = expand(dir)
+	}
+
+	selected, err := conf.selectChain(chain)
+	if err != nil {
+		return nil, err
+	}
+	conf.Chain = selected
+
+	// Only the main file is allowed to include other files
+	includes := append([]string{}, conf.global["includeconf"]...)
+	includes = append(includes, conf.sections[string(selected)]["includeconf"]...)
+	for _, include := range includes {
+		include = expand(include)
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(conf.DataDir, include)
+		}
+		if err := conf.parse(include); err != nil {
+			return nil, fmt.Errorf("failed to read included file: %v", err)
+		}
+	}
+
+	return conf, nil
+}
+
+// parse reads a single configuration file into the global and section option maps.
+func (b *BitcoinConf) parse(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i] // Strip comments
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("parse error on line %d of %s: %s, expected 'key=value'", n, path, line)
+		}
+		key, value = strings.TrimSpace(strings.TrimPrefix(key, "-")), strings.TrimSpace(value)
+
+		// Options can also be scoped inline, as in 'regtest.rpcport=18443'
+		target := section
+		if prefix, name, ok := strings.Cut(key, "."); ok {
+			target, key = prefix, name
+		}
+
+		if target == "" {
+			b.global[key] = append(b.global[key], value)
+			continue
+		}
+		if b.sections[target] == nil {
+			b.sections[target] = map[string][]string{}
+		}
+		b.sections[target][key] = append(b.sections[target][key], value)
+	}
+
+	return scanner.Err()
+}
+
+// selectChain resolves the chain from the given flag or, when empty, from the file itself.
+func (b *BitcoinConf) selectChain(flag string) (Chain, error) {
+	if flag != "" {
+		return ChainFrom(flag)
+	}
+
+	if chain, ok := last(b.global["chain"]); ok {
+		return ChainFrom(chain)
+	}
+
+	for _, chain := range []Chain{ChainTestnet4, ChainSignet, ChainRegtest} {
+		if b.enabled(string(chain)) {
+			return chain, nil
+		}
+	}
+	if b.enabled("testnet") {
+		return ChainTest, nil
+	}
+
+	return ChainMain, nil
+}
+
+// enabled reports whether a boolean option is set to a truthy value in the global scope.
+func (b *BitcoinConf) enabled(key string) bool {
+	value, ok := last(b.global[key])
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err == nil && enabled
+}
+
+// last returns the last value set for an option, since later values take precedence.
+func last(values []string) (string, bool) {
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// Get returns the value of an option for the selected chain.
+//
+// Values set in the chain section take precedence over global ones. Network-only options
+// (such as 'rpcport' or 'rpcbind') are ignored outside of a section unless the chain is main.
+func (b *BitcoinConf) Get(key string) (string, bool) {
+	if value, ok := last(b.sections[string(b.Chain)][key]); ok {
+		return value, true
+	}
+
+	if b.Chain != ChainMain && networkOnly[key] {
+		return "", false
+	}
+
+	return last(b.global[key])
+}
+
+// ChainDir returns the chain-specific data directory (e.g. ~/.bitcoin/signet).
+func (b *BitcoinConf) ChainDir() string {
+	return filepath.Join(b.DataDir, b.Chain.Directory())
+}
+
+// RPCURL returns the URL of the node's RPC server, built from 'rpcconnect' and 'rpcport'.
+//
+// 'rpcconnect' defaults to 127.0.0.1 and may carry its own port, in which case it takes
+// precedence over the chain's default port but not over an explicit 'rpcport'.
+func (b *BitcoinConf) RPCURL() (string, error) {
+	host, port := "127.0.0.1", strconv.Itoa(b.Chain.RPCPort())
+
+	if connect, ok := b.Get("rpcconnect"); ok && connect != "" {
+		host = connect
+		if h, p, err := net.SplitHostPort(connect); err == nil {
+			host, port = h, p
+		}
+	}
+
+	if rpcport, ok := b.Get("rpcport"); ok {
+		if _, err := strconv.Atoi(rpcport); err != nil {
+			return "", fmt.Errorf("invalid rpcport '%s'", rpcport)
+		}
+		port = rpcport
+	}
+
+	return "http://" + net.JoinHostPort(strings.Trim(host, "[]"), port), nil
+}
+
+// RPCCredentials returns the RPC credentials in the 'username:password' format.
+//
+// 'rpcuser' and 'rpcpassword' are used when present. Otherwise, the cookie file written by
+// the node is read, from 'rpccookiefile' or '.cookie' inside the chain's data directory.
+func (b *BitcoinConf) RPCCredentials() (string, error) {
+	if password, ok := b.Get("rpcpassword"); ok && password != "" {
+		user, _ := b.Get("rpcuser")
+		if user == "" {
+			return "", fmt.Errorf("rpcpassword is set in %s, but rpcuser isn't", b.Path)
+		}
+		return user + ":" + password, nil
+	}
+
+	cookie, ok := b.Get("rpccookiefile")
+	if !ok || cookie == "" {
+		cookie = ".cookie"
+	}
+	cookie = expand(cookie)
+	if !filepath.IsAbs(cookie) {
+		cookie = filepath.Join(b.ChainDir(), cookie)
+	}
+
+	content, err := os.ReadFile(cookie)
+	if err != nil {
+		return "", fmt.Errorf("no rpcpassword set and unable to read cookie file: %v", err)
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// expand replaces a leading '~' in a path with the user's home directory.
+func expand(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,138 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avila-r/bitclient/config"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func Test_ReadBitcoinConf(t *testing.T) {
+	datadir := t.TempDir()
+
+	write(t, filepath.Join(datadir, "bitcoin.conf"), `
+# Global options
+rpcuser=alice
+rpcpassword=secret # inline comment
+rpcport=9999
+includeconf=extra.conf
+
+[test]
+rpcconnect=10.0.0.2
+
+[regtest]
+rpcport=19000
+rpcpassword=
+`)
+	write(t, filepath.Join(datadir, "extra.conf"), "signet.rpcconnect=10.0.0.3:40000\n")
+	write(t, filepath.Join(datadir, "regtest", ".cookie"), "__cookie__:abcdef\n")
+
+	cases := []struct {
+		Chain       string
+		URL         string
+		Credentials string
+	}{
+		{Chain: "main", URL: "http://127.0.0.1:9999", Credentials: "alice:secret"},
+		{Chain: "test", URL: "http://10.0.0.2:18332", Credentials: "alice:secret"},
+		{Chain: "signet", URL: "http://10.0.0.3:40000", Credentials: "alice:secret"},
+		{Chain: "regtest", URL: "http://127.0.0.1:19000", Credentials: "__cookie__:abcdef"},
+	}
+
+	for _, test := range cases {
+		t.Run(test.Chain, func(t *testing.T) {
+			conf, err := config.ReadBitcoinConf(datadir, "", test.Chain)
+			if err != nil {
+				t.Fatalf("Failed to read bitcoin.conf: %v", err)
+			}
+
+			url, err := conf.RPCURL()
+			if err != nil {
+				t.Fatalf("Failed to derive rpc url: %v", err)
+			}
+			if url != test.URL {
+				t.Errorf("Expected url %s, got %s", test.URL, url)
+			}
+
+			credentials, err := conf.RPCCredentials()
+			if err != nil {
+				t.Fatalf("Failed to derive rpc credentials: %v", err)
+			}
+			if credentials != test.Credentials {
+				t.Errorf("Expected credentials %s, got %s", test.Credentials, credentials)
+			}
+		})
+	}
+}
+
+func Test_ReadBitcoinConfChainSelection(t *testing.T) {
+	cases := []struct {
+		Content string
+		Flag    string
+		Chain   config.Chain
+	}{
+		{Content: "", Chain: config.ChainMain},
+		{Content: "testnet=1\n", Chain: config.ChainTest},
+		{Content: "regtest=1\n", Chain: config.ChainRegtest},
+		{Content: "chain=signet\n", Chain: config.ChainSignet},
+		{Content: "chain=signet\n", Flag: "testnet4", Chain: config.ChainTestnet4},
+	}
+
+	for _, test := range cases {
+		t.Run(string(test.Chain), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "node.conf")
+			write(t, path, test.Content)
+
+			conf, err := config.ReadBitcoinConf("", path, test.Flag)
+			if err != nil {
+				t.Fatalf("Failed to read bitcoin.conf: %v", err)
+			}
+			if conf.Chain != test.Chain {
+				t.Errorf("Expected chain %s, got %s", test.Chain, conf.Chain)
+			}
+		})
+	}
+
+	datadir := t.TempDir()
+	write(t, filepath.Join(datadir, "bitcoin.conf"), "rpcuser=alice\n")
+	if _, err := config.ReadBitcoinConf(datadir, "", "mars"); err == nil || !strings.Contains(err.Error(), "mars") {
+		t.Errorf("Expected failure for an invalid chain, got %v", err)
+	}
+	write(t, filepath.Join(datadir, "bitcoin.conf"), "chain=mars\n")
+	if _, err := config.ReadBitcoinConf(datadir, "", ""); err == nil || !strings.Contains(err.Error(), "mars") {
+		t.Errorf("Expected failure for an invalid chain in the file, got %v", err)
+	}
+}
+
+func Test_ReadBitcoinConfMissing(t *testing.T) {
+	// Nodes authenticated by cookie may have no bitcoin.conf
+	datadir := t.TempDir()
+	write(t, filepath.Join(datadir, "regtest", ".cookie"), "__cookie__:abcdef\n")
+
+	conf, err := config.ReadBitcoinConf(datadir, "", "regtest")
+	if err != nil {
+		t.Fatalf("Failed to read a missing default bitcoin.conf: %v", err)
+	}
+	if url, err := conf.RPCURL(); err != nil || url != "http://127.0.0.1:18443" {
+		t.Errorf("Expected the default regtest url, got %s (%v)", url, err)
+	}
+	if credentials, err := conf.RPCCredentials(); err != nil || credentials != "__cookie__:abcdef" {
+		t.Errorf("Expected the cookie's credentials, got %s (%v)", credentials, err)
+	}
+
+	// Unless the file was given
+	if _, err := config.ReadBitcoinConf(datadir, "bitcoin.conf", "regtest"); err == nil {
+		t.Errorf("Expected failure for a missing file given explicitly")
+	}
+}
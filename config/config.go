@@ -33,6 +33,14 @@ type Properties struct {
 
 		Ping command `toml:"ping"` // Health check command settings
 
+		Dashboard command `toml:"dashboard"` // Live node status dashboard settings
+
+		Shell command `toml:"shell"` // Interactive session settings
+
+		Completion command `toml:"completion"` // Shell completion script settings
+
+		Exporter command `toml:"exporter"` // Prometheus exporter settings
+
 		// Blockchain contains blockchain-related command settings
 		Blockchain struct {
 			command         // General command settings for blockchain
@@ -46,9 +54,20 @@ type Properties struct {
 			Filter  command `toml:"filter"`
 			Hash    command `toml:"hash"`
 			Header  command `toml:"header"`
+			Scan    command `toml:"scan"`
 			Stats   command `toml:"stats"`
+			Watch   command `toml:"watch"`
+
+			VerifyFilters command `toml:"verify-filters"`
+			VerifyHeaders command `toml:"verify-headers"`
 		} `toml:"blocks"`
 
+		// Monitor contains monitoring daemons' command settings
+		Monitor struct {
+			command           // General command settings for monitor
+			ChainTips command `toml:"chaintips"`
+		} `toml:"monitor"`
+
 		// Nodes contains node-related command settings
 		Nodes struct {
 			command            // General command settings for nodes
@@ -72,8 +91,23 @@ type Properties struct {
 			Peers       command `toml:"peers"`
 			Ban         command `toml:"ban"`
 			Unban       command `toml:"unban"`
-			Blacklist   command `toml:"blacklist"`
+			Guard       command `toml:"guard"`
+
+			// Blacklist contains ban list command settings
+			Blacklist struct {
+				command         // General command settings for blacklist
+				List    command `toml:"list"`
+				Export  command `toml:"export"`
+				Import  command `toml:"import"`
+				Sync    command `toml:"sync"`
+			} `toml:"blacklist"`
 		} `toml:"network"`
+
+		// Zmq contains ZeroMQ-related command settings
+		Zmq struct {
+			command         // General command settings for zmq
+			Listen  command `toml:"listen"`
+		} `toml:"zmq"`
 	} `toml:"commands"`
 }
 
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/avila-r/env"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Profile holds the settings used to connect to a node's RPC server.
+//
+// The default profile is read from the environment (and the '.env' file at RootPath).
+// Named profiles are read from '.env.<name>' files at RootPath, whose values take
+// precedence over the default ones.
+type Profile struct {
+	Name string `toml:"name,omitempty"` // Name of the profile, empty for the default one
+
+	URL        string `toml:"url"`                   // RPC_URL: URL of the RPC server
+	AuthType   string `toml:"auth_type"`             // RPC_AUTH_TYPE: Authentication type ("api-key", "user:password" or "url-path")
+	AuthLabel  string `toml:"auth_label"`            // RPC_AUTH_LABEL: API key, 'username:password' credentials or a secret reference
+	AuthHeader string `toml:"auth_header,omitempty"` // RPC_AUTH_HEADER: Header carrying the API key (e.g. x-api-key)
+	AuthScheme string `toml:"auth_scheme,omitempty"` // RPC_AUTH_SCHEME: Scheme prefixed to the API key (e.g. Bearer)
+
+	// TLS contains the settings used to reach HTTPS endpoints
+	TLS struct {
+		CA         string `toml:"ca,omitempty"`          // RPC_TLS_CA: Path to a PEM bundle with trusted certificate authorities
+		Cert       string `toml:"cert,omitempty"`        // RPC_TLS_CERT: Path to the PEM-encoded client certificate
+		Key        string `toml:"key,omitempty"`         // RPC_TLS_KEY: Path to the PEM-encoded client private key
+		ServerName string `toml:"server_name,omitempty"` // RPC_TLS_SERVER_NAME: Server name used for SNI and verification
+		MinVersion string `toml:"min_version,omitempty"` // RPC_TLS_MIN_VERSION: Minimum TLS version ("1.0" to "1.3")
+	} `toml:"tls,omitempty"`
+
+	// Proxy contains the settings used to reach the node through a proxy (e.g. Tor)
+	Proxy struct {
+		URL       string `toml:"url,omitempty"`       // RPC_PROXY: socks5://[user:password@]host:port or http://host:port
+		Randomize bool   `toml:"randomize,omitempty"` // RPC_PROXY_RANDOMIZE: Use random SOCKS5 credentials for stream isolation
+	} `toml:"proxy,omitempty"`
+}
+
+// LoadProfile loads a connection profile by its name.
+//
+// An empty name loads the default profile from the environment. Otherwise, the
+// '.env.<name>' file at RootPath must exist, and its values override the default ones.
+func LoadProfile(name string) (*Profile, error) {
+	values := map[string]string{}
+
+	if name != "" {
+		path := filepath.Join(RootPath, ".env."+name)
+		read, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read profile '%s' at %s: %v", name, path, err)
+		}
+		values = read
+	}
+
+	lookup := func(key string) string {
+		if value, ok := values[key]; ok {
+			return value
+		}
+		return env.Get(key)
+	}
+
+	profile := Profile{
+		Name:      name,
+		URL:       lookup("RPC_URL"),
+		AuthType:  lookup("RPC_AUTH_TYPE"),
+		AuthLabel: lookup("RPC_AUTH_LABEL"),
+
+		AuthHeader: lookup("RPC_AUTH_HEADER"),
+		AuthScheme: lookup("RPC_AUTH_SCHEME"),
+	}
+
+	profile.TLS.CA = lookup("RPC_TLS_CA")
+	profile.TLS.Cert = lookup("RPC_TLS_CERT")
+	profile.TLS.Key = lookup("RPC_TLS_KEY")
+	profile.TLS.ServerName = lookup("RPC_TLS_SERVER_NAME")
+	profile.TLS.MinVersion = lookup("RPC_TLS_MIN_VERSION")
+
+	profile.Proxy.URL = lookup("RPC_PROXY")
+	if randomize := lookup("RPC_PROXY_RANDOMIZE"); randomize != "" {
+		enabled, err := strconv.ParseBool(randomize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RPC_PROXY_RANDOMIZE value '%s': %v", randomize, err)
+		}
+		profile.Proxy.Randomize = enabled
+	}
+
+	return &profile, nil
+}
+
+// IsComplete reports whether the profile has the URL and authentication settings required to connect.
+func (p *Profile) IsComplete() bool {
+	return p.URL != "" && p.AuthType != "" && p.AuthLabel != ""
+}
+
+// UseBitcoinConf replaces the profile's URL and authentication with the ones derived from a bitcoin.conf file.
+func (p *Profile) UseBitcoinConf(conf *BitcoinConf) error {
+	url, err := conf.RPCURL()
+	if err != nil {
+		return err
+	}
+
+	credentials, err := conf.RPCCredentials()
+	if err != nil {
+		return err
+	}
+
+	p.URL = url
+	p.AuthType = "user:password"
+	p.AuthLabel = credentials
+
+	return nil
+}
+
+// masked is the placeholder shown instead of secrets.
+const masked = "********"
+
+// Prefixes used to reference secrets kept outside of the configuration (see rpc.ResolveSecret).
+const (
+	SecretFilePrefix    = "file:" // Reads the secret from a file
+	SecretEnvPrefix     = "env:"  // Reads the secret from an environment variable
+	SecretCommandPrefix = "cmd:"  // Reads the secret from a command's standard output
+)
+
+// IsSecretReference reports whether a value references a secret instead of containing it.
+func IsSecretReference(value string) bool {
+	for _, prefix := range []string{SecretFilePrefix, SecretEnvPrefix, SecretCommandPrefix} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Masked returns a copy of the profile whose secrets are masked, so it can be safely displayed.
+// Labels that reference secrets (e.g. "cmd:pass show btc/rpc") are kept, since they don't contain any.
+func (p *Profile) Masked() *Profile {
+	clone := *p
+
+	if clone.AuthLabel != "" && !IsSecretReference(p.AuthLabel) {
+		clone.AuthLabel = masked
+		if user, _, ok := strings.Cut(p.AuthLabel, ":"); ok && p.AuthType == "user:password" {
+			clone.AuthLabel = user + ":" + masked
+		}
+	}
+
+	if parsed, err := url.Parse(p.Proxy.URL); err == nil && parsed.User != nil {
+		clone.Proxy.URL = parsed.Redacted()
+	}
+
+	return &clone
+}
+
+// ToString serializes the profile into a string in TOML format, under a 'connection' table, with its secrets masked.
+func (p *Profile) ToString() string {
+	bytes, err := toml.Marshal(struct {
+		Connection *Profile `toml:"connection"`
+	}{p.Masked()})
+	if err != nil {
+		slog.Debug("failed to marshal profile into .toml format", "error", err)
+	}
+	return string(bytes)
+}
+
+// Log outputs the string representation of the profile, with its secrets masked, to the console.
+func (p *Profile) Log() {
+	fmt.Print(p.ToString())
+}
@@ -0,0 +1,322 @@
+// Package dashboard implements a full-screen terminal dashboard of a node's status: its chain
+// height and sync progress, mempool, network traffic, peers and latest blocks, refreshed live.
+// Peers can be selected to be disconnected, or banned if they're IPv4 or IPv6 peers.
+package dashboard
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/avila-r/bitclient/assets"
+	"github.com/avila-r/bitclient/network"
+)
+
+// Options configures the dashboard.
+type Options struct {
+	// Interval is the time between refreshes (default: 2s).
+	Interval time.Duration
+
+	// Blocks is the number of latest blocks listed (default: 6).
+	Blocks int
+
+	// BanTime is the duration of bans, 24 hours if zero.
+	BanTime time.Duration
+}
+
+// Model is the bubbletea model of the dashboard.
+type Model struct {
+	options  Options
+	snapshot *Snapshot
+	err      error  // Error of the latest refresh
+	selected int    // Index of the selected peer
+	pending  string // Action awaiting confirmation: "ban" or "disconnect"
+	target   Peer   // Peer of the pending action, as selected when it was requested
+	status   string // Outcome of the latest action
+	width    int
+}
+
+type (
+	// snapshotMsg carries the outcome of a refresh.
+	snapshotMsg struct {
+		snapshot  *Snapshot
+		err       error
+		scheduled bool // Whether the refresh was scheduled, rather than requested by a key press or an action
+	}
+
+	// tickMsg triggers a refresh.
+	tickMsg time.Time
+
+	// actionMsg carries the outcome of an action on a peer.
+	actionMsg struct {
+		status string
+		err    error
+	}
+)
+
+// New creates the model of a dashboard.
+//
+// Parameters:
+// - options (Options): The refresh interval, number of blocks listed and ban duration.
+//
+// Returns:
+// - Model: The model, to be run with Run or a tea.Program.
+func New(options Options) Model {
+	if options.Interval <= 0 {
+		options.Interval = 2 * time.Second
+	}
+	if options.Blocks <= 0 {
+		options.Blocks = 6
+	}
+	return Model{options: options, width: 100}
+}
+
+// Run runs the dashboard full-screen until the user quits.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient dashboard --interval 5s
+//
+//   - Using Go:
+//     err := dashboard.Run(dashboard.Options{Interval: 5 * time.Second})
+func Run(options Options) error {
+	_, err := tea.NewProgram(New(options), tea.WithAltScreen()).Run()
+	return err
+}
+
+// Init fetches the first snapshot. Following refreshes are scheduled once each scheduled snapshot
+// arrives, so that a single refresh loop runs however many refreshes are requested in between.
+func (m Model) Init() tea.Cmd {
+	return m.fetch(true)
+}
+
+// Update handles refreshes, action outcomes and key presses.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+
+	case tickMsg:
+		return m, m.fetch(true)
+
+	case snapshotMsg:
+		m.err = msg.err
+		if msg.snapshot != nil {
+			m.snapshot = msg.snapshot
+			m.selected = min(m.selected, max(len(m.snapshot.Peers)-1, 0))
+		}
+		if !msg.scheduled {
+			return m, nil
+		}
+		return m, tea.Tick(m.options.Interval, func(t time.Time) tea.Msg { return tickMsg(t) })
+
+	case actionMsg:
+		m.status = msg.status
+		if msg.err != nil {
+			m.status = msg.err.Error()
+		}
+		return m, m.fetch(false)
+
+	case tea.KeyMsg:
+		return m.key(msg.String())
+	}
+
+	return m, nil
+}
+
+// key handles a key press.
+func (m Model) key(key string) (tea.Model, tea.Cmd) {
+	if m.pending != "" {
+		action := m.pending
+		m.pending = ""
+		if key == "y" {
+			return m, m.act(action)
+		}
+		m.status = action + " cancelled"
+		return m, nil
+	}
+
+	peers := 0
+	if m.snapshot != nil {
+		peers = len(m.snapshot.Peers)
+	}
+
+	switch key {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m.selected = max(m.selected-1, 0)
+	case "down", "j":
+		m.selected = min(m.selected+1, max(peers-1, 0))
+	case "r":
+		return m, m.fetch(false)
+	case "b", "d":
+		if peers == 0 {
+			m.status = "no peer selected"
+			return m, nil
+		}
+		// Peers of other networks, such as Tor or I2P inbound peers, connect from their proxy's
+		// address, shared by all of them
+		if peer := m.snapshot.Peers[m.selected]; key == "b" && peer.Network != "ipv4" && peer.Network != "ipv6" {
+			m.status = fmt.Sprintf("%s peer %s can't be banned, only disconnected", peer.Network, peer.Address)
+			return m, nil
+		}
+		m.pending = map[string]string{"b": "ban", "d": "disconnect"}[key]
+		m.target = m.snapshot.Peers[m.selected]
+	}
+	return m, nil
+}
+
+// fetch returns the command taking a snapshot, following the current one. Only scheduled
+// snapshots schedule the next refresh.
+func (m Model) fetch(scheduled bool) tea.Cmd {
+	return func() tea.Msg {
+		snapshot, err := Fetch(m.snapshot, m.options.Blocks)
+		return snapshotMsg{snapshot: snapshot, err: err, scheduled: scheduled}
+	}
+}
+
+// act bans or disconnects the target peer, disconnecting it by ID.
+func (m Model) act(action string) tea.Cmd {
+	peer := m.target
+
+	return func() tea.Msg {
+		if action == "disconnect" {
+			if err := network.DisconnectNode(strconv.Itoa(peer.ID)); err != nil {
+				return actionMsg{err: fmt.Errorf("failed to disconnect peer %s: %v", peer.Address, err.Error())}
+			}
+			return actionMsg{status: "disconnected peer " + peer.Address}
+		}
+
+		host := peer.Address
+		if h, _, err := net.SplitHostPort(peer.Address); err == nil {
+			host = h
+		}
+		if err := network.SetBan(network.Ban{Target: host, Time: int(m.options.BanTime.Seconds())}); err != nil {
+			return actionMsg{err: fmt.Errorf("failed to ban peer %s: %v", host, err.Error())}
+		}
+		return actionMsg{status: "banned " + host}
+	}
+}
+
+var (
+	title     = lipgloss.NewStyle().Foreground(assets.LightOrange).Bold(true)
+	pane      = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(assets.DarkOrange).Padding(0, 1)
+	label     = lipgloss.NewStyle().Foreground(assets.Gray)
+	highlight = lipgloss.NewStyle().Foreground(assets.Black).Background(assets.LightOrange)
+	failed    = lipgloss.NewStyle().Foreground(assets.Red)
+)
+
+// View renders the dashboard.
+func (m Model) View() string {
+	if m.snapshot == nil {
+		if m.err != nil {
+			return failed.Render(m.err.Error()) + "\n\n" + label.Render("r: retry  q: quit")
+		}
+		return "Connecting to the node..."
+	}
+	s := m.snapshot
+
+	// A third of the width for each summary pane, minus borders and padding
+	width := max(m.width/3-4, 24)
+	sync := fmt.Sprintf("%.2f%%", s.Chain.Progress*100)
+	if s.Chain.Syncing {
+		sync += " (initial block download)"
+	}
+	chain := pane.Width(width).Render(lines(
+		title.Render("Chain "+s.Chain.Name),
+		field("Height", fmt.Sprintf("%d", s.Chain.Blocks)),
+		field("Headers", fmt.Sprintf("%d", s.Chain.Headers)),
+		field("Synced", sync),
+	))
+	pool := pane.Width(width).Render(lines(
+		title.Render("Mempool"),
+		field("Transactions", fmt.Sprintf("%d", s.Mempool.Size)),
+		field("Size", formatBytes(float64(s.Mempool.Bytes))),
+		field("Memory", fmt.Sprintf("%s / %s", formatBytes(float64(s.Mempool.Usage)), formatBytes(float64(s.Mempool.Max)))),
+		field("Min fee", fmt.Sprintf("%.2f sat/vB", s.Mempool.MinFee*1e5)),
+	))
+	traffic := pane.Width(width).Render(lines(
+		title.Render("Traffic"),
+		field("Receiving", formatBytes(s.Traffic.ReceiveRate)+"/s"),
+		field("Sending", formatBytes(s.Traffic.SendRate)+"/s"),
+		field("Received", formatBytes(float64(s.Traffic.Received))),
+		field("Sent", formatBytes(float64(s.Traffic.Sent))),
+	))
+
+	peers := []string{title.Render(fmt.Sprintf("Peers (%d)", len(s.Peers)))}
+	peers = append(peers, label.Render(fmt.Sprintf("%-4s %-28s %-8s %-19s %8s %10s %10s  %s", "id", "address", "dir", "type", "ping", "recv", "sent", "version")))
+	for i, peer := range s.Peers {
+		direction := "outbound"
+		if peer.Inbound {
+			direction = "inbound"
+		}
+		row := fmt.Sprintf("%-4d %-28s %-8s %-19s %6.0fms %10s %10s  %s", peer.ID, truncate(peer.Address, 28), direction,
+			truncate(peer.ConnectionType, 19), peer.PingTime*1000, formatBytes(float64(peer.BytesRecv)), formatBytes(float64(peer.BytesSent)), peer.SubVersion)
+		if i == m.selected {
+			row = highlight.Render(row)
+		}
+		peers = append(peers, row)
+	}
+
+	latest := []string{title.Render("Latest blocks")}
+	latest = append(latest, label.Render(fmt.Sprintf("%-8s %-64s %6s  %s", "height", "hash", "txs", "time")))
+	for _, block := range s.Blocks {
+		latest = append(latest, fmt.Sprintf("%-8d %-64s %6d  %s", block.Height, block.Hash, block.Txs, time.Unix(block.Time, 0).Format(time.DateTime)))
+	}
+
+	footer := label.Render("↑/↓: select peer  d: disconnect  b: ban  r: refresh  q: quit")
+	switch {
+	case m.pending != "":
+		footer = title.Render(fmt.Sprintf("%s peer %s? (y/n)", m.pending, m.target.Address))
+	case m.err != nil:
+		footer = failed.Render(m.err.Error()) + "  " + footer
+	case m.status != "":
+		footer = m.status + "  " + footer
+	}
+
+	return lines(
+		title.Render("bitclient dashboard")+label.Render("  updated "+s.Time.Format(time.TimeOnly)),
+		lipgloss.JoinHorizontal(lipgloss.Top, chain, pool, traffic),
+		pane.Render(lines(peers...)),
+		pane.Render(lines(latest...)),
+		footer,
+	)
+}
+
+// lines joins lines of text.
+func lines(values ...string) string {
+	return strings.Join(values, "\n")
+}
+
+// field renders a labeled value.
+func field(name, value string) string {
+	return label.Render(name+": ") + value
+}
+
+// truncate shortens a value to a width, marking the cut with an ellipsis.
+func truncate(value string, width int) string {
+	if len(value) <= width {
+		return value
+	}
+	return value[:width-1] + "…"
+}
+
+// formatBytes formats a number of bytes with a binary unit.
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for ; n >= 1024 && i < len(units)-1; i++ {
+		n /= 1024
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
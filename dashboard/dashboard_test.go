@@ -0,0 +1,199 @@
+package dashboard_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/dashboard"
+	"github.com/avila-r/bitclient/network"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+func Test_Fetch(t *testing.T) {
+	// Totals grow by 2048 bytes received and 1024 sent every 2 seconds
+	samples := 0
+	fake := rpctest.Use(t)
+	fake.Handle(network.MethodGetNetTotals, func(params []json.RawMessage) (any, error) {
+		samples++
+		return map[string]any{
+			"totalbytesrecv": 2048 * samples,
+			"totalbytessent": 1024 * samples,
+			"timemillis":     2000 * samples,
+		}, nil
+	})
+
+	first, err := dashboard.Fetch(nil, 6)
+	if err != nil {
+		t.Fatalf("Failed to fetch snapshot: %v", err)
+	}
+
+	if first.Chain.Name != "regtest" || first.Chain.Blocks != 200 || first.Chain.Progress != 1 {
+		t.Errorf("Unexpected chain: %+v", first.Chain)
+	}
+	if first.Mempool.Max != 300_000_000 {
+		t.Errorf("Unexpected mempool: %+v", first.Mempool)
+	}
+	if len(first.Peers) != 3 || first.Peers[0].Address != "203.0.113.10:8333" {
+		t.Errorf("Unexpected peers: %+v", first.Peers)
+	}
+	if first.Traffic.ReceiveRate != 0 || first.Traffic.SendRate != 0 {
+		t.Errorf("Expected no rates without a previous snapshot, got %+v", first.Traffic)
+	}
+
+	if len(first.Blocks) != 6 {
+		t.Fatalf("Expected 6 blocks, got %d", len(first.Blocks))
+	}
+	for i, block := range first.Blocks {
+		if block.Height != 200-i || block.Hash != fake.Chain.Block(200-i).Hash || block.Txs != 1 {
+			t.Errorf("Unexpected block %d: %+v", i, block)
+		}
+	}
+
+	headers := fake.Calls(blocks.MethodGetBlockHeader)
+	fake.Chain.Mine(2)
+
+	second, err := dashboard.Fetch(first, 6)
+	if err != nil {
+		t.Fatalf("Failed to fetch snapshot: %v", err)
+	}
+
+	if second.Traffic.ReceiveRate != 1024 || second.Traffic.SendRate != 512 {
+		t.Errorf("Expected rates of 1024 and 512 B/s, got %+v", second.Traffic)
+	}
+	if second.Blocks[0].Height != 202 || second.Blocks[5].Height != 197 {
+		t.Errorf("Expected blocks 202-197, got %+v", second.Blocks)
+	}
+	if calls := fake.Calls(blocks.MethodGetBlockHeader) - headers; calls != 2 {
+		t.Errorf("Expected only the 2 new blocks to be fetched, got %d header calls", calls)
+	}
+}
+
+// update feeds a message to the model, running the resulting command if run is set.
+func update(model tea.Model, msg tea.Msg, run bool) (tea.Model, tea.Msg) {
+	model, cmd := model.Update(msg)
+	if !run || cmd == nil {
+		return model, nil
+	}
+	return model, cmd()
+}
+
+func key(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func Test_Model(t *testing.T) {
+	fake := rpctest.Use(t)
+
+	model := tea.Model(dashboard.New(dashboard.Options{}))
+	model, _ = update(model, model.Init()(), false)
+
+	view := model.View()
+	for _, expected := range []string{"Chain regtest", "Height: 200", "Peers (3)", "203.0.113.10:8333", fake.Chain.Tip().Hash} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected the view to contain %q:\n%s", expected, view)
+		}
+	}
+
+	// Disconnect the second peer
+	model, _ = update(model, key("j"), false)
+	model, _ = update(model, key("d"), false)
+	if view := model.View(); !strings.Contains(view, "disconnect peer 198.51.100.7:8333? (y/n)") {
+		t.Errorf("Expected a confirmation prompt:\n%s", view)
+	}
+	model, msg := update(model, key("y"), true)
+	model, msg = update(model, msg, true)
+	model, _ = update(model, msg, false)
+
+	if !strings.Contains(model.View(), "disconnected peer 198.51.100.7:8333") || strings.Contains(model.View(), "Peers (3)") {
+		t.Errorf("Expected the peer to be disconnected:\n%s", model.View())
+	}
+
+	// Cancel a ban, then ban the first peer's address
+	model, _ = update(model, key("k"), false)
+	model, _ = update(model, key("b"), false)
+	model, _ = update(model, key("n"), false)
+	if calls := fake.Calls(network.MethodSetBan); calls != 0 {
+		t.Errorf("Expected a cancelled ban not to be sent, got %d setban calls", calls)
+	}
+
+	model, _ = update(model, key("b"), false)
+	model, msg = update(model, key("y"), true)
+	model, _ = update(model, msg, false)
+
+	banned, err := network.ListBanned()
+	if err != nil {
+		t.Fatalf("Failed to list banned: %v", err)
+	}
+	if len(*banned) != 1 || (*banned)[0]["address"] != "203.0.113.10/32" {
+		t.Errorf("Expected the first peer's address to be banned, got %v", *banned)
+	}
+	if !strings.Contains(model.View(), "banned 203.0.113.10") {
+		t.Errorf("Expected the ban to be reported:\n%s", model.View())
+	}
+
+	if _, cmd := model.Update(key("q")); cmd == nil || cmd() != tea.Quit() {
+		t.Errorf("Expected q to quit")
+	}
+}
+
+func Test_ModelProxied(t *testing.T) {
+	// Tor inbound peers connect from the proxy's address
+	fake := rpctest.Use(t)
+	fake.Peers = []rpctest.Peer{{ID: 7, Address: "127.0.0.1:34567", Network: "onion", Inbound: true, ConnectionType: "inbound"}}
+
+	model := tea.Model(dashboard.New(dashboard.Options{}))
+	model, _ = update(model, model.Init()(), false)
+
+	model, _ = update(model, key("b"), false)
+	if view := model.View(); !strings.Contains(view, "onion peer 127.0.0.1:34567 can't be banned") || strings.Contains(view, "(y/n)") {
+		t.Errorf("Expected the ban to be refused:\n%s", view)
+	}
+	if calls := fake.Calls(network.MethodSetBan); calls != 0 {
+		t.Errorf("Expected no ban of the proxy's address, got %d setban calls", calls)
+	}
+
+	// Disconnected by ID, since the address is the proxy's
+	model, _ = update(model, key("d"), false)
+	model, msg := update(model, key("y"), true)
+	update(model, msg, false)
+
+	requests := fake.Requests()
+	if params, _ := json.Marshal(requests[len(requests)-1].Params); string(params) != `["",7]` {
+		t.Errorf("Expected disconnectnode params [\"\",7], got %s", params)
+	}
+}
+
+func Test_Refresh(t *testing.T) {
+	rpctest.Use(t)
+
+	// snapshot feeds the snapshot taken by a command to the model, and reports whether it scheduled a refresh
+	snapshot := func(model tea.Model, cmd tea.Cmd) (tea.Model, bool) {
+		model, tick := model.Update(cmd())
+		return model, tick != nil
+	}
+
+	model := tea.Model(dashboard.New(dashboard.Options{Interval: time.Millisecond}))
+	model, scheduled := snapshot(model, model.Init())
+	ticks := 0
+	if scheduled {
+		ticks++
+	}
+
+	// Refreshes requested by key presses don't start refresh loops of their own
+	for i := 0; i < 2; i++ {
+		var cmd tea.Cmd
+		model, cmd = model.Update(key("r"))
+		if model, scheduled = snapshot(model, cmd); scheduled {
+			ticks++
+		}
+	}
+
+	if ticks != 1 {
+		t.Errorf("Expected a single refresh loop, got %d ticks scheduled", ticks)
+	}
+}
@@ -0,0 +1,160 @@
+package dashboard
+
+import (
+	"time"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/mempool"
+	"github.com/avila-r/bitclient/network"
+)
+
+// Snapshot is the state of the node at a point in time, as shown by the dashboard.
+type Snapshot struct {
+	Time time.Time // Time the snapshot was taken
+
+	Chain   Chain   // Height and sync progress of the chain
+	Mempool Mempool // Size of the mempool
+	Traffic Traffic // Network traffic totals, with rates since the previous snapshot
+	Peers   []Peer  // Connected peers
+	Blocks  []Block // Latest blocks of the active chain, tip first
+}
+
+// Chain is the state of the active chain, from 'getblockchaininfo'.
+type Chain struct {
+	Name          string  `json:"chain"`
+	Blocks        int     `json:"blocks"`
+	Headers       int     `json:"headers"`
+	BestBlockHash string  `json:"bestblockhash"`
+	Progress      float64 `json:"verificationprogress"`
+	Syncing       bool    `json:"initialblockdownload"`
+}
+
+// Mempool is the state of the mempool, from 'getmempoolinfo'.
+type Mempool struct {
+	Size   int64   `json:"size"`          // Number of transactions
+	Bytes  int64   `json:"bytes"`         // Total virtual size of the transactions
+	Usage  int64   `json:"usage"`         // Memory used
+	Max    int64   `json:"maxmempool"`    // Maximum memory usage
+	MinFee float64 `json:"mempoolminfee"` // Minimum fee rate to enter the mempool, in BTC/kvB
+}
+
+// Traffic holds the node's network traffic totals, from 'getnettotals'.
+type Traffic struct {
+	Received uint64 `json:"totalbytesrecv"`
+	Sent     uint64 `json:"totalbytessent"`
+	Millis   int64  `json:"timemillis"`
+
+	ReceiveRate float64 `json:"-"` // Bytes received per second since the previous snapshot
+	SendRate    float64 `json:"-"` // Bytes sent per second since the previous snapshot
+}
+
+// Peer is a connected peer, from 'getpeerinfo'.
+type Peer struct {
+	ID             int     `json:"id"`
+	Address        string  `json:"addr"`
+	Network        string  `json:"network"`
+	SubVersion     string  `json:"subver"`
+	Inbound        bool    `json:"inbound"`
+	ConnectionType string  `json:"connection_type"`
+	ConnTime       int64   `json:"conntime"`
+	PingTime       float64 `json:"pingtime"`
+	BytesSent      uint64  `json:"bytessent"`
+	BytesRecv      uint64  `json:"bytesrecv"`
+	SyncedBlocks   int     `json:"synced_blocks"`
+}
+
+// Block is a block of the active chain, from 'getblockheader'.
+type Block struct {
+	Hash     string `json:"hash"`
+	Height   int    `json:"height"`
+	Time     int64  `json:"time"`
+	Txs      int    `json:"nTx"`
+	Previous string `json:"previousblockhash"`
+}
+
+// Fetch takes a snapshot of the node's state.
+//
+// Traffic rates are derived from the totals of the previous snapshot, if any, and the latest
+// blocks are fetched by walking back from the tip through their headers, reusing the blocks of
+// the previous snapshot that are still part of the active chain.
+//
+// Parameters:
+// - previous (*Snapshot): The previous snapshot, or nil for the first one.
+// - n (int): The number of latest blocks to list.
+//
+// Returns:
+// - *Snapshot: The state of the node.
+// - error: An error if any of the requests fails.
+func Fetch(previous *Snapshot, n int) (*Snapshot, error) {
+	snapshot := &Snapshot{Time: time.Now()}
+
+	info, err := blocks.GetBlockchainInfo()
+	if err != nil {
+		return nil, failure.Of("failed to get blockchain info: %v", err.Error())
+	}
+	if err := info.Bind(&snapshot.Chain); err != nil {
+		return nil, err
+	}
+
+	pool, err := mempool.GetMempoolInfo()
+	if err != nil {
+		return nil, failure.Of("failed to get mempool info: %v", err.Error())
+	}
+	if err := pool.Bind(&snapshot.Mempool); err != nil {
+		return nil, err
+	}
+
+	traffic, err := network.InspectTraffic()
+	if err != nil {
+		return nil, failure.Of("failed to inspect network traffic: %v", err.Error())
+	}
+	if err := traffic.Bind(&snapshot.Traffic); err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		snapshot.Traffic.ReceiveRate, snapshot.Traffic.SendRate = rates(previous.Traffic, snapshot.Traffic)
+	}
+
+	peers, err := network.GetPeers()
+	if err != nil {
+		return nil, failure.Of("failed to get peers: %v", err.Error())
+	}
+	if err := peers.Bind(&snapshot.Peers); err != nil {
+		return nil, err
+	}
+
+	known := map[string]Block{}
+	if previous != nil {
+		for _, block := range previous.Blocks {
+			known[block.Hash] = block
+		}
+	}
+
+	for hash := snapshot.Chain.BestBlockHash; hash != "" && len(snapshot.Blocks) < n; {
+		block, ok := known[hash]
+		if !ok {
+			response, err := blocks.GetBlockHeader(hash, true)
+			if err != nil {
+				return nil, failure.Of("failed to get block header %s: %v", hash, err.Error())
+			}
+			if err := response.Bind(&block); err != nil {
+				return nil, failure.Of("failed to parse block header %s: %v", hash, err.Error())
+			}
+		}
+		snapshot.Blocks = append(snapshot.Blocks, block)
+		hash = block.Previous
+	}
+
+	return snapshot, nil
+}
+
+// rates computes the bytes received and sent per second between two traffic samples.
+func rates(previous, current Traffic) (float64, float64) {
+	elapsed := float64(current.Millis-previous.Millis) / 1000
+	if elapsed <= 0 || current.Received < previous.Received || current.Sent < previous.Sent {
+		// Counters reset when the node restarts
+		return 0, 0
+	}
+	return float64(current.Received-previous.Received) / elapsed, float64(current.Sent-previous.Sent) / elapsed
+}
@@ -0,0 +1,216 @@
+// Package export streams tabular records, such as the statistics of a range of blocks, to CSV,
+// JSON Lines or Parquet files. Records are rows of values matching a fixed list of columns.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Format defines the file format of an export.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"     // Comma-separated values, with a header line
+	FormatJSONL   Format = "jsonl"   // One JSON object per line
+	FormatParquet Format = "parquet" // Apache Parquet, uncompressed
+)
+
+// FormatFrom returns the format with the given name, or the format matching the extension of a path.
+//
+// Parameters:
+// - s (string): A format name (csv, jsonl or parquet) or a file path.
+//
+// Returns:
+// - Format: The export format.
+// - error: An error if the format is unknown.
+func FormatFrom(s string) (Format, error) {
+	name := strings.ToLower(strings.TrimPrefix(filepath.Ext(s), "."))
+	if name == "" {
+		name = strings.ToLower(s)
+	}
+
+	switch Format(name) {
+	case FormatCSV, FormatJSONL, FormatParquet:
+		return Format(name), nil
+	case "json", "ndjson":
+		return FormatJSONL, nil
+	}
+	return "", failure.Of("unknown export format '%s', valid formats are csv, jsonl and parquet", s)
+}
+
+// Kind defines the type of a column's values.
+type Kind int
+
+const (
+	KindInt    Kind = iota // 64-bit integers
+	KindFloat              // 64-bit floating point numbers
+	KindString             // UTF-8 strings
+)
+
+// Column is a named and typed column of an export.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Writer writes records to an export.
+type Writer interface {
+	// Write writes a record, whose values match the export's columns: int64 for KindInt,
+	// float64 for KindFloat and string for KindString.
+	Write(record []any) error
+
+	// Close flushes the records written so far, without closing the underlying writer.
+	Close() error
+}
+
+// NewWriter creates a writer of records in a format.
+//
+// Parameters:
+// - format (Format): The format of the export.
+// - w (io.Writer): The destination of the export, such as a file.
+// - columns ([]Column): The columns of the records.
+//
+// Returns:
+// - Writer: The writer, which must be closed once every record is written.
+// - error: An error if the format is unknown or the columns are empty.
+//
+// Example:
+//
+//	writer, err := export.NewWriter(export.FormatCSV, file, []export.Column{{Name: "height", Kind: export.KindInt}})
+//	err = writer.Write([]any{int64(840000)})
+//	err = writer.Close()
+func NewWriter(format Format, w io.Writer, columns []Column) (Writer, error) {
+	if len(columns) == 0 {
+		return nil, failure.Of("an export needs at least one column")
+	}
+
+	switch format {
+	case FormatCSV:
+		return newCSV(w, columns), nil
+	case FormatJSONL:
+		return &jsonl{w: w, columns: columns}, nil
+	case FormatParquet:
+		return newParquet(w, columns), nil
+	}
+	return nil, failure.Of("unknown export format '%s', valid formats are csv, jsonl and parquet", format)
+}
+
+// check verifies that a record matches the columns.
+func check(columns []Column, record []any) error {
+	if len(record) != len(columns) {
+		return failure.Of("record has %d values for %d columns", len(record), len(columns))
+	}
+
+	for i, column := range columns {
+		ok := false
+		switch column.Kind {
+		case KindInt:
+			_, ok = record[i].(int64)
+		case KindFloat:
+			_, ok = record[i].(float64)
+		case KindString:
+			_, ok = record[i].(string)
+		}
+		if !ok {
+			return failure.Of("invalid value %v (%T) for column %s", record[i], record[i], column.Name)
+		}
+	}
+	return nil
+}
+
+// csvWriter writes records as comma-separated values, after a header line.
+type csvWriter struct {
+	w       *csv.Writer
+	columns []Column
+	header  bool // Whether the header line was written
+}
+
+func newCSV(w io.Writer, columns []Column) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+func (c *csvWriter) Write(record []any) error {
+	if err := check(c.columns, record); err != nil {
+		return err
+	}
+
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	fields := []string{}
+	for _, value := range record {
+		switch value := value.(type) {
+		case int64:
+			fields = append(fields, strconv.FormatInt(value, 10))
+		case float64:
+			fields = append(fields, strconv.FormatFloat(value, 'f', -1, 64))
+		case string:
+			fields = append(fields, value)
+		}
+	}
+	return c.w.Write(fields)
+}
+
+func (c *csvWriter) Close() error {
+	// The header is written even if there are no records
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// writeHeader writes the header line, once.
+func (c *csvWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+
+	names := []string{}
+	for _, column := range c.columns {
+		names = append(names, column.Name)
+	}
+	c.header = true
+	return c.w.Write(names)
+}
+
+// jsonl writes records as JSON objects, one per line, keeping the order of the columns.
+type jsonl struct {
+	w       io.Writer
+	columns []Column
+}
+
+func (j *jsonl) Write(record []any) error {
+	if err := check(j.columns, record); err != nil {
+		return err
+	}
+
+	line := []byte{'{'}
+	for i, column := range j.columns {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		name, _ := json.Marshal(column.Name)
+		value, err := json.Marshal(record[i])
+		if err != nil {
+			return failure.Of("failed to serialize column %s: %v", column.Name, err.Error())
+		}
+		line = append(append(append(line, name...), ':'), value...)
+	}
+	line = append(line, '}', '\n')
+
+	_, err := j.w.Write(line)
+	return err
+}
+
+func (j *jsonl) Close() error {
+	return nil
+}
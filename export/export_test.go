@@ -0,0 +1,288 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/avila-r/bitclient/export"
+)
+
+// columns and records are the fixture of every test.
+var (
+	columns = []export.Column{
+		{Name: "height", Kind: export.KindInt},
+		{Name: "blockhash", Kind: export.KindString},
+		{Name: "share", Kind: export.KindFloat},
+	}
+	records = [][]any{
+		{int64(1), "4da79563", 0.5},
+		{int64(2), "519e7d1e", 0.25},
+		{int64(3), "with \"quotes\", commas", 0.0},
+	}
+)
+
+func write(t *testing.T, format export.Format) []byte {
+	buffer := &bytes.Buffer{}
+	writer, err := export.NewWriter(format, buffer, columns)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			t.Fatalf("Failed to write record: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func Test_FormatFrom(t *testing.T) {
+	cases := []struct {
+		Value  string
+		Format export.Format
+	}{
+		{Value: "csv", Format: export.FormatCSV},
+		{Value: "stats.jsonl", Format: export.FormatJSONL},
+		{Value: "out/stats.PARQUET", Format: export.FormatParquet},
+		{Value: "json", Format: export.FormatJSONL},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			format, err := export.FormatFrom(test.Value)
+			if err != nil || format != test.Format {
+				t.Errorf("Expected format %s for %s, got %s (%v)", test.Format, test.Value, format, err)
+			}
+		})
+	}
+
+	if _, err := export.FormatFrom("stats.xlsx"); err == nil {
+		t.Errorf("Expected an unknown format to be rejected")
+	}
+}
+
+func Test_CSV(t *testing.T) {
+	expected := "height,blockhash,share\n1,4da79563,0.5\n2,519e7d1e,0.25\n3,\"with \"\"quotes\"\", commas\",0\n"
+	if data := string(write(t, export.FormatCSV)); data != expected {
+		t.Errorf("Unexpected CSV:\n%s", data)
+	}
+
+	buffer := &bytes.Buffer{}
+	writer, _ := export.NewWriter(export.FormatCSV, buffer, columns)
+	if err := writer.Close(); err != nil || buffer.String() != "height,blockhash,share\n" {
+		t.Errorf("Expected an empty export to have a header line, got %q (%v)", buffer.String(), err)
+	}
+}
+
+func Test_JSONL(t *testing.T) {
+	expected := `{"height":1,"blockhash":"4da79563","share":0.5}
+{"height":2,"blockhash":"519e7d1e","share":0.25}
+{"height":3,"blockhash":"with \"quotes\", commas","share":0}
+`
+	if data := string(write(t, export.FormatJSONL)); data != expected {
+		t.Errorf("Unexpected JSON Lines:\n%s", data)
+	}
+}
+
+func Test_Write(t *testing.T) {
+	writer, _ := export.NewWriter(export.FormatParquet, &bytes.Buffer{}, columns)
+
+	if err := writer.Write([]any{int64(1), "hash"}); err == nil {
+		t.Errorf("Expected a record with missing values to be rejected")
+	}
+	if err := writer.Write([]any{1, "hash", 0.5}); err == nil {
+		t.Errorf("Expected a value of the wrong type to be rejected")
+	}
+	if _, err := export.NewWriter(export.FormatCSV, &bytes.Buffer{}, nil); err == nil {
+		t.Errorf("Expected an export without columns to be rejected")
+	}
+}
+
+func Test_Parquet(t *testing.T) {
+	defer func(size int) { export.RowGroupSize = size }(export.RowGroupSize)
+	export.RowGroupSize = 2 // Two row groups: 2 records, then 1
+
+	data := write(t, export.FormatParquet)
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("Expected the PAR1 magic at both ends")
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	metadata := decode(t, data[len(data)-8-length:len(data)-8])
+
+	if metadata[1] != int64(1) || metadata[3] != int64(3) || metadata[6] != "bitclient" {
+		t.Errorf("Unexpected file metadata: %v", metadata)
+	}
+
+	schema := metadata[2].([]any)
+	if len(schema) != 4 || schema[0].(map[int16]any)[5] != int64(3) {
+		t.Fatalf("Expected a root and 3 columns, got %v", schema)
+	}
+	for i, column := range columns {
+		element := schema[i+1].(map[int16]any)
+		if element[4] != column.Name || element[3] != int64(0) {
+			t.Errorf("Unexpected schema element %v for column %s", element, column.Name)
+		}
+	}
+
+	// Read the values back from each column chunk's data page
+	decoded := make([][]any, len(records))
+	row := 0
+	for _, group := range metadata[4].([]any) {
+		group := group.(map[int16]any)
+		rows := int(group[3].(int64))
+		for c, chunk := range group[1].([]any) {
+			meta := chunk.(map[int16]any)[3].(map[int16]any)
+			offset := meta[9].(int64)
+			header, size := decodeStruct(t, data[offset:])
+			page := data[int(offset)+size : int(offset)+size+int(header[3].(int64))]
+
+			for r := range rows {
+				switch columns[c].Kind {
+				case export.KindInt:
+					decoded[row+r] = append(decoded[row+r], int64(binary.LittleEndian.Uint64(page)))
+					page = page[8:]
+				case export.KindFloat:
+					decoded[row+r] = append(decoded[row+r], math.Float64frombits(binary.LittleEndian.Uint64(page)))
+					page = page[8:]
+				case export.KindString:
+					n := binary.LittleEndian.Uint32(page)
+					decoded[row+r] = append(decoded[row+r], string(page[4:4+n]))
+					page = page[4+n:]
+				}
+			}
+		}
+		row += rows
+	}
+
+	if fmt.Sprint(decoded) != fmt.Sprint(records) {
+		t.Errorf("Expected records %v, got %v", records, decoded)
+	}
+}
+
+func Test_ParquetGolden(t *testing.T) {
+	// A single INT64 column holding 1, encoded by hand from parquet.thrift and the Thrift compact protocol
+	page := []byte{
+		0x15, 0x00, // PageHeader.type: DATA_PAGE
+		0x15, 0x10, // PageHeader.uncompressed_page_size: 8
+		0x15, 0x10, // PageHeader.compressed_page_size: 8
+		0x2c,       // PageHeader.data_page_header
+		0x15, 0x02, // DataPageHeader.num_values: 1
+		0x15, 0x00, // DataPageHeader.encoding: PLAIN
+		0x15, 0x06, // DataPageHeader.definition_level_encoding: RLE
+		0x15, 0x06, // DataPageHeader.repetition_level_encoding: RLE
+		0x00, 0x00, // End of DataPageHeader and PageHeader
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // PLAIN value: 1
+	}
+	metadata := "" +
+		"\x15\x02" + // FileMetaData.version: 1
+		"\x19\x2c" + // FileMetaData.schema: list of 2 structs
+		"\x48\x06schema\x15\x02\x00" + // Root: name, num_children: 1
+		"\x15\x04\x25\x00\x18\x06height\x00" + // Leaf: type INT64, repetition REQUIRED, name
+		"\x16\x02" + // FileMetaData.num_rows: 1
+		"\x19\x1c" + // FileMetaData.row_groups: list of 1 struct
+		"\x19\x1c" + // RowGroup.columns: list of 1 struct
+		"\x26\x08" + // ColumnChunk.file_offset: 4
+		"\x1c" + // ColumnChunk.meta_data
+		"\x15\x04" + // ColumnMetaData.type: INT64
+		"\x19\x25\x00\x06" + // ColumnMetaData.encodings: PLAIN, RLE
+		"\x19\x18\x06height" + // ColumnMetaData.path_in_schema
+		"\x15\x00" + // ColumnMetaData.codec: UNCOMPRESSED
+		"\x16\x02" + // ColumnMetaData.num_values: 1
+		"\x16\x32\x16\x32" + // ColumnMetaData.total_uncompressed_size and total_compressed_size: 25
+		"\x26\x08" + // ColumnMetaData.data_page_offset: 4
+		"\x00\x00" + // End of ColumnMetaData and ColumnChunk
+		"\x16\x32\x16\x02\x00" + // RowGroup.total_byte_size: 25, num_rows: 1
+		"\x28\x09bitclient" + // FileMetaData.created_by
+		"\x00" // End of FileMetaData
+
+	expected := append([]byte("PAR1"), page...)
+	expected = append(expected, metadata...)
+	expected = append(expected, 81, 0, 0, 0) // Length of the metadata
+	expected = append(expected, "PAR1"...)
+
+	buffer := &bytes.Buffer{}
+	writer, err := export.NewWriter(export.FormatParquet, buffer, []export.Column{{Name: "height", Kind: export.KindInt}})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if err := writer.Write([]any{int64(1)}); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("Expected parquet file\n%x\ngot\n%x", expected, buffer.Bytes())
+	}
+}
+
+// decode decodes a Thrift compact protocol struct into its fields, by id.
+func decode(t *testing.T, data []byte) map[int16]any {
+	fields, _ := decodeStruct(t, data)
+	return fields
+}
+
+// decodeStruct decodes a Thrift compact protocol struct, returning its fields and its size.
+func decodeStruct(t *testing.T, data []byte) (map[int16]any, int) {
+	fields := map[int16]any{}
+	position, id := 0, int16(0)
+
+	varint := func() int64 {
+		value, n := binary.Uvarint(data[position:])
+		position += n
+		return int64(value>>1) ^ -int64(value&1)
+	}
+
+	var value func(kind byte) any
+	value = func(kind byte) any {
+		switch kind {
+		case 5, 6: // i32, i64
+			return varint()
+		case 8: // binary
+			n, size := binary.Uvarint(data[position:])
+			position += size
+			position += int(n)
+			return string(data[position-int(n) : position])
+		case 9: // list
+			header := data[position]
+			position++
+			n, kind := int(header>>4), header&0x0f
+			if n == 15 {
+				size, m := binary.Uvarint(data[position:])
+				n, position = int(size), position+m
+			}
+			items := []any{}
+			for range n {
+				items = append(items, value(kind))
+			}
+			return items
+		case 12: // struct
+			nested, size := decodeStruct(t, data[position:])
+			position += size
+			return nested
+		}
+		t.Fatalf("Unexpected thrift type %d", kind)
+		return nil
+	}
+
+	for {
+		header := data[position]
+		position++
+		if header == 0 {
+			return fields, position
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(varint())
+		}
+		fields[id] = value(header & 0x0f)
+	}
+}
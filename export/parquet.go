@@ -0,0 +1,303 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// RowGroupSize is the number of records buffered in memory before a Parquet row group is written.
+var RowGroupSize = 10_000
+
+// Parquet constants, as defined in the format's parquet.thrift.
+const (
+	parquetMagic = "PAR1"
+
+	parquetInt64     = 2 // Physical type INT64
+	parquetDouble    = 5 // Physical type DOUBLE
+	parquetByteArray = 6 // Physical type BYTE_ARRAY
+
+	parquetRequired = 0 // Repetition type REQUIRED: every record has a value
+	parquetUTF8     = 0 // Converted type UTF8, for strings
+	parquetPlain    = 0 // Encoding PLAIN
+	parquetRLE      = 3 // Encoding RLE, declared for the (absent) levels
+	parquetDataPage = 0 // Page type DATA_PAGE
+)
+
+// parquet writes records as an uncompressed Parquet file with a flat schema of required columns.
+// Records are buffered by column and written as a row group, each column in a single PLAIN-encoded
+// data page, every RowGroupSize records. The footer holding the file metadata is written on Close.
+type parquet struct {
+	w       io.Writer
+	columns []Column
+	values  [][]byte // PLAIN-encoded values of the buffered records, by column
+	rows    int      // Number of buffered records
+	offset  int64    // Number of bytes written so far
+	groups  []parquetRowGroup
+	total   int64 // Number of records written in row groups
+}
+
+// parquetRowGroup holds the metadata of a written row group.
+type parquetRowGroup struct {
+	rows    int64
+	size    int64
+	offsets []int64 // Offset of each column's data page
+	sizes   []int64 // Size of each column chunk, page header included
+}
+
+func newParquet(w io.Writer, columns []Column) *parquet {
+	return &parquet{w: w, columns: columns, values: make([][]byte, len(columns))}
+}
+
+func (p *parquet) Write(record []any) error {
+	if err := check(p.columns, record); err != nil {
+		return err
+	}
+
+	for i, value := range record {
+		switch value := value.(type) {
+		case int64:
+			p.values[i] = binary.LittleEndian.AppendUint64(p.values[i], uint64(value))
+		case float64:
+			p.values[i] = binary.LittleEndian.AppendUint64(p.values[i], math.Float64bits(value))
+		case string:
+			p.values[i] = binary.LittleEndian.AppendUint32(p.values[i], uint32(len(value)))
+			p.values[i] = append(p.values[i], value...)
+		}
+	}
+
+	p.rows++
+	if p.rows >= RowGroupSize {
+		return p.flush()
+	}
+	return nil
+}
+
+func (p *parquet) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	if p.offset == 0 {
+		if err := p.write([]byte(parquetMagic)); err != nil {
+			return err
+		}
+	}
+
+	footer := p.metadata()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	return p.write(append(footer, parquetMagic...))
+}
+
+// flush writes the buffered records as a row group.
+func (p *parquet) flush() error {
+	if p.rows == 0 {
+		return nil
+	}
+	if p.offset == 0 {
+		if err := p.write([]byte(parquetMagic)); err != nil {
+			return err
+		}
+	}
+
+	group := parquetRowGroup{rows: int64(p.rows)}
+	for i, values := range p.values {
+		header := &thrift{}
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(values)))
+		header.i32(3, int32(len(values)))
+		header.begin(5) // DataPageHeader
+		header.i32(1, int32(p.rows))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.end()
+		header.stop()
+
+		group.offsets = append(group.offsets, p.offset)
+		group.sizes = append(group.sizes, int64(len(header.data)+len(values)))
+		group.size += int64(len(header.data) + len(values))
+
+		if err := p.write(append(header.data, values...)); err != nil {
+			return err
+		}
+		p.values[i] = values[:0]
+	}
+
+	p.groups = append(p.groups, group)
+	p.total += int64(p.rows)
+	p.rows = 0
+	return nil
+}
+
+// write writes data, keeping track of the offset.
+func (p *parquet) write(data []byte) error {
+	n, err := p.w.Write(data)
+	p.offset += int64(n)
+	if err != nil {
+		return failure.Of("failed to write parquet data: %v", err.Error())
+	}
+	return nil
+}
+
+// metadata serializes the FileMetaData of the file.
+func (p *parquet) metadata() []byte {
+	t := &thrift{}
+	t.i32(1, 1) // Version
+
+	// Schema: a root element, followed by a required leaf per column
+	t.list(2, thriftStruct, len(p.columns)+1)
+	t.item()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(p.columns)))
+	t.end()
+	for _, column := range p.columns {
+		t.item()
+		t.i32(1, parquetType(column.Kind))
+		t.i32(3, parquetRequired)
+		t.binary(4, column.Name)
+		if column.Kind == KindString {
+			t.i32(6, parquetUTF8)
+		}
+		t.end()
+	}
+
+	t.i64(3, p.total)
+
+	t.list(4, thriftStruct, len(p.groups))
+	for _, group := range p.groups {
+		t.item()
+		t.list(1, thriftStruct, len(p.columns))
+		for i, column := range p.columns {
+			t.item()
+			t.i64(2, group.offsets[i])
+			t.begin(3) // ColumnMetaData
+			t.i32(1, parquetType(column.Kind))
+			t.list(2, thriftI32, 2)
+			t.varint(zigzag(parquetPlain))
+			t.varint(zigzag(parquetRLE))
+			t.list(3, thriftBinary, 1)
+			t.varint(uint64(len(column.Name)))
+			t.data = append(t.data, column.Name...)
+			t.i32(4, 0) // Uncompressed
+			t.i64(5, group.rows)
+			t.i64(6, group.sizes[i])
+			t.i64(7, group.sizes[i])
+			t.i64(9, group.offsets[i])
+			t.end()
+			t.end()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.rows)
+		t.end()
+	}
+
+	t.binary(6, "bitclient")
+	t.stop()
+	return t.data
+}
+
+// parquetType returns the physical type of a column kind.
+func parquetType(kind Kind) int32 {
+	switch kind {
+	case KindFloat:
+		return parquetDouble
+	case KindString:
+		return parquetByteArray
+	}
+	return parquetInt64
+}
+
+// Types of the Thrift compact protocol.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thrift encodes structs with the Thrift compact protocol, in which Parquet metadata is serialized.
+// Field ids are encoded as deltas from the previous field of the same struct.
+type thrift struct {
+	data []byte
+	last []int16 // Last field id of each open struct, innermost last
+}
+
+// field writes a field header.
+func (t *thrift) field(id int16, kind byte) {
+	if len(t.last) == 0 {
+		t.last = []int16{0}
+	}
+
+	delta := id - t.last[len(t.last)-1]
+	if delta > 0 && delta <= 15 {
+		t.data = append(t.data, byte(delta)<<4|kind)
+	} else {
+		t.data = append(t.data, kind)
+		t.varint(zigzag(int64(id)))
+	}
+	t.last[len(t.last)-1] = id
+}
+
+func (t *thrift) i32(id int16, value int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(value)))
+}
+
+func (t *thrift) i64(id int16, value int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(value))
+}
+
+func (t *thrift) binary(id int16, value string) {
+	t.field(id, thriftBinary)
+	t.varint(uint64(len(value)))
+	t.data = append(t.data, value...)
+}
+
+// list writes the header of a list field with n elements of a type.
+func (t *thrift) list(id int16, kind byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.data = append(t.data, byte(n)<<4|kind)
+		return
+	}
+	t.data = append(t.data, 0xf0|kind)
+	t.varint(uint64(n))
+}
+
+// begin opens a struct field.
+func (t *thrift) begin(id int16) {
+	t.field(id, thriftStruct)
+	t.last = append(t.last, 0)
+}
+
+// item opens a struct element of a list.
+func (t *thrift) item() {
+	if len(t.last) == 0 {
+		t.last = []int16{0}
+	}
+	t.last = append(t.last, 0)
+}
+
+// end closes the innermost struct.
+func (t *thrift) end() {
+	t.stop()
+	t.last = t.last[:len(t.last)-1]
+}
+
+// stop writes the end of a struct.
+func (t *thrift) stop() {
+	t.data = append(t.data, 0)
+}
+
+func (t *thrift) varint(value uint64) {
+	t.data = binary.AppendUvarint(t.data, value)
+}
+
+// zigzag maps signed integers to unsigned ones, small magnitudes first.
+func zigzag(value int64) uint64 {
+	return uint64(value<<1) ^ uint64(value>>63)
+}
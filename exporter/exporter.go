@@ -0,0 +1,258 @@
+// Package exporter implements a Prometheus exporter of a node's metrics: chain height and sync
+// progress, connections, traffic, bans and mempool size, polled from the node, along with the
+// latency and errors of the RPC requests sent to it.
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/mempool"
+	"github.com/avila-r/bitclient/network"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// metric is a metric polled from the node.
+type metric struct {
+	name    string
+	help    string
+	kind    string // "gauge" or "counter"
+	samples []sample
+}
+
+// sample is a value of a metric, with its labels in the Prometheus text format (e.g. direction="in").
+type sample struct {
+	labels string
+	value  float64
+}
+
+// Options configures the exporter.
+type Options struct {
+	// Interval is the time between two polls of the node (default: 15s).
+	Interval time.Duration
+}
+
+// Exporter polls a node's metrics and serves them in the Prometheus text format.
+type Exporter struct {
+	options Options
+	rpc     *RPCMetrics
+
+	mu       sync.Mutex
+	metrics  []metric  // Metrics of the latest successful poll
+	up       bool      // Whether the latest poll succeeded
+	polled   time.Time // Time of the latest successful poll
+	failures uint64    // Number of failed polls
+}
+
+// New creates an exporter of the node the default rpc.Client is connected to, whose requests are
+// timed from then on.
+//
+// Parameters:
+// - options (Options): The polling interval.
+//
+// Returns:
+// - *Exporter: The exporter, whose metrics are polled by Run and served as an http.Handler.
+// - error: An error if there is no client.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient exporter --listen :9332
+//
+//   - Using Go:
+//     e, err := exporter.New(exporter.Options{Interval: 15 * time.Second})
+//     go e.Run(ctx)
+//     http.Handle("/metrics", e)
+func New(options Options) (*Exporter, error) {
+	if rpc.Client == nil {
+		return nil, failure.Of("no rpc.Client configured")
+	}
+	if options.Interval <= 0 {
+		options.Interval = 15 * time.Second
+	}
+
+	e := &Exporter{options: options, rpc: NewRPCMetrics()}
+	rpc.Client.Observe(e.rpc)
+	return e, nil
+}
+
+// Run polls the node every interval until the context is done, starting right away.
+//
+// Parameters:
+// - ctx (context.Context): Stops polling when done.
+// - failed (func(error)): Called with the error of every failed poll. It can be nil.
+func (e *Exporter) Run(ctx context.Context, failed func(error)) {
+	ticker := time.NewTicker(e.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Poll(); err != nil && failed != nil {
+			failed(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll retrieves the node's metrics. When it fails, the metrics of the previous poll are kept and
+// the node is reported as down.
+//
+// Returns:
+// - error: An error if any of the requests fails.
+func (e *Exporter) Poll() error {
+	metrics, err := poll()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.up = err == nil
+	if err != nil {
+		e.failures++
+		return err
+	}
+	e.metrics = metrics
+	e.polled = time.Now()
+	return nil
+}
+
+// poll retrieves the node's metrics.
+func poll() ([]metric, error) {
+	chain := struct {
+		Blocks     float64 `json:"blocks"`
+		Headers    float64 `json:"headers"`
+		Progress   float64 `json:"verificationprogress"`
+		Difficulty float64 `json:"difficulty"`
+	}{}
+	info, err := blocks.GetBlockchainInfo()
+	if err != nil {
+		return nil, failure.Of("failed to get blockchain info: %v", err.Error())
+	}
+	if err := info.Bind(&chain); err != nil {
+		return nil, err
+	}
+
+	connections := struct {
+		In  float64 `json:"connections_in"`
+		Out float64 `json:"connections_out"`
+	}{}
+	networkInfo, err := network.GetNetworkInfo()
+	if err != nil {
+		return nil, failure.Of("failed to get network info: %v", err.Error())
+	}
+	if err := networkInfo.Bind(&connections); err != nil {
+		return nil, err
+	}
+
+	traffic := struct {
+		Received float64 `json:"totalbytesrecv"`
+		Sent     float64 `json:"totalbytessent"`
+	}{}
+	totals, err := network.InspectTraffic()
+	if err != nil {
+		return nil, failure.Of("failed to inspect network traffic: %v", err.Error())
+	}
+	if err := totals.Bind(&traffic); err != nil {
+		return nil, err
+	}
+
+	banned, err := network.ListBanned()
+	if err != nil {
+		return nil, failure.Of("failed to list banned: %v", err.Error())
+	}
+
+	pool := struct {
+		Size  float64 `json:"size"`
+		Bytes float64 `json:"bytes"`
+	}{}
+	poolInfo, err := mempool.GetMempoolInfo()
+	if err != nil {
+		return nil, failure.Of("failed to get mempool info: %v", err.Error())
+	}
+	if err := poolInfo.Bind(&pool); err != nil {
+		return nil, err
+	}
+
+	gauge := func(name, help string, value float64) metric {
+		return metric{name: name, help: help, kind: "gauge", samples: []sample{{value: value}}}
+	}
+	counter := func(name, help string, value float64) metric {
+		return metric{name: name, help: help, kind: "counter", samples: []sample{{value: value}}}
+	}
+
+	return []metric{
+		gauge("bitcoin_blocks", "Height of the active chain.", chain.Blocks),
+		gauge("bitcoin_headers", "Height of the most-work header chain, whose blocks may not be validated yet.", chain.Headers),
+		gauge("bitcoin_verification_progress", "Estimate of the verification progress, between 0 and 1.", chain.Progress),
+		gauge("bitcoin_difficulty", "Proof-of-work difficulty of the tip, as a multiple of the minimum difficulty.", chain.Difficulty),
+		{name: "bitcoin_connections", help: "Number of connections to peers, per direction.", kind: "gauge", samples: []sample{
+			{labels: `direction="in"`, value: connections.In},
+			{labels: `direction="out"`, value: connections.Out},
+		}},
+		counter("bitcoin_received_bytes_total", "Bytes received from peers since the node started.", traffic.Received),
+		counter("bitcoin_sent_bytes_total", "Bytes sent to peers since the node started.", traffic.Sent),
+		gauge("bitcoin_banned", "Number of banned IP addresses and subnets.", float64(len(*banned))),
+		gauge("bitcoin_mempool_transactions", "Number of transactions in the mempool.", pool.Size),
+		gauge("bitcoin_mempool_bytes", "Total virtual size of the transactions in the mempool.", pool.Bytes),
+	}, nil
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.Write(w)
+}
+
+// Write writes the metrics in the Prometheus text format.
+//
+// Parameters:
+// - w (io.Writer): The writer the metrics are written to.
+func (e *Exporter) Write(w io.Writer) {
+	e.mu.Lock()
+	up := 0.0
+	if e.up {
+		up = 1
+	}
+	metrics := append([]metric{
+		{name: "bitclient_up", help: "Whether the latest poll of the node succeeded.", kind: "gauge", samples: []sample{{value: up}}},
+		{name: "bitclient_poll_failures_total", help: "Number of failed polls of the node.", kind: "counter", samples: []sample{{value: float64(e.failures)}}},
+	}, e.metrics...)
+	if !e.polled.IsZero() {
+		metrics = append(metrics, metric{name: "bitclient_last_poll_timestamp_seconds", help: "Time of the latest successful poll of the node.", kind: "gauge",
+			samples: []sample{{value: float64(e.polled.UnixMilli()) / 1000}}})
+	}
+	e.mu.Unlock()
+
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+		for _, s := range m.samples {
+			name := m.name
+			if s.labels != "" {
+				name += "{" + s.labels + "}"
+			}
+			fmt.Fprintf(w, "%s %s\n", name, format(s.value))
+		}
+	}
+
+	e.rpc.Write(w)
+}
+
+// format formats a value in decimal notation, unless it's too small or too large to be readable.
+func format(value float64) string {
+	if abs := math.Abs(value); abs == 0 || (abs >= 1e-4 && abs < 1e21) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
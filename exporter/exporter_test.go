@@ -0,0 +1,159 @@
+package exporter_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/exporter"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+// scrape retrieves the metrics served by an exporter.
+func scrape(t *testing.T, e *exporter.Exporter) string {
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type: %v", resp.Header.Get("Content-Type"))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+// expect checks that the metrics contain each of the lines.
+func expect(t *testing.T, metrics string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(metrics, "\n"+line+"\n") {
+			t.Errorf("Expected line %q in metrics:\n%s", line, metrics)
+		}
+	}
+}
+
+func Test_Exporter(t *testing.T) {
+	fake := rpctest.Use(t)
+	fake.Bans = []rpctest.Banned{{Address: "203.0.113.0/24"}, {Address: "198.51.100.7/32"}}
+
+	e, err := exporter.New(exporter.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+
+	if err := e.Poll(); err != nil {
+		t.Fatalf("Failed to poll node: %v", err)
+	}
+	tip := fake.Chain.Tip()
+	metrics := scrape(t, e)
+	expect(t, metrics,
+		"# TYPE bitcoin_blocks gauge",
+		"bitcoin_blocks "+strconv.Itoa(tip.Height),
+		"bitcoin_headers "+strconv.Itoa(tip.Height),
+		"bitcoin_verification_progress 1",
+		`bitcoin_connections{direction="in"} 1`,
+		`bitcoin_connections{direction="out"} 2`,
+		"# TYPE bitcoin_received_bytes_total counter",
+		"bitcoin_received_bytes_total 1048576",
+		"bitcoin_sent_bytes_total 524288",
+		"bitcoin_banned 2",
+		"bitcoin_mempool_transactions 0",
+		"bitcoin_mempool_bytes 0",
+		"bitclient_up 1",
+		"bitclient_poll_failures_total 0",
+		"# TYPE bitclient_rpc_duration_seconds histogram",
+		`bitclient_rpc_duration_seconds_bucket{method="getblockchaininfo",le="+Inf"} 1`,
+		`bitclient_rpc_duration_seconds_count{method="listbanned"} 1`,
+		`bitclient_rpc_errors_total{method="getmempoolinfo"} 0`,
+	)
+	if !strings.Contains(metrics, "\nbitclient_last_poll_timestamp_seconds ") {
+		t.Errorf("Expected the time of the latest poll in metrics:\n%s", metrics)
+	}
+
+	// A failed poll keeps the previous values, and counts the error of its method
+	fake.Fail("getmempoolinfo", -1, "unavailable")
+	if err := e.Poll(); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("Expected the poll to fail, got %v", err)
+	}
+	expect(t, scrape(t, e),
+		"bitclient_up 0",
+		"bitclient_poll_failures_total 1",
+		"bitcoin_banned 2",
+		`bitclient_rpc_duration_seconds_count{method="getmempoolinfo"} 2`,
+		`bitclient_rpc_errors_total{method="getmempoolinfo"} 1`,
+		`bitclient_rpc_errors_total{method="getblockchaininfo"} 0`,
+	)
+
+	fake.Recover("getmempoolinfo")
+	if err := e.Poll(); err != nil {
+		t.Fatalf("Failed to poll node: %v", err)
+	}
+	expect(t, scrape(t, e), "bitclient_up 1", "bitclient_poll_failures_total 1")
+}
+
+func Test_ExporterRun(t *testing.T) {
+	fake := rpctest.Use(t)
+
+	e, err := exporter.New(exporter.Options{Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx, func(err error) { t.Errorf("Failed to poll node: %v", err) })
+		close(done)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); fake.Calls("getblockchaininfo") < 3; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for polls")
+		}
+	}
+	cancel()
+	<-done
+
+	expect(t, scrape(t, e), "bitclient_up 1")
+
+	// Without a client, there's nothing to export
+	rpc.Client = nil
+	if _, err := exporter.New(exporter.Options{}); err == nil {
+		t.Errorf("Expected an error without a client")
+	}
+}
+
+func Test_RPCMetrics(t *testing.T) {
+	m := exporter.NewRPCMetrics()
+	m.Observe("getblock", 3*time.Millisecond, false)
+	m.Observe("getblock", 200*time.Millisecond, true)
+	m.Observe("getblock", time.Minute, false)
+
+	var b strings.Builder
+	m.Write(&b)
+	expect(t, "\n"+b.String(),
+		`bitclient_rpc_duration_seconds_bucket{method="getblock",le="0.001"} 0`,
+		`bitclient_rpc_duration_seconds_bucket{method="getblock",le="0.005"} 1`,
+		`bitclient_rpc_duration_seconds_bucket{method="getblock",le="0.1"} 1`,
+		`bitclient_rpc_duration_seconds_bucket{method="getblock",le="0.25"} 2`,
+		`bitclient_rpc_duration_seconds_bucket{method="getblock",le="10"} 2`,
+		`bitclient_rpc_duration_seconds_bucket{method="getblock",le="+Inf"} 3`,
+		`bitclient_rpc_duration_seconds_sum{method="getblock"} 60.203`,
+		`bitclient_rpc_duration_seconds_count{method="getblock"} 3`,
+		`bitclient_rpc_errors_total{method="getblock"} 1`,
+	)
+}
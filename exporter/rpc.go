@@ -0,0 +1,109 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// Buckets are the upper bounds, in seconds, of the RPC latency histograms.
+var Buckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram counts observations into cumulative buckets.
+type histogram struct {
+	counts []uint64 // Observations per bucket of Buckets, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// RPCMetrics records the latency and errors of RPC requests per method. It's an rpc.Observer.
+type RPCMetrics struct {
+	mu        sync.Mutex
+	durations map[string]*histogram
+	errors    map[string]uint64
+}
+
+// NewRPCMetrics creates an empty record of RPC metrics.
+func NewRPCMetrics() *RPCMetrics {
+	return &RPCMetrics{durations: map[string]*histogram{}, errors: map[string]uint64{}}
+}
+
+// Start does nothing: requests are recorded once they end.
+func (m *RPCMetrics) Start(ctx context.Context, call *rpc.Call) context.Context {
+	return ctx
+}
+
+// End records a request, counting as errors the requests that fail to reach the node, get an HTTP
+// error or a JSON-RPC error.
+func (m *RPCMetrics) End(ctx context.Context, call *rpc.Call) {
+	m.Observe(string(call.Method), call.Duration, call.Err != nil)
+}
+
+// Observe records a request to a method.
+//
+// Parameters:
+// - method (string): The method of the request.
+// - duration (time.Duration): The time it took to get its response.
+// - failed (bool): Whether it failed.
+func (m *RPCMetrics) Observe(method string, duration time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.durations[method]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(Buckets))}
+		m.durations[method] = h
+		m.errors[method] = 0
+	}
+
+	seconds := duration.Seconds()
+	if i, _ := slices.BinarySearch(Buckets, seconds); i < len(Buckets) {
+		h.counts[i]++
+	}
+	h.sum += seconds
+	h.count++
+
+	if failed {
+		m.errors[method]++
+	}
+}
+
+// Write writes the RPC metrics in the Prometheus text format.
+//
+// Parameters:
+// - w (io.Writer): The writer the metrics are written to.
+func (m *RPCMetrics) Write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	methods := []string{}
+	for method := range m.durations {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods)
+
+	fmt.Fprintln(w, "# HELP bitclient_rpc_duration_seconds Latency of the RPC requests sent to the node, per method.")
+	fmt.Fprintln(w, "# TYPE bitclient_rpc_duration_seconds histogram")
+	for _, method := range methods {
+		h := m.durations[method]
+		cumulative := uint64(0)
+		for i, bound := range Buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "bitclient_rpc_duration_seconds_bucket{method=%q,le=%q} %d\n", method, format(bound), cumulative)
+		}
+		fmt.Fprintf(w, "bitclient_rpc_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", method, h.count)
+		fmt.Fprintf(w, "bitclient_rpc_duration_seconds_sum{method=%q} %s\n", method, format(h.sum))
+		fmt.Fprintf(w, "bitclient_rpc_duration_seconds_count{method=%q} %d\n", method, h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP bitclient_rpc_errors_total RPC requests to the node that failed, per method.")
+	fmt.Fprintln(w, "# TYPE bitclient_rpc_errors_total counter")
+	for _, method := range methods {
+		fmt.Fprintf(w, "bitclient_rpc_errors_total{method=%q} %d\n", method, m.errors[method])
+	}
+}
@@ -0,0 +1,281 @@
+// Package filters decodes and matches BIP158 compact block filters: Golomb-coded sets of the
+// scripts a block spends and creates, hashed with SipHash keyed by the block hash. Matching a
+// filter locally tells whether a block may involve a script, without downloading the block.
+package filters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+	"slices"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// Type defines the parameters of a filter type.
+type Type struct {
+	Name string // Name of the type, as accepted by 'getblockfilter'
+	P    uint8  // Golomb-Rice coding parameter: number of bits of each remainder
+	M    uint64 // Inverse of the false positive rate
+}
+
+// Basic is the basic filter type (BIP158), covering the scripts of a block's outputs and of the outputs its inputs spend.
+var Basic = Type{Name: "basic", P: 19, M: 784931}
+
+// TypeFrom returns the filter type with the given name.
+func TypeFrom(name string) (Type, error) {
+	if name == Basic.Name {
+		return Basic, nil
+	}
+	return Type{}, failure.Of("unknown filter type '%s', the only supported type is basic", name)
+}
+
+// Filter is a decoded compact block filter.
+type Filter struct {
+	Type  Type      // Type of the filter
+	Block wire.Hash // Hash of the block, whose first 16 bytes key the filter's hashes
+
+	data   []byte   // Serialized filter: element count and Golomb-Rice coded set
+	values []uint64 // Hashed elements, in increasing order
+}
+
+// New decodes a serialized filter of a block: a CompactSize element count followed by the
+// Golomb-Rice coded deltas between the sorted hashed elements.
+//
+// Parameters:
+// - t (Type): The type of the filter.
+// - block (wire.Hash): The hash of the block the filter belongs to.
+// - data ([]byte): The serialized filter, such as the 'filter' field of 'getblockfilter' results.
+//
+// Returns:
+// - *Filter: The decoded filter.
+// - error: An error if the filter is malformed.
+func New(t Type, block wire.Hash, data []byte) (*Filter, error) {
+	n, size := uvarint(data)
+	if size == 0 {
+		return nil, failure.Of("invalid filter: missing element count")
+	}
+
+	// Each element takes at least P+1 bits
+	r := &bitReader{data: data[size:]}
+	if n > uint64(len(r.data))*8/(uint64(t.P)+1) {
+		return nil, failure.Of("invalid filter: %d elements can't fit in %d bytes", n, len(r.data))
+	}
+
+	values := make([]uint64, n)
+	value := uint64(0)
+	for i := range values {
+		delta, ok := r.golomb(t.P)
+		if !ok {
+			return nil, failure.Of("invalid filter: truncated after %d of %d elements", i, n)
+		}
+		value += delta
+		values[i] = value
+	}
+
+	return &Filter{Type: t, Block: block, data: data, values: values}, nil
+}
+
+// N returns the number of elements in the filter.
+func (f *Filter) N() int {
+	return len(f.values)
+}
+
+// Bytes returns the serialized filter.
+func (f *Filter) Bytes() []byte {
+	return f.data
+}
+
+// Hash returns the filter hash: the double SHA-256 of the serialized filter.
+func (f *Filter) Hash() wire.Hash {
+	return wire.Hash256(f.data)
+}
+
+// Header returns the filter header, committing to the filter and to the previous block's filter header.
+func (f *Filter) Header(previous wire.Hash) wire.Hash {
+	return Header(f.Hash(), previous)
+}
+
+// Match reports whether an element (e.g. a scriptPubKey) may be in the filter. False positives
+// happen with a probability of 1/M; an element of the filter is always matched.
+func (f *Filter) Match(element []byte) bool {
+	return f.MatchAny([][]byte{element})
+}
+
+// MatchAny reports whether any of the elements may be in the filter.
+func (f *Filter) MatchAny(elements [][]byte) bool {
+	if len(f.values) == 0 || len(elements) == 0 {
+		return false
+	}
+
+	queries := hashes(f.Type, f.Block, uint64(len(f.values)), elements)
+	for i, j := 0, 0; i < len(queries) && j < len(f.values); {
+		switch {
+		case queries[i] == f.values[j]:
+			return true
+		case queries[i] < f.values[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return false
+}
+
+// Header computes a filter header: the double SHA-256 of the filter hash and the previous
+// block's filter header (zero for the genesis block).
+func Header(filter wire.Hash, previous wire.Hash) wire.Hash {
+	return wire.Hash256(append(filter[:], previous[:]...))
+}
+
+// Build builds the serialized filter of a block holding the given elements. Empty and duplicate elements are ignored.
+func Build(t Type, block wire.Hash, elements [][]byte) []byte {
+	unique := [][]byte{}
+	for _, element := range elements {
+		if len(element) > 0 && !slices.ContainsFunc(unique, func(e []byte) bool { return bytes.Equal(e, element) }) {
+			unique = append(unique, element)
+		}
+	}
+
+	data := appendCompactSize(nil, uint64(len(unique)))
+
+	w := &bitWriter{}
+	previous := uint64(0)
+	for _, value := range hashes(t, block, uint64(len(unique)), unique) {
+		w.golomb(value-previous, t.P)
+		previous = value
+	}
+
+	return append(data, w.data...)
+}
+
+// BasicElements returns the elements of a block's basic filter: the scripts of its outputs,
+// except OP_RETURN ones, and the scripts of the outputs spent by its inputs (given by the caller,
+// since blocks don't include them).
+func BasicElements(block *wire.Block, spent [][]byte) [][]byte {
+	elements := [][]byte{}
+	for _, tx := range block.Transactions {
+		for _, output := range tx.Outputs {
+			if len(output.ScriptPubKey) > 0 && output.ScriptPubKey[0] != 0x6a {
+				elements = append(elements, output.ScriptPubKey)
+			}
+		}
+	}
+	return append(elements, spent...)
+}
+
+// hashes maps elements to the range [0, n*M), sorted, as the elements of a filter with n elements.
+func hashes(t Type, block wire.Hash, n uint64, elements [][]byte) []uint64 {
+	k0 := binary.LittleEndian.Uint64(block[0:8])
+	k1 := binary.LittleEndian.Uint64(block[8:16])
+
+	values := make([]uint64, len(elements))
+	for i, element := range elements {
+		// Multiply-and-shift maps the 64-bit hash to the range without a division
+		values[i], _ = bits.Mul64(siphash(k0, k1, element), n*t.M)
+	}
+	slices.Sort(values)
+	return values
+}
+
+// bitReader reads a bit stream, most significant bits first.
+type bitReader struct {
+	data []byte
+	pos  int // Position in bits
+}
+
+// bit reads a single bit.
+func (r *bitReader) bit() (uint64, bool) {
+	if r.pos >= len(r.data)*8 {
+		return 0, false
+	}
+	b := uint64(r.data[r.pos/8]>>(7-r.pos%8)) & 1
+	r.pos++
+	return b, true
+}
+
+// golomb reads a Golomb-Rice coded value: a unary quotient and a p-bit remainder.
+func (r *bitReader) golomb(p uint8) (uint64, bool) {
+	quotient := uint64(0)
+	for {
+		b, ok := r.bit()
+		if !ok {
+			return 0, false
+		}
+		if b == 0 {
+			break
+		}
+		quotient++
+	}
+
+	remainder := uint64(0)
+	for range p {
+		b, ok := r.bit()
+		if !ok {
+			return 0, false
+		}
+		remainder = remainder<<1 | b
+	}
+
+	return quotient<<p | remainder, true
+}
+
+// bitWriter writes a bit stream, most significant bits first.
+type bitWriter struct {
+	data []byte
+	pos  int // Position in bits
+}
+
+// bit writes a single bit.
+func (w *bitWriter) bit(b uint64) {
+	if w.pos%8 == 0 {
+		w.data = append(w.data, 0)
+	}
+	w.data[len(w.data)-1] |= byte(b&1) << (7 - w.pos%8)
+	w.pos++
+}
+
+// golomb writes a Golomb-Rice coded value.
+func (w *bitWriter) golomb(value uint64, p uint8) {
+	for range value >> p {
+		w.bit(1)
+	}
+	w.bit(0)
+
+	for i := int(p) - 1; i >= 0; i-- {
+		w.bit(value >> i)
+	}
+}
+
+// uvarint decodes a CompactSize integer, returning it and its size (0 if data is too short).
+func uvarint(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+
+	switch prefix := data[0]; {
+	case prefix < 0xfd:
+		return uint64(prefix), 1
+	case prefix == 0xfd && len(data) >= 3:
+		return uint64(binary.LittleEndian.Uint16(data[1:])), 3
+	case prefix == 0xfe && len(data) >= 5:
+		return uint64(binary.LittleEndian.Uint32(data[1:])), 5
+	case prefix == 0xff && len(data) >= 9:
+		return binary.LittleEndian.Uint64(data[1:]), 9
+	}
+	return 0, 0
+}
+
+// appendCompactSize appends a CompactSize integer to data.
+func appendCompactSize(data []byte, n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return append(data, byte(n))
+	case n <= 0xffff:
+		return binary.LittleEndian.AppendUint16(append(data, 0xfd), uint16(n))
+	case n <= 0xffffffff:
+		return binary.LittleEndian.AppendUint32(append(data, 0xfe), uint32(n))
+	}
+	return binary.LittleEndian.AppendUint64(append(data, 0xff), n)
+}
@@ -0,0 +1,115 @@
+package filters_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/avila-r/bitclient/filters"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// genesis is the output script of the testnet3 genesis block's coinbase.
+const genesis = "4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac"
+
+func Test_Build(t *testing.T) {
+	// Test vector of the testnet3 genesis block, from BIP158
+	block, _ := wire.HashFrom("000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943")
+	script, _ := hex.DecodeString(genesis)
+
+	data := filters.Build(filters.Basic, block, [][]byte{script, script, {}})
+	if encoded := hex.EncodeToString(data); encoded != "019dfca8" {
+		t.Errorf("Expected filter 019dfca8, got %s", encoded)
+	}
+
+	filter, err := filters.New(filters.Basic, block, data)
+	if err != nil {
+		t.Fatalf("Failed to decode filter: %v", err)
+	}
+	if filter.N() != 1 {
+		t.Errorf("Expected 1 element, got %d", filter.N())
+	}
+	if header := filter.Header(wire.Hash{}).String(); header != "21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750" {
+		t.Errorf("Unexpected filter header %s", header)
+	}
+	if !filter.Match(script) {
+		t.Errorf("Expected the genesis script to match")
+	}
+
+	if empty := filters.Build(filters.Basic, block, nil); hex.EncodeToString(empty) != "00" {
+		t.Errorf("Expected an empty filter to be 00, got %x", empty)
+	}
+}
+
+func Test_Match(t *testing.T) {
+	block := wire.Hash256([]byte("block"))
+
+	elements := [][]byte{}
+	for i := range 500 {
+		elements = append(elements, binary.BigEndian.AppendUint32([]byte{0x51}, uint32(i)))
+	}
+
+	filter, err := filters.New(filters.Basic, block, filters.Build(filters.Basic, block, elements))
+	if err != nil {
+		t.Fatalf("Failed to decode filter: %v", err)
+	}
+	if filter.N() != len(elements) {
+		t.Errorf("Expected %d elements, got %d", len(elements), filter.N())
+	}
+
+	for i, element := range elements {
+		if !filter.Match(element) {
+			t.Errorf("Expected element %d to match", i)
+		}
+	}
+
+	// False positives happen once in 784931 elements
+	misses := [][]byte{}
+	for i := range 1000 {
+		misses = append(misses, binary.BigEndian.AppendUint32([]byte{0x52}, uint32(i)))
+	}
+	if filter.MatchAny(misses) {
+		t.Errorf("Expected elements outside the filter not to match")
+	}
+	if !filter.MatchAny(append(misses, elements[250])) {
+		t.Errorf("Expected a matching element among misses to match")
+	}
+	if filter.MatchAny(nil) {
+		t.Errorf("Expected no elements not to match")
+	}
+
+	// The key is the block hash: another block's filter doesn't match the same elements
+	other, _ := filters.New(filters.Basic, wire.Hash256([]byte("other")), filter.Bytes())
+	if other.MatchAny(elements[:10]) {
+		t.Errorf("Expected elements not to match under another block's key")
+	}
+}
+
+func Test_New(t *testing.T) {
+	cases := []struct{ Data string }{
+		{Data: ""},         // Missing element count
+		{Data: "02"},       // Missing elements
+		{Data: "029dfca8"}, // Too many elements for the data
+		{Data: "01ffffff"}, // Unterminated quotient
+		{Data: "fd01"},     // Truncated element count
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			data, _ := hex.DecodeString(test.Data)
+			if _, err := filters.New(filters.Basic, wire.Hash{}, data); err == nil {
+				t.Errorf("Expected filter %q to be rejected", test.Data)
+			}
+		})
+	}
+}
+
+func Test_TypeFrom(t *testing.T) {
+	if filterType, err := filters.TypeFrom("basic"); err != nil || filterType != filters.Basic {
+		t.Errorf("Failed to get basic filter type: %v", err)
+	}
+	if _, err := filters.TypeFrom("extended"); err == nil {
+		t.Errorf("Expected an unknown filter type to be rejected")
+	}
+}
@@ -0,0 +1,208 @@
+// Package guard implements a daemon scoring the peers of a node against a policy of rules, such as
+// a maximum ping or a minimum version, and disconnecting or banning the peers breaking them.
+package guard
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/network"
+)
+
+// Options configures the guard.
+type Options struct {
+	// Policy is the set of rules peers are evaluated against.
+	Policy Policy
+
+	// Interval is the time between two evaluations of the peers (default: 1m).
+	Interval time.Duration
+
+	// BanTime is the duration of the bans (default: 0, the node's default of 24 hours).
+	BanTime time.Duration
+
+	// DryRun only reports the decisions, without disconnecting or banning any peer.
+	DryRun bool
+
+	// Once evaluates the peers a single time, rather than until the context is done.
+	Once bool
+
+	// Audit is the file every decision is appended to as a JSON line, if any.
+	Audit string
+}
+
+// Run evaluates the peers of the node against options.Policy every options.Interval until the
+// context is done, disconnecting or banning the peers breaking a rule. Each decision is reported
+// and appended to the audit log, with the error its action failed with, if any. Decisions already
+// taken for a peer aren't repeated, so a dry run reports each peer once. Failed evaluations are
+// reported as errors, and the guard goes on.
+//
+// Parameters:
+// - ctx (context.Context): Stops the guard when done.
+// - options (Options): The policy, the polling interval and the audit log.
+// - report (func(Decision)): Called with every decision.
+// - failed (func(error)): Called with every failed evaluation or audit.
+//
+// Returns:
+// - error: An error if the policy is invalid or if the peers can't be retrieved when the guard starts.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient network guard --max-ping 2s --min-version 25.0 --ban outdated --dry-run
+//
+//   - Using Go:
+//     policy := guard.Policy{MaxPing: 2 * time.Second, MinVersion: "25.0", Ban: []string{guard.RuleOutdated}}
+//     err := guard.Run(ctx, guard.Options{Policy: policy, DryRun: true}, func(decision guard.Decision) {
+//     fmt.Println(decision.Address, decision.Reason)
+//     }, nil)
+func Run(ctx context.Context, options Options, report func(Decision), failed func(error)) error {
+	if err := options.Policy.Validate(); err != nil {
+		return err
+	}
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+	if failed == nil {
+		failed = func(error) {}
+	}
+
+	g := &guard{options: options, decided: map[key]bool{}}
+
+	peers, err := Peers()
+	if err != nil {
+		return err
+	}
+	g.apply(peers, report, failed)
+
+	if options.Once {
+		return nil
+	}
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		peers, err := Peers()
+		if err != nil {
+			failed(err)
+			continue
+		}
+		g.apply(peers, report, failed)
+	}
+}
+
+// key identifies a decision taken for a peer, to avoid repeating it.
+type key struct {
+	peer   int
+	action Action
+}
+
+// guard holds the state of a running guard.
+type guard struct {
+	options Options
+	decided map[key]bool
+	mu      sync.Mutex // Guards the audit log
+}
+
+// apply evaluates peers, then acts on and reports the decisions not taken yet.
+func (g *guard) apply(peers []Peer, report func(Decision), failed func(error)) {
+	now := time.Now()
+	connected := map[key]bool{}
+
+	for _, decision := range g.options.Policy.Evaluate(peers, now) {
+		k := key{peer: decision.Peer, action: decision.Action}
+		connected[k] = true
+		if g.decided[k] {
+			continue
+		}
+
+		decision.Time = now
+		decision.DryRun = g.options.DryRun
+		if !g.options.DryRun {
+			if err := g.act(decision); err != nil {
+				decision.Error = err.Error()
+			}
+		}
+		if decision.Error == "" {
+			g.decided[k] = true
+		}
+
+		report(decision)
+		if err := g.audit(decision); err != nil {
+			failed(err)
+		}
+	}
+
+	// Forget the peers that are gone or no longer break a rule
+	for k := range g.decided {
+		if !connected[k] {
+			delete(g.decided, k)
+		}
+	}
+}
+
+// act disconnects or bans the peer of a decision. Bitcoin Core disconnects banned peers by itself.
+func (g *guard) act(decision Decision) error {
+	if decision.Action == ActionBan {
+		ban := network.Ban{Target: decision.Target, Time: int(g.options.BanTime.Seconds())}
+		if err := network.SetBan(ban); err != nil {
+			return failure.Of("failed to ban %s: %v", decision.Target, err.Error())
+		}
+		return nil
+	}
+
+	if err := network.DisconnectNode(decision.Target); err != nil {
+		return failure.Of("failed to disconnect %s: %v", decision.Target, err.Error())
+	}
+	return nil
+}
+
+// audit appends a decision to the audit log, if any.
+func (g *guard) audit(decision Decision) error {
+	if g.options.Audit == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return failure.Of("failed to serialize decision: %v", err.Error())
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	file, err := os.OpenFile(g.options.Audit, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return failure.Of("failed to open audit log: %v", err.Error())
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return failure.Of("failed to write audit log: %v", err.Error())
+	}
+	return nil
+}
+
+// Peers retrieves the peers connected to the node.
+func Peers() ([]Peer, error) {
+	response, err := network.GetPeers()
+	if err != nil {
+		return nil, failure.Of("failed to get peers: %v", err.Error())
+	}
+
+	peers := []Peer{}
+	if err := response.Bind(&peers); err != nil {
+		return nil, failure.Of("failed to read peers: %v", err.Error())
+	}
+	return peers, nil
+}
@@ -0,0 +1,279 @@
+package guard_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/guard"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+// now is the time peers are evaluated at, an hour after they connected.
+var now = time.Unix(1700000000+3600, 0)
+
+// peer builds a healthy inbound Bitcoin Core peer.
+func peer(id int, address string) guard.Peer {
+	return guard.Peer{
+		ID: id, Address: address, Network: "ipv4", Services: "0000000000000409", ServicesNames: []string{"NETWORK", "WITNESS", "NETWORK_LIMITED"},
+		LastBlock: 1700000500, ConnTime: 1700000000 + int64(id), PingTime: 0.1, SubVersion: "/Satoshi:27.1.0/", Inbound: true, ConnectionType: "inbound",
+	}
+}
+
+// with modifies a peer.
+func with(p guard.Peer, modify func(*guard.Peer)) guard.Peer {
+	modify(&p)
+	return p
+}
+
+func Test_Evaluate(t *testing.T) {
+	_, protected, _ := net.ParseCIDR("10.0.0.0/8")
+
+	cases := []struct {
+		Policy   guard.Policy
+		Peers    []guard.Peer
+		Expected []string // ID, rule, action and target of the decisions
+	}{
+		// Healthy peers break no rule
+		{
+			Policy:   guard.Policy{MaxPing: time.Second, MinVersion: "25.0", NoServices: true, Stale: 30 * time.Minute, MaxPerSubnet: 2},
+			Peers:    []guard.Peer{peer(1, "203.0.113.1:8333"), peer(2, "198.51.100.1:8333")},
+			Expected: []string{},
+		},
+		{
+			Policy:   guard.Policy{MaxPing: time.Second},
+			Peers:    []guard.Peer{with(peer(1, "203.0.113.1:8333"), func(p *guard.Peer) { p.PingTime = 1.5 })},
+			Expected: []string{"1 ping disconnect 203.0.113.1:8333"},
+		},
+		// Versions are compared number by number, and other clients aren't judged
+		{
+			Policy: guard.Policy{MinVersion: "25.0"},
+			Peers: []guard.Peer{
+				with(peer(1, "203.0.113.1:8333"), func(p *guard.Peer) { p.SubVersion = "/Satoshi:0.20.1/" }),
+				with(peer(2, "203.0.113.2:8333"), func(p *guard.Peer) { p.SubVersion = "/Satoshi:25.0.0(pruned)/" }),
+				with(peer(3, "203.0.113.3:8333"), func(p *guard.Peer) { p.SubVersion = "/Satoshi:9.0.0/" }),
+				with(peer(4, "203.0.113.4:8333"), func(p *guard.Peer) { p.SubVersion = "/btcd:0.24.0/" }),
+			},
+			Expected: []string{"1 outdated disconnect 203.0.113.1:8333", "3 outdated disconnect 203.0.113.3:8333"},
+		},
+		{
+			Policy:   guard.Policy{NoServices: true, Ban: []string{guard.RuleNoServices}},
+			Peers:    []guard.Peer{with(peer(1, "203.0.113.1:8333"), func(p *guard.Peer) { p.Services, p.ServicesNames = "0000000000000000", nil })},
+			Expected: []string{"1 no-services ban 203.0.113.1"},
+		},
+		// Peers are stale once connected for longer than the duration without relaying a block
+		{
+			Policy: guard.Policy{Stale: 30 * time.Minute},
+			Peers: []guard.Peer{
+				with(peer(1, "203.0.113.1:8333"), func(p *guard.Peer) { p.LastBlock = 0 }),
+				with(peer(2, "203.0.113.2:8333"), func(p *guard.Peer) { p.LastBlock, p.ConnTime = 0, now.Unix()-60 }),
+			},
+			Expected: []string{"1 stale disconnect 203.0.113.1:8333"},
+		},
+		// The most recent connections in excess in a /16 are disconnected, whatever their port
+		{
+			Policy: guard.Policy{MaxPerSubnet: 1},
+			Peers: []guard.Peer{
+				peer(3, "203.0.7.1:8333"),
+				peer(1, "203.0.113.1:8333"),
+				peer(2, "203.0.113.2:51234"),
+				peer(4, "198.51.100.1:8333"),
+				with(peer(5, "[2001:db8:1::1]:8333"), func(p *guard.Peer) { p.Network = "ipv6" }),
+				with(peer(6, "[2001:db8:2::1]:8333"), func(p *guard.Peer) { p.Network = "ipv6" }),
+				with(peer(7, "abcdefghijklmnop.onion:8333"), func(p *guard.Peer) { p.Network = "onion" }),
+			},
+			Expected: []string{"3 subnet disconnect 203.0.7.1:8333", "2 subnet disconnect 203.0.113.2:51234", "6 subnet disconnect [2001:db8:2::1]:8333"},
+		},
+		// Peers without mapped AS aren't grouped
+		{
+			Policy: guard.Policy{MaxPerASN: 1, Ban: []string{guard.RuleASN}},
+			Peers: []guard.Peer{
+				with(peer(1, "203.0.113.1:8333"), func(p *guard.Peer) { p.MappedAS = 64496 }),
+				with(peer(2, "198.51.100.1:8333"), func(p *guard.Peer) { p.MappedAS = 64496 }),
+				peer(3, "192.0.2.1:8333"),
+				peer(4, "192.0.2.2:8333"),
+			},
+			Expected: []string{"2 asn ban 198.51.100.1"},
+		},
+		// A rule banning its breakers comes before the first rule broken, but onion peers can't be banned
+		{
+			Policy: guard.Policy{MaxPing: time.Second, MinVersion: "25.0", Ban: []string{guard.RuleOutdated}},
+			Peers: []guard.Peer{
+				with(peer(1, "203.0.113.1:8333"), func(p *guard.Peer) { p.PingTime, p.SubVersion = 2, "/Satoshi:0.21.0/" }),
+				with(peer(2, "abcdefghijklmnop.onion:8333"), func(p *guard.Peer) { p.Network, p.SubVersion = "onion", "/Satoshi:0.21.0/" }),
+			},
+			Expected: []string{"1 outdated ban 203.0.113.1", "2 outdated disconnect 2"},
+		},
+		// Tor and I2P inbound peers connect from 127.0.0.1: they're neither banned nor grouped, only disconnected by ID
+		{
+			Policy: guard.Policy{MaxPing: time.Second, MaxPerSubnet: 1, Ban: []string{guard.RulePing, guard.RuleSubnet}},
+			Peers: []guard.Peer{
+				with(peer(1, "127.0.0.1:40001"), func(p *guard.Peer) { p.Network = "onion" }),
+				with(peer(2, "127.0.0.1:40002"), func(p *guard.Peer) { p.Network = "onion" }),
+				with(peer(3, "127.0.0.1:40003"), func(p *guard.Peer) { p.Network, p.PingTime = "i2p", 2 }),
+			},
+			Expected: []string{"3 ping disconnect 3"},
+		},
+		// Manual connections, noban peers, outbound peers when judging inbound ones only, and protected subnets are spared
+		{
+			Policy: guard.Policy{MaxPing: time.Second, InboundOnly: true, Protect: []net.IPNet{*protected}},
+			Peers: []guard.Peer{
+				with(peer(1, "203.0.113.1:8333"), func(p *guard.Peer) { p.PingTime, p.ConnectionType = 2, "manual" }),
+				with(peer(5, "203.0.113.5:8333"), func(p *guard.Peer) { p.PingTime, p.Permissions = 2, []string{"noban", "relay"} }),
+				with(peer(2, "203.0.113.2:8333"), func(p *guard.Peer) { p.PingTime, p.Inbound, p.ConnectionType = 2, false, "outbound-full-relay" }),
+				with(peer(3, "10.1.2.3:8333"), func(p *guard.Peer) { p.PingTime = 2 }),
+				with(peer(4, "203.0.113.4:8333"), func(p *guard.Peer) { p.PingTime = 2 }),
+			},
+			Expected: []string{"4 ping disconnect 203.0.113.4:8333"},
+		},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			got := []string{}
+			for _, decision := range test.Policy.Evaluate(test.Peers, now) {
+				if decision.Reason == "" {
+					t.Errorf("Expected decision to have a reason, got %+v", decision)
+				}
+				got = append(got, fmt.Sprintf("%d %s %s %s", decision.Peer, decision.Rule, decision.Action, decision.Target))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.Expected) {
+				t.Errorf("Expected decisions %v, got %v", test.Expected, got)
+			}
+		})
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	cases := []struct {
+		Policy guard.Policy
+		Valid  bool
+	}{
+		{Policy: guard.Policy{MinVersion: "25.0", Ban: guard.Rules}, Valid: true},
+		{Policy: guard.Policy{Ban: []string{"latency"}}, Valid: false},
+		{Policy: guard.Policy{MinVersion: "v25"}, Valid: false},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			if err := test.Policy.Validate(); (err == nil) != test.Valid {
+				t.Errorf("Expected policy to be valid: %v, got error %v", test.Valid, err)
+			}
+		})
+	}
+}
+
+func Test_Run(t *testing.T) {
+	fake := rpctest.Use(t)
+
+	audit := filepath.Join(t.TempDir(), "audit.jsonl")
+	options := guard.Options{
+		Policy: guard.Policy{MaxPing: time.Second, MinVersion: "28.0", Ban: []string{guard.RulePing}},
+		Once:   true,
+		Audit:  audit,
+	}
+	run := func() []guard.Decision {
+		decisions := []guard.Decision{}
+		err := guard.Run(context.Background(), options, func(decision guard.Decision) {
+			decisions = append(decisions, decision)
+		}, func(err error) { t.Errorf("Unexpected failure: %v", err) })
+		if err != nil {
+			t.Fatalf("Failed to run guard: %v", err)
+		}
+		return decisions
+	}
+
+	// A dry run only reports the decisions
+	options.DryRun = true
+	decisions := run()
+	if len(decisions) != 2 || !decisions[0].DryRun || decisions[0].Peer != 2 || decisions[0].Action != guard.ActionDisconnect ||
+		decisions[1].Peer != 3 || decisions[1].Rule != guard.RulePing || decisions[1].Action != guard.ActionBan || decisions[1].Target != "192.0.2.33" {
+		t.Fatalf("Unexpected decisions: %+v", decisions)
+	}
+	if len(fake.Peers) != 3 || len(fake.Bans) != 0 {
+		t.Errorf("Expected a dry run to leave peers alone, got peers %+v and bans %+v", fake.Peers, fake.Bans)
+	}
+
+	options.DryRun = false
+	options.BanTime = time.Hour
+	decisions = run()
+	if len(decisions) != 2 || decisions[0].DryRun || decisions[0].Error != "" || decisions[1].Error != "" {
+		t.Fatalf("Unexpected decisions: %+v", decisions)
+	}
+	if len(fake.Peers) != 1 || fake.Peers[0].ID != 1 {
+		t.Errorf("Expected only peer 1 to remain connected, got %+v", fake.Peers)
+	}
+	if len(fake.Bans) != 1 || fake.Bans[0].Address != "192.0.2.33/32" || fake.Bans[0].BanDuration != 3600 {
+		t.Errorf("Expected peer 3 to be banned for an hour, got %+v", fake.Bans)
+	}
+
+	// Every decision is audited
+	data, err := os.ReadFile(audit)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 audited decisions, got %q", lines)
+	}
+	var audited guard.Decision
+	if err := json.Unmarshal([]byte(lines[3]), &audited); err != nil {
+		t.Fatalf("Failed to parse audited decision: %v", err)
+	}
+	if audited.Peer != 3 || audited.Action != guard.ActionBan || audited.DryRun || audited.Time.IsZero() {
+		t.Errorf("Unexpected audited decision: %+v", audited)
+	}
+
+	// Failed actions are reported with their error
+	fake.Peers = append(fake.Peers, rpctest.DefaultPeers()[1])
+	fake.Fail("disconnectnode", rpctest.RPCClientNodeNotConnected, "Node not found in connected nodes")
+	if decisions := run(); len(decisions) != 1 || !strings.Contains(decisions[0].Error, "Node not found") {
+		t.Errorf("Expected the failed disconnection to be reported, got %+v", decisions)
+	}
+
+	// Invalid policies and unreachable nodes fail the guard
+	options.Policy.Ban = []string{"latency"}
+	if err := guard.Run(context.Background(), options, func(guard.Decision) {}, nil); err == nil {
+		t.Errorf("Expected an invalid policy to fail")
+	}
+	options.Policy.Ban = nil
+	fake.Close()
+	if err := guard.Run(context.Background(), options, func(guard.Decision) {}, nil); err == nil {
+		t.Errorf("Expected the guard to fail when the node can't be reached")
+	}
+}
+
+func Test_RunRepeated(t *testing.T) {
+	rpctest.Use(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	decisions := make(chan guard.Decision, 16)
+	options := guard.Options{Policy: guard.Policy{MaxPing: time.Second}, Interval: 10 * time.Millisecond, DryRun: true}
+	result := make(chan error)
+	go func() {
+		result <- guard.Run(ctx, options, func(decision guard.Decision) { decisions <- decision }, nil)
+	}()
+
+	// Decisions aren't repeated while the peer is connected
+	if decision := <-decisions; decision.Peer != 3 {
+		t.Errorf("Expected peer 3 to be disconnected, got %+v", decision)
+	}
+	select {
+	case decision := <-decisions:
+		t.Errorf("Expected the decision not to be repeated, got %+v", decision)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-result; err != nil {
+		t.Errorf("Failed to run guard: %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+package guard
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Action is what's done to a peer breaking a rule.
+type Action string
+
+const (
+	ActionDisconnect Action = "disconnect" // Disconnect the peer, which may connect again
+	ActionBan        Action = "ban"        // Ban the peer's address, then disconnect it
+)
+
+// Rule names, as used by Policy.Ban and in decisions.
+const (
+	RulePing       = "ping"        // Ping above Policy.MaxPing
+	RuleOutdated   = "outdated"    // Bitcoin Core version below Policy.MinVersion
+	RuleNoServices = "no-services" // No service advertised
+	RuleStale      = "stale"       // Connected for longer than Policy.Stale without relaying a block
+	RuleSubnet     = "subnet"      // More than Policy.MaxPerSubnet peers from the same /16 (IPv4) or /32 (IPv6)
+	RuleASN        = "asn"         // More than Policy.MaxPerASN peers from the same autonomous system
+)
+
+// Rules lists the names of the rules, in the order they're evaluated.
+var Rules = []string{RulePing, RuleOutdated, RuleNoServices, RuleStale, RuleSubnet, RuleASN}
+
+// Policy is the set of rules peers are evaluated against. Rules left to their zero value are disabled.
+type Policy struct {
+	MaxPing      time.Duration // Maximum ping time (e.g. 2s)
+	MinVersion   string        // Minimum version of Bitcoin Core peers (e.g. "25.0"); other clients aren't judged
+	NoServices   bool          // Whether peers advertising no service break the rules
+	Stale        time.Duration // Time after which peers that never relayed a block break the rules (e.g. 30m)
+	MaxPerSubnet int           // Maximum number of peers from the same /16 (IPv4) or /32 (IPv6)
+	MaxPerASN    int           // Maximum number of peers from the same autonomous system (requires -asmap)
+
+	Ban         []string    // Rules whose breakers are banned rather than disconnected
+	InboundOnly bool        // Whether only inbound peers are judged
+	Protect     []net.IPNet // Subnets whose peers are never judged
+}
+
+// Peer is a connected peer, from 'getpeerinfo'.
+type Peer struct {
+	ID             int      `json:"id"`
+	Address        string   `json:"addr"`
+	Network        string   `json:"network"`
+	Services       string   `json:"services"`
+	ServicesNames  []string `json:"servicesnames"`
+	LastBlock      int64    `json:"last_block"`
+	ConnTime       int64    `json:"conntime"`
+	PingTime       float64  `json:"pingtime"`
+	SubVersion     string   `json:"subver"`
+	Inbound        bool     `json:"inbound"`
+	ConnectionType string   `json:"connection_type"`
+	MappedAS       int      `json:"mapped_as"`
+	Permissions    []string `json:"permissions"`
+}
+
+// Decision is the action decided for a peer breaking a rule.
+type Decision struct {
+	Time    time.Time `json:"time"`
+	Peer    int       `json:"peer"`              // ID of the peer
+	Address string    `json:"address"`           // Address of the peer
+	Rule    string    `json:"rule"`              // Name of the rule broken
+	Reason  string    `json:"reason"`            // Why the rule is broken, e.g. "ping 1.5s above 1s"
+	Action  Action    `json:"action"`            // Action decided
+	Target  string    `json:"target"`            // Address or ID of the peer disconnected, or IP banned
+	DryRun  bool      `json:"dry_run,omitempty"` // Whether the action was only printed
+	Error   string    `json:"error,omitempty"`   // Error the action failed with, if any
+}
+
+// Validate checks that the rules banning peers exist and that the minimum version is valid.
+func (p *Policy) Validate() error {
+	for _, rule := range p.Ban {
+		if !slices.Contains(Rules, rule) {
+			return failure.Of("unknown rule %q: must be one of %s", rule, strings.Join(Rules, ", "))
+		}
+	}
+	if p.MinVersion != "" {
+		if _, ok := parseVersion(p.MinVersion); !ok {
+			return failure.Of("invalid minimum version %q: must be numbers separated by dots (e.g. 25.0)", p.MinVersion)
+		}
+	}
+	return nil
+}
+
+// Evaluate decides the action for each peer breaking a rule, in the order of the peers. A peer
+// breaking several rules gets a single decision: the first rule it breaks whose breakers are
+// banned, or else the first rule it breaks. Manual connections and peers with the noban permission
+// are never judged. Only IPv4 and IPv6 peers are banned or grouped by subnet: the others, such as
+// Tor or I2P peers that all connect from 127.0.0.1, are only disconnected, by ID.
+//
+// Parameters:
+// - peers ([]Peer): The connected peers.
+// - now (time.Time): The time connection times are compared to.
+//
+// Returns:
+// - []Decision: The decisions, without time, for the peers breaking a rule.
+func (p *Policy) Evaluate(peers []Peer, now time.Time) []Decision {
+	judged := []Peer{}
+	for _, peer := range peers {
+		if peer.ConnectionType == "manual" || slices.Contains(peer.Permissions, "noban") || (p.InboundOnly && !peer.Inbound) || p.protected(peer) {
+			continue
+		}
+		judged = append(judged, peer)
+	}
+
+	// Peers in excess in their /16 or autonomous system, the most recent connections first
+	excess := map[string]map[int]string{
+		RuleSubnet: p.excess(judged, p.MaxPerSubnet, netgroup),
+		RuleASN: p.excess(judged, p.MaxPerASN, func(peer Peer) string {
+			if peer.MappedAS == 0 {
+				return ""
+			}
+			return fmt.Sprintf("AS%d", peer.MappedAS)
+		}),
+	}
+
+	decisions := []Decision{}
+	for _, peer := range judged {
+		var decision *Decision
+		for _, rule := range Rules {
+			reason := p.check(rule, peer, now, excess)
+			if reason == "" {
+				continue
+			}
+
+			action := ActionDisconnect
+			if slices.Contains(p.Ban, rule) && banable(peer) {
+				action = ActionBan
+			}
+			if decision == nil || (action == ActionBan && decision.Action != ActionBan) {
+				decision = &Decision{Peer: peer.ID, Address: peer.Address, Rule: rule, Reason: reason, Action: action, Target: peer.Address}
+				switch {
+				case action == ActionBan:
+					decision.Target = host(peer.Address)
+				case ip(peer) == nil:
+					decision.Target = strconv.Itoa(peer.ID)
+				}
+			}
+		}
+		if decision != nil {
+			decisions = append(decisions, *decision)
+		}
+	}
+	return decisions
+}
+
+// check returns why a peer breaks a rule, or "" if it doesn't.
+func (p *Policy) check(rule string, peer Peer, now time.Time, excess map[string]map[int]string) string {
+	switch rule {
+	case RulePing:
+		ping := time.Duration(peer.PingTime * float64(time.Second))
+		if p.MaxPing > 0 && ping > p.MaxPing {
+			return fmt.Sprintf("ping %v above %v", ping.Round(time.Millisecond), p.MaxPing)
+		}
+	case RuleOutdated:
+		minimum, _ := parseVersion(p.MinVersion)
+		if version, ok := satoshi(peer.SubVersion); ok && p.MinVersion != "" && compare(version, minimum) < 0 {
+			return fmt.Sprintf("version %s below %s", peer.SubVersion, p.MinVersion)
+		}
+	case RuleNoServices:
+		if p.NoServices && len(peer.ServicesNames) == 0 && strings.Trim(peer.Services, "0") == "" {
+			return "no service advertised"
+		}
+	case RuleStale:
+		connected := now.Sub(time.Unix(peer.ConnTime, 0))
+		if p.Stale > 0 && peer.LastBlock == 0 && connected > p.Stale {
+			return fmt.Sprintf("no block relayed in %v", connected.Round(time.Second))
+		}
+	case RuleSubnet, RuleASN:
+		return excess[rule][peer.ID]
+	}
+	return ""
+}
+
+// excess returns why peers are in excess in their group, by ID: the peers beyond the maximum
+// number of peers sharing a group, the most recent connections first. Peers without group are ignored.
+func (p *Policy) excess(peers []Peer, maximum int, group func(Peer) string) map[int]string {
+	excess := map[int]string{}
+	if maximum <= 0 {
+		return excess
+	}
+
+	groups := map[string][]Peer{}
+	for _, peer := range peers {
+		if key := group(peer); key != "" {
+			groups[key] = append(groups[key], peer)
+		}
+	}
+
+	for key, members := range groups {
+		if len(members) <= maximum {
+			continue
+		}
+		slices.SortStableFunc(members, func(a, b Peer) int {
+			return cmp.Or(cmp.Compare(a.ConnTime, b.ConnTime), cmp.Compare(a.ID, b.ID))
+		})
+		for _, peer := range members[maximum:] {
+			excess[peer.ID] = fmt.Sprintf("%d peers from %s, above %d", len(members), key, maximum)
+		}
+	}
+	return excess
+}
+
+// protected reports whether an IPv4 or IPv6 peer is in a protected subnet.
+func (p *Policy) protected(peer Peer) bool {
+	ip := ip(peer)
+	for _, subnet := range p.Protect {
+		if ip != nil && subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// host returns the host of an address, without port.
+func host(address string) string {
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		return h
+	}
+	return address
+}
+
+// ip returns the IP address of an IPv4 or IPv6 peer, or nil for other networks. Peers of other
+// networks may have IP addresses, such as Tor and I2P inbound peers connecting from 127.0.0.1,
+// but those are their proxy's, shared by all of them.
+func ip(peer Peer) net.IP {
+	if peer.Network != "ipv4" && peer.Network != "ipv6" {
+		return nil
+	}
+	return net.ParseIP(host(peer.Address))
+}
+
+// banable reports whether a peer's address can be banned, which 'setban' only supports for IPv4 and IPv6 peers.
+func banable(peer Peer) bool {
+	return ip(peer) != nil
+}
+
+// netgroup returns the /16 (IPv4) or /32 (IPv6) subnet of a peer, or "" for other networks.
+func netgroup(peer Peer) string {
+	ip := ip(peer)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return (&net.IPNet{IP: ip.To4().Mask(net.CIDRMask(16, 32)), Mask: net.CIDRMask(16, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(32, 128)), Mask: net.CIDRMask(32, 128)}).String()
+}
+
+// satoshi returns the version of a Bitcoin Core user agent (e.g. "/Satoshi:27.1.0/"), if it's one.
+func satoshi(subversion string) ([]int, bool) {
+	_, version, found := strings.Cut(subversion, "/Satoshi:")
+	if !found {
+		return nil, false
+	}
+	if end := strings.IndexAny(version, "/("); end >= 0 {
+		version = version[:end]
+	}
+	return parseVersion(version)
+}
+
+// parseVersion parses a version made of numbers separated by dots.
+func parseVersion(version string) ([]int, bool) {
+	numbers := []int{}
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, true
+}
+
+// compare compares versions, missing numbers counting as zeros.
+func compare(a, b []int) int {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
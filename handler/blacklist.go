@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/banlist"
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// BlacklistExport writes the node's ban list in --format (json or csv) to --output, or to the
+// standard output.
+func (n *networkHandler) BlacklistExport(cmd *cobra.Command, args []string) {
+	value, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+
+	format, err := banlist.ParseFormat(value)
+	if err != nil {
+		logger.Errorf("%v", err.Error())
+		return
+	}
+
+	entries, err := banlist.List(rpc.Client)
+	if err != nil {
+		logger.Errorf("%v", err.Error())
+		return
+	}
+
+	destination := os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			logger.Errorf("failed to create output file: %v", err.Error())
+			return
+		}
+		defer file.Close()
+		destination = file
+	}
+
+	if err := banlist.Write(destination, entries, format); err != nil {
+		logger.Errorf("%v", err.Error())
+		return
+	}
+	logger.Debugf("exported %d banned subnets as %s", len(entries), format)
+}
+
+// BlacklistImport bans the subnets of a ban list file (or '-' for the standard input) until their
+// expiry times, skipping the expired ones. With --dry-run, the changes are only printed.
+func (n *networkHandler) BlacklistImport(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		if err := cmd.Help(); err != nil {
+			logger.Errorf("failed to show output for command %s: %v", cmd.Short, err.Error())
+		}
+		return
+	}
+
+	source := os.Stdin
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			logger.Errorf("failed to open ban list: %v", err.Error())
+			return
+		}
+		defer file.Close()
+		source = file
+	}
+
+	entries, err := banlist.Read(source)
+	if err != nil {
+		logger.Errorf("%v", err.Error())
+		return
+	}
+
+	synchronize(cmd, entries, rpc.Client)
+}
+
+// BlacklistSync makes the ban list of the --to profile's node match the one of the --from profile's
+// node, the default profile being used when either is omitted. With --dry-run, the diff is only printed.
+// Both clients are built from their profiles alone, so the flags setting up the default rpc.Client's
+// cassette or tracing are rejected rather than silently ignored.
+func (n *networkHandler) BlacklistSync(cmd *cobra.Command, args []string) {
+	for _, flag := range []string{"record", "replay", "trace", "otlp-endpoint"} {
+		if cmd.Flags().Changed(flag) {
+			logger.Errorf("--%s isn't supported by sync, which connects to the --from and --to profiles' nodes", flag)
+			return
+		}
+	}
+
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	if from == to {
+		logger.Errorf("--from and --to must be different profiles")
+		return
+	}
+
+	source, err := client(from)
+	if err != nil {
+		fail("%v", err.Error())
+		return
+	}
+	target, err := client(to)
+	if err != nil {
+		fail("%v", err.Error())
+		return
+	}
+
+	entries, err := banlist.List(source)
+	if err != nil {
+		fail("%v", err.Error())
+		return
+	}
+
+	synchronize(cmd, entries, target)
+}
+
+// synchronize applies the changes making a node's ban list match entries, or prints them with --dry-run.
+// Bans missing from entries are removed with --prune. The command fails if any change does.
+func synchronize(cmd *cobra.Command, entries []banlist.Entry, target *rpc.RPCClient) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	prune, _ := cmd.Flags().GetBool("prune")
+
+	current, err := banlist.List(target)
+	if err != nil {
+		fail("%v", err.Error())
+		return
+	}
+
+	changes := banlist.Diff(entries, current, time.Now(), prune)
+	if len(changes) == 0 {
+		logger.Info("ban lists are already in sync")
+		return
+	}
+
+	if dryRun {
+		for _, change := range changes {
+			logger.Print(change.String())
+		}
+		return
+	}
+
+	failed := banlist.Apply(target, changes, func(change banlist.Change, err error) {
+		if err != nil {
+			logger.Errorf("%v", err.Error())
+			return
+		}
+		logger.Print(change.String())
+	})
+	if failed > 0 {
+		fail("%d changes applied, %d failed", len(changes)-failed, failed)
+		return
+	}
+	logger.Infof("%d changes applied", len(changes))
+}
+
+// client creates a client from a connection profile, the default one for an empty name.
+func client(name string) (*rpc.RPCClient, error) {
+	profile, err := config.LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.FromProfile(profile)
+}
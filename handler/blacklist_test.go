@@ -0,0 +1,53 @@
+package handler_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/handler"
+	"github.com/avila-r/bitclient/network"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+func Test_BlacklistImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banlist.json")
+	until := time.Now().Add(time.Hour).Unix()
+	content := fmt.Sprintf(`[{"address":"203.0.113.0/24","ban_created":1,"banned_until":%d}]`, until)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write ban list: %v", err)
+	}
+
+	cases := []struct {
+		Fail bool // Whether the node fails to ban the subnet
+	}{
+		{Fail: false},
+		{Fail: true},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			fake := rpctest.Use(t)
+			fake.Bans = nil
+			if test.Fail {
+				fake.Fail(network.MethodSetBan, rpctest.RPCClientInvalidIPOrSubnet, "Error: Invalid IP/Subnet")
+			}
+
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("prune", false, "")
+			cmd.Flags().Bool("dry-run", false, "")
+			handler.Network.BlacklistImport(cmd, []string{path})
+
+			if err := handler.Failed(); (err != nil) != test.Fail {
+				t.Errorf("Expected the import to fail: %v, got %v", test.Fail, err)
+			}
+			if banned := len(fake.Bans) == 1; banned == test.Fail {
+				t.Errorf("Expected the subnet to be banned: %v, got %+v", !test.Fail, fake.Bans)
+			}
+		})
+	}
+}
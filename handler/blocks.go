@@ -1,14 +1,26 @@
 package handler
 
 import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/avila-r/bitclient/address"
 	"github.com/avila-r/bitclient/blocks"
 	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/export"
 	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
 )
 
 // blocksHandler is a custom handler type based on the Handler function type.
@@ -67,6 +79,20 @@ func (b *blocksHandler) Get(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	// Decode the serialized block locally, if requested
+	if decode, _ := cmd.Flags().GetBool("decode"); decode {
+		logger.Debugf("getting serialized block with blockhash %v", target)
+
+		block, err := blocks.GetDecodedBlock(target)
+		if err != nil {
+			logger.Errorf("failed to get decoded block: %v", err.Error())
+			return
+		}
+
+		printJSON(block)
+		return
+	}
+
 	// Retrieve the verbosity level from flags
 	verbosity, err := cmd.Flags().GetInt("verbosity")
 	if err != nil {
@@ -91,9 +117,54 @@ func (b *blocksHandler) Filter(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	filtertype, _ := cmd.Flags().GetString("type")
+
+	// Match scripts against the decoded filter locally, if requested
+	if values, _ := cmd.Flags().GetStringSlice("script"); len(values) > 0 {
+		// Only BIP 158 basic filters can be decoded, the sole type Bitcoin Core builds
+		if filtertype != "basic" {
+			logger.Errorf("scripts can only be matched against basic filters, got type '%v'", filtertype)
+			return
+		}
+
+		scripts := [][]byte{}
+		for _, value := range values {
+			script, err := hex.DecodeString(value)
+			if err != nil || len(script) == 0 {
+				logger.Errorf("script should be a hex-encoded scriptPubKey, got '%v'", value)
+				return
+			}
+			scripts = append(scripts, script)
+		}
+
+		logger.Debugf("matching %d scripts against block filter with blockhash %v", len(scripts), target)
+
+		filter, header, err := blocks.GetDecodedBlockFilter(target)
+		if err != nil {
+			logger.Errorf("failed to get decoded block filter: %v", err.Error())
+			return
+		}
+
+		matches := []string{}
+		for i, script := range scripts {
+			if filter.Match(script) {
+				matches = append(matches, values[i])
+			}
+		}
+
+		printJSON(map[string]any{
+			"block":   filter.Block,
+			"header":  header,
+			"n":       filter.N(),
+			"match":   len(matches) > 0,
+			"matches": matches,
+		})
+		return
+	}
+
 	logger.Debugf("getting block filter with blockhash %v", target)
 
-	response, err := blocks.GetBlockFilter(target)
+	response, err := blocks.GetBlockFilter(target, filtertype)
 	if err != nil {
 		logger.Errorf("failed to get block filter: %v", err.Error())
 		return
@@ -136,6 +207,18 @@ func (b *blocksHandler) Header(cmd *cobra.Command, args []string) {
 		logger.Errorf("failed to get hex param: %v", err.Error())
 	}
 
+	// Decode the serialized header locally, if requested
+	if decode, _ := cmd.Flags().GetBool("decode"); decode {
+		header, err := blocks.GetDecodedBlockHeader(target)
+		if err != nil {
+			logger.Errorf("failed to get decoded block header: %v", err.Error())
+			return
+		}
+
+		printJSON(header)
+		return
+	}
+
 	response, err := blocks.GetBlockHeader(target, !hex)
 	if err != nil {
 		logger.Errorf("failed to get block header: %v", err.Error())
@@ -146,6 +229,13 @@ func (b *blocksHandler) Header(cmd *cobra.Command, args []string) {
 }
 
 func (b *blocksHandler) Stats(cmd *cobra.Command, args []string) {
+	for _, flag := range []string{"from", "to", "range", "since", "until"} {
+		if cmd.Flags().Changed(flag) {
+			b.StatsRange(cmd, args)
+			return
+		}
+	}
+
 	target, ok := getTargetBlock(cmd, args)
 	if !ok {
 		return
@@ -173,6 +263,279 @@ func (b *blocksHandler) Stats(cmd *cobra.Command, args []string) {
 	response.Print()
 }
 
+// StatsRange exports the statistics of the blocks between --from and --to (or within --range), or
+// within the --since and --until time window, to --output (or the standard output) as CSV, JSON Lines
+// or Parquet. With --aggregate, the aggregates of the range are printed once the export completes, to
+// the standard error when the stats are written to the standard output, so as not to corrupt them.
+func (b *blocksHandler) StatsRange(cmd *cobra.Command, args []string) {
+	if value, _ := cmd.Flags().GetString("range"); value != "" {
+		from, to, ok := strings.Cut(value, "-")
+		if to == "" {
+			to = "-1"
+		}
+		_, invalid := strconv.Atoi(from)
+		if _, err := strconv.Atoi(to); !ok || invalid != nil || err != nil {
+			logger.Errorf("range should be FROM-TO heights, e.g. 800000-800143, got '%v'", value)
+			return
+		}
+		_ = cmd.Flags().Set("from", from)
+		_ = cmd.Flags().Set("to", to)
+	}
+
+	options := blocks.StatsOptions{}
+	options.From, _ = cmd.Flags().GetInt("from")
+	options.Stats, _ = cmd.Flags().GetStringSlice("stat")
+	options.Workers, _ = cmd.Flags().GetInt("workers")
+	options.Aggregate, _ = cmd.Flags().GetBool("aggregate")
+
+	for flag, target := range map[string]*time.Time{"since": &options.Since, "until": &options.Until} {
+		value, _ := cmd.Flags().GetString(flag)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseTime(value)
+		if err != nil {
+			logger.Errorf("%s should be a date, an RFC 3339 time or a unix timestamp, got '%v'", flag, value)
+			return
+		}
+		*target = parsed
+	}
+
+	columns, err := blocks.StatsColumnsOf(options.Stats)
+	if err != nil {
+		logger.Errorf("%v", err.Error())
+		return
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	format, _ := cmd.Flags().GetString("format")
+	if format == "" {
+		format = string(export.FormatJSONL)
+		if output != "" {
+			format = output
+		}
+	}
+	exported, err := export.FormatFrom(format)
+	if err != nil {
+		logger.Errorf("%v", err.Error())
+		return
+	}
+	if exported == export.FormatParquet && output == "" {
+		logger.Errorf("parquet exports need an --output file")
+		return
+	}
+
+	to, ok := getTargetHeight(cmd)
+	if !ok {
+		return
+	}
+	options.To = to
+
+	destination := os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			logger.Errorf("failed to create output file: %v", err.Error())
+			return
+		}
+		defer file.Close()
+		destination = file
+	}
+
+	writer, err := export.NewWriter(exported, destination, columns)
+	if err != nil {
+		logger.Errorf("%v", err.Error())
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Debugf("exporting block stats %d-%d as %s", options.From, options.To, exported)
+
+	result, err := blocks.GetBlockStatsRange(ctx, options, func(stats rpc.Json) error {
+		return writer.Write(blocks.StatsRecord(stats, columns))
+	})
+	if closed := writer.Close(); err == nil {
+		err = closed
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		logger.Printf("export interrupted after %d blocks, resume with --from %d", result.Blocks, result.From+result.Blocks)
+	case err != nil:
+		fail("export failed: %v", err.Error())
+	case options.Aggregate && output == "":
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(os.Stderr, string(data))
+	case options.Aggregate:
+		printJSON(result)
+	case result.Blocks == 0:
+		logger.Printf("no blocks within the range")
+	case output != "":
+		logger.Printf("exported stats of %d blocks (%d-%d) to %s", result.Blocks, result.From, result.To, output)
+	}
+}
+
+// parseTime parses a date (2006-01-02), an RFC 3339 time or a unix timestamp.
+func parseTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// Watch streams the changes of the active chain's tip as JSON lines, until interrupted.
+func (b *blocksHandler) Watch(cmd *cobra.Command, args []string) {
+	options := blocks.WatchOptions{}
+	options.Poll, _ = cmd.Flags().GetBool("poll")
+	options.Interval, _ = cmd.Flags().GetDuration("interval")
+	options.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	options.Depth, _ = cmd.Flags().GetInt("depth")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	events, err := blocks.Watch(ctx, options)
+	if err != nil {
+		logger.Errorf("failed to watch blocks: %v", err.Error())
+		return
+	}
+
+	for event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			logger.Errorf("failed to serialize event: %v", err.Error())
+			continue
+		}
+		logger.Print(string(line))
+	}
+}
+
+// VerifyHeaders verifies the headers between --from and --to against the chain's consensus rules,
+// exiting with an error at the first violation.
+func (b *blocksHandler) VerifyHeaders(cmd *cobra.Command, args []string) {
+	from, _ := cmd.Flags().GetInt("from")
+	to, ok := getTargetHeight(cmd)
+	if !ok {
+		return
+	}
+
+	logger.Debugf("verifying headers %d-%d", from, to)
+
+	if err := blocks.VerifyHeaders(from, to); err != nil {
+		fail("header verification failed: %v", err.Error())
+		return
+	}
+
+	logger.Printf("verified %d headers (%d-%d)", to-from+1, from, to)
+}
+
+// VerifyFilters verifies the chain of filter headers between --from and --to, exiting with an
+// error at the first header that doesn't commit to its filter.
+func (b *blocksHandler) VerifyFilters(cmd *cobra.Command, args []string) {
+	from, _ := cmd.Flags().GetInt("from")
+	to, ok := getTargetHeight(cmd)
+	if !ok {
+		return
+	}
+
+	logger.Debugf("verifying filter headers %d-%d", from, to)
+
+	if err := blocks.VerifyFilterHeaders(from, to); err != nil {
+		fail("filter header verification failed: %v", err.Error())
+		return
+	}
+
+	logger.Printf("verified %d filter headers (%d-%d)", to-from+1, from, to)
+}
+
+// Scan looks for the outputs paying to, and inputs spending from, the --address and --script
+// targets between --from and --to, printing a JSON line for every match.
+func (b *blocksHandler) Scan(cmd *cobra.Command, args []string) {
+	options := blocks.ScanOptions{}
+	options.From, _ = cmd.Flags().GetInt("from")
+	options.Workers, _ = cmd.Flags().GetInt("workers")
+	options.Checkpoint, _ = cmd.Flags().GetString("checkpoint")
+
+	addresses, _ := cmd.Flags().GetStringSlice("address")
+	for _, value := range addresses {
+		decoded, err := address.Decode(value)
+		if err != nil {
+			logger.Errorf("%v", err.Error())
+			return
+		}
+		options.Scripts = append(options.Scripts, decoded.Script)
+	}
+
+	scripts, _ := cmd.Flags().GetStringSlice("script")
+	for _, value := range scripts {
+		script, err := hex.DecodeString(value)
+		if err != nil || len(script) == 0 {
+			logger.Errorf("script should be a hex-encoded scriptPubKey, got '%v'", value)
+			return
+		}
+		options.Scripts = append(options.Scripts, script)
+	}
+
+	if len(options.Scripts) == 0 {
+		if err := cmd.Help(); err != nil {
+			logger.Errorf("failed to show output for command %s: %v", cmd.Short, err.Error())
+		}
+		return
+	}
+
+	// Left negative for the tip, resolved by the scan unless it resumes a checkpoint
+	options.To, _ = cmd.Flags().GetInt("to")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Debugf("scanning blocks from %d for %d scripts", options.From, len(options.Scripts))
+
+	result, err := blocks.Scan(ctx, options, func(match blocks.Match) {
+		line, err := json.Marshal(match)
+		if err != nil {
+			logger.Errorf("failed to serialize match: %v", err.Error())
+			return
+		}
+		logger.Print(string(line))
+	})
+
+	switch {
+	case errors.Is(err, context.Canceled) && options.Checkpoint != "":
+		logger.Printf("scan interrupted at height %d, run the same command to resume", result.Next)
+	case errors.Is(err, context.Canceled):
+		logger.Printf("scan interrupted at height %d, resume with --from %d", result.Next, result.Next)
+	case err != nil:
+		fail("scan failed: %v", err.Error())
+	default:
+		logger.Printf("scanned %d blocks (%d-%d), fetched %d, found %d matches", result.Scanned, result.From, result.To, result.Fetched, result.Matches)
+	}
+}
+
+// getTargetHeight returns the 'to' flag, or the height of the tip if it's negative.
+var getTargetHeight = func(cmd *cobra.Command) (int, bool) {
+	to, _ := cmd.Flags().GetInt("to")
+	if to >= 0 {
+		return to, true
+	}
+
+	response, err := blocks.GetBlockCount()
+	if err != nil {
+		logger.Errorf("failed to get block count: %v", err.Error())
+		return 0, false
+	}
+	if err := response.Bind(&to); err != nil {
+		logger.Errorf("failed to parse block count: %v", err.Error())
+		return 0, false
+	}
+	return to, true
+}
+
 var getTargetBlock = func(cmd *cobra.Command, args []string) (string, bool) {
 	target := ""
 	if len(args) <= 0 {
@@ -190,7 +553,11 @@ var getTargetBlock = func(cmd *cobra.Command, args []string) (string, bool) {
 			logger.Errorf("failed to show output for command %s: %v", cmd.Short, err.Error())
 		}
 		return "", false
-	} else {
-		return target, true
 	}
+
+	if err := validateBlock(target); err != nil {
+		logger.Errorf("%v", err.Error())
+		return "", false
+	}
+	return strings.TrimSpace(target), true
 }
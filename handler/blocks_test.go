@@ -0,0 +1,81 @@
+package handler_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/handler"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+func Test_Verify(t *testing.T) {
+	cases := []struct {
+		Run   handler.Handler
+		Setup func(fake *rpctest.Server)
+		Error string
+	}{
+		// A header whose target doesn't follow the chain's
+		{
+			Run:   handler.Blocks.VerifyHeaders,
+			Error: "header verification failed",
+		},
+		// A node failing to serve the filters
+		{
+			Run: handler.Blocks.VerifyFilters,
+			Setup: func(fake *rpctest.Server) {
+				fake.Fail(blocks.MethodGetBlockFilter, rpctest.RPCMiscError, "unavailable")
+			},
+			Error: "filter header verification failed",
+		},
+	}
+
+	// Commands print to the standard output, so they run in a child process
+	if value := os.Getenv("HANDLER_TEST_VERIFY"); value != "" {
+		index, _ := strconv.Atoi(value)
+		test := cases[index]
+
+		chain := rpctest.NewChain(30)
+		chain.MineWith(1, func(b *rpctest.Block) { b.Bits = 0x1f7fffff })
+		chain.Mine(5)
+		fake := rpctest.Use(t, rpctest.WithChain(chain))
+		if test.Setup != nil {
+			test.Setup(fake)
+		}
+
+		cmd := &cobra.Command{}
+		cmd.Flags().Int("from", 0, "")
+		cmd.Flags().Int("to", -1, "")
+		test.Run(cmd, []string{})
+		if handler.Failed() == nil {
+			t.Errorf("Expected the command to fail")
+		}
+		return
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			child := exec.Command(os.Args[0], "-test.run=^Test_Verify$")
+			child.Env = append(os.Environ(), fmt.Sprintf("HANDLER_TEST_VERIFY=%d", i))
+			stderr := &strings.Builder{}
+			child.Stderr = stderr
+			stdout, err := child.Output()
+			if err != nil {
+				t.Fatalf("Failed to run the command: %v: %s%s", err, stdout, stderr)
+			}
+
+			if strings.Contains(string(stdout), "verified") {
+				t.Errorf("Expected no success line after a failure, got %q", stdout)
+			}
+			if !strings.Contains(stderr.String(), test.Error) {
+				t.Errorf("Expected the error %q, got %q", test.Error, stderr)
+			}
+		})
+	}
+}
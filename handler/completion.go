@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/network"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// recentBlocks is the number of latest blocks offered when completing a block.
+const recentBlocks = 10
+
+// Completion prints the completion script of a shell.
+var Completion = func(cmd *cobra.Command, args []string) {
+	var err error
+	switch args[0] {
+	case "bash":
+		err = cmd.Root().GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		err = cmd.Root().GenZshCompletion(os.Stdout)
+	case "fish":
+		err = cmd.Root().GenFishCompletion(os.Stdout, true)
+	}
+	if err != nil {
+		logger.Errorf("failed to generate %s completion: %v", args[0], err.Error())
+	}
+}
+
+// completeHandler is a custom type for the functions completing arguments and flags, as cobra
+// calls them.
+type completeHandler func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)
+
+// Complete holds the functions completing the arguments and flags of commands with values
+// retrieved from the node.
+var Complete completeHandler = nil
+
+// connected connects to the node as the command would, since completions are requested without
+// running the persistent hooks, and reports whether there's a client to complete values with.
+// Only the client is built: cassettes, log files and exporters are never opened while completing,
+// and failures leave the values uncompleted rather than exiting.
+func (c *completeHandler) connected(cmd *cobra.Command, args []string) bool {
+	if err := connect(cmd.Flags()); err != nil {
+		return false
+	}
+	return rpc.Client != nil
+}
+
+// Block completes a block argument with the hashes and heights of the latest blocks.
+func (c *completeHandler) Block(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || !c.connected(cmd, args) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	response, err := blocks.GetBlockCount()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	tip := 0
+	if err := response.Bind(&tip); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	// Heights first, then hashes: the shell keeps those matching what's being typed
+	heights, hashes := []string{}, []string{}
+	for height := tip; height >= 0 && height > tip-recentBlocks; height-- {
+		hash, err := blocks.GetBlockHash(height)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		heights = append(heights, fmt.Sprintf("%d\t%s", height, hash))
+		hashes = append(hashes, fmt.Sprintf("%s\theight %d", hash, height))
+	}
+	completions := append(heights, hashes...)
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// Banned completes an IP or subnet argument with the banned subnets.
+func (c *completeHandler) Banned(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || !c.connected(cmd, args) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	banned, err := network.ListBanned()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := []string{}
+	for _, ban := range *banned {
+		if address, ok := ban["address"].(string); ok {
+			completions = append(completions, address)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Peer completes a node argument with the addresses and ids of the connected peers.
+func (c *completeHandler) Peer(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || !c.connected(cmd, args) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	peers, err := network.GetPeers()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := []string{}
+	for _, peer := range *peers {
+		address, _ := peer["addr"].(string)
+		id, _ := peer["id"].(float64)
+		subversion, _ := peer["subver"].(string)
+		completions = append(completions,
+			fmt.Sprintf("%s\tpeer %d %s", address, int(id), subversion),
+			fmt.Sprintf("%d\t%s %s", int(id), address, subversion),
+		)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Stat completes the comma-separated statistics of the --stat flag with the statistics of
+// 'getblockstats' not given yet.
+func (c *completeHandler) Stat(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	given := strings.Split(toComplete, ",")
+	prefix := strings.Join(given[:len(given)-1], ",")
+	if prefix != "" {
+		prefix += ","
+	}
+
+	completions := []string{}
+	for _, stat := range statistics() {
+		if !slices.Contains(given[:len(given)-1], stat) {
+			completions = append(completions, prefix+stat)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
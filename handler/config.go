@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/logger"
+)
+
+// Config is the handler for the 'config' command. It prints the loaded configuration,
+// followed by the selected connection profile with its secrets masked.
+var Config = func(cmd *cobra.Command, args []string) {
+	config.Get().Log()
+
+	name, _ := cmd.Flags().GetString("profile")
+	profile, err := config.LoadProfile(name)
+	if err != nil {
+		logger.Errorf("failed to load profile: %v", err.Error())
+		return
+	}
+
+	logger.Print()
+	profile.Log()
+}
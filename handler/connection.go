@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rest"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// cassette is the cassette the default rpc.Client records to or replays from, if any.
+var cassette *rpc.Cassette
+
+// Connect is a persistent pre-run handler that sets up the logger from the '--log-*' flags, then
+// the default rpc.Client from the selected profile ('--profile') and, when '--datadir', '--conf'
+// or '--chain' are provided, from the node's bitcoin.conf. Otherwise, the client initialized from the environment is kept.
+// With '--record' or '--replay', the client's traffic is recorded to or replayed from a cassette,
+// with '--rest', block reads go through the node's REST interface, and with '--trace' or
+// '--otlp-endpoint', requests are timed or traced.
+var Connect = func(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	logging(flags)
+
+	if err := connect(flags); err != nil {
+		logger.Fatalf("%v", err.Error())
+	}
+
+	if flags.Changed("record") || flags.Changed("replay") {
+		useCassette(flags)
+	}
+
+	if enabled, _ := flags.GetBool("rest"); enabled {
+		useREST()
+	}
+
+	observe(cmd)
+}
+
+// Disconnect is a persistent post-run handler that closes the cassette being recorded, if any,
+// and reports the requests timed or traced. When a command exits through logger.Fatalf instead,
+// the cassette is closed before exiting.
+var Disconnect = func(cmd *cobra.Command, args []string) {
+	if cassette != nil {
+		cassette.Close()
+	}
+
+	report()
+	logger.Close()
+}
+
+// connect sets up the default rpc.Client from the selected profile and bitcoin.conf, when
+// '--profile', '--datadir', '--conf' or '--chain' are provided. It only builds the client, so
+// that it's also used to complete values, without opening cassettes, log files or exporters.
+func connect(flags *pflag.FlagSet) error {
+	if !flags.Changed("profile") && !flags.Changed("datadir") && !flags.Changed("conf") && !flags.Changed("chain") {
+		return nil
+	}
+
+	name, _ := flags.GetString("profile")
+	profile, err := config.LoadProfile(name)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %v", err.Error())
+	}
+
+	if flags.Changed("datadir") || flags.Changed("conf") || flags.Changed("chain") {
+		datadir, _ := flags.GetString("datadir")
+		path, _ := flags.GetString("conf")
+		chain, _ := flags.GetString("chain")
+
+		conf, err := config.ReadBitcoinConf(datadir, path, chain)
+		if err != nil {
+			return fmt.Errorf("failed to read bitcoin.conf: %v", err.Error())
+		}
+
+		if err := profile.UseBitcoinConf(conf); err != nil {
+			return fmt.Errorf("failed to derive connection settings from %s: %v", conf.Path, err.Error())
+		}
+
+		logger.Debugf("using %s (%s chain)", conf.Path, conf.Chain)
+	}
+
+	client, err := rpc.FromProfile(profile)
+	if err != nil {
+		return fmt.Errorf("failed to set up rpc client: %v", err.Error())
+	}
+
+	logger.Debugf("connecting to %s", client.URL)
+
+	rpc.Client = client
+	return nil
+}
+
+// useCassette makes the default rpc.Client record its traffic to, or replay it from, a cassette.
+// When replaying without connection settings, requests are served from the cassette alone.
+func useCassette(flags *pflag.FlagSet) {
+	var err error
+	if path, _ := flags.GetString("record"); path != "" {
+		cassette, err = rpc.Record(path)
+	} else {
+		path, _ = flags.GetString("replay")
+		cassette, err = rpc.Replay(path)
+	}
+	if err != nil {
+		logger.Fatalf("%v", err.Error())
+	}
+
+	// Recorded interactions are kept even when the command exits early
+	logger.AtExit(func() { cassette.Close() })
+
+	if rpc.Client == nil {
+		if cassette.IsRecording() {
+			logger.Fatalf("unable to record: no rpc client is configured")
+		}
+
+		if rpc.Client, err = cassette.Client(); err != nil {
+			logger.Fatalf("failed to set up replay client: %v", err.Error())
+		}
+		return
+	}
+
+	rpc.Client.Use(cassette.Middleware())
+
+	logger.Debugf("using cassette %s", cassette.Path)
+}
+
+// useREST makes block reads go through the REST interface of the node the default rpc.Client is connected to.
+func useREST() {
+	if cassette != nil && !cassette.IsRecording() {
+		logger.Fatalf("unable to use REST: REST requests can't be replayed from a cassette")
+	}
+
+	client, err := rest.FromRPC(rpc.Client)
+	if err != nil {
+		logger.Fatalf("failed to set up rest client: %v", err.Error())
+	}
+
+	logger.Debugf("reading blocks from %s/rest/", client.URL)
+
+	blocks.REST = client
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/dashboard"
+	"github.com/avila-r/bitclient/logger"
+)
+
+// Dashboard runs the full-screen dashboard of the node's status until the user quits.
+var Dashboard = func(cmd *cobra.Command, args []string) {
+	options := dashboard.Options{}
+	options.Interval, _ = cmd.Flags().GetDuration("interval")
+	options.Blocks, _ = cmd.Flags().GetInt("blocks")
+	options.BanTime, _ = cmd.Flags().GetDuration("ban-time")
+
+	if err := dashboard.Run(options); err != nil {
+		logger.Errorf("dashboard failed: %v", err.Error())
+	}
+}
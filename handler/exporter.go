@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/exporter"
+	"github.com/avila-r/bitclient/logger"
+)
+
+// Exporter serves the node's metrics at /metrics on --listen until interrupted, polling them
+// every --interval.
+var Exporter = func(cmd *cobra.Command, args []string) {
+	listen, _ := cmd.Flags().GetString("listen")
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	e, err := exporter.New(exporter.Options{Interval: interval})
+	if err != nil {
+		logger.Errorf("failed to start exporter: %v", err.Error())
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go e.Run(ctx, func(err error) {
+		logger.Warnf("failed to poll node: %v", err.Error())
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	server := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	logger.Infof("serving metrics at http://%s/metrics", listen)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Errorf("failed to serve metrics: %v", err.Error())
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/guard"
+	"github.com/avila-r/bitclient/logger"
+)
+
+// Guard evaluates the node's peers against the rules given by the flags until interrupted,
+// disconnecting or banning the peers breaking them and streaming the decisions as JSON lines.
+// With --dry-run, the decisions are only printed.
+func (n *networkHandler) Guard(cmd *cobra.Command, args []string) {
+	options := guard.Options{}
+	options.Interval, _ = cmd.Flags().GetDuration("interval")
+	options.BanTime, _ = cmd.Flags().GetDuration("ban-time")
+	options.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	options.Once, _ = cmd.Flags().GetBool("once")
+	options.Audit, _ = cmd.Flags().GetString("audit-log")
+
+	policy := &options.Policy
+	policy.MaxPing, _ = cmd.Flags().GetDuration("max-ping")
+	policy.MinVersion, _ = cmd.Flags().GetString("min-version")
+	policy.NoServices, _ = cmd.Flags().GetBool("no-services")
+	policy.Stale, _ = cmd.Flags().GetDuration("stale")
+	policy.MaxPerSubnet, _ = cmd.Flags().GetInt("max-per-subnet")
+	policy.MaxPerASN, _ = cmd.Flags().GetInt("max-per-asn")
+	policy.Ban, _ = cmd.Flags().GetStringSlice("ban")
+	policy.InboundOnly, _ = cmd.Flags().GetBool("inbound-only")
+
+	protected, _ := cmd.Flags().GetStringArray("protect")
+	for _, target := range protected {
+		if err := validateIP(target); err != nil {
+			logger.Errorf("invalid protected subnet %q: %v", target, err.Error())
+			return
+		}
+		if _, subnet, err := net.ParseCIDR(target); err == nil {
+			policy.Protect = append(policy.Protect, *subnet)
+		} else {
+			ip := net.ParseIP(target)
+			policy.Protect = append(policy.Protect, net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Debugf("guarding peers every %v (dry run: %v)", options.Interval, options.DryRun)
+
+	err := guard.Run(ctx, options, func(decision guard.Decision) {
+		line, err := json.Marshal(decision)
+		if err != nil {
+			logger.Errorf("failed to serialize decision: %v", err.Error())
+			return
+		}
+		logger.Print(string(line))
+	}, func(err error) {
+		logger.Warnf("%v", err.Error())
+	})
+	if err != nil {
+		logger.Errorf("failed to guard peers: %v", err.Error())
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/avila-r/bitclient/logger"
+)
+
+// logging sets the logger up from '--log-level', '--log-format' and '--log-file', each of them
+// overriding the LOG_LEVEL, LOG_FORMAT and LOG_FILE environment variables.
+func logging(flags *pflag.FlagSet) {
+	if !flags.Changed("log-level") && !flags.Changed("log-format") && !flags.Changed("log-file") {
+		return // Already set up from the environment
+	}
+
+	options, err := logger.FromEnvironment()
+	if err != nil {
+		logger.Warnf("%v", err.Error())
+	}
+
+	if flags.Changed("log-level") {
+		value, _ := flags.GetString("log-level")
+		if options.Level, err = logger.ParseLevel(value); err != nil {
+			logger.Fatalf("%v", err.Error())
+		}
+	}
+	if flags.Changed("log-format") {
+		value, _ := flags.GetString("log-format")
+		if options.Format, err = logger.ParseFormat(value); err != nil {
+			logger.Fatalf("%v", err.Error())
+		}
+	}
+	if flags.Changed("log-file") {
+		options.File, _ = flags.GetString("log-file")
+	}
+
+	if err := logger.Setup(options); err != nil {
+		logger.Fatalf("%v", err.Error())
+	}
+}
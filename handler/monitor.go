@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/monitor"
+)
+
+type monitorHandler Handler
+
+var Monitor monitorHandler = nil
+
+// ChainTips monitors the tips of the block tree until interrupted, streaming alerts as JSON lines
+// and executing the hooks given by --exec, --webhook and --file on each of them.
+func (m *monitorHandler) ChainTips(cmd *cobra.Command, args []string) {
+	options := monitor.Options{}
+	options.Interval, _ = cmd.Flags().GetDuration("interval")
+	options.Depth, _ = cmd.Flags().GetInt("depth")
+
+	commands, _ := cmd.Flags().GetStringArray("exec")
+	for _, command := range commands {
+		options.Hooks = append(options.Hooks, &monitor.Command{Command: command})
+	}
+	webhooks, _ := cmd.Flags().GetStringArray("webhook")
+	for _, url := range webhooks {
+		options.Hooks = append(options.Hooks, &monitor.Webhook{URL: url})
+	}
+	files, _ := cmd.Flags().GetStringArray("file")
+	for _, path := range files {
+		options.Hooks = append(options.Hooks, &monitor.File{Path: path})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Debugf("monitoring chain tips every %v with %d hooks", options.Interval, len(options.Hooks))
+
+	err := monitor.ChainTips(ctx, options, func(alert monitor.Alert) {
+		line, err := json.Marshal(alert)
+		if err != nil {
+			logger.Errorf("failed to serialize alert: %v", err.Error())
+			return
+		}
+		logger.Print(string(line))
+	})
+	if err != nil {
+		logger.Errorf("failed to monitor chain tips: %v", err.Error())
+	}
+}
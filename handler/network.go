@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"net"
 	"strings"
 
@@ -149,10 +150,19 @@ var getTargetIP = func(cmd *cobra.Command, args []string) (string, bool) {
 		return "", false
 	}
 
-	if _, _, err := net.ParseCIDR(target); err != nil && net.ParseIP(target) == nil {
-		logger.Errorf("invalid format. the target must be either a valid IP address (e.g., '192.168.1.1') or a valid subnet mask (e.g., '192.168.1.0/24)")
+	if err := validateIP(target); err != nil {
+		logger.Errorf("%v", err.Error())
 		return "", false
 	}
 
 	return strings.TrimSpace(target), true
 }
+
+// validateIP checks that a target is an IP address or a subnet.
+func validateIP(target string) error {
+	target = strings.TrimSpace(target)
+	if _, _, err := net.ParseCIDR(target); err != nil && net.ParseIP(target) == nil {
+		return fmt.Errorf("invalid format. the target must be either a valid IP address (e.g., '192.168.1.1') or a valid subnet mask (e.g., '192.168.1.0/24)")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/network"
+)
+
+// nodesHandler is a custom handler type based on the Handler function type.
+type nodesHandler Handler
+
+// Nodes is a variable representing the handler for the 'nodes' command.
+// This handler is currently set to nil, meaning there's no handler defined for this command by default.
+var Nodes nodesHandler = nil
+
+// Disconnect disconnects the node given by its address (e.g. "192.168.0.6:8333") or its numeric
+// ID, as listed by 'network peers' and completed from the connected peers.
+func (n *nodesHandler) Disconnect(cmd *cobra.Command, args []string) {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		// If no node is provided, show the command help
+		if err := cmd.Help(); err != nil {
+			logger.Errorf("failed to show output for command %s: %v", cmd.Short, err.Error())
+		}
+		return
+	}
+	node := strings.TrimSpace(args[0])
+
+	if err := network.DisconnectNode(node); err != nil {
+		logger.Errorf("failed to disconnect node: %s", err.Error())
+	} else {
+		logger.Infof("node %s was disconnected!", node)
+	}
+}
@@ -1,60 +1,225 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 
 	"github.com/avila-r/bitclient/assets"
-	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/blocks"
 	"github.com/avila-r/bitclient/logger"
 )
 
-// Root is the handler for the root command of the CLI application. It presents an interactive form to the user,
-// allowing them to choose one of the available options and execute the corresponding command.
+// back is the value of the menu option returning to the parent command.
+const back = ".."
+
+// accessible switches the menu's forms to plain prompts, friendlier to screen readers, when the
+// ACCESSIBLE environment variable is set.
+var accessible = os.Getenv("ACCESSIBLE") != ""
+
+// Root is the handler for the root command of the CLI application. It presents an interactive menu,
+// generated from the command tree, to browse the commands down to the one to execute. Commands
+// expecting a block, an IP or subnet, a ban duration or statistics ask for them through a form,
+// validated as their flags and arguments are.
 var Root = func(cmd *cobra.Command, args []string) {
-	// Declare a variable to hold the user-selected command
-	var command string
+	current := cmd
+	for {
+		var selected string
+
+		options := []huh.Option[string]{}
+		for _, children := range menu(current) {
+			options = append(options, huh.NewOption(fmt.Sprintf("%-16s %s", children.Name(), children.Short), children.Name()))
+		}
+		if current != cmd {
+			options = append(options, huh.NewOption("← Back", back))
+		}
+
+		// Create a form for the user to select an option from a list
+		form := huh.NewForm(huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(strings.TrimSpace(current.CommandPath() + ": choose an option")).
+				Options(options...).
+				Value(&selected),
+		)).WithTheme(assets.FormTheme).WithAccessible(accessible)
+
+		// Run the form and handle errors if any
+		if err := form.Run(); err != nil {
+			if !errors.Is(err, huh.ErrUserAborted) {
+				logger.Error(err.Error())
+			}
+			return
+		}
+
+		if selected == back {
+			current = current.Parent()
+			continue
+		}
+
+		command := subcommand(current, selected)
+		if command == nil {
+			logger.Errorf("invalid command: %s", selected)
+			return
+		}
+
+		// Browse down to commands with subcommands, and execute the others
+		if len(menu(command)) > 0 {
+			current = command
+			continue
+		}
+
+		fields, collect := inputs(command)
+		if len(fields) > 0 {
+			if err := huh.NewForm(huh.NewGroup(fields...)).WithTheme(assets.FormTheme).WithAccessible(accessible).Run(); err != nil {
+				if !errors.Is(err, huh.ErrUserAborted) {
+					logger.Error(err.Error())
+				}
+				return
+			}
+		}
+
+		arguments, err := collect()
+		if err != nil {
+			logger.Errorf("%v", err.Error())
+			return
+		}
+		command.Run(command, arguments)
+		return
+	}
+}
 
-	// Define a map of available commands for the root menu
-	commands := map[string]*cobra.Command{
-		"config": subcommand(cmd, "config"),
+// menu returns the subcommands of a command offered by the interactive menu.
+func menu(cmd *cobra.Command) []*cobra.Command {
+	commands := []*cobra.Command{}
+	for _, children := range cmd.Commands() {
+		if children.IsAvailableCommand() && children.Name() != "help" && children.Name() != "completion" {
+			commands = append(commands, children)
+		}
 	}
+	return commands
+}
+
+// inputs builds the form fields asking for the block, IP or subnet, ban duration and statistics
+// a command expects, with a function collecting them into its arguments and flags once submitted.
+func inputs(cmd *cobra.Command) ([]huh.Field, func() ([]string, error)) {
+	fields := []huh.Field{}
+	collectors := []func(args []string) ([]string, error){}
+
+	// Positional arguments, as documented by the command's usage (e.g. "stats [block]")
+	for _, argument := range strings.Fields(cmd.Use)[1:] {
+		name := strings.Trim(argument, "[]<>")
+		value := new(string)
+
+		input := huh.NewInput().Value(value)
+		switch name {
+		case "block":
+			input.Title("Block").Description("A block hash or height").Validate(validateBlock)
+		case "ip":
+			input.Title("Target").Description("An IP address (e.g. 192.168.1.1) or a subnet (e.g. 192.168.1.0/24)").Validate(validateIP)
+		default:
+			input.Title(strings.ToUpper(name[:1]) + name[1:])
+		}
 
-	// Check if the '--debug' flag is set and update the configuration accordingly
-	if flag, err := cmd.PersistentFlags().GetBool("debug"); err == nil {
-		config.Get().Advanced.Debug = flag
+		fields = append(fields, input)
+		collectors = append(collectors, func(args []string) ([]string, error) {
+			if *value == "" {
+				return args, nil
+			}
+			return append(args, strings.TrimSpace(*value)), nil
+		})
 	}
 
-	// Create a form for the user to select an option from a list
-	form := huh.NewForm(huh.NewGroup(
-		huh.NewSelect[string]().
-			Title("Choose an option").
-			Options(
-				huh.NewOption("See current configuration", "config"),
-			).
+	// Flags expecting inputs a user can't be expected to know by heart
+	if flag := cmd.Flags().Lookup("time"); flag != nil && flag.Value.Type() == "int" {
+		value := new(string)
+		fields = append(fields, huh.NewInput().
+			Title("Ban duration").
+			Description("In seconds, 0 for the default of 24 hours").
+			Placeholder("0").
 			Validate(func(s string) error {
-				// Validate the user's choice against the available commands
-				for cmd := range commands {
-					if s == cmd {
-						return nil
-					}
+				if seconds, err := strconv.Atoi(s); s != "" && (err != nil || seconds < 0) {
+					return fmt.Errorf("the ban duration must be a non-negative number of seconds")
 				}
-
-				// If the selected option is invalid, return an error
-				return fmt.Errorf("invalid command: %s (valid commands are: %v)", s, commands)
+				return nil
 			}).
-			Value(&command),
-	)).WithTheme(assets.FormTheme)
+			Value(value))
+		collectors = append(collectors, func(args []string) ([]string, error) {
+			if *value == "" {
+				return args, nil
+			}
+			return args, cmd.Flags().Set("time", *value)
+		})
+	}
 
-	// Run the form and handle errors if any
-	if err := form.Run(); err != nil {
-		logger.Error(err.Error())
-	} else {
-		// If a valid command is selected, execute it
-		if command, exists := commands[command]; exists {
-			command.Run(cmd, args)
+	if cmd.Flags().Lookup("stat") != nil {
+		stats := new([]string)
+		options := []huh.Option[string]{}
+		for _, stat := range statistics() {
+			options = append(options, huh.NewOption(stat, stat))
 		}
+		fields = append(fields, huh.NewMultiSelect[string]().
+			Title("Statistics").
+			Description("Leave empty to retrieve all of them").
+			Options(options...).
+			Height(10).
+			Value(stats))
+		collectors = append(collectors, func(args []string) ([]string, error) {
+			selected := []string{}
+			for _, stat := range *stats {
+				if !slices.Contains(selected, stat) {
+					selected = append(selected, stat)
+				}
+			}
+			if len(selected) == 0 {
+				return args, nil
+			}
+			return args, cmd.Flags().Set("stat", strings.Join(selected, ","))
+		})
+	}
+
+	return fields, func() ([]string, error) {
+		args := []string{}
+		for _, collect := range collectors {
+			var err error
+			if args, err = collect(args); err != nil {
+				return nil, err
+			}
+		}
+		return args, nil
+	}
+}
+
+// statistics returns the names of the statistics of 'getblockstats', the percentile columns
+// standing for the feerate_percentiles statistic.
+func statistics() []string {
+	names := []string{}
+	for _, column := range blocks.StatsColumns {
+		switch {
+		case column.Name == "feerate_percentiles_10":
+			names = append(names, "feerate_percentiles")
+		case !strings.HasPrefix(column.Name, "feerate_percentiles_"):
+			names = append(names, column.Name)
+		}
+	}
+	return names
+}
+
+// validateBlock checks that a target is a block hash or a non-negative height.
+func validateBlock(target string) error {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return fmt.Errorf("a block hash or height is required")
+	}
+	if height, err := strconv.Atoi(target); err == nil && height >= 0 {
+		return nil
+	}
+	if blocks.IsBlockHashInvalid(target) {
+		return fmt.Errorf("block must be a valid block hash or a numeric height")
 	}
+	return nil
 }
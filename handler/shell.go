@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/shell"
+)
+
+// Shell runs an interactive session sending subcommands and raw RPC requests through the
+// connection the command was started with, until the user exits.
+var Shell = func(cmd *cobra.Command, args []string) {
+	path, _ := cmd.Flags().GetString("history")
+	if disabled, _ := cmd.Flags().GetBool("no-history"); disabled {
+		path = ""
+	} else if path == "" {
+		var err error
+		if path, err = shell.HistoryPath(); err != nil {
+			logger.Warnf("history disabled: %v", err.Error())
+		}
+	}
+
+	session, err := shell.New(shell.Options{Root: cmd.Root(), History: path})
+	if err != nil {
+		logger.Errorf("failed to start shell: %v", err.Error())
+		return
+	}
+
+	if err := session.Run(); err != nil {
+		logger.Errorf("shell failed: %v", err.Error())
+	}
+
+	// Failures of the subcommands run in the session were reported as they happened
+	_ = Failed()
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/tracing"
+)
+
+var (
+	// summary times the requests of the command when '--trace' is given.
+	summary *tracing.Summary
+
+	// tracer exports the requests of the command as traces when '--otlp-endpoint' is given.
+	tracer *tracing.Exporter
+
+	// end ends the span of the command.
+	end func(error)
+)
+
+// observe registers the observers of the default rpc.Client: a summary of the requests with
+// '--trace', an exporter of traces with '--otlp-endpoint' (or OTEL_EXPORTER_OTLP_ENDPOINT), and
+// a slog logger of every request at debug level.
+func observe(cmd *cobra.Command) {
+	if rpc.Client == nil {
+		return
+	}
+
+	if logger.Enabled(slog.LevelDebug) {
+		rpc.Client.Observe(&rpc.SlogObserver{})
+	}
+
+	if enabled, _ := cmd.Flags().GetBool("trace"); enabled {
+		summary = tracing.NewSummary()
+		rpc.Client.Observe(summary)
+	}
+
+	endpoint, _ := cmd.Flags().GetString("otlp-endpoint")
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint != "" {
+		var err error
+		tracer, err = tracing.NewExporter(tracing.Options{
+			Endpoint: endpoint,
+			OnError:  func(err error) { logger.Warnf("%v", err.Error()) },
+		})
+		if err != nil {
+			logger.Fatalf("failed to set up tracing: %v", err.Error())
+		}
+		rpc.Client.Observe(tracer)
+		end = tracer.Begin(cmd.CommandPath())
+
+		logger.Debugf("exporting traces to %s", endpoint)
+	}
+}
+
+// report prints the summary of the requests to the standard error, so that it doesn't mix with
+// the command's output, and exports the traces of the command.
+func report() {
+	if summary != nil {
+		summary.Write(os.Stderr)
+	}
+
+	if tracer != nil {
+		end(nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := tracer.Flush(ctx); err != nil {
+			logger.Warnf("%v", err.Error())
+		}
+	}
+}
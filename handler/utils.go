@@ -1,10 +1,35 @@
 package handler
 
-import "github.com/spf13/cobra"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/logger"
+)
 
 // Handler defines a function type that handles commands with a cobra.Command
 type Handler func(*cobra.Command, []string)
 
+// failed is the error the last command failed with through fail, if any.
+var failed error
+
+// fail logs the error a command failed with, making bitclient exit with status 1 once the command
+// returns. Unlike logger.Fatalf, it doesn't exit right away: deferred calls and the persistent
+// post-run handler still run, and the shell keeps going.
+func fail(format string, v ...any) {
+	failed = fmt.Errorf(format, v...)
+	logger.Errorf("%v", failed.Error())
+}
+
+// Failed returns the error the last command failed with, if any, and clears it.
+func Failed() error {
+	err := failed
+	failed = nil
+	return err
+}
+
 // subcommand searches for a subcommand by its name within the parent command's list of subcommands.
 // It returns the matching subcommand or nil if no match is found.
 func subcommand(cmd *cobra.Command, name string) *cobra.Command {
@@ -16,3 +41,13 @@ func subcommand(cmd *cobra.Command, name string) *cobra.Command {
 	// Return nil if no subcommand with the name is found
 	return nil
 }
+
+// printJSON prints a value as indented JSON.
+func printJSON(value any) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		logger.Errorf("failed to serialize result: %v", err.Error())
+		return
+	}
+	logger.Print(string(data))
+}
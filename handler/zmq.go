@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/zmq"
+)
+
+// zmqHandler is a custom handler type based on the Handler function type.
+type zmqHandler Handler
+
+// Zmq is a variable representing the handler for the 'zmq' command.
+var Zmq zmqHandler = nil
+
+// Listen streams the node's ZeroMQ notifications as JSON lines, until interrupted.
+func (z *zmqHandler) Listen(cmd *cobra.Command, args []string) {
+	names, _ := cmd.Flags().GetStringSlice("topic")
+	address, _ := cmd.Flags().GetString("endpoint")
+	retry, _ := cmd.Flags().GetDuration("retry")
+
+	topics := []zmq.Topic{}
+	for _, name := range names {
+		if !slices.Contains(zmq.Topics, zmq.Topic(name)) {
+			logger.Errorf("unknown topic '%s', must be one of %v", name, zmq.Topics)
+			return
+		}
+		topics = append(topics, zmq.Topic(name))
+	}
+
+	endpoints := []zmq.Endpoint{}
+	if address != "" {
+		if len(topics) == 0 {
+			topics = zmq.Topics
+		}
+		for _, topic := range topics {
+			endpoints = append(endpoints, zmq.Endpoint{Topic: topic, Address: address})
+		}
+	} else {
+		enabled, err := zmq.Notifications()
+		if err != nil {
+			logger.Errorf("failed to discover zmq endpoints: %v", err.Error())
+			return
+		}
+		for _, endpoint := range enabled {
+			if len(topics) == 0 || slices.Contains(topics, endpoint.Topic) {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+		if len(endpoints) == 0 {
+			logger.Errorf("no matching zmq notifications are enabled on the node (see the -zmqpub* options), or provide --endpoint")
+			return
+		}
+	}
+
+	for _, endpoint := range endpoints {
+		logger.Debugf("subscribing to %s on %s", endpoint.Topic, endpoint.Address)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	notifications, err := zmq.Listen(ctx, endpoints, zmq.ListenOptions{Retry: retry})
+	if err != nil {
+		logger.Errorf("failed to listen to zmq notifications: %v", err.Error())
+		return
+	}
+
+	for notification := range notifications {
+		if notification.Err != nil {
+			logger.Errorf("%s: %v", notification.Endpoint, notification.Err.Error())
+			continue
+		}
+
+		line, err := json.Marshal(summarize(notification))
+		if err != nil {
+			logger.Errorf("failed to serialize notification: %v", err.Error())
+			continue
+		}
+		logger.Print(string(line))
+	}
+}
+
+// summarize describes a notification, decoding its payload.
+func summarize(notification zmq.Notification) rpc.Json {
+	summary := rpc.Json{
+		"topic":    notification.Topic,
+		"sequence": notification.Sequence,
+	}
+	if notification.Missed > 0 {
+		summary["missed"] = notification.Missed
+	}
+
+	switch notification.Topic {
+	case zmq.TopicHashBlock, zmq.TopicHashTx:
+		hash, err := notification.Hash()
+		if err != nil {
+			summary["error"] = err.Error()
+			break
+		}
+		summary["hash"] = hash
+
+	case zmq.TopicRawBlock:
+		block, err := notification.Block()
+		if err != nil {
+			summary["error"] = err.Error()
+			break
+		}
+		summary["hash"] = block.Hash()
+		summary["previousblockhash"] = block.PreviousBlock
+		summary["time"] = block.Time
+		summary["size"] = len(notification.Body)
+		summary["nTx"] = len(block.Transactions)
+
+	case zmq.TopicRawTx:
+		tx, err := notification.Tx()
+		if err != nil {
+			summary["error"] = err.Error()
+			break
+		}
+		value := int64(0)
+		for _, output := range tx.Outputs {
+			value += output.Value
+		}
+		summary["txid"] = tx.TxID()
+		summary["size"] = len(notification.Body)
+		summary["vin"] = len(tx.Inputs)
+		summary["vout"] = len(tx.Outputs)
+		summary["value"] = value
+
+	case zmq.TopicSequence:
+		event, err := notification.Event()
+		if err != nil {
+			summary["error"] = err.Error()
+			break
+		}
+		summary["hash"] = event.Hash
+		summary["label"] = event.Label
+		if event.MempoolSequence > 0 {
+			summary["mempool_sequence"] = event.MempoolSequence
+		}
+	}
+
+	return summary
+}
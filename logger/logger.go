@@ -1,110 +1,286 @@
+// Package logger logs leveled, structured messages through log/slog, as text or JSON, to the
+// standard error or a file, and prints the output of commands to the standard output.
 package logger
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"os"
-
-	"github.com/fatih/color"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/avila-r/bitclient/config"
 )
 
-// The logger and printer variables are used for logging messages and outputting formatted text.
+// Format is the format of the logged messages.
+type Format string
+
+const (
+	FormatText Format = "text" // key=value pairs, as slog.TextHandler writes them
+	FormatJSON Format = "json" // JSON objects, one per line, as slog.JSONHandler writes them
+)
+
+// Options configures the logger.
+type Options struct {
+	Level  slog.Level // Minimum level of the messages logged
+	Format Format     // Format of the messages (default: text)
+	File   string     // File the messages are appended to, instead of the standard error (created readable by its owner only)
+}
+
 var (
-	// logger is the main logger instance used for logging with prefixes.
-	logger = log.New(os.Stdout, "[bitclient]", log.Ldate|log.Ltime|log.Lmsgprefix)
-	// printer is used for printing simple output without any prefixes.
-	printer = log.New(os.Stdout, "", 0)
+	// mu guards the file messages are written to.
+	mu sync.Mutex
+	// file is the file messages are written to, if any.
+	file *os.File
+	// exits are the functions run by Fatal and Fatalf before exiting, last registered first.
+	exits []func()
 
-	// Predefined colors for colored text output.
-	yellow = color.New(color.FgYellow).SprintFunc()
-	red    = color.New(color.FgRed).SprintFunc()
-	cyan   = color.New(color.FgCyan).SprintFunc()
+	// printer prints the output of commands, without any decoration.
+	printer = log.New(os.Stdout, "", 0)
 )
 
+// init sets the logger up from the environment, so that messages logged before the command
+// line is parsed (e.g. while setting up the default rpc.Client) are leveled and formatted too.
+func init() {
+	options, err := FromEnvironment()
+	if err == nil {
+		err = Setup(options)
+	}
+	if err != nil {
+		_ = Setup(Options{Level: options.Level})
+		Warnf("%v", err.Error())
+	}
+}
+
+// FromEnvironment returns the options set by the LOG_LEVEL, LOG_FORMAT and LOG_FILE environment
+// variables. The level defaults to debug when debugging is enabled in the configuration, and
+// to info otherwise.
+//
+// Returns:
+// - Options: The options of the logger.
+// - error: An error if the level or the format is invalid.
+func FromEnvironment() (Options, error) {
+	options := Options{Level: slog.LevelInfo, Format: FormatText, File: os.Getenv("LOG_FILE")}
+	if config.Get().Advanced.Debug {
+		options.Level = slog.LevelDebug
+	}
+
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		level, err := ParseLevel(value)
+		if err != nil {
+			return options, err
+		}
+		options.Level = level
+	}
+
+	if value := os.Getenv("LOG_FORMAT"); value != "" {
+		format, err := ParseFormat(value)
+		if err != nil {
+			return options, err
+		}
+		options.Format = format
+	}
+
+	return options, nil
+}
+
+// ParseLevel parses a level name: debug, info, warn (or warning) or error, case-insensitively.
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if strings.EqualFold(value, "warning") {
+		value = "warn"
+	}
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", value)
+	}
+	return level, nil
+}
+
+// ParseFormat parses a format name: text or json, case-insensitively.
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case FormatText, FormatJSON:
+		return format, nil
+	}
+	return FormatText, fmt.Errorf("invalid log format %q: must be text or json", value)
+}
+
+// Setup replaces the logger, and slog's default logger, by one logging messages of at least
+// options.Level in options.Format. The file and line messages are logged from are added at debug level.
+//
+// Parameters:
+// - options (Options): The level, format and file of the logger.
+//
+// Returns:
+// - error: An error if the file can't be opened, in which case the logger is left unchanged.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks get 100 --log-level debug --log-format json --log-file bitclient.log
+//
+//   - Using Go:
+//     err := logger.Setup(logger.Options{Level: slog.LevelDebug, Format: logger.FormatJSON})
+func Setup(options Options) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var output io.Writer = os.Stderr
+	var opened *os.File
+	if options.File != "" {
+		var err error
+		opened, err = os.OpenFile(options.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %v", err.Error())
+		}
+		output = opened
+	}
+
+	handlerOptions := &slog.HandlerOptions{
+		Level:     options.Level,
+		AddSource: options.Level <= slog.LevelDebug,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if source, ok := attr.Value.Any().(*slog.Source); ok && attr.Key == slog.SourceKey {
+				return slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", relative(source.File), source.Line))
+			}
+			return attr
+		},
+	}
+
+	var handler slog.Handler = slog.NewTextHandler(output, handlerOptions)
+	if options.Format == FormatJSON {
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	if file != nil {
+		file.Close()
+	}
+	file = opened
+
+	return nil
+}
+
+// Close closes the file messages are written to, if any, logging to the standard error from then on.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file = nil
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	return err
+}
+
+// Enabled reports whether messages of a level are logged, e.g. to skip building costly ones.
+func Enabled(level slog.Level) bool {
+	return slog.Default().Enabled(context.Background(), level)
+}
+
+// write logs a message through slog's default logger, attributed to the caller of the exported function.
+func write(level slog.Level, message string) {
+	logger := slog.Default()
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // Skip runtime.Callers, write and its caller
+	record := slog.NewRecord(time.Now(), level, message, pcs[0])
+	_ = logger.Handler().Handle(context.Background(), record)
+}
+
 // Info logs an info message.
 func Info(v ...any) {
-	logger.SetPrefix("[INFO] ")
-	logger.Print(v...)
+	write(slog.LevelInfo, fmt.Sprint(v...))
 }
 
 // Infof logs a formatted info message.
 func Infof(format string, v ...any) {
-	logger.SetPrefix("[INFO] ")
-	logger.Printf(format, v...)
+	write(slog.LevelInfo, fmt.Sprintf(format, v...))
 }
 
 // Error logs an error message.
 func Error(v ...any) {
-	logger.SetPrefix(red("[ERROR] "))
-	logger.Print(v...)
+	write(slog.LevelError, fmt.Sprint(v...))
 }
 
 // Errorf logs a formatted error message.
 func Errorf(format string, v ...any) {
-	logger.SetPrefix(red("[ERROR] "))
-	logger.Printf(format, v...)
+	write(slog.LevelError, fmt.Sprintf(format, v...))
 }
 
-// Fatal logs an error message and exits the program with status 1.
+// Fatal logs an error message and exits the program with status 1, once the functions registered
+// with AtExit ran.
 func Fatal(v ...any) {
-	logger.SetPrefix(red("[ERROR] "))
-	logger.Print(v...)
-	os.Exit(1)
+	write(slog.LevelError, fmt.Sprint(v...))
+	exit()
 }
 
-// Fatalf logs a formatted error message and exits the program with status 1.
+// Fatalf logs a formatted error message and exits the program with status 1, once the functions
+// registered with AtExit ran.
 func Fatalf(format string, v ...any) {
-	logger.SetPrefix(red("[ERROR] "))
-	logger.Printf(format, v...)
+	write(slog.LevelError, fmt.Sprintf(format, v...))
+	exit()
+}
+
+// AtExit registers a function run by Fatal and Fatalf before exiting, e.g. to close the files that
+// deferred calls would have closed. Functions run in the reverse order of their registration.
+func AtExit(f func()) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	exits = append(exits, f)
+}
+
+// exit runs the functions registered with AtExit, closes the log file and exits with status 1.
+func exit() {
+	mu.Lock()
+	registered := exits
+	exits = nil
+	mu.Unlock()
+
+	for i := len(registered) - 1; i >= 0; i-- {
+		registered[i]()
+	}
+	Close()
 	os.Exit(1)
 }
 
 // Warn logs a warning message.
 func Warn(v ...any) {
-	logger.SetPrefix(yellow("[WARN] "))
-	logger.Print(v...)
+	write(slog.LevelWarn, fmt.Sprint(v...))
 }
 
 // Warnf logs a formatted warning message.
 func Warnf(format string, v ...any) {
-	logger.SetPrefix(yellow("[WARN] "))
-	logger.Printf(format, v...)
+	write(slog.LevelWarn, fmt.Sprintf(format, v...))
 }
 
-// Debug logs a debug message if debugging is enabled in the configuration.
+// Debug logs a debug message.
 func Debug(v ...any) {
-	if !config.Get().Advanced.Debug {
-		return // Do nothing if debug is false
-	}
-	logger.SetPrefix(fmt.Sprintf("%s %s", caller(), cyan("[DEBUG] ")))
-	logger.Print(v...)
+	write(slog.LevelDebug, fmt.Sprint(v...))
 }
 
-// Debugf logs a formatted debug message if debugging is enabled in the configuration.
+// Debugf logs a formatted debug message.
 func Debugf(format string, v ...any) {
-	if !config.Get().Advanced.Debug {
-		return // Do nothing if debug is false
-	}
-	logger.SetPrefix(fmt.Sprintf("%s %s", caller(), cyan("[DEBUG] ")))
-	logger.Printf(format, v...)
+	write(slog.LevelDebug, fmt.Sprintf(format, v...))
 }
 
-// Print outputs the message without any prefix.
+// Print outputs the message to the standard output, without any decoration.
 func Print(v ...any) {
 	printer.Print(v...)
 }
 
-// Printf outputs a formatted message without any prefix.
+// Printf outputs a formatted message to the standard output, without any decoration.
 func Printf(format string, v ...any) {
 	printer.Printf(format, v...)
 }
-
-// init initializes the logging system by setting the log level for `slog` if debugging is enabled.
-func init() {
-	if config.Get().Advanced.Debug {
-		slog.SetLogLoggerLevel(slog.LevelDebug)
-	}
-}
@@ -0,0 +1,185 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/avila-r/bitclient/logger"
+)
+
+func Test_ParseLevel(t *testing.T) {
+	cases := []struct {
+		Value    string
+		Expected slog.Level
+		Valid    bool
+	}{
+		{Value: "debug", Expected: slog.LevelDebug, Valid: true},
+		{Value: "INFO", Expected: slog.LevelInfo, Valid: true},
+		{Value: "warn", Expected: slog.LevelWarn, Valid: true},
+		{Value: "Warning", Expected: slog.LevelWarn, Valid: true},
+		{Value: "error", Expected: slog.LevelError, Valid: true},
+		{Value: "verbose", Valid: false},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			level, err := logger.ParseLevel(test.Value)
+			if (err == nil) != test.Valid {
+				t.Fatalf("Expected level %q to be valid: %v, got error %v", test.Value, test.Valid, err)
+			}
+			if test.Valid && level != test.Expected {
+				t.Errorf("Expected level %v, got %v", test.Expected, level)
+			}
+		})
+	}
+
+	if _, err := logger.ParseFormat("JSON"); err != nil {
+		t.Errorf("Failed to parse format: %v", err)
+	}
+	if _, err := logger.ParseFormat("xml"); err == nil {
+		t.Errorf("Expected an error for an invalid format")
+	}
+}
+
+// setup sets the logger up for a test, logging to a file whose lines are returned by the function.
+func setup(t *testing.T, options logger.Options) func() []string {
+	options.File = filepath.Join(t.TempDir(), "bitclient.log")
+	if err := logger.Setup(options); err != nil {
+		t.Fatalf("Failed to set up logger: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+
+	return func() []string {
+		data, err := os.ReadFile(options.File)
+		if err != nil {
+			t.Fatalf("Failed to read log file: %v", err)
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+func Test_Setup(t *testing.T) {
+	lines := setup(t, logger.Options{Level: slog.LevelWarn, Format: logger.FormatText})
+
+	logger.Debugf("hidden %d", 1)
+	logger.Infof("hidden %d", 2)
+	logger.Warnf("shown %d", 3)
+	logger.Error("shown ", 4)
+	slog.Warn("through slog", "key", "value")
+
+	got := lines()
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 lines at warn level, got %q", got)
+	}
+	for i, expected := range []string{`level=WARN msg="shown 3"`, `level=ERROR msg="shown 4"`, `level=WARN msg="through slog" key=value`} {
+		if !strings.Contains(got[i], expected) {
+			t.Errorf("Expected line %q to contain %q", got[i], expected)
+		}
+	}
+	if strings.Contains(got[0], "source=") {
+		t.Errorf("Expected no source above debug level, got %q", got[0])
+	}
+}
+
+func Test_SetupPermissions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bitclient.log")
+	if err := logger.Setup(logger.Options{File: file}); err != nil {
+		t.Fatalf("Failed to set up logger: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+
+	// Log files may hold request details, so only their owner can read them
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("Failed to stat log file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected log file mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func Test_SetupJSON(t *testing.T) {
+	lines := setup(t, logger.Options{Level: slog.LevelDebug, Format: logger.FormatJSON})
+
+	logger.Debug("debugging")
+
+	entry := struct {
+		Level  string `json:"level"`
+		Msg    string `json:"msg"`
+		Source string `json:"source"`
+	}{}
+	got := lines()
+	if err := json.Unmarshal([]byte(got[0]), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON line %q: %v", got[0], err)
+	}
+	if entry.Level != "DEBUG" || entry.Msg != "debugging" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if !strings.HasPrefix(entry.Source, "logger/logger_test.go:") {
+		t.Errorf("Expected the caller's project-relative source, got %q", entry.Source)
+	}
+	if !logger.Enabled(slog.LevelDebug) {
+		t.Errorf("Expected debug level to be enabled")
+	}
+
+	if err := logger.Setup(logger.Options{File: filepath.Join(t.TempDir(), "missing", "bitclient.log")}); err == nil {
+		t.Errorf("Expected an error for a file in a missing directory")
+	}
+}
+
+func Test_Concurrency(t *testing.T) {
+	lines := setup(t, logger.Options{Level: slog.LevelInfo})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				logger.Infof("info %d", j)
+				logger.Errorf("error %d", j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := lines()
+	if len(got) != 800 {
+		t.Fatalf("Expected 800 lines, got %d", len(got))
+	}
+	for _, line := range got {
+		if !strings.Contains(line, `level=INFO msg="info `) && !strings.Contains(line, `level=ERROR msg="error `) {
+			t.Fatalf("Expected messages to be logged with their own level, got %q", line)
+		}
+	}
+}
+
+func Test_AtExit(t *testing.T) {
+	// The fatal error is raised in a child process, since it exits
+	if marker := os.Getenv("LOGGER_TEST_MARKER"); marker != "" {
+		logger.AtExit(func() { os.WriteFile(marker, []byte("first"), 0o600) })
+		logger.AtExit(func() { os.WriteFile(marker, []byte("last"), 0o600) })
+		logger.Fatalf("fatal %d", 1)
+		return
+	}
+
+	marker := filepath.Join(t.TempDir(), "marker")
+	child := exec.Command(os.Args[0], "-test.run=^Test_AtExit$")
+	child.Env = append(os.Environ(), "LOGGER_TEST_MARKER="+marker)
+	output, err := child.CombinedOutput()
+
+	if exit, ok := err.(*exec.ExitError); !ok || exit.ExitCode() != 1 {
+		t.Fatalf("Expected the child to exit with status 1, got %v: %s", err, output)
+	}
+	// Functions run last registered first, so the first one wrote last
+	if data, err := os.ReadFile(marker); err != nil || string(data) != "first" {
+		t.Errorf("Expected the registered functions to run before exiting, got %q (%v)", data, err)
+	}
+}
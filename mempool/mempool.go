@@ -0,0 +1,63 @@
+// Package mempool retrieves the state of the node's memory pool of unconfirmed transactions.
+package mempool
+
+import "github.com/avila-r/bitclient/rpc"
+
+// GetMempoolInfo retrieves the state of the mempool: its number of transactions, size and fee limits.
+//
+// This function sends a JSON-RPC request using the "getmempoolinfo" procedure call.
+//
+// Returns:
+// - *rpc.Json: The JSON-RPC response containing the mempool information.
+// - error: An error if the request fails.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient dashboard
+//
+//   - Using the Bitcoin CLI:
+//     $ bitcoin-cli getmempoolinfo
+//
+//   - Using cURL:
+//     $ curl --user {username} --data-binary '{"jsonrpc": "1.0", "id": "curltest", "method": "getmempoolinfo", "params": []}' \
+//     -H 'content-type: text/plain;' {url}
+//
+// RPC Request Example:
+//
+//	{
+//	  "jsonrpc": "1.0",
+//	  "id": "curltest",
+//	  "method": "getmempoolinfo",
+//	  "params": []
+//	}
+//
+// JSON Response Example:
+//
+//	{
+//	  "loaded": true,
+//	  "size": 4127,
+//	  "bytes": 2217436,
+//	  "usage": 9614192,
+//	  "total_fee": 0.05236112,
+//	  "maxmempool": 300000000,
+//	  "mempoolminfee": 0.00001000,
+//	  "minrelaytxfee": 0.00001000,
+//	  "incrementalrelayfee": 0.00001000,
+//	  "unbroadcastcount": 0,
+//	  "fullrbf": true
+//	}
+//
+// Notes:
+//   - "size" is the number of transactions, "bytes" their total virtual size and "usage" the memory
+//     used by the mempool, bounded by "maxmempool".
+func GetMempoolInfo() (*rpc.Json, error) {
+	request := rpc.Request{
+		ID:      rpc.Identifier,
+		Version: rpc.Version2,
+		Method:  MethodGetMempoolInfo,
+		Params:  rpc.NoParams,
+	}
+
+	return rpc.JsonResult(rpc.Client.Do(request))
+}
@@ -0,0 +1,21 @@
+package mempool_test
+
+import (
+	"testing"
+
+	"github.com/avila-r/bitclient/mempool"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+func Test_GetMempoolInfo(t *testing.T) {
+	rpctest.Use(t)
+
+	info, err := mempool.GetMempoolInfo()
+	if err != nil {
+		t.Fatalf("Failed to get mempool info: %v", err)
+	}
+
+	if (*info)["loaded"] != true || (*info)["size"] != float64(0) || (*info)["maxmempool"] != float64(300_000_000) {
+		t.Errorf("Unexpected mempool info: %v", *info)
+	}
+}
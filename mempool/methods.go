@@ -0,0 +1,7 @@
+package mempool
+
+import "github.com/avila-r/bitclient/rpc"
+
+const (
+	MethodGetMempoolInfo rpc.Method = "getmempoolinfo" // Method to get the state of the mempool
+)
@@ -0,0 +1,143 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Hook is an action executed on every alert of the monitor (except errors).
+type Hook interface {
+	// Fire executes the action for an alert.
+	Fire(ctx context.Context, alert Alert) error
+
+	// String describes the hook in error messages.
+	String() string
+}
+
+// Command is a hook running a shell command. The alert is written as JSON to its standard input,
+// and its fields are set in the BITCLIENT_ALERT_* environment variables.
+type Command struct {
+	Command string        // Command line, run by 'sh -c'
+	Timeout time.Duration // Time after which the command is killed (default: 30s)
+}
+
+// Fire runs the command for an alert.
+func (c *Command) Fire(ctx context.Context, alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return failure.Of("failed to serialize alert: %v", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout(c.Timeout))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"BITCLIENT_ALERT_TYPE="+string(alert.Type),
+		fmt.Sprintf("BITCLIENT_ALERT_HEIGHT=%d", alert.Height),
+		"BITCLIENT_ALERT_HASH="+alert.Hash,
+		fmt.Sprintf("BITCLIENT_ALERT_DEPTH=%d", alert.Depth),
+		"BITCLIENT_ALERT_MESSAGE="+alert.Message,
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return failure.Of("command failed: %v: %s", err.Error(), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (c *Command) String() string {
+	return "exec " + c.Command
+}
+
+// Webhook is a hook posting the alert as JSON to a URL.
+type Webhook struct {
+	URL     string        // URL the alert is posted to
+	Timeout time.Duration // Timeout of the request (default: 30s)
+}
+
+// Fire posts an alert, failing unless the response has a 2xx status.
+func (w *Webhook) Fire(ctx context.Context, alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return failure.Of("failed to serialize alert: %v", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout(w.Timeout))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return failure.Of("failed to set up webhook request: %v", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return failure.Of("failed to send webhook request: %v", err.Error())
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return failure.Of("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+func (w *Webhook) String() string {
+	return "webhook " + w.URL
+}
+
+// File is a hook appending the alert as a JSON line to a file.
+type File struct {
+	Path string // Path of the file, created if it doesn't exist
+
+	mu sync.Mutex
+}
+
+// Fire appends an alert to the file.
+func (f *File) Fire(ctx context.Context, alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return failure.Of("failed to serialize alert: %v", err.Error())
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return failure.Of("failed to open alerts file: %v", err.Error())
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return failure.Of("failed to write alert: %v", err.Error())
+	}
+	return nil
+}
+
+func (f *File) String() string {
+	return "file " + f.Path
+}
+
+// timeout returns a hook's timeout, or the default one if unset.
+func timeout(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 30 * time.Second
+	}
+	return d
+}
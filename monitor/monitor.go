@@ -0,0 +1,100 @@
+// Package monitor implements daemons watching a node for events worth alerting about, such as
+// reorgs and new branches of the block tree, executing hooks when they happen.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/avila-r/bitclient/blocks"
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Options configures the chain tips monitor.
+type Options struct {
+	// Interval is the time between two 'getchaintips' polls (default: 10s).
+	Interval time.Duration
+
+	// Depth is the depth reorgs must exceed to be alerted (default: 0, every reorg).
+	Depth int
+
+	// Hooks are executed on every alert, in order.
+	Hooks []Hook
+}
+
+// ChainTips polls the tips of the block tree until the context is done, alerting on reorgs deeper
+// than options.Depth and on new valid-fork or invalid branches. Each alert is reported, then passed
+// to the hooks. Failed polls and hooks are reported as AlertError alerts, and polling goes on.
+//
+// Parameters:
+// - ctx (context.Context): Stops the monitor when done.
+// - options (Options): The polling interval, reorg depth and hooks.
+// - report (func(Alert)): Called with every alert, including errors.
+//
+// Returns:
+// - error: An error if the tips can't be retrieved when the monitor starts.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient monitor chaintips --depth 2 --webhook https://example.com/alerts
+//
+//   - Using Go:
+//     hooks := []monitor.Hook{&monitor.File{Path: "alerts.jsonl"}}
+//     err := monitor.ChainTips(ctx, monitor.Options{Depth: 2, Hooks: hooks}, func(alert monitor.Alert) {
+//     fmt.Println(alert.Message)
+//     })
+func ChainTips(ctx context.Context, options Options, report func(Alert)) error {
+	if options.Interval <= 0 {
+		options.Interval = 10 * time.Second
+	}
+
+	tracker := NewTracker(options.Depth)
+
+	tips, err := chainTips()
+	if err != nil {
+		return err
+	}
+	tracker.Update(tips)
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		tips, err := chainTips()
+		if err != nil {
+			report(Alert{Type: AlertError, Time: time.Now(), Message: err.Error()})
+			continue
+		}
+
+		for _, alert := range tracker.Update(tips) {
+			report(alert)
+			for _, hook := range options.Hooks {
+				if err := hook.Fire(ctx, alert); err != nil {
+					report(Alert{Type: AlertError, Time: time.Now(), Height: alert.Height, Hash: alert.Hash, Message: fmt.Sprintf("%s: %v", hook, err.Error())})
+				}
+			}
+		}
+	}
+}
+
+// chainTips retrieves the tips of the block tree.
+func chainTips() ([]Tip, error) {
+	response, err := blocks.GetChainTips()
+	if err != nil {
+		return nil, failure.Of("failed to get chain tips: %v", err.Error())
+	}
+
+	tips := []Tip{}
+	if err := response.Bind(&tips); err != nil {
+		return nil, failure.Of("failed to read chain tips: %v", err.Error())
+	}
+	return tips, nil
+}
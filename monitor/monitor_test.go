@@ -0,0 +1,281 @@
+package monitor_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/monitor"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+// tip builds a chain tip with a readable hash.
+func tip(height, branch int, status, name string) rpctest.ChainTip {
+	return rpctest.ChainTip{Height: height, Hash: name, BranchLen: branch, Status: status}
+}
+
+func Test_Tracker(t *testing.T) {
+	cases := []struct {
+		Depth    int
+		Sequence [][]rpctest.ChainTip
+		Expected []string // Type and hash of the alerts of the last step
+	}{
+		// A tip extended by new blocks isn't a reorg
+		{
+			Sequence: [][]rpctest.ChainTip{{tip(100, 0, "active", "a100")}, {tip(102, 0, "active", "a102")}},
+			Expected: []string{},
+		},
+		// The previous tip left behind as a fork of 2 blocks
+		{
+			Sequence: [][]rpctest.ChainTip{
+				{tip(100, 0, "active", "a100")},
+				{tip(101, 0, "active", "b101"), tip(100, 2, "valid-fork", "a100")},
+			},
+			Expected: []string{"reorg b101"},
+		},
+		// Reorgs not deeper than the depth aren't alerted, nor is the stale branch
+		{
+			Depth: 2,
+			Sequence: [][]rpctest.ChainTip{
+				{tip(100, 0, "active", "a100")},
+				{tip(101, 0, "active", "b101"), tip(100, 2, "valid-fork", "a100")},
+			},
+			Expected: []string{},
+		},
+		// New branches, alerted once as they grow
+		{
+			Sequence: [][]rpctest.ChainTip{
+				{tip(100, 0, "active", "a100")},
+				{tip(101, 0, "active", "a101"), tip(99, 1, "valid-fork", "f99"), tip(95, 2, "invalid", "x95")},
+				{tip(102, 0, "active", "a102"), tip(100, 2, "valid-fork", "f100"), tip(95, 2, "invalid", "x95"), tip(90, 1, "headers-only", "h90")},
+			},
+			Expected: []string{},
+		},
+		{
+			Sequence: [][]rpctest.ChainTip{
+				{tip(100, 0, "active", "a100")},
+				{tip(101, 0, "active", "a101"), tip(99, 1, "valid-fork", "f99"), tip(95, 2, "invalid", "x95"), tip(90, 1, "headers-only", "h90")},
+			},
+			Expected: []string{"valid-fork f99", "invalid x95"},
+		},
+		// Branches known when the monitor starts aren't alerted
+		{
+			Sequence: [][]rpctest.ChainTip{
+				{tip(100, 0, "active", "a100"), tip(99, 1, "valid-fork", "f99")},
+				{tip(100, 0, "active", "a100"), tip(99, 1, "valid-fork", "f99")},
+			},
+			Expected: []string{},
+		},
+		// An invalidated tip is both a reorg and an invalid branch
+		{
+			Sequence: [][]rpctest.ChainTip{
+				{tip(100, 0, "active", "a100")},
+				{tip(99, 0, "active", "a99"), tip(100, 1, "invalid", "a100")},
+			},
+			Expected: []string{"reorg a99", "invalid a100"},
+		},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			tracker := monitor.NewTracker(test.Depth)
+
+			var alerts []monitor.Alert
+			for _, step := range test.Sequence {
+				tips := []monitor.Tip{}
+				for _, tip := range step {
+					tips = append(tips, monitor.Tip(tip))
+				}
+				alerts = tracker.Update(tips)
+			}
+
+			got := []string{}
+			for _, alert := range alerts {
+				got = append(got, fmt.Sprintf("%s %s", alert.Type, alert.Hash))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.Expected) {
+				t.Errorf("Expected alerts %v, got %v", test.Expected, got)
+			}
+		})
+	}
+}
+
+// run monitors the chain tips of a fake node until done returns true for the alerts reported so
+// far, or times out.
+func run(t *testing.T, fake *rpctest.Server, options monitor.Options, done func(alerts []monitor.Alert) bool) []monitor.Alert {
+	fake.Use(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	alerts := []monitor.Alert{}
+	report := func(alert monitor.Alert) {
+		mu.Lock()
+		defer mu.Unlock()
+		alerts = append(alerts, alert)
+	}
+	snapshot := func() []monitor.Alert {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(alerts)
+	}
+
+	result := make(chan error)
+	options.Interval = 10 * time.Millisecond
+	go func() { result <- monitor.ChainTips(ctx, options, report) }()
+
+	for deadline := time.Now().Add(5 * time.Second); !done(snapshot()); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for alerts, got %+v", snapshot())
+		}
+	}
+	cancel()
+
+	if err := <-result; err != nil {
+		t.Fatalf("Failed to monitor chain tips: %v", err)
+	}
+	return snapshot()
+}
+
+// count returns a condition met once n alerts were reported.
+func count(n int) func([]monitor.Alert) bool {
+	return func(alerts []monitor.Alert) bool { return len(alerts) >= n }
+}
+
+func Test_ChainTips(t *testing.T) {
+	fake := rpctest.NewServer()
+	defer fake.Close()
+
+	fake.PlayTips(
+		[]rpctest.ChainTip{tip(100, 0, "active", "a100")},
+		[]rpctest.ChainTip{tip(101, 0, "active", "a101")},
+		[]rpctest.ChainTip{tip(102, 0, "active", "b102"), tip(101, 3, "valid-fork", "a101")},
+		[]rpctest.ChainTip{tip(103, 0, "active", "b103"), tip(101, 3, "valid-fork", "a101"), tip(97, 1, "invalid", "x97")},
+	)
+
+	// Hooks
+	dir := t.TempDir()
+	file := filepath.Join(dir, "alerts.jsonl")
+	env := filepath.Join(dir, "env")
+
+	var mu sync.Mutex
+	posted := []monitor.Alert{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		alert := monitor.Alert{}
+		if err := json.Unmarshal(body, &alert); err != nil || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		posted = append(posted, alert)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	hooks := []monitor.Hook{
+		&monitor.Command{Command: fmt.Sprintf(`echo "$BITCLIENT_ALERT_TYPE $BITCLIENT_ALERT_DEPTH $(cat)" >> %s`, env)},
+		&monitor.Webhook{URL: server.URL},
+		&monitor.File{Path: file},
+	}
+
+	// The file hook runs last, once the others are done
+	alerts := run(t, fake, monitor.Options{Hooks: hooks}, func(alerts []monitor.Alert) bool {
+		data, _ := os.ReadFile(file)
+		return strings.Count(string(data), "\n") == 2
+	})
+	if alerts[0].Type != monitor.AlertReorg || alerts[0].Depth != 3 || alerts[0].Hash != "b102" || alerts[0].Stale != "a101" || alerts[0].ForkPoint != 98 {
+		t.Errorf("Unexpected reorg alert: %+v", alerts[0])
+	}
+	if alerts[1].Type != monitor.AlertInvalid || alerts[1].Hash != "x97" {
+		t.Errorf("Unexpected invalid branch alert: %+v", alerts[1])
+	}
+
+	data, err := os.ReadFile(env)
+	if err != nil {
+		t.Fatalf("Failed to read command output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `reorg 3 {"type":"reorg"`) || !strings.HasPrefix(lines[1], "invalid 1 ") {
+		t.Errorf("Unexpected command hook output: %q", lines)
+	}
+
+	if len(posted) != 2 || posted[0].Type != monitor.AlertReorg || posted[1].Type != monitor.AlertInvalid {
+		t.Errorf("Expected the alerts to be posted, got %+v", posted)
+	}
+
+	data, err = os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read alerts file: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], `"hash":"x97"`) {
+		t.Errorf("Unexpected alerts file: %q", lines)
+	}
+}
+
+func Test_ChainTipsReorg(t *testing.T) {
+	fake := rpctest.NewServer()
+	defer fake.Close()
+
+	// Reorg the fake chain once the monitor started
+	tip := fake.Chain.Tip()
+	go func() {
+		for fake.Calls("getchaintips") < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		fake.Chain.Reorg(2, 3)
+	}()
+
+	alerts := run(t, fake, monitor.Options{Depth: 1}, count(1))
+	if alerts[0].Type != monitor.AlertReorg || alerts[0].Depth != 2 || alerts[0].Stale != tip.Hash || alerts[0].Hash != fake.Chain.Tip().Hash {
+		t.Errorf("Unexpected reorg alert: %+v", alerts[0])
+	}
+}
+
+func Test_ChainTipsErrors(t *testing.T) {
+	fake := rpctest.Use(t)
+
+	fake.PlayTips(
+		[]rpctest.ChainTip{tip(100, 0, "active", "a100")},
+		[]rpctest.ChainTip{tip(100, 0, "active", "a100"), tip(99, 1, "valid-fork", "f99")},
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	alerts := run(t, fake, monitor.Options{Hooks: []monitor.Hook{&monitor.Webhook{URL: server.URL}}}, count(2))
+	if alerts[0].Type != monitor.AlertFork || alerts[1].Type != monitor.AlertError || !strings.Contains(alerts[1].Message, "500") {
+		t.Errorf("Expected a fork alert followed by the webhook's failure, got %+v", alerts)
+	}
+
+	// Failed polls are reported, and polling goes on
+	calls := fake.Calls("getchaintips")
+	go func() {
+		for fake.Calls("getchaintips") < calls+2 {
+			time.Sleep(time.Millisecond)
+		}
+		fake.Fail("getchaintips", -1, "unavailable")
+	}()
+	alerts = run(t, fake, monitor.Options{}, count(2))
+	if alerts[0].Type != monitor.AlertError || !strings.Contains(alerts[0].Message, "unavailable") {
+		t.Errorf("Expected the failed polls to be reported, got %+v", alerts)
+	}
+
+	// Unless they fail when the monitor starts
+	if err := monitor.ChainTips(context.Background(), monitor.Options{}, func(monitor.Alert) {}); err == nil {
+		t.Errorf("Expected the monitor to fail when the tips can't be retrieved at start")
+	}
+}
@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+)
+
+// AlertType defines the kind of event reported by the chain tips monitor.
+type AlertType string
+
+const (
+	// AlertReorg reports a reorg of the active chain deeper than the configured depth.
+	AlertReorg AlertType = "reorg"
+
+	// AlertFork reports a new valid branch off the active chain.
+	AlertFork AlertType = "valid-fork"
+
+	// AlertInvalid reports a new branch the node considers invalid.
+	AlertInvalid AlertType = "invalid"
+
+	// AlertError reports a failure of the monitor or of a hook. It isn't passed to hooks.
+	AlertError AlertType = "error"
+)
+
+// Alert is an event of the block tree, as detected by the chain tips monitor.
+type Alert struct {
+	Type      AlertType `json:"type"`                // Kind of event
+	Time      time.Time `json:"time"`                // Time the event was detected
+	Height    int       `json:"height"`              // Height of the new active tip, or of the branch's tip
+	Hash      string    `json:"hash,omitempty"`      // Hash of the new active tip, or of the branch's tip
+	Depth     int       `json:"depth,omitempty"`     // Blocks disconnected by a reorg, or length of a branch
+	Stale     string    `json:"stale,omitempty"`     // Hash of the previous active tip, for reorgs
+	ForkPoint int       `json:"forkpoint,omitempty"` // Height of the last block shared with the active chain
+	Message   string    `json:"message"`             // Description of the event
+}
+
+// Tip is a tip of the block tree, from 'getchaintips'.
+type Tip struct {
+	Height    int    `json:"height"`
+	Hash      string `json:"hash"`
+	BranchLen int    `json:"branchlen"`
+	Status    string `json:"status"`
+}
+
+// forkPoint returns the height of the last block a tip's branch shares with the active chain.
+func (t Tip) forkPoint() int {
+	return t.Height - t.BranchLen
+}
+
+// Tracker follows the tips of the block tree over time, detecting reorgs and new branches.
+type Tracker struct {
+	depth    int             // Reorgs disconnecting more blocks than depth are alerted
+	active   *Tip            // Active tip of the previous update, nil before the first one
+	branches map[string]bool // Branches already seen, by fork point and status
+}
+
+// NewTracker creates a tracker of the tips of the block tree.
+//
+// Parameters:
+// - depth (int): Reorgs are alerted when they disconnect more than depth blocks (0 for every reorg).
+//
+// Returns:
+// - *Tracker: The tracker, fed the tips of successive 'getchaintips' calls with Update.
+func NewTracker(depth int) *Tracker {
+	return &Tracker{depth: max(depth, 0), branches: map[string]bool{}}
+}
+
+// Update compares the current tips with those of the previous update, returning the alerts of the
+// changes. The first update only records the tips, whose existing branches aren't alerted.
+//
+// A reorg is detected when the previous active tip became the tip of a branch off the new active
+// chain: its branch length is the number of blocks the reorg disconnected. A previous active tip
+// that's no longer listed was extended by the new one, which isn't a reorg.
+//
+// Parameters:
+// - tips ([]Tip): The tips of the block tree, as returned by 'getchaintips'.
+//
+// Returns:
+// - []Alert: The alerts of the changes since the previous update, if any.
+func (t *Tracker) Update(tips []Tip) []Alert {
+	now := time.Now()
+	alerts := []Alert{}
+
+	var active *Tip
+	for i := range tips {
+		if tips[i].Status == "active" {
+			active = &tips[i]
+		}
+	}
+
+	first := t.active == nil
+	for _, tip := range tips {
+		if tip.Status != string(AlertFork) && tip.Status != string(AlertInvalid) {
+			continue
+		}
+
+		// Branches are identified by where they leave the active chain, so that a growing branch
+		// is alerted once
+		key := fmt.Sprintf("%d/%s", tip.forkPoint(), tip.Status)
+		seen := t.branches[key]
+		t.branches[key] = true
+		if first {
+			continue
+		}
+
+		// The previous active tip, left behind by a reorg
+		stale := t.active != nil && tip.Hash == t.active.Hash
+		if stale && active != nil && tip.BranchLen > t.depth {
+			alerts = append(alerts, Alert{
+				Type:      AlertReorg,
+				Time:      now,
+				Height:    active.Height,
+				Hash:      active.Hash,
+				Depth:     tip.BranchLen,
+				Stale:     tip.Hash,
+				ForkPoint: tip.forkPoint(),
+				Message:   fmt.Sprintf("reorg of %d blocks at height %d: tip %s replaced by %s", tip.BranchLen, tip.forkPoint(), tip.Hash, active.Hash),
+			})
+		}
+		// A stale tip is a new valid branch by construction, but an invalidated one is alerted too
+		if seen || stale && tip.Status == string(AlertFork) {
+			continue
+		}
+
+		kind := "valid"
+		if tip.Status == string(AlertInvalid) {
+			kind = "invalid"
+		}
+		alerts = append(alerts, Alert{
+			Type:      AlertType(tip.Status),
+			Time:      now,
+			Height:    tip.Height,
+			Hash:      tip.Hash,
+			Depth:     tip.BranchLen,
+			ForkPoint: tip.forkPoint(),
+			Message:   fmt.Sprintf("new %s branch of %d blocks from height %d, tip %s", kind, tip.BranchLen, tip.forkPoint(), tip.Hash),
+		})
+	}
+
+	if active != nil {
+		current := *active
+		t.active = &current
+	}
+	return alerts
+}
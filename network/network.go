@@ -256,12 +256,12 @@ func ClearBanned() error {
 //     If both are provided, only the valid argument will be used.
 func DisconnectNode(node string) error {
 	params := rpc.Params{}
-	if _, err := strconv.Atoi(node); err != nil {
+	if id, err := strconv.Atoi(node); err != nil {
 		// If 'node' is not a numeric ID, it is treated as an address.
 		params = append(params, node)
 	} else {
-		// Otherwise, it is treated as a node ID.
-		params = rpc.Params{"", node}
+		// Otherwise, it is treated as a node ID, which must be sent as a number.
+		params = rpc.Params{"", id}
 	}
 
 	request := rpc.Request{
@@ -871,6 +871,12 @@ func Health() bool {
 //   - If `absolute` is set to true, the `bantime` should be a UNIX timestamp indicating the absolute
 //     time the ban should end.
 func SetBan(ban Ban) error {
+	return SetBanWith(rpc.Client, ban)
+}
+
+// SetBanWith bans a subnet/IP like SetBan, through the given client rather than the default
+// rpc.Client, e.g. to change the ban list of another node.
+func SetBanWith(client *rpc.RPCClient, ban Ban) error {
 	if ban.Target == "" {
 		return failure.Of("ban's subnet must be provided")
 	}
@@ -889,7 +895,7 @@ func SetBan(ban Ban) error {
 		Params:  params,
 	}
 
-	_, err := rpc.Client.Do(request)
+	_, err := client.Do(request)
 
 	return err
 }
@@ -935,6 +941,12 @@ func SetBan(ban Ban) error {
 // Notes:
 //   - A subnet can be specified in the form of an IP address with a subnet mask (e.g., "192.168.0.0/24").
 func Unban(subnet string) error {
+	return UnbanWith(rpc.Client, subnet)
+}
+
+// UnbanWith unbans a subnet/IP like Unban, through the given client rather than the default
+// rpc.Client, e.g. to change the ban list of another node.
+func UnbanWith(client *rpc.RPCClient, subnet string) error {
 	if subnet == "" {
 		return failure.Of("ban's subnet must be provided")
 	}
@@ -951,7 +963,7 @@ func Unban(subnet string) error {
 		Params:  params,
 	}
 
-	_, err := rpc.Client.Do(request)
+	_, err := client.Do(request)
 
 	return err
 }
@@ -1,58 +1,139 @@
 package network_test
 
 import (
+	"encoding/json"
+	"os"
 	"testing"
 
-	"github.com/avila-r/env"
-
-	"github.com/avila-r/bitclient/logger"
 	"github.com/avila-r/bitclient/network"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
 )
 
-func init() {
-	var envs = []string{
-		"RPC_URL",
-		"RPC_AUTH_TYPE",
-		"RPC_AUTH_LABEL",
-	}
+// server is the fake bitcoind every test runs against.
+var server *rpctest.Server
 
-	for _, key := range envs {
-		if env.Get(key) == "" {
-			logger.Fatalf("Client isn't available. %v variables must be provided", envs)
-		}
-	}
+func TestMain(m *testing.M) {
+	server = rpctest.NewServer()
+	rpc.Client = server.Client()
+
+	code := m.Run()
+
+	server.Close()
+	os.Exit(code)
 }
 
 func Test_ConnectToNode(t *testing.T) {
-	// TODO
+	if err := network.ConnectToNode("192.168.0.6:8333"); err != nil {
+		t.Errorf("Failed to connect to node: %v", err)
+	}
 }
 
 func Test_AddNode(t *testing.T) {
-	// TODO
+	node := "192.168.0.7:8333"
+	defer network.RemoveNode(node)
+
+	if err := network.AddNode(node); err != nil {
+		t.Errorf("Failed to add node: %v", err)
+	}
+	if err := network.AddNode(node); err == nil {
+		t.Errorf("Expected failure when adding a node twice")
+	}
 }
 
 func Test_RemoveNode(t *testing.T) {
-	// TODO
+	node := "192.168.0.8:8333"
+
+	if err := network.RemoveNode(node); err == nil {
+		t.Errorf("Expected failure when removing a node that wasn't added")
+	}
+
+	if err := network.AddNode(node); err != nil {
+		t.Fatalf("Failed to add node: %v", err)
+	}
+	if err := network.RemoveNode(node); err != nil {
+		t.Errorf("Failed to remove node: %v", err)
+	}
 }
 
 func Test_ClearBanned(t *testing.T) {
-	// TODO
+	if err := network.SetBan(network.Ban{Target: "10.0.0.0/8"}); err != nil {
+		t.Fatalf("Failed to set ban: %v", err)
+	}
+	if err := network.ClearBanned(); err != nil {
+		t.Errorf("Failed to clear banned: %v", err)
+	}
+
+	banned, err := network.ListBanned()
+	if err != nil {
+		t.Fatalf("Failed to list banned: %v", err)
+	}
+	if len(*banned) != 0 {
+		t.Errorf("Expected an empty ban list, got %v", *banned)
+	}
 }
 
 func Test_DisconnectNode(t *testing.T) {
-	// TODO
+	server := rpctest.Use(t)
+
+	if err := network.DisconnectNode(server.Peers[0].Address); err != nil {
+		t.Errorf("Failed to disconnect node by address: %v", err)
+	}
+	if err := network.DisconnectNode("3"); err != nil {
+		t.Errorf("Failed to disconnect node by id: %v", err)
+	}
+
+	// Numeric ids are sent as numbers, with an empty address, as bitcoind rejects ids given as strings
+	requests := server.Requests()
+	if params, _ := json.Marshal(requests[len(requests)-1].Params); string(params) != `["",3]` {
+		t.Errorf("Expected disconnectnode params [\"\",3], got %s", params)
+	}
+	if _, err := server.Client().Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: network.MethodDisconnectNode, Params: rpc.Params{"", "2"}}); err == nil {
+		t.Errorf("Expected failure when disconnecting a node by a string id")
+	}
+	if err := network.DisconnectNode("203.0.113.250:8333"); err == nil {
+		t.Errorf("Expected failure when disconnecting an unknown node")
+	}
+
+	if len(server.Peers) != 1 {
+		t.Errorf("Expected a single peer left, got %v", len(server.Peers))
+	}
 }
 
 func Test_InspectAddedNodes(t *testing.T) {
-	// TODO
+	node := "192.168.0.9:8333"
+	if err := network.AddNode(node); err != nil {
+		t.Fatalf("Failed to add node: %v", err)
+	}
+	defer network.RemoveNode(node)
+
+	nodes, err := network.InspectAddedNodes(node)
+	if err != nil {
+		t.Fatalf("Failed to inspect added nodes: %v", err)
+	}
+	if len(*nodes) != 1 || (*nodes)[0]["addednode"] != node {
+		t.Errorf("Expected added node %s, got %v", node, *nodes)
+	}
 }
 
 func Test_GetConnectionCount(t *testing.T) {
-	// TODO
+	response, err := network.GetConnectionCount()
+	if err != nil {
+		t.Fatalf("Failed to get connection count: %v", err)
+	}
+	if string(response.Result) != "3" {
+		t.Errorf("Expected 3 connections, got %s", response.Result)
+	}
 }
 
 func Test_InspectTraffic(t *testing.T) {
-	// TODO
+	traffic, err := network.InspectTraffic()
+	if err != nil {
+		t.Fatalf("Failed to inspect traffic: %v", err)
+	}
+	if _, ok := (*traffic)["totalbytesrecv"]; !ok {
+		t.Errorf("Expected total received bytes, got %v", *traffic)
+	}
 }
 
 func Test_GetNetworkInfo(t *testing.T) {
@@ -62,15 +143,29 @@ func Test_GetNetworkInfo(t *testing.T) {
 }
 
 func Test_FindAddresses(t *testing.T) {
-	// TODO
+	addresses, err := network.FindAddresses(2)
+	if err != nil {
+		t.Fatalf("Failed to find addresses: %v", err)
+	}
+	if len(*addresses) != 2 {
+		t.Errorf("Expected 2 addresses, got %v", len(*addresses))
+	}
 }
 
 func Test_GetPeers(t *testing.T) {
-	// TODO
+	peers, err := network.GetPeers()
+	if err != nil {
+		t.Fatalf("Failed to get peers: %v", err)
+	}
+	if len(*peers) != len(rpctest.DefaultPeers()) {
+		t.Errorf("Expected %v peers, got %v", len(rpctest.DefaultPeers()), len(*peers))
+	}
 }
 
 func Test_ListBanned(t *testing.T) {
-	// TODO
+	if _, err := network.ListBanned(); err != nil {
+		t.Errorf("Failed to list banned: %v", err)
+	}
 }
 
 func Test_Ping(t *testing.T) {
@@ -83,16 +178,61 @@ func Test_Health(t *testing.T) {
 	if ok := network.Health(); !ok {
 		t.Errorf("RPC server isn't uptime")
 	}
+
+	server.Fail(network.MethodPing, rpctest.RPCClientInInitialDownload, "Loading block index...")
+	defer server.Recover(network.MethodPing)
+
+	if ok := network.Health(); ok {
+		t.Errorf("Expected an unhealthy server while ping fails")
+	}
 }
 
 func Test_SetBan(t *testing.T) {
-	// TODO
+	defer network.ClearBanned()
+
+	if err := network.SetBan(network.Ban{Target: "192.168.0.6", Time: 3600}); err != nil {
+		t.Errorf("Failed to set ban: %v", err)
+	}
+	if err := network.SetBan(network.Ban{Target: "not-an-ip"}); err == nil {
+		t.Errorf("Expected failure for an invalid subnet")
+	}
+	if err := network.SetBan(network.Ban{}); err == nil {
+		t.Errorf("Expected failure for an empty subnet")
+	}
+
+	banned, err := network.ListBanned()
+	if err != nil {
+		t.Fatalf("Failed to list banned: %v", err)
+	}
+	if len(*banned) != 1 || (*banned)[0]["address"] != "192.168.0.6/32" {
+		t.Errorf("Expected 192.168.0.6/32 to be banned, got %v", *banned)
+	}
 }
 
-func Test_Unbah(t *testing.T) {
-	// TODO
+func Test_Unban(t *testing.T) {
+	if err := network.SetBan(network.Ban{Target: "192.168.1.0/24"}); err != nil {
+		t.Fatalf("Failed to set ban: %v", err)
+	}
+	if err := network.Unban("192.168.1.0/24"); err != nil {
+		t.Errorf("Failed to unban: %v", err)
+	}
+	if err := network.Unban("192.168.1.0/24"); err == nil {
+		t.Errorf("Expected failure when unbanning a subnet that isn't banned")
+	}
 }
 
 func Test_SetNetworkActive(t *testing.T) {
-	// TODO
+	defer network.SetNetworkActive(true)
+
+	if err := network.SetNetworkActive(false); err != nil {
+		t.Fatalf("Failed to disable network activity: %v", err)
+	}
+
+	info, err := network.GetNetworkInfo()
+	if err != nil {
+		t.Fatalf("Failed to get network info: %v", err)
+	}
+	if (*info)["networkactive"] != false {
+		t.Errorf("Expected network activity to be disabled, got %v", (*info)["networkactive"])
+	}
 }
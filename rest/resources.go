@@ -0,0 +1,293 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// Block retrieves a block, with full transaction details in JSON format.
+//
+// Parameters:
+// - hash (string): The hash of the block.
+// - format (Format): The format of the response.
+//
+// Returns:
+//   - []byte: The block. In JSON format, it matches 'getblock' with verbosity 3 (transactions with
+//     their previous outputs); otherwise it's the block's consensus serialization.
+//   - error: An error if the block is unknown or the request fails.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks get {blockhash} --verbosity 2 --rest
+//
+//   - Using cURL:
+//     $ curl {url}/rest/block/{blockhash}.json
+func (c *Client) Block(hash string, format Format) ([]byte, error) {
+	return c.Get("block/"+hash, format, nil)
+}
+
+// BlockNoTxDetails retrieves a block, listing only the ids of its transactions in JSON format.
+//
+// Parameters:
+// - hash (string): The hash of the block.
+// - format (Format): The format of the response.
+//
+// Returns:
+//   - []byte: The block. In JSON format, it matches 'getblock' with verbosity 1; otherwise it's
+//     the block's consensus serialization, like Block.
+//   - error: An error if the block is unknown or the request fails.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks get {blockhash} --rest
+//
+//   - Using cURL:
+//     $ curl {url}/rest/block/notxdetails/{blockhash}.json
+func (c *Client) BlockNoTxDetails(hash string, format Format) ([]byte, error) {
+	return c.Get("block/notxdetails/"+hash, format, nil)
+}
+
+// Headers retrieves consecutive block headers of the active chain, starting at a block.
+//
+// Parameters:
+// - hash (string): The hash of the first block.
+// - count (int): The number of headers to retrieve (1-2000). Fewer are returned near the tip.
+// - format (Format): The format of the response.
+//
+// Returns:
+//   - []byte: The headers. In JSON format, it's an array of 'getblockheader' objects; otherwise
+//     it's the concatenation of the 80-byte serialized headers.
+//   - error: An error if the block is unknown, the count is out of range or the request fails.
+//
+// Example Usage:
+//
+//   - Using cURL:
+//     $ curl {url}/rest/headers/{blockhash}.json?count=10
+//
+// Notes:
+//   - A block that isn't part of the active chain yields no headers.
+func (c *Client) Headers(hash string, count int, format Format) ([]byte, error) {
+	return c.Get("headers/"+hash, format, url.Values{"count": {strconv.Itoa(count)}})
+}
+
+// BlockHashByHeight retrieves the hash of the active chain's block at a height.
+//
+// Parameters:
+// - height (int): The height of the block.
+// - format (Format): The format of the response.
+//
+// Returns:
+//   - []byte: The hash. In JSON format, it's an object with a 'blockhash' field; in hex format it's
+//     displayed byte-reversed, like RPC results; in binary format it's in internal byte order.
+//   - error: An error if the height is out of range or the request fails.
+//
+// Example Usage:
+//
+//   - Using cURL:
+//     $ curl {url}/rest/blockhashbyheight/{height}.hex
+func (c *Client) BlockHashByHeight(height int, format Format) ([]byte, error) {
+	return c.Get("blockhashbyheight/"+strconv.Itoa(height), format, nil)
+}
+
+// ChainInfo retrieves the state of the active chain, available in JSON format only.
+//
+// Returns:
+// - *rpc.Json: The same object as 'getblockchaininfo'.
+// - error: An error if the request fails.
+//
+// Example Usage:
+//
+//   - Using cURL:
+//     $ curl {url}/rest/chaininfo.json
+func (c *Client) ChainInfo() (*rpc.Json, error) {
+	return c.object("chaininfo")
+}
+
+// MempoolInfo retrieves the state of the mempool, available in JSON format only.
+//
+// Returns:
+// - *rpc.Json: The same object as 'getmempoolinfo'.
+// - error: An error if the request fails.
+//
+// Example Usage:
+//
+//   - Using cURL:
+//     $ curl {url}/rest/mempool/info.json
+func (c *Client) MempoolInfo() (*rpc.Json, error) {
+	return c.object("mempool/info")
+}
+
+// MempoolContents retrieves the transactions in the mempool, available in JSON format only.
+//
+// Returns:
+// - *rpc.Json: The same object as 'getrawmempool' with verbose enabled, indexed by transaction id.
+// - error: An error if the request fails.
+//
+// Example Usage:
+//
+//   - Using cURL:
+//     $ curl {url}/rest/mempool/contents.json
+func (c *Client) MempoolContents() (*rpc.Json, error) {
+	return c.object("mempool/contents")
+}
+
+// UTXOs checks whether transaction outputs are unspent, returning the unspent ones.
+//
+// Parameters:
+// - mempool (bool): Whether to consider the mempool, reporting outputs it spends as spent.
+// - format (Format): The format of the response.
+// - outpoints (...wire.OutPoint): The outputs to check (at most 15).
+//
+// Returns:
+//   - []byte: The chain's tip, a bitmap of the unspent outpoints and their outputs.
+//   - error: An error if no outpoints are given or the request fails.
+//
+// Example Usage:
+//
+//   - Using cURL:
+//     $ curl {url}/rest/getutxos/checkmempool/{txid}-{n}.json
+func (c *Client) UTXOs(mempool bool, format Format, outpoints ...wire.OutPoint) ([]byte, error) {
+	if len(outpoints) == 0 {
+		return nil, failure.Of("at least one outpoint must be provided")
+	}
+
+	path := []string{"getutxos"}
+	if mempool {
+		path = append(path, "checkmempool")
+	}
+	for _, outpoint := range outpoints {
+		path = append(path, fmt.Sprintf("%s-%d", outpoint.Hash, outpoint.Index))
+	}
+
+	return c.Get(strings.Join(path, "/"), format, nil)
+}
+
+// Tx retrieves a transaction, either from the mempool or from the blockchain (requiring -txindex).
+//
+// Parameters:
+// - txid (string): The id of the transaction.
+// - format (Format): The format of the response.
+//
+// Returns:
+//   - []byte: The transaction. In JSON format, it matches 'getrawtransaction' with verbose enabled;
+//     otherwise it's the transaction's consensus serialization, with witness data.
+//   - error: An error if the transaction isn't found or the request fails.
+//
+// Example Usage:
+//
+//   - Using cURL:
+//     $ curl {url}/rest/tx/{txid}.hex
+func (c *Client) Tx(txid string, format Format) ([]byte, error) {
+	return c.Get("tx/"+txid, format, nil)
+}
+
+// UTXOSet is the JSON response of '/rest/getutxos/'.
+type UTXOSet struct {
+	ChainHeight  int    `json:"chainHeight"`  // Height of the chain's tip
+	ChainTipHash string `json:"chaintipHash"` // Hash of the chain's tip
+	Bitmap       string `json:"bitmap"`       // One character per outpoint: '1' if unspent, '0' otherwise
+	UTXOs        []UTXO `json:"utxos"`        // Unspent outputs, in the order of the outpoints
+}
+
+// UTXO is an unspent output, as returned by '/rest/getutxos/'.
+type UTXO struct {
+	Height       int      `json:"height"`       // Height of the block holding the output
+	Value        float64  `json:"value"`        // Amount, in BTC
+	ScriptPubKey rpc.Json `json:"scriptPubKey"` // Locking script (asm, desc, hex and type)
+}
+
+// Unspent reports whether the i-th outpoint of the request is unspent.
+func (s *UTXOSet) Unspent(i int) bool {
+	return i >= 0 && i < len(s.Bitmap) && s.Bitmap[i] == '1'
+}
+
+// ReadBlock retrieves a block in binary format, decoding it.
+func (c *Client) ReadBlock(hash string) (*wire.Block, error) {
+	data, err := c.Block(hash, FormatBinary)
+	if err != nil {
+		return nil, err
+	}
+	return wire.DecodeBlock(data)
+}
+
+// ReadHeaders retrieves consecutive block headers in binary format, decoding them.
+func (c *Client) ReadHeaders(hash string, count int) ([]*wire.Header, error) {
+	data, err := c.Headers(hash, count, FormatBinary)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%wire.HeaderSize != 0 {
+		return nil, failure.Of("invalid headers response: %d bytes isn't a multiple of %d", len(data), wire.HeaderSize)
+	}
+
+	headers := make([]*wire.Header, 0, len(data)/wire.HeaderSize)
+	for i := 0; i < len(data); i += wire.HeaderSize {
+		header, err := wire.DecodeHeader(data[i : i+wire.HeaderSize])
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+// ReadBlockHash retrieves the hash of the active chain's block at a height in binary format.
+func (c *Client) ReadBlockHash(height int) (wire.Hash, error) {
+	data, err := c.BlockHashByHeight(height, FormatBinary)
+	if err != nil {
+		return wire.Hash{}, err
+	}
+	if len(data) != len(wire.Hash{}) {
+		return wire.Hash{}, failure.Of("invalid block hash response: expected 32 bytes, got %d", len(data))
+	}
+
+	hash := wire.Hash{}
+	copy(hash[:], data)
+	return hash, nil
+}
+
+// ReadTx retrieves a transaction in binary format, decoding it.
+func (c *Client) ReadTx(txid string) (*wire.Tx, error) {
+	data, err := c.Tx(txid, FormatBinary)
+	if err != nil {
+		return nil, err
+	}
+	return wire.DecodeTx(data)
+}
+
+// ReadUTXOs checks whether transaction outputs are unspent in JSON format, decoding the response.
+func (c *Client) ReadUTXOs(mempool bool, outpoints ...wire.OutPoint) (*UTXOSet, error) {
+	data, err := c.UTXOs(mempool, FormatJSON, outpoints...)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &UTXOSet{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, failure.Of("failed to parse utxos: %v", err.Error())
+	}
+	return set, nil
+}
+
+// object retrieves a JSON-only resource.
+func (c *Client) object(path string) (*rpc.Json, error) {
+	data, err := c.Get(path, FormatJSON, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := rpc.Json{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, failure.Of("failed to process result: %v", err.Error())
+	}
+	return &result, nil
+}
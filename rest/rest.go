@@ -0,0 +1,150 @@
+// Package rest reads from Bitcoin Core's REST interface, enabled on bitcoind through -rest.
+// REST requests are unauthenticated and reach the same port as JSON-RPC, serving blocks,
+// headers, transactions and UTXOs in JSON, hex or binary format, which is much faster than
+// JSON-RPC for bulk reads.
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// Format defines the format of a REST response, selected through the extension of the requested path.
+type Format string
+
+const (
+	// FormatJSON returns the same JSON objects as the matching RPC methods.
+	FormatJSON Format = "json"
+
+	// FormatHex returns the hex encoding of the consensus serialization, followed by a newline.
+	FormatHex Format = "hex"
+
+	// FormatBinary returns the consensus serialization, decodable through the wire package.
+	FormatBinary Format = "bin"
+)
+
+// FormatFrom converts a string (json, hex or bin) to the corresponding Format.
+func FormatFrom(s string) (Format, error) {
+	switch format := Format(strings.ToLower(s)); format {
+	case FormatJSON, FormatHex, FormatBinary:
+		return format, nil
+	}
+	return "", failure.Of("invalid REST format '%s', valid formats are json, hex and bin", s)
+}
+
+// Client is a client of a node's REST interface.
+type Client struct {
+	URL    string       // Base URL of the node (e.g. http://127.0.0.1:8332)
+	client *http.Client // HTTP client used to send requests
+}
+
+// Option configures the optional settings of a Client created through New.
+type Option func(*Client)
+
+// WithTransport replaces the default transport used to send requests.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.client.Transport = transport
+	}
+}
+
+// New creates a client of the REST interface reachable at uri. Only its scheme and host are used,
+// since REST paths are always rooted at '/rest/'.
+func New(uri string, options ...Option) (*Client, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || !strings.HasPrefix(parsed.Scheme, "http") || parsed.Host == "" {
+		return nil, failure.Of("invalid URL: must be a valid HTTP/HTTPS URL")
+	}
+
+	c := &Client{
+		URL:    parsed.Scheme + "://" + parsed.Host,
+		client: &http.Client{},
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c, nil
+}
+
+// FromRPC creates a client of the REST interface of the node an RPC client is connected to,
+// reaching it through the same transport (TLS, proxy and unix socket settings). Its middleware
+// (e.g. cassettes) isn't applied, since it handles JSON-RPC requests only.
+func FromRPC(client *rpc.RPCClient) (*Client, error) {
+	if client == nil {
+		return nil, failure.Of("rpc client isn't available")
+	}
+
+	uri := client.URL
+	if parsed, err := url.Parse(uri); err == nil && parsed.Scheme == "unix" {
+		uri = "http://localhost"
+	}
+
+	return New(uri, WithTransport(client.Transport()))
+}
+
+// Get requests a REST resource in the given format, returning the response body.
+//
+// Parameters:
+// - path (string): Path of the resource under '/rest/', without extension (e.g. "chaininfo").
+// - format (Format): Format of the response, appended to the path as extension.
+// - query (url.Values): Query parameters, if any (e.g. count for headers).
+//
+// Returns:
+// - []byte: The response body. Hex responses are returned without their trailing newline.
+// - error: An error if the request fails or the node doesn't answer with status 200.
+func (c *Client) Get(path string, format Format, query url.Values) ([]byte, error) {
+	return c.GetContext(context.Background(), path, format, query)
+}
+
+// GetContext requests a REST resource like Get, aborting the request when ctx is done.
+func (c *Client) GetContext(ctx context.Context, path string, format Format, query url.Values) ([]byte, error) {
+	uri := fmt.Sprintf("%s/rest/%s.%s", c.URL, strings.Trim(path, "/"), format)
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, failure.Of("failed to set up http request: %v", err.Error())
+	}
+
+	logger.Debugf("GET %s", uri)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, failure.Of("failed to send REST request: %v", err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, failure.Of("failed to read REST response: %v", err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(string(body))
+		if resp.StatusCode == http.StatusNotFound && message == "" {
+			return nil, failure.Of("REST interface isn't available: bitcoind must be started with -rest")
+		}
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		return nil, failure.Of("REST request failed with status %d: %s", resp.StatusCode, message)
+	}
+
+	if format == FormatHex {
+		body = []byte(strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,266 @@
+package rest_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/avila-r/bitclient/rest"
+	"github.com/avila-r/bitclient/rpctest"
+	"github.com/avila-r/bitclient/wire"
+)
+
+var (
+	// server is the fake bitcoind every test runs against.
+	server *rpctest.Server
+
+	// client is connected to the server's REST interface.
+	client *rest.Client
+)
+
+func TestMain(m *testing.M) {
+	server = rpctest.NewServer()
+
+	var err error
+	if client, err = rest.FromRPC(server.Client()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create rest client: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	server.Close()
+	os.Exit(code)
+}
+
+func Test_FormatFrom(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected rest.Format
+		Valid    bool
+	}{
+		{Input: "json", Expected: rest.FormatJSON, Valid: true},
+		{Input: "HEX", Expected: rest.FormatHex, Valid: true},
+		{Input: "bin", Expected: rest.FormatBinary, Valid: true},
+		{Input: "xml", Valid: false},
+		{Input: "", Valid: false},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			format, err := rest.FormatFrom(c.Input)
+			if c.Valid && (err != nil || format != c.Expected) {
+				t.Errorf("Expected %v, got %v (%v)", c.Expected, format, err)
+			}
+			if !c.Valid && err == nil {
+				t.Errorf("Expected '%s' to be rejected", c.Input)
+			}
+		})
+	}
+}
+
+func Test_Block(t *testing.T) {
+	expected := server.Chain.Block(100)
+
+	block, err := client.ReadBlock(expected.Hash)
+	if err != nil {
+		t.Fatalf("Failed to read block: %v", err)
+	}
+	if block.Header.Hash().String() != expected.Hash {
+		t.Errorf("Expected block %s, got %s", expected.Hash, block.Header.Hash())
+	}
+
+	data, err := client.Block(expected.Hash, rest.FormatHex)
+	if err != nil {
+		t.Fatalf("Failed to get block in hex: %v", err)
+	}
+	if string(data) != hex.EncodeToString(expected.Serialize()) {
+		t.Errorf("Hex block doesn't match the serialized block")
+	}
+
+	cases := []struct {
+		Get       func(string, rest.Format) ([]byte, error)
+		TxDetails bool
+	}{
+		{Get: client.Block, TxDetails: true},
+		{Get: client.BlockNoTxDetails, TxDetails: false},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			data, err := c.Get(expected.Hash, rest.FormatJSON)
+			if err != nil {
+				t.Fatalf("Failed to get block in json: %v", err)
+			}
+
+			result := struct {
+				Hash   string            `json:"hash"`
+				Height int               `json:"height"`
+				Tx     []json.RawMessage `json:"tx"`
+			}{}
+			if err := json.Unmarshal(data, &result); err != nil {
+				t.Fatalf("Failed to parse block: %v", err)
+			}
+			if result.Hash != expected.Hash || result.Height != 100 || len(result.Tx) != 1 {
+				t.Errorf("Unexpected block: %s", data)
+			}
+			if details := strings.HasPrefix(string(result.Tx[0]), "{"); details != c.TxDetails {
+				t.Errorf("Expected transaction details to be %v, got %s", c.TxDetails, result.Tx[0])
+			}
+		})
+	}
+}
+
+func Test_Headers(t *testing.T) {
+	cases := []struct {
+		Height   int
+		Count    int
+		Expected int
+	}{
+		{Height: 10, Count: 1, Expected: 1},
+		{Height: 10, Count: 20, Expected: 20},
+		{Height: 195, Count: 20, Expected: 6}, // Stops at the tip
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			headers, err := client.ReadHeaders(server.Chain.Block(c.Height).Hash, c.Count)
+			if err != nil {
+				t.Fatalf("Failed to read headers: %v", err)
+			}
+			if len(headers) != c.Expected {
+				t.Fatalf("Expected %v headers, got %v", c.Expected, len(headers))
+			}
+
+			for j, header := range headers {
+				if expected := server.Chain.Block(c.Height + j).Hash; header.Hash().String() != expected {
+					t.Errorf("Header %v: expected %s, got %s", j, expected, header.Hash())
+				}
+			}
+
+			data, err := client.Headers(server.Chain.Block(c.Height).Hash, c.Count, rest.FormatJSON)
+			if err != nil {
+				t.Fatalf("Failed to get headers in json: %v", err)
+			}
+			result := []map[string]any{}
+			if err := json.Unmarshal(data, &result); err != nil || len(result) != c.Expected {
+				t.Errorf("Expected %v json headers, got %s", c.Expected, data)
+			}
+		})
+	}
+
+	if _, err := client.Headers(server.Chain.Block(10).Hash, 2001, rest.FormatJSON); err == nil {
+		t.Errorf("Expected a count above 2000 to be rejected")
+	}
+}
+
+func Test_BlockHashByHeight(t *testing.T) {
+	expected := server.Chain.Block(42).Hash
+
+	hash, err := client.ReadBlockHash(42)
+	if err != nil {
+		t.Fatalf("Failed to read block hash: %v", err)
+	}
+	if hash.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, hash)
+	}
+
+	data, err := client.BlockHashByHeight(42, rest.FormatHex)
+	if err != nil || string(data) != expected {
+		t.Errorf("Expected hex %s, got %s (%v)", expected, data, err)
+	}
+
+	data, err = client.BlockHashByHeight(42, rest.FormatJSON)
+	if err != nil || !strings.Contains(string(data), expected) {
+		t.Errorf("Expected json with %s, got %s (%v)", expected, data, err)
+	}
+
+	if _, err := client.BlockHashByHeight(server.Chain.Height()+1, rest.FormatHex); err == nil {
+		t.Errorf("Expected a height above the tip to fail")
+	}
+}
+
+func Test_ChainInfo(t *testing.T) {
+	info, err := client.ChainInfo()
+	if err != nil {
+		t.Fatalf("Failed to get chain info: %v", err)
+	}
+	if (*info)["bestblockhash"] != server.Chain.Tip().Hash {
+		t.Errorf("Expected best block %s, got %v", server.Chain.Tip().Hash, (*info)["bestblockhash"])
+	}
+}
+
+func Test_Mempool(t *testing.T) {
+	if _, err := client.MempoolInfo(); err != nil {
+		t.Errorf("Failed to get mempool info: %v", err)
+	}
+	if _, err := client.MempoolContents(); err != nil {
+		t.Errorf("Failed to get mempool contents: %v", err)
+	}
+}
+
+func Test_UTXOs(t *testing.T) {
+	txid, _ := wire.HashFrom(server.Chain.Block(50).TxIDs[0])
+	unknown := wire.Hash{0x01}
+
+	set, err := client.ReadUTXOs(true, wire.OutPoint{Hash: txid, Index: 0}, wire.OutPoint{Hash: txid, Index: 1}, wire.OutPoint{Hash: unknown})
+	if err != nil {
+		t.Fatalf("Failed to read utxos: %v", err)
+	}
+
+	if set.Bitmap != "100" || !set.Unspent(0) || set.Unspent(1) || set.Unspent(2) {
+		t.Errorf("Unexpected bitmap: %s", set.Bitmap)
+	}
+	if len(set.UTXOs) != 1 || set.UTXOs[0].Height != 50 || set.UTXOs[0].Value != 50 {
+		t.Errorf("Unexpected utxos: %+v", set.UTXOs)
+	}
+	if set.ChainHeight != server.Chain.Height() || set.ChainTipHash != server.Chain.Tip().Hash {
+		t.Errorf("Unexpected chain tip: %v %s", set.ChainHeight, set.ChainTipHash)
+	}
+
+	if _, err := client.UTXOs(false, rest.FormatBinary); err == nil {
+		t.Errorf("Expected a request without outpoints to fail")
+	}
+}
+
+func Test_Tx(t *testing.T) {
+	block := server.Chain.Block(7)
+
+	tx, err := client.ReadTx(block.TxIDs[0])
+	if err != nil {
+		t.Fatalf("Failed to read transaction: %v", err)
+	}
+	if tx.TxID().String() != block.TxIDs[0] || !tx.IsCoinbase() {
+		t.Errorf("Expected coinbase %s, got %s", block.TxIDs[0], tx.TxID())
+	}
+
+	data, err := client.Tx(block.TxIDs[0], rest.FormatJSON)
+	if err != nil || !strings.Contains(string(data), block.Hash) {
+		t.Errorf("Expected json with block hash %s, got %s (%v)", block.Hash, data, err)
+	}
+
+	if _, err := client.Tx(strings.Repeat("ab", 32), rest.FormatHex); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected unknown transaction to be not found, got %v", err)
+	}
+}
+
+func Test_Unavailable(t *testing.T) {
+	restless := rpctest.NewServer(rpctest.WithoutREST())
+	defer restless.Close()
+
+	c, err := rest.New(restless.URL)
+	if err != nil {
+		t.Fatalf("Failed to create rest client: %v", err)
+	}
+
+	if _, err := c.ChainInfo(); err == nil || !strings.Contains(err.Error(), "-rest") {
+		t.Errorf("Expected a hint about -rest, got %v", err)
+	}
+
+	if _, err := rest.New("unix:///tmp/bitcoind.sock"); err == nil {
+		t.Errorf("Expected a unix URL to be rejected without an RPC client")
+	}
+}
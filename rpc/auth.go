@@ -8,9 +8,15 @@ import (
 )
 
 // Authentication represents the authentication details used for HTTP requests.
+//
+// The label may also reference a secret kept elsewhere (see ResolveSecret), such as
+// "file:/run/secrets/rpc", "env:RPC_SECRET" or "cmd:pass show btc/rpc".
 type Authentication struct {
-	Type  AuthenticationType // The type of authentication (API key or credentials)
+	Type  AuthenticationType // The type of authentication (API key, credentials or URL path)
 	Label string             // The authentication label (e.g., API key or username:password)
+
+	Header string // Header carrying the API key (default: Authorization)
+	Scheme string // Scheme prefixed to the API key (default: Bearer, only when Header isn't set)
 }
 
 // AuthenticationType defines the type of authentication used.
@@ -22,12 +28,18 @@ const (
 	AuthenticationTypeKey AuthenticationType = "api-key"
 	// AuthenticationTypeCredentials represents a username and password authentication type.
 	AuthenticationTypeCredentials AuthenticationType = "user:password"
+	// AuthenticationTypePath represents an API key embedded in the URL path, replacing a '{key}'
+	// placeholder (e.g. https://provider.example/{key}/mainnet) or appended as the last segment.
+	AuthenticationTypePath AuthenticationType = "url-path"
 )
 
+// KeyPlaceholder is the placeholder replaced by the API key in URLs when using AuthenticationTypePath.
+const KeyPlaceholder = "{key}"
+
 // Validate checks whether the authentication type and label are valid.
 func (a *Authentication) Validate() error {
-	// Check if the authentication type is valid (either API key or credentials).
-	if a.Type != AuthenticationTypeCredentials && a.Type != AuthenticationTypeKey {
+	// Check if the authentication type is valid (API key, credentials or URL path).
+	if a.Type != AuthenticationTypeCredentials && a.Type != AuthenticationTypeKey && a.Type != AuthenticationTypePath {
 		return failure.Of("invalid authentication type")
 	}
 
@@ -79,7 +91,27 @@ func (a *Authentication) Setup(req *http.Request) error {
 	// Based on the authentication type, set the appropriate headers for the request.
 	switch a.Type {
 	case AuthenticationTypeKey:
-		req.Header.Set(AuthorizationHeaderLabel, Bearer(a.Label)) // Set the API key in the Authorization header.
+		header, value := a.Header, a.Label
+		if header == "" {
+			// Default to the Authorization header, using the Bearer scheme unless told otherwise.
+			header = AuthorizationHeaderLabel
+			if a.Scheme == "" {
+				value = Bearer(a.Label)
+			}
+		}
+		if a.Scheme != "" {
+			value = a.Scheme + " " + a.Label
+		}
+		req.Header.Set(header, value) // Set the API key in the configured header.
+	case AuthenticationTypePath:
+		// Embed the API key in the URL path, replacing the placeholder when there's one.
+		path := req.URL.Path
+		if strings.Contains(path, KeyPlaceholder) {
+			path = strings.ReplaceAll(path, KeyPlaceholder, a.Label)
+		} else {
+			path = strings.TrimSuffix(path, "/") + "/" + a.Label
+		}
+		req.URL.Path, req.URL.RawPath = path, ""
 	case AuthenticationTypeCredentials:
 		// Get the username and password for basic authentication.
 		username, password := a.GetCredentials()
@@ -90,3 +122,15 @@ func (a *Authentication) Setup(req *http.Request) error {
 
 	return nil
 }
+
+// Resolve replaces a label that references a secret (see ResolveSecret) with the secret itself.
+func (a *Authentication) Resolve() error {
+	label, err := ResolveSecret(a.Label)
+	if err != nil {
+		return err
+	}
+
+	a.Label = label
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package rpc_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+func Test_AuthenticationSetup(t *testing.T) {
+	cases := []struct {
+		Authentication rpc.Authentication
+		Header         string
+		Value          string
+	}{
+		{
+			Authentication: rpc.Authentication{Type: rpc.AuthenticationTypeKey, Label: "key"},
+			Header:         "Authorization",
+			Value:          "Bearer key",
+		},
+		{
+			Authentication: rpc.Authentication{Type: rpc.AuthenticationTypeKey, Label: "key", Scheme: "Token"},
+			Header:         "Authorization",
+			Value:          "Token key",
+		},
+		{
+			Authentication: rpc.Authentication{Type: rpc.AuthenticationTypeKey, Label: "key", Header: "x-api-key"},
+			Header:         "X-Api-Key",
+			Value:          "key",
+		},
+		{
+			Authentication: rpc.Authentication{Type: rpc.AuthenticationTypeKey, Label: "key", Header: "x-api-key", Scheme: "Bearer"},
+			Header:         "X-Api-Key",
+			Value:          "Bearer key",
+		},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "http://localhost:8332", nil)
+			if err := test.Authentication.Setup(req); err != nil {
+				t.Fatalf("Test case %d failed: %v", i, err)
+			}
+			if value := req.Header.Get(test.Header); value != test.Value {
+				t.Errorf("Test case %d failed: expected header %s to be '%s', got '%s'", i, test.Header, test.Value, value)
+			}
+		})
+	}
+}
+
+func Test_AuthenticationPath(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		pong(w, r)
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		URL  string
+		Path string
+	}{
+		{URL: server.URL + "/{key}/mainnet", Path: "/secret/mainnet"},
+		{URL: server.URL + "/v1/", Path: "/v1/secret"},
+		{URL: server.URL, Path: "/secret"},
+	}
+
+	for i, test := range cases {
+		authentication := rpc.Authentication{Type: rpc.AuthenticationTypePath, Label: "secret"}
+		client, err := rpc.New(test.URL, authentication)
+		if err != nil {
+			t.Fatalf("Test case %d failed: unable to create client: %v", i, err)
+		}
+		if _, err := client.Do(ping); err != nil {
+			t.Fatalf("Test case %d failed: %v", i, err)
+		}
+		if path != test.Path {
+			t.Errorf("Test case %d failed: expected path %s, got %s", i, test.Path, path)
+		}
+	}
+}
+
+func Test_AuthenticationPathError(t *testing.T) {
+	// Find a port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	listener.Close()
+
+	authentication := rpc.Authentication{Type: rpc.AuthenticationTypePath, Label: "s3cr3t-path"}
+	client, err := rpc.New("http://"+listener.Addr().String()+"/{key}/mainnet", authentication)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.Do(ping)
+	if err == nil {
+		t.Fatalf("Expected an error from a closed port")
+	}
+	if strings.Contains(err.Error(), "s3cr3t-path") || !strings.Contains(err.Error(), "[REDACTED]") {
+		t.Errorf("Expected the key to be redacted from the error, got %v", err)
+	}
+}
+
+func Test_ResolveSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(file, []byte("from-file\nmetadata: ignored\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+	t.Setenv("BITCLIENT_TEST_SECRET", "from-env")
+
+	cases := []struct {
+		Value         string
+		Secret        string
+		ExpectSuccess bool
+	}{
+		{Value: "plain", Secret: "plain", ExpectSuccess: true},
+		{Value: "file:" + file, Secret: "from-file", ExpectSuccess: true},
+		{Value: "env:BITCLIENT_TEST_SECRET", Secret: "from-env", ExpectSuccess: true},
+		{Value: "cmd:echo from-command", Secret: "from-command", ExpectSuccess: true},
+		{Value: "env:BITCLIENT_TEST_MISSING", ExpectSuccess: false},
+		{Value: "file:" + file + ".missing", ExpectSuccess: false},
+		{Value: "cmd:exit 1", ExpectSuccess: false},
+	}
+
+	for i, test := range cases {
+		secret, err := rpc.ResolveSecret(test.Value)
+		if test.ExpectSuccess && err != nil {
+			t.Errorf("Test case %d failed: expected success but got error: %v", i, err)
+		}
+		if !test.ExpectSuccess && err == nil {
+			t.Errorf("Test case %d failed: expected failure but got '%s'", i, secret)
+		}
+		if secret != test.Secret {
+			t.Errorf("Test case %d failed: expected secret '%s', got '%s'", i, test.Secret, secret)
+		}
+	}
+
+	// Secrets are resolved lazily, so that creating a client never runs a command
+	marker := filepath.Join(t.TempDir(), "resolved")
+	client, err := rpc.New("http://localhost:8332", rpc.Authentication{
+		Type:  rpc.AuthenticationTypeCredentials,
+		Label: "cmd:touch " + marker + " && echo user:password",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client with a secret reference: %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil || !rpc.IsSecretReference(client.Authentication.Label) {
+		t.Errorf("Expected the label not to be resolved when creating the client, got '%s'", client.Authentication.Label)
+	}
+	if err := client.Resolve(); err != nil {
+		t.Fatalf("Failed to resolve the label: %v", err)
+	}
+	if client.Authentication.Label != "user:password" {
+		t.Errorf("Expected the label to be resolved, got '%s'", client.Authentication.Label)
+	}
+
+	// Failed resolutions fail requests
+	client, err = rpc.New("http://localhost:8332", rpc.Authentication{Type: rpc.AuthenticationTypeKey, Label: "cmd:exit 1"})
+	if err != nil {
+		t.Fatalf("Failed to create client with a secret reference: %v", err)
+	}
+	if _, err := client.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: "getblockcount", Params: rpc.NoParams}); err == nil {
+		t.Errorf("Expected the request to fail when the secret can't be resolved")
+	}
+}
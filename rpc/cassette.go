@@ -0,0 +1,243 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Cassette is a JSONL file of RPC interactions. In record mode, every request sent through
+// its middleware and the response received are appended to the file. In replay mode, no
+// request reaches the server: responses are served from the file, matching requests by
+// method and params.
+type Cassette struct {
+	Path string // Path to the cassette file
+
+	mu           sync.Mutex
+	file         *os.File                  // File being recorded, nil in replay mode
+	interactions map[string][]*Interaction // Recorded interactions, indexed by method and params
+	served       map[string]int            // Number of interactions served for each method and params
+}
+
+// Interaction is a recorded request/response pair, stored as a line of a cassette.
+type Interaction struct {
+	Method   Method          `json:"method"`             // Method of the request
+	Params   json.RawMessage `json:"params"`             // Params of the request
+	Status   int             `json:"status"`             // HTTP status code of the response
+	Response json.RawMessage `json:"response,omitempty"` // Response body, when it's valid JSON
+	Body     string          `json:"body,omitempty"`     // Response body otherwise (e.g. for 401 responses)
+}
+
+// Record creates (or truncates) a cassette file, recording every interaction of the clients using it.
+func Record(path string) (*Cassette, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, failure.Of("failed to create cassette: %v", err.Error())
+	}
+
+	return &Cassette{Path: path, file: file}, nil
+}
+
+// Replay loads a cassette file, serving its recorded responses to the clients using it.
+func Replay(path string) (*Cassette, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, failure.Of("failed to open cassette: %v", err.Error())
+	}
+	defer file.Close()
+
+	c := &Cassette{
+		Path:         path,
+		interactions: map[string][]*Interaction{},
+		served:       map[string]int{},
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024) // Lines may hold whole blocks
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		interaction := &Interaction{}
+		if err := json.Unmarshal(scanner.Bytes(), interaction); err != nil {
+			return nil, failure.Of("invalid cassette interaction at %s:%d: %v", path, line, err.Error())
+		}
+
+		key := key(interaction.Method, interaction.Params)
+		c.interactions[key] = append(c.interactions[key], interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, failure.Of("failed to read cassette: %v", err.Error())
+	}
+
+	return c, nil
+}
+
+// IsRecording reports whether the cassette records interactions, rather than replaying them.
+func (c *Cassette) IsRecording() bool {
+	return c.file != nil
+}
+
+// Close closes the file being recorded. It's a no-op in replay mode.
+func (c *Cassette) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+// Middleware returns the middleware that records interactions to, or replays them from, the cassette.
+// In replay mode, the next transport is never called.
+func (c *Cassette) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if !c.IsRecording() {
+			return c
+		}
+
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			method, params, err := read(req)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, failure.Of("failed to read response to record: %v", err.Error())
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+
+			interaction := Interaction{Method: method, Params: params, Status: resp.StatusCode}
+			if json.Valid(body) {
+				interaction.Response = bytes.TrimSpace(body)
+			} else {
+				interaction.Body = string(body)
+			}
+
+			if err := c.write(interaction); err != nil {
+				return nil, err
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// RoundTrip serves a request from the recorded interactions. Identical requests are served
+// in recording order; once they're exhausted, the last recorded response is served again.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	method, params, err := read(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	key := key(method, params)
+	recorded := c.interactions[key]
+	if len(recorded) == 0 {
+		c.mu.Unlock()
+		return nil, failure.Of("no interaction recorded in %s for %s %s", c.Path, method, params)
+	}
+	interaction := recorded[min(c.served[key], len(recorded)-1)]
+	c.served[key]++
+	c.mu.Unlock()
+
+	body := []byte(interaction.Body)
+	if interaction.Response != nil {
+		body = interaction.Response
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(interaction.Status),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{ContentTypeHeaderLabel: []string{string(ContentTypeApplicationJson)}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Client returns a client that never reaches a server, serving every request from the cassette.
+// It's meant for replaying cassettes when no connection settings are available.
+func (c *Cassette) Client() (*RPCClient, error) {
+	if c.IsRecording() {
+		return nil, failure.Of("a recording cassette can't serve requests on its own")
+	}
+
+	authentication := Authentication{Type: AuthenticationTypeCredentials, Label: "cassette:replay"}
+	return New("http://cassette.invalid/", authentication, WithTransport(c))
+}
+
+// WithCassette records the client's interactions to, or replays them from, a cassette.
+func WithCassette(c *Cassette) Option {
+	return WithMiddleware(c.Middleware())
+}
+
+// write appends an interaction to the cassette file.
+func (c *Cassette) write(interaction Interaction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return failure.Of("failed to serialize interaction: %v", err.Error())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return failure.Of("failed to record interaction: %v", err.Error())
+	}
+	return nil
+}
+
+// read extracts the method and params of a request, restoring its body afterwards.
+func read(req *http.Request) (Method, json.RawMessage, error) {
+	if req.Body == nil {
+		return "", nil, failure.Of("request has no body")
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", nil, failure.Of("failed to read request: %v", err.Error())
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	request := struct {
+		Method Method          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return "", nil, failure.Of("failed to parse request: %v", err.Error())
+	}
+
+	return request.Method, request.Params, nil
+}
+
+// key builds the key matching a request against recorded interactions. Params are
+// normalized, so that formatting differences (e.g. whitespace) don't prevent a match.
+func key(method Method, params json.RawMessage) string {
+	var value any
+	if err := json.Unmarshal(params, &value); err != nil || value == nil {
+		value = []any{}
+	}
+	normalized, _ := json.Marshal(value)
+	return string(method) + " " + string(normalized)
+}
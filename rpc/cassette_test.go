@@ -0,0 +1,78 @@
+package rpc_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+func Test_Cassette(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	requests := []rpc.Request{
+		{ID: rpc.Identifier, Version: rpc.Version2, Method: "getblockcount", Params: rpc.NoParams},
+		{ID: rpc.Identifier, Version: rpc.Version2, Method: "getblockhash", Params: rpc.Params{10}},
+		{ID: rpc.Identifier, Version: rpc.Version2, Method: "getblockhash", Params: rpc.Params{20}},
+	}
+
+	// Record a session against the fake server
+	recorder, err := rpc.Record(path)
+	if err != nil {
+		t.Fatalf("Failed to create cassette: %v", err)
+	}
+
+	client, err := rpc.New(server.URL, rpctest.Credentials, rpc.WithCassette(recorder))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	recorded := []string{}
+	for _, request := range requests {
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatalf("Failed to record %s: %v", request.Method, err)
+		}
+		recorded = append(recorded, string(response.Result))
+	}
+	recorder.Close()
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != len(requests) {
+		t.Errorf("Expected %v recorded interactions, got %v", len(requests), lines)
+	}
+
+	// Replay it without reaching any server, in a different order
+	player, err := rpc.Replay(path)
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+	offline, err := player.Client()
+	if err != nil {
+		t.Fatalf("Failed to create replay client: %v", err)
+	}
+
+	for _, i := range []int{2, 0, 1, 1} {
+		response, err := offline.Do(requests[i])
+		if err != nil {
+			t.Fatalf("Failed to replay %s: %v", requests[i].Method, err)
+		}
+		if string(response.Result) != recorded[i] {
+			t.Errorf("Expected replayed result %s, got %s", recorded[i], response.Result)
+		}
+	}
+
+	if _, err := offline.Do(rpc.Request{ID: rpc.Identifier, Version: rpc.Version2, Method: "getblockhash", Params: rpc.Params{30}}); err == nil {
+		t.Errorf("Expected failure for a request missing from the cassette")
+	}
+
+	if calls := server.Calls("getblockhash"); calls != 2 {
+		t.Errorf("Expected the server to be reached only while recording, got %v calls", calls)
+	}
+}
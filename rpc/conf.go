@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/failure"
+)
+
+// FromBitcoinConf creates a new RPCClient from a parsed bitcoin.conf file.
+//
+// The URL is derived from the 'rpcconnect' and 'rpcport' options of the selected chain,
+// and the credentials from 'rpcuser'/'rpcpassword' or, when absent, the node's cookie file.
+//
+// Example:
+//
+//	conf, err := config.ReadBitcoinConf("~/.bitcoin", "", "signet")
+//	if err != nil {
+//	    // Handle error
+//	}
+//	client, err := rpc.FromBitcoinConf(conf)
+func FromBitcoinConf(conf *config.BitcoinConf) (*RPCClient, error) {
+	if conf == nil {
+		return nil, failure.Of("bitcoin.conf must be provided")
+	}
+
+	url, err := conf.RPCURL()
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := conf.RPCCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(url, Authentication{
+		Type:  AuthenticationTypeCredentials,
+		Label: credentials,
+	})
+}
+
+// FromProfile creates a new RPCClient from a connection profile, including its TLS settings.
+func FromProfile(profile *config.Profile) (*RPCClient, error) {
+	if profile == nil {
+		return nil, failure.Of("profile must be provided")
+	}
+
+	authentication := Authentication{
+		Type:   AuthenticationType(profile.AuthType),
+		Label:  profile.AuthLabel,
+		Header: profile.AuthHeader,
+		Scheme: profile.AuthScheme,
+	}
+
+	proxy := Proxy{
+		URL:       profile.Proxy.URL,
+		Randomize: profile.Proxy.Randomize,
+	}
+
+	return New(profile.URL, authentication, WithTLS(TLS(profile.TLS)), WithProxy(proxy))
+}
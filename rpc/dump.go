@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/avila-r/bitclient/logger"
+)
+
+// redacted replaces secrets in dumps.
+const redacted = "[REDACTED]"
+
+// dumpLimit is the size beyond which dumped bodies are truncated, since responses may hold whole blocks.
+const dumpLimit = 4096
+
+// dump returns the middleware dumping requests and responses at debug level, with the client's
+// credentials redacted. It's the innermost middleware, so that requests are dumped as they're sent.
+func (c *RPCClient) dump() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !logger.Enabled(slog.LevelDebug) {
+				return next.RoundTrip(req)
+			}
+
+			var body []byte
+			if req.Body != nil {
+				var err error
+				if body, err = io.ReadAll(req.Body); err != nil {
+					return nil, err
+				}
+				req.Body.Close()
+				req.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			slog.Debug("rpc request dump",
+				slog.String("method", req.Method),
+				slog.String("url", c.redact(req.URL.Redacted())),
+				slog.String("headers", c.headers(req.Header)),
+				slog.String("body", c.redact(truncate(body))),
+			)
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				slog.Debug("rpc response dump", slog.String("error", c.redact(err.Error())))
+				return nil, err
+			}
+
+			payload, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(payload))
+			slog.Debug("rpc response dump",
+				slog.Int("status", resp.StatusCode),
+				slog.String("headers", c.headers(resp.Header)),
+				slog.String("body", c.redact(truncate(payload))),
+			)
+
+			return resp, nil
+		})
+	}
+}
+
+// headers formats headers for a dump, with the ones carrying credentials redacted.
+func (c *RPCClient) headers(headers http.Header) string {
+	sensitive := map[string]bool{"Authorization": true, "Proxy-Authorization": true, "Cookie": true, "Set-Cookie": true}
+	if c.Authentication.Header != "" {
+		sensitive[http.CanonicalHeaderKey(c.Authentication.Header)] = true
+	}
+
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		for _, value := range headers[name] {
+			if sensitive[name] {
+				value = redacted
+			}
+			fmt.Fprintf(&b, "%s: %s; ", name, c.redact(value))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "; ")
+}
+
+// redact replaces the client's credentials in a string, e.g. an API key embedded in the URL path.
+func (c *RPCClient) redact(s string) string {
+	secrets := []string{c.Authentication.Label}
+	if c.Authentication.Type == AuthenticationTypeCredentials {
+		if _, password := c.Authentication.GetCredentials(); password != "" {
+			secrets = append(secrets, password)
+		}
+	}
+	if c.Proxy.URL != "" {
+		if proxy, err := url.Parse(c.Proxy.URL); err == nil && proxy.User != nil {
+			if password, ok := proxy.User.Password(); ok && password != "" {
+				secrets = append(secrets, password)
+			}
+		}
+	}
+
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+			s = strings.ReplaceAll(s, url.PathEscape(secret), redacted)
+		}
+	}
+	return s
+}
+
+// truncate returns a body as a string, truncated beyond dumpLimit bytes.
+func truncate(body []byte) string {
+	if len(body) <= dumpLimit {
+		return string(body)
+	}
+	return fmt.Sprintf("%s... (%d bytes)", body[:dumpLimit], len(body))
+}
@@ -0,0 +1,61 @@
+package rpc_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avila-r/bitclient/logger"
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+func Test_Dump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bitclient.log")
+	if err := logger.Setup(logger.Options{Level: slog.LevelDebug, File: path}); err != nil {
+		t.Fatalf("Failed to set up logger: %v", err)
+	}
+	defer logger.Close()
+
+	cases := []struct {
+		Authentication rpc.Authentication
+		Secret         string
+	}{
+		{Authentication: rpctest.Credentials, Secret: strings.SplitN(rpctest.Credentials.Label, ":", 2)[1]},
+		{Authentication: rpc.Authentication{Type: rpc.AuthenticationTypeKey, Label: "s3cr3t-key", Header: "x-api-key"}, Secret: "s3cr3t-key"},
+		{Authentication: rpc.Authentication{Type: rpc.AuthenticationTypePath, Label: "s3cr3t-path"}, Secret: "s3cr3t-path"},
+	}
+
+	for _, test := range cases {
+		fake := rpctest.NewServer(rpctest.WithAuthentication(test.Authentication))
+		client := fake.Client()
+		if _, err := client.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: "getblockhash", Params: rpc.Params{1}}); err != nil {
+			t.Errorf("Failed to send request with %v authentication: %v", test.Authentication.Type, err)
+		}
+		fake.Close()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read log file: %v", err)
+		}
+		dumps := string(data)
+		if strings.Contains(dumps, test.Secret) {
+			t.Errorf("Expected the %v secret to be redacted, got:\n%s", test.Authentication.Type, dumps)
+		}
+		if !strings.Contains(dumps, `msg="rpc request dump"`) || !strings.Contains(dumps, `"method\":\"getblockhash\"`) || !strings.Contains(dumps, `msg="rpc response dump" status=200`) {
+			t.Errorf("Expected the request and response to be dumped, got:\n%s", dumps)
+		}
+	}
+
+	// Nothing is dumped above debug level
+	if err := logger.Setup(logger.Options{Level: slog.LevelInfo, File: path}); err != nil {
+		t.Fatalf("Failed to set up logger: %v", err)
+	}
+	before, _ := os.ReadFile(path)
+	rpc.Client.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: "getblockcount", Params: rpc.NoParams})
+	if after, _ := os.ReadFile(path); len(after) != len(before) {
+		t.Errorf("Expected no dump at info level, got:\n%s", after[len(before):])
+	}
+}
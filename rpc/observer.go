@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Call describes an RPC request to the observers of a client. It's passed to Observer.Start once
+// the request is about to be sent, then to Observer.End, completed with its outcome.
+type Call struct {
+	Method     Method        // Method of the request
+	ParamsSize int           // Size of the serialized params, in bytes
+	Start      time.Time     // Time the request was sent
+	Duration   time.Duration // Time it took to get the response, set when it ends
+	Status     int           // HTTP status code of the response, 0 if none was received
+	ErrorCode  int           // JSON-RPC error code of the response, 0 if none
+	Err        error         // Error the request failed with, if any
+}
+
+// Observer is notified of the start and end of every RPC request of a client, e.g. to log them,
+// collect metrics or trace them. Unlike middleware, observers see requests as RPC calls rather
+// than HTTP requests, and are notified even when the request can't be sent.
+type Observer interface {
+	// Start is called before a request is sent. The returned context is passed to End, and to the
+	// observers started next, e.g. to carry a span.
+	Start(ctx context.Context, call *Call) context.Context
+
+	// End is called once the request ended, successfully or not.
+	End(ctx context.Context, call *Call)
+}
+
+// WithObserver registers observers notified of every request of the client, in order.
+func WithObserver(observers ...Observer) Option {
+	return func(c *RPCClient) {
+		c.observers = append(c.observers, observers...)
+	}
+}
+
+// Observe registers observers notified of every request of an existing client, in order. It's safe
+// to call while requests are sent: requests already started aren't notified to the new observers.
+func (c *RPCClient) Observe(observers ...Observer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.observers = append(slices.Clip(c.observers), observers...)
+}
+
+// observe sends a request through do, notifying the observers of its start and end.
+// Observers are started in order and ended in reverse order, like nested calls.
+func (c *RPCClient) observe(ctx context.Context, request Request, do func(context.Context, *Call) (*Response, error)) (*Response, error) {
+	c.mu.RLock()
+	observers := c.observers
+	c.mu.RUnlock()
+
+	if len(observers) == 0 {
+		return do(ctx, &Call{})
+	}
+
+	call := &Call{Method: request.Method}
+	if params, err := json.Marshal(request.Params); err == nil {
+		call.ParamsSize = len(params)
+	}
+
+	contexts := make([]context.Context, len(observers))
+	call.Start = time.Now()
+	for i, observer := range observers {
+		ctx = observer.Start(ctx, call)
+		contexts[i] = ctx
+	}
+
+	response, err := do(ctx, call)
+	call.Duration = time.Since(call.Start)
+	call.Err = err
+
+	for i := len(observers) - 1; i >= 0; i-- {
+		observers[i].End(contexts[i], call)
+	}
+	return response, err
+}
+
+// code returns the code of a JSON-RPC error, or 0 if it has none.
+func code(err any) int {
+	if object, ok := err.(map[string]any); ok {
+		if code, ok := object["code"].(float64); ok {
+			return int(code)
+		}
+	}
+	return 0
+}
+
+// SlogObserver is an observer logging RPC requests through a slog.Logger: their start at debug
+// level, and their end at info level, or at warn level when they fail.
+type SlogObserver struct {
+	Logger *slog.Logger // Logger the requests are logged to (default: slog.Default())
+}
+
+// Start logs the start of a request.
+func (o *SlogObserver) Start(ctx context.Context, call *Call) context.Context {
+	o.logger().DebugContext(ctx, "rpc request started",
+		slog.String("method", string(call.Method)),
+		slog.Int("params_size", call.ParamsSize),
+	)
+	return ctx
+}
+
+// End logs the end of a request, with its outcome.
+func (o *SlogObserver) End(ctx context.Context, call *Call) {
+	attributes := []slog.Attr{
+		slog.String("method", string(call.Method)),
+		slog.Int("params_size", call.ParamsSize),
+		slog.Int("status", call.Status),
+		slog.Duration("duration", call.Duration),
+	}
+
+	level := slog.LevelInfo
+	if call.Err != nil {
+		level = slog.LevelWarn
+		if call.ErrorCode != 0 {
+			attributes = append(attributes, slog.Int("error_code", call.ErrorCode))
+		}
+		attributes = append(attributes, slog.String("error", call.Err.Error()))
+	}
+
+	o.logger().LogAttrs(ctx, level, "rpc request", attributes...)
+}
+
+// logger returns the observer's logger, or the default one if unset.
+func (o *SlogObserver) logger() *slog.Logger {
+	if o.Logger == nil {
+		return slog.Default()
+	}
+	return o.Logger
+}
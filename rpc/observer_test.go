@@ -0,0 +1,132 @@
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+// recorder is an observer recording the calls it's notified of.
+type recorder struct {
+	name   string
+	events *[]string
+	calls  []rpc.Call
+}
+
+type key string
+
+func (r *recorder) Start(ctx context.Context, call *rpc.Call) context.Context {
+	*r.events = append(*r.events, fmt.Sprintf("start %s %v", r.name, ctx.Value(key("started"))))
+	return context.WithValue(ctx, key("started"), r.name)
+}
+
+func (r *recorder) End(ctx context.Context, call *rpc.Call) {
+	*r.events = append(*r.events, fmt.Sprintf("end %s %v", r.name, ctx.Value(key("started"))))
+	r.calls = append(r.calls, *call)
+}
+
+func Test_Observer(t *testing.T) {
+	fake := rpctest.NewServer()
+	defer fake.Close()
+
+	events := []string{}
+	first, second := &recorder{name: "first", events: &events}, &recorder{name: "second", events: &events}
+	client, err := rpc.New(fake.URL, rpctest.Credentials, rpc.WithObserver(first))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.Observe(second)
+
+	if _, err := client.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: "getblockhash", Params: rpc.Params{1}}); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	expected := []string{"start first <nil>", "start second first", "end second second", "end first first"}
+	if fmt.Sprint(events) != fmt.Sprint(expected) {
+		t.Errorf("Expected observers to be nested as %v, got %v", expected, events)
+	}
+
+	call := first.calls[0]
+	if call.Method != "getblockhash" || call.ParamsSize != len("[1]") || call.Status != http.StatusOK || call.ErrorCode != 0 || call.Err != nil || call.Duration <= 0 || call.Start.IsZero() {
+		t.Errorf("Unexpected call of a successful request: %+v", call)
+	}
+
+	// JSON-RPC errors, with status 200 (JSON-RPC 2.0) or 500 (JSON-RPC 1.0)
+	fake.Fail("getblockcount", rpctest.RPCClientInInitialDownload, "Loading block index")
+	_, err = client.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: "getblockcount", Params: rpc.NoParams})
+	if failed := (&rpc.Error{}); !errors.As(err, &failed) || failed.Status != http.StatusOK || failed.Code != rpctest.RPCClientInInitialDownload {
+		t.Errorf("Expected an rpc.Error with the JSON-RPC error code, got %#v", err)
+	}
+	if call := first.calls[1]; call.Status != http.StatusOK || call.ErrorCode != rpctest.RPCClientInInitialDownload || call.Err == nil {
+		t.Errorf("Unexpected call of a failed request: %+v", call)
+	}
+
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":"1"}`))
+	}))
+	defer legacy.Close()
+
+	observer := &recorder{events: &[]string{}}
+	client, _ = rpc.New(legacy.URL, credentials, rpc.WithObserver(observer))
+	_, err = client.Do(ping)
+	if failed := (&rpc.Error{}); !errors.As(err, &failed) || failed.Status != http.StatusInternalServerError || failed.Code != -28 {
+		t.Errorf("Expected an rpc.Error with the HTTP status and JSON-RPC error code, got %#v", err)
+	}
+	if call := observer.calls[0]; call.Status != http.StatusInternalServerError || call.ErrorCode != -28 || call.Err == nil {
+		t.Errorf("Unexpected call of a failed JSON-RPC 1.0 request: %+v", call)
+	}
+
+	// Requests that never reach the server
+	legacy.Close()
+	if _, err := client.Do(ping); err == nil {
+		t.Errorf("Expected request to fail")
+	}
+	if call := observer.calls[1]; call.Status != 0 || call.ErrorCode != 0 || call.Err == nil {
+		t.Errorf("Unexpected call of an unsent request: %+v", call)
+	}
+}
+
+func Test_SlogObserver(t *testing.T) {
+	fake := rpctest.NewServer()
+	defer fake.Close()
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := rpc.New(fake.URL, rpctest.Credentials, rpc.WithObserver(&rpc.SlogObserver{Logger: logger}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	client.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: "getblockhash", Params: rpc.Params{1}})
+	fake.Fail("getblockcount", rpctest.RPCMiscError, "unavailable")
+	client.Do(rpc.Request{ID: "1", Version: rpc.Version2, Method: "getblockcount", Params: rpc.NoParams})
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 log lines, got %q", lines)
+	}
+
+	expected := []string{
+		`level=DEBUG msg="rpc request started" method=getblockhash params_size=3`,
+		`level=INFO msg="rpc request" method=getblockhash params_size=3 status=200 duration=`,
+		`level=DEBUG msg="rpc request started" method=getblockcount params_size=2`,
+		`level=WARN msg="rpc request" method=getblockcount params_size=2 status=200 duration=`,
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, expected[i]) {
+			t.Errorf("Expected log line %q to contain %q", line, expected[i])
+		}
+	}
+	if !strings.Contains(lines[3], "error_code=-1 error=") || strings.Contains(lines[1], "error") {
+		t.Errorf("Expected only the failed request to log its error, got %q", lines)
+	}
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Option configures the optional settings of an RPCClient created through New.
+type Option func(*RPCClient)
+
+// WithTLS sets the TLS settings used to reach HTTPS endpoints.
+func WithTLS(t TLS) Option {
+	return func(c *RPCClient) {
+		c.TLS = t
+	}
+}
+
+// WithProxy sets the SOCKS5 or HTTP CONNECT proxy used to reach the RPC server.
+func WithProxy(p Proxy) Option {
+	return func(c *RPCClient) {
+		c.Proxy = p
+	}
+}
+
+// setup builds the HTTP client used to send requests, according to the client's settings.
+func (c *RPCClient) setup() error {
+	if c.transport == nil {
+		transport, err := c.defaultTransport()
+		if err != nil {
+			return err
+		}
+		c.transport = transport
+	}
+
+	c.client = &http.Client{Transport: c.chain()}
+
+	return nil
+}
+
+// defaultTransport builds the transport used when no custom one is provided,
+// applying the TLS, proxy and unix socket settings.
+func (c *RPCClient) defaultTransport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if !c.TLS.IsZero() {
+		config, err := c.TLS.Config()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = config
+	}
+
+	if !c.Proxy.IsZero() {
+		dialer, err := c.Proxy.Dialer()
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = nil // Ignore HTTP_PROXY and friends, the configured proxy takes over
+		transport.DialContext = dialer
+	}
+
+	if parsed, err := url.Parse(c.URL); err == nil && parsed.Scheme == "unix" {
+		transport.Proxy = nil
+		transport.DialContext = unix(parsed.Path)
+	}
+
+	return transport, nil
+}
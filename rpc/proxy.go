@@ -0,0 +1,294 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Proxy represents the proxy used to reach the RPC server, such as a local Tor SOCKS port.
+type Proxy struct {
+	// URL is the proxy address. Supported schemes are:
+	//   - socks5://[user:password@]host:port, whose credentials are used for stream isolation.
+	//   - http://[user:password@]host:port, which tunnels every connection through HTTP CONNECT.
+	URL string
+
+	// Randomize generates random SOCKS5 credentials for each client, so that Tor
+	// isolates its streams from other applications (similar to Core's -proxyrandomize).
+	Randomize bool
+}
+
+// Dialer is a function used to establish network connections.
+type Dialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+// SOCKS5 protocol constants, as defined in RFC 1928 and RFC 1929.
+const (
+	socks5Version           = 0x05
+	socks5AuthNone          = 0x00
+	socks5AuthPassword      = 0x02
+	socks5AuthNoAcceptable  = 0xff
+	socks5PasswordVersion   = 0x01
+	socks5CommandConnect    = 0x01
+	socks5AddressIPv4       = 0x01
+	socks5AddressDomainName = 0x03
+	socks5AddressIPv6       = 0x04
+)
+
+// handshakeTimeout bounds the handshake with the proxy, when the context has no earlier deadline,
+// so that an unresponsive proxy doesn't hang the connection forever.
+const handshakeTimeout = 30 * time.Second
+
+// IsZero reports whether no proxy has been provided.
+func (p Proxy) IsZero() bool {
+	return p.URL == ""
+}
+
+// Dialer builds the function used to dial the RPC server through the proxy.
+func (p Proxy) Dialer() (Dialer, error) {
+	parsed, err := url.Parse(p.URL)
+	if err != nil || parsed.Host == "" {
+		return nil, failure.Of("invalid proxy url '%s'", p.URL)
+	}
+
+	switch parsed.Scheme {
+	case "socks5", "socks5h":
+		user, password := "", ""
+		if parsed.User != nil {
+			user = parsed.User.Username()
+			password, _ = parsed.User.Password()
+		}
+
+		if p.Randomize && user == "" {
+			user, password = random(), random()
+		}
+
+		return socks5(parsed.Host, user, password), nil
+	case "http":
+		return connect(parsed.Host, parsed.User), nil
+	}
+
+	return nil, failure.Of("unsupported proxy scheme '%s' (must be socks5 or http)", parsed.Scheme)
+}
+
+// socks5 returns a Dialer that connects through a SOCKS5 proxy.
+// Host names are resolved by the proxy, which is required to reach onion services.
+func socks5(proxy, user, password string) Dialer {
+	var dialer net.Dialer
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, "tcp", proxy)
+		if err != nil {
+			return nil, failure.Of("failed to reach socks5 proxy: %v", err.Error())
+		}
+
+		defer bound(ctx, conn)()
+
+		if err := handshake(conn, address, user, password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return conn, nil
+	}
+}
+
+// bound aborts the handshake over a proxy connection once handshakeTimeout elapses, the context's
+// deadline passes, or the context is canceled, whichever comes first. It returns the function lifting
+// the deadline once the handshake is over.
+func bound(ctx context.Context, conn net.Conn) func() {
+	deadline := time.Now().Add(handshakeTimeout)
+	if earlier, ok := ctx.Deadline(); ok && earlier.Before(deadline) {
+		deadline = earlier
+	}
+	conn.SetDeadline(deadline)
+
+	// Unblock pending reads and writes as soon as the context is canceled
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) })
+
+	return func() {
+		stop()
+		conn.SetDeadline(time.Time{})
+	}
+}
+
+// handshake negotiates authentication and requests a connection to the target address.
+func handshake(conn net.Conn, address, user, password string) error {
+	host, portLabel, err := net.SplitHostPort(address)
+	if err != nil {
+		return failure.Of("invalid target address '%s': %v", address, err.Error())
+	}
+	port, err := strconv.Atoi(portLabel)
+	if err != nil {
+		return failure.Of("invalid target port '%s'", portLabel)
+	}
+
+	method := byte(socks5AuthNone)
+	if user != "" {
+		method = socks5AuthPassword
+	}
+
+	if _, err := conn.Write([]byte{socks5Version, 1, method}); err != nil {
+		return failure.Of("failed to send socks5 greeting: %v", err.Error())
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return failure.Of("failed to read socks5 greeting: %v", err.Error())
+	}
+	if reply[0] != socks5Version {
+		return failure.Of("unexpected socks version %d", reply[0])
+	}
+	if reply[1] == socks5AuthNoAcceptable || reply[1] != method {
+		return failure.Of("socks5 proxy rejected the authentication method")
+	}
+
+	if method == socks5AuthPassword {
+		if len(user) > 255 || len(password) > 255 {
+			return failure.Of("socks5 credentials must be at most 255 bytes long")
+		}
+
+		request := []byte{socks5PasswordVersion, byte(len(user))}
+		request = append(request, user...)
+		request = append(request, byte(len(password)))
+		request = append(request, password...)
+		if _, err := conn.Write(request); err != nil {
+			return failure.Of("failed to send socks5 credentials: %v", err.Error())
+		}
+
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return failure.Of("failed to read socks5 authentication reply: %v", err.Error())
+		}
+		if reply[1] != 0x00 {
+			return failure.Of("socks5 proxy rejected the credentials")
+		}
+	}
+
+	request := []byte{socks5Version, socks5CommandConnect, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return failure.Of("host name '%s' is too long", host)
+		}
+		request = append(request, socks5AddressDomainName, byte(len(host)))
+		request = append(request, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		request = append(request, socks5AddressIPv4)
+		request = append(request, ip4...)
+	} else {
+		request = append(request, socks5AddressIPv6)
+		request = append(request, ip.To16()...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+
+	if _, err := conn.Write(request); err != nil {
+		return failure.Of("failed to send socks5 connect request: %v", err.Error())
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return failure.Of("failed to read socks5 connect reply: %v", err.Error())
+	}
+	if header[1] != 0x00 {
+		return failure.Of("socks5 proxy failed to connect to %s (reply code %d)", address, header[1])
+	}
+
+	// Discard the bound address and port
+	length := 0
+	switch header[3] {
+	case socks5AddressIPv4:
+		length = net.IPv4len
+	case socks5AddressIPv6:
+		length = net.IPv6len
+	case socks5AddressDomainName:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return failure.Of("failed to read socks5 bound address: %v", err.Error())
+		}
+		length = int(size[0])
+	default:
+		return failure.Of("unexpected socks5 address type %d", header[3])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, length+2)); err != nil {
+		return failure.Of("failed to read socks5 bound address: %v", err.Error())
+	}
+
+	return nil
+}
+
+// connect returns a Dialer that tunnels connections through an HTTP proxy using the CONNECT method.
+func connect(proxy string, user *url.Userinfo) Dialer {
+	var dialer net.Dialer
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, "tcp", proxy)
+		if err != nil {
+			return nil, failure.Of("failed to reach http proxy: %v", err.Error())
+		}
+
+		defer bound(ctx, conn)()
+
+		request := &http.Request{
+			Method: http.MethodConnect,
+			URL:    &url.URL{Opaque: address},
+			Host:   address,
+			Header: http.Header{},
+		}
+		if user != nil {
+			password, _ := user.Password()
+			credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+			request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+		}
+
+		if err := request.Write(conn); err != nil {
+			conn.Close()
+			return nil, failure.Of("failed to send connect request: %v", err.Error())
+		}
+
+		reader := bufio.NewReader(conn)
+		response, err := http.ReadResponse(reader, request)
+		if err != nil {
+			conn.Close()
+			return nil, failure.Of("failed to read connect response: %v", err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			conn.Close()
+			return nil, failure.Of("http proxy failed to connect to %s: %s", address, response.Status)
+		}
+
+		// Keep anything the proxy may have sent past the response
+		if reader.Buffered() > 0 {
+			return &buffered{Conn: conn, reader: reader}, nil
+		}
+
+		return conn, nil
+	}
+}
+
+// buffered is a net.Conn whose first bytes are read from a buffered reader.
+type buffered struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (b *buffered) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+// random generates a random hex-encoded label, used as SOCKS5 isolation credentials.
+func random() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,269 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// socksServer is a minimal in-process SOCKS5 proxy. Every CONNECT request is
+// forwarded to target, while the requested host and credentials are recorded.
+type socksServer struct {
+	listener net.Listener
+	target   string
+
+	mu          sync.Mutex
+	hosts       []string
+	credentials []string
+}
+
+func newSocksServer(t *testing.T, target string) *socksServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	s := &socksServer{listener: listener, target: target}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return s
+}
+
+func (s *socksServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return
+	}
+	methods := make([]byte, greeting[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+
+	method := methods[0]
+	conn.Write([]byte{0x05, method})
+
+	credentials := ""
+	if method == 0x02 {
+		header := make([]byte, 2)
+		io.ReadFull(conn, header)
+		user := make([]byte, header[1])
+		io.ReadFull(conn, user)
+		size := make([]byte, 1)
+		io.ReadFull(conn, size)
+		password := make([]byte, size[0])
+		io.ReadFull(conn, password)
+		credentials = string(user) + ":" + string(password)
+		conn.Write([]byte{0x01, 0x00})
+	}
+
+	request := make([]byte, 5)
+	if _, err := io.ReadFull(conn, request); err != nil || request[3] != 0x03 {
+		return // Only domain names are expected
+	}
+	host := make([]byte, request[4])
+	io.ReadFull(conn, host)
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+
+	s.mu.Lock()
+	s.hosts = append(s.hosts, net.JoinHostPort(string(host), strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	s.credentials = append(s.credentials, credentials)
+	s.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", s.target)
+	if err != nil {
+		conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+
+	conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0, 0})
+
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func Test_ProxySocks5(t *testing.T) {
+	server := httptest.NewServer(pong)
+	defer server.Close()
+
+	socks := newSocksServer(t, server.Listener.Addr().String())
+
+	cases := []struct {
+		Proxy       rpc.Proxy
+		Credentials string
+	}{
+		{Proxy: rpc.Proxy{URL: "socks5://" + socks.listener.Addr().String()}, Credentials: ""},
+		{Proxy: rpc.Proxy{URL: "socks5://circuit:one@" + socks.listener.Addr().String()}, Credentials: "circuit:one"},
+	}
+
+	for _, test := range cases {
+		client, err := rpc.New("http://exampleonionaddress.onion:8332", credentials, rpc.WithProxy(test.Proxy))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if _, err := client.Do(ping); err != nil {
+			t.Errorf("Failed to reach server through socks5 proxy: %v", err)
+		}
+	}
+
+	socks.mu.Lock()
+	defer socks.mu.Unlock()
+
+	for i, test := range cases {
+		if socks.hosts[i] != "exampleonionaddress.onion:8332" {
+			t.Errorf("Expected the proxy to resolve the onion address, got %s", socks.hosts[i])
+		}
+		if socks.credentials[i] != test.Credentials {
+			t.Errorf("Expected credentials '%s', got '%s'", test.Credentials, socks.credentials[i])
+		}
+	}
+}
+
+func Test_ProxySocks5Randomize(t *testing.T) {
+	server := httptest.NewServer(pong)
+	defer server.Close()
+
+	socks := newSocksServer(t, server.Listener.Addr().String())
+	proxy := rpc.Proxy{URL: "socks5://" + socks.listener.Addr().String(), Randomize: true}
+
+	for range 2 {
+		client, err := rpc.New("http://node.onion:8332", credentials, rpc.WithProxy(proxy))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if _, err := client.Do(ping); err != nil {
+			t.Errorf("Failed to reach server through socks5 proxy: %v", err)
+		}
+	}
+
+	socks.mu.Lock()
+	defer socks.mu.Unlock()
+
+	if len(socks.credentials) != 2 || socks.credentials[0] == "" || socks.credentials[0] == socks.credentials[1] {
+		t.Errorf("Expected distinct random credentials per client, got %v", socks.credentials)
+	}
+}
+
+func Test_ProxyConnect(t *testing.T) {
+	server := httptest.NewServer(pong)
+	defer server.Close()
+
+	var target, authorization string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		target, authorization = r.Host, r.Header.Get("Proxy-Authorization")
+
+		upstream, err := net.Dial("tcp", server.Listener.Addr().String())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+
+		go io.Copy(upstream, conn)
+		io.Copy(conn, upstream)
+	}))
+	defer proxy.Close()
+
+	client, err := rpc.New("http://node.internal:8332", credentials, rpc.WithProxy(rpc.Proxy{
+		URL: "http://user:pass@" + proxy.Listener.Addr().String(),
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Do(ping); err != nil {
+		t.Fatalf("Failed to reach server through http proxy: %v", err)
+	}
+
+	if target != "node.internal:8332" {
+		t.Errorf("Expected CONNECT to node.internal:8332, got %s", target)
+	}
+	if authorization != "Basic dXNlcjpwYXNz" {
+		t.Errorf("Expected proxy credentials, got '%s'", authorization)
+	}
+
+	if _, err := rpc.New("http://node.internal:8332", credentials, rpc.WithProxy(rpc.Proxy{URL: "ftp://proxy:21"})); err == nil {
+		t.Errorf("Expected failure for an unsupported proxy scheme")
+	}
+}
+
+func Test_ProxyHandshakeCanceled(t *testing.T) {
+	// Proxies accepting connections without ever answering
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	for _, scheme := range []string{"socks5", "http"} {
+		t.Run(scheme, func(t *testing.T) {
+			dial, err := rpc.Proxy{URL: scheme + "://" + listener.Addr().String()}.Dialer()
+			if err != nil {
+				t.Fatalf("Failed to create dialer: %v", err)
+			}
+
+			// Canceling a context without deadline aborts the handshake
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := dial(ctx, "tcp", "node.onion:8332")
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Errorf("Expected the handshake to fail")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Timed out waiting for the handshake to be aborted")
+			}
+		})
+	}
+}
@@ -77,6 +77,30 @@ func (r *Response) Bind(target any) error {
 	return nil
 }
 
+// Bind decodes a Json object into a target, such as a struct with JSON tags.
+// Returns an error if the object doesn't fit the target.
+func (j Json) Bind(target any) error {
+	return bind(j, target)
+}
+
+// Bind decodes an Array of Json objects into a target, such as a slice of structs with JSON tags.
+// Returns an error if the array doesn't fit the target.
+func (a Array) Bind(target any) error {
+	return bind(a, target)
+}
+
+// bind decodes a value into a target through its JSON representation.
+func bind(value any, target any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return failure.Of("failed to serialize result: %v", err.Error())
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return failure.Of("failed to parse result: %v", err.Error())
+	}
+	return nil
+}
+
 // ToString serializes a Json object into a formatted string.
 // Returns a string representation of the Json object.
 func (j Json) ToString() string {
@@ -2,14 +2,16 @@ package rpc
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
-	"github.com/avila-r/env"
-
+	"github.com/avila-r/bitclient/config"
 	"github.com/avila-r/bitclient/failure"
 	"github.com/avila-r/bitclient/logger"
 )
@@ -18,7 +20,17 @@ import (
 type RPCClient struct {
 	URL            string         // The URL of the RPC server
 	Authentication Authentication // Authentication method used to access the RPC server
+	TLS            TLS            // TLS settings used to reach HTTPS endpoints
+	Proxy          Proxy          // Proxy used to reach the RPC server (e.g. Tor's SOCKS port)
 	client         *http.Client   // HTTP client used to send requests
+
+	transport  http.RoundTripper // Base transport, wrapped by the middleware chain
+	middleware []Middleware      // Middleware applied to every request, outermost first
+	observers  []Observer        // Observers notified of every request, in order
+	mu         sync.RWMutex      // Guards the client, middleware and observers, which Use and Observe change
+
+	resolving sync.Mutex // Guards the resolution of the authentication label
+	resolved  bool       // Whether the authentication label was resolved
 }
 
 // Request struct represents the structure of an RPC request.
@@ -36,58 +48,114 @@ type Response struct {
 	Result json.RawMessage `json:"result"` // Raw response data
 }
 
+// CodeMethodNotFound is the JSON-RPC error code of a request for a method the server doesn't support.
+const CodeMethodNotFound = -32601
+
+// Error is the error of a request the server answered with an error: an HTTP status other than
+// 200, or a JSON-RPC error object.
+type Error struct {
+	Status  int    // HTTP status code of the response
+	Code    int    // JSON-RPC error code of the response, 0 if none
+	Message string // Description of the error
+}
+
+// Error returns the description of the error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
 var (
 	// Client initializes the default RPCClient based on environment variables.
 	Client = func() *RPCClient {
-		rpcURL := env.Get("RPC_URL")              // Get RPC URL from environment
-		rpcAuthType := env.Get("RPC_AUTH_TYPE")   // Get RPC authentication type
-		rpcAuthLabel := env.Get("RPC_AUTH_LABEL") // Get RPC authentication label
+		profile, err := config.LoadProfile("") // Load the default profile from the environment
 
 		// If any of the required environment variables are missing, log a warning and return nil
-		if rpcURL == "" || rpcAuthType == "" || rpcAuthLabel == "" {
+		if err != nil || !profile.IsComplete() {
 			logger.Warnf("unable to initialize a default rpc.Client (RPC_URL, RPC_AUTH_TYPE and RPC_AUTH_LABEL must be provided)")
 			return nil
 		}
 
 		// Return a new RPCClient initialized with environment values
-		return &RPCClient{
-			client: &http.Client{},
-			URL:    rpcURL,
-			Authentication: Authentication{
-				Type:  AuthenticationType(rpcAuthType),
-				Label: rpcAuthLabel,
-			},
+		client, err := FromProfile(profile)
+		if err != nil {
+			logger.Warnf("unable to initialize a default rpc.Client: %v", err.Error())
+			return nil
 		}
+
+		return client
 	}()
 )
 
-// New creates and returns a new RPCClient. It validates the URL and authentication parameters.
-func New(uri string, authentication Authentication) (*RPCClient, error) {
+// New creates and returns a new RPCClient. It validates the URL and authentication parameters,
+// and applies the given options (e.g. WithTLS) before setting up the underlying HTTP client.
+func New(uri string, authentication Authentication, options ...Option) (*RPCClient, error) {
 	// Validate URL
 	if uri == "" {
 		return nil, failure.Of("URL cannot be empty")
 	}
 
 	parsed, err := url.Parse(uri) // Parse the URI
-	if err != nil || !strings.HasPrefix(parsed.Scheme, "http") {
-		return nil, failure.Of("invalid URL: must be a valid HTTP/HTTPS URL")
+	if err != nil || !(strings.HasPrefix(parsed.Scheme, "http") || parsed.Scheme == "unix" && parsed.Path != "") {
+		return nil, failure.Of("invalid URL: must be a valid HTTP/HTTPS URL or a unix socket (unix:///path/to/socket)")
 	}
 
-	// Validate the authentication details
+	// Validate the authentication details. Secrets referenced by the label are only resolved
+	// on the first request (see Resolve), so that creating a client never runs a command.
 	if err := authentication.Validate(); err != nil {
 		return nil, err
 	}
 
-	// Return a new RPCClient instance if all validations pass
-	return &RPCClient{
+	client := &RPCClient{
 		URL:            uri,
 		Authentication: authentication,
-		client:         &http.Client{},
-	}, nil
+	}
+
+	// Apply the optional settings
+	for _, option := range options {
+		option(client)
+	}
+
+	// Set up the HTTP client according to the settings
+	if err := client.setup(); err != nil {
+		return nil, err
+	}
+
+	// Return a new RPCClient instance if all validations pass
+	return client, nil
+}
+
+// Resolve replaces an authentication label that references a secret (see ResolveSecret) with the
+// secret itself. It's called by the first request, and may be called beforehand to fail early; once
+// it succeeded, it does nothing.
+func (c *RPCClient) Resolve() error {
+	c.resolving.Lock()
+	defer c.resolving.Unlock()
+
+	if c.resolved {
+		return nil
+	}
+	if err := c.Authentication.Resolve(); err != nil {
+		return err
+	}
+	c.resolved = true
+	return nil
 }
 
 // Do sends an RPC request and returns the corresponding response or an error.
 func (c *RPCClient) Do(request Request) (*Response, error) {
+	return c.DoContext(context.Background(), request)
+}
+
+// DoContext sends an RPC request like Do, aborting it when ctx is done.
+// It's meant for long-running calls, such as 'waitfornewblock'.
+func (c *RPCClient) DoContext(ctx context.Context, request Request) (*Response, error) {
+	return c.observe(ctx, request, func(ctx context.Context, call *Call) (*Response, error) {
+		return c.do(ctx, request, call)
+	})
+}
+
+// do sends an RPC request, completing the call observed with the status and error code of its response.
+func (c *RPCClient) do(ctx context.Context, request Request, call *Call) (*Response, error) {
 	// Serialize the request to JSON
 	body, err := json.Marshal(request)
 	if err != nil {
@@ -96,13 +164,16 @@ func (c *RPCClient) Do(request Request) (*Response, error) {
 	}
 
 	// Create a new HTTP POST request
-	req, err := http.NewRequest("POST", c.URL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(), bytes.NewBuffer(body))
 	if err != nil {
 		logger.Debugf("Error creating HTTP request: %v", err)
 		return nil, failure.Of("failed to set up http request: %v", err.Error())
 	}
 
-	// Setup authentication headers
+	// Resolve the secret referenced by the authentication label, then setup authentication headers
+	if err := c.Resolve(); err != nil {
+		return nil, err
+	}
 	if err := c.Authentication.Setup(req); err != nil {
 		return nil, err
 	}
@@ -111,12 +182,17 @@ func (c *RPCClient) Do(request Request) (*Response, error) {
 	req.Header.Set(ContentTypeHeaderLabel, string(ContentTypeApplicationJson))
 
 	// Send the HTTP request
-	resp, err := c.client.Do(req)
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+	resp, err := client.Do(req)
 	if err != nil {
-		logger.Debugf("Error sending request: %v", err)
-		return nil, failure.Of("failed to send http request: %v", err.Error())
+		// The error embeds the URL, which may carry the API key
+		logger.Debugf("Error sending request: %v", c.redact(err.Error()))
+		return nil, failure.Of("failed to send http request: %v", c.redact(err.Error()))
 	}
 	defer resp.Body.Close()
+	call.Status = resp.StatusCode
 
 	// Read the response body
 	payload, err := io.ReadAll(resp.Body)
@@ -128,7 +204,10 @@ func (c *RPCClient) Do(request Request) (*Response, error) {
 	// Check if the response status is OK (200)
 	if resp.StatusCode != http.StatusOK {
 		logger.Debugf("Server response error: %s", payload)
-		return nil, failure.Of("server responded with status code %d: %s", resp.StatusCode, payload)
+		if response := (Response{}); json.Unmarshal(payload, &response) == nil {
+			call.ErrorCode = code(response.Error)
+		}
+		return nil, &Error{Status: resp.StatusCode, Code: call.ErrorCode, Message: fmt.Sprintf("server responded with status code %d: %s", resp.StatusCode, payload)}
 	}
 
 	// Unmarshal the response payload into the Response struct
@@ -141,7 +220,8 @@ func (c *RPCClient) Do(request Request) (*Response, error) {
 	// If the response contains an error, return it
 	if response.Error != nil {
 		logger.Debugf("RPC call error: %v", response.Error)
-		return nil, failure.Of("%v", response.Error)
+		call.ErrorCode = code(response.Error)
+		return nil, &Error{Status: resp.StatusCode, Code: call.ErrorCode, Message: fmt.Sprint(response.Error)}
 	}
 
 	// Return the successfully unmarshaled response
@@ -2,26 +2,21 @@ package rpc_test
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
-	"github.com/avila-r/env"
-
-	"github.com/avila-r/bitclient/logger"
 	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
 )
 
-func init() {
-	var envs = []string{
-		"RPC_URL",
-		"RPC_AUTH_TYPE",
-		"RPC_AUTH_LABEL",
-	}
+func TestMain(m *testing.M) {
+	server := rpctest.NewServer()
+	rpc.Client = server.Client()
 
-	for _, key := range envs {
-		if env.Get(key) == "" {
-			logger.Fatalf("Client isn't available. %v variables must be provided", envs)
-		}
-	}
+	code := m.Run()
+
+	server.Close()
+	os.Exit(code)
 }
 
 func Test_GetMemoryInfo(t *testing.T) {
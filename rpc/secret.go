@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/avila-r/bitclient/config"
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Prefixes used to reference secrets kept outside of the configuration.
+const (
+	SecretFilePrefix    = config.SecretFilePrefix    // Reads the secret from a file
+	SecretEnvPrefix     = config.SecretEnvPrefix     // Reads the secret from an environment variable
+	SecretCommandPrefix = config.SecretCommandPrefix // Reads the secret from a command's standard output
+)
+
+// IsSecretReference reports whether a value references a secret instead of containing it.
+func IsSecretReference(value string) bool {
+	return config.IsSecretReference(value)
+}
+
+// ResolveSecret returns the secret referenced by a value, so that credentials don't need
+// to be stored in plaintext alongside the rest of the configuration.
+//
+// Supported references:
+//   - "file:/path/to/secret": The first line of the file (e.g. a Docker secret or the node's cookie file).
+//   - "env:VARIABLE": The value of the environment variable.
+//   - "cmd:pass show btc/rpc": The first line printed by the command, run through the system shell.
+//
+// Values without any of these prefixes are returned unchanged.
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SecretFilePrefix):
+		path := strings.TrimPrefix(value, SecretFilePrefix)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", failure.Of("failed to read secret from file: %v", err.Error())
+		}
+		return firstLine(content), nil
+
+	case strings.HasPrefix(value, SecretEnvPrefix):
+		name := strings.TrimPrefix(value, SecretEnvPrefix)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", failure.Of("secret environment variable %s isn't set", name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, SecretCommandPrefix):
+		command := strings.TrimPrefix(value, SecretCommandPrefix)
+
+		shell, flag := "sh", "-c"
+		if runtime.GOOS == "windows" {
+			shell, flag = "cmd", "/C"
+		}
+
+		var stderr bytes.Buffer
+		cmd := exec.Command(shell, flag, command)
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return "", failure.Of("failed to run secret command '%s': %v %s", command, err.Error(), strings.TrimSpace(stderr.String()))
+		}
+		return firstLine(output), nil
+	}
+
+	return value, nil
+}
+
+// firstLine returns the first line of content, without surrounding whitespace.
+func firstLine(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	if scanner.Scan() {
+		return strings.TrimSpace(scanner.Text())
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// TLS represents the TLS settings used to reach an RPC server over HTTPS,
+// e.g. a node exposed behind a reverse proxy that requires client certificates.
+type TLS struct {
+	CA         string // Path to a PEM bundle with the certificate authorities to trust
+	Cert       string // Path to the PEM-encoded client certificate
+	Key        string // Path to the PEM-encoded private key of the client certificate
+	ServerName string // Server name used for SNI and certificate verification
+	MinVersion string // Minimum TLS version accepted ("1.0", "1.1", "1.2" or "1.3")
+}
+
+// tlsVersions maps the supported version labels to their crypto/tls values.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// IsZero reports whether no TLS setting has been provided.
+func (t TLS) IsZero() bool {
+	return t == TLS{}
+}
+
+// Validate checks whether the TLS settings are consistent.
+func (t TLS) Validate() error {
+	// A client certificate is useless without its key, and vice versa.
+	if (t.Cert == "") != (t.Key == "") {
+		return failure.Of("tls client certificate and key must be provided together")
+	}
+
+	if _, ok := tlsVersions[t.MinVersion]; t.MinVersion != "" && !ok {
+		return failure.Of("invalid tls minimum version '%s' (valid versions are 1.0, 1.1, 1.2 and 1.3)", t.MinVersion)
+	}
+
+	return nil
+}
+
+// Config builds the crypto/tls configuration described by the settings,
+// loading the CA bundle and the client key pair from disk.
+func (t TLS) Config() (*tls.Config, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		ServerName: t.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if t.MinVersion != "" {
+		config.MinVersion = tlsVersions[t.MinVersion]
+	}
+
+	if t.CA != "" {
+		bundle, err := os.ReadFile(t.CA)
+		if err != nil {
+			return nil, failure.Of("failed to read tls ca bundle: %v", err.Error())
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, failure.Of("no valid certificates found in tls ca bundle %s", t.CA)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.Cert != "" {
+		certificate, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, failure.Of("failed to load tls client certificate: %v", err.Error())
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
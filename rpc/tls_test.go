@@ -0,0 +1,181 @@
+package rpc_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+var credentials = rpc.Authentication{
+	Type:  rpc.AuthenticationTypeCredentials,
+	Label: "user:password",
+}
+
+// pong answers every request with a successful JSON-RPC response.
+var pong = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{"id":"bitclient","result":null,"error":null}`))
+})
+
+var ping = rpc.Request{
+	ID:      rpc.Identifier,
+	Version: rpc.Version2,
+	Method:  "ping",
+	Params:  rpc.NoParams,
+}
+
+// writePEM encodes a DER block into a PEM file inside dir and returns its path.
+func writePEM(t *testing.T, dir, name, kind string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// clientCertificate generates a self-signed client certificate, returning its parsed form and the PEM files.
+func clientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bitclient"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	encoded, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	return certificate, writePEM(t, dir, "client.pem", "CERTIFICATE", der), writePEM(t, dir, "client.key", "EC PRIVATE KEY", encoded)
+}
+
+func Test_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(pong)
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	// Without the CA bundle, the server's certificate can't be verified
+	client, err := rpc.New(server.URL, credentials)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Do(ping); err == nil {
+		t.Errorf("Expected failure for an untrusted certificate")
+	}
+
+	client, err = rpc.New(server.URL, credentials, rpc.WithTLS(rpc.TLS{CA: ca}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Do(ping); err != nil {
+		t.Errorf("Failed to reach tls server: %v", err)
+	}
+}
+
+func Test_TLSClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certificate, cert, key := clientCertificate(t, dir)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+
+	server := httptest.NewUnstartedServer(pong)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	ca := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	cases := []struct {
+		TLS           rpc.TLS
+		ExpectSuccess bool
+	}{
+		{TLS: rpc.TLS{CA: ca}, ExpectSuccess: false},
+		{TLS: rpc.TLS{CA: ca, Cert: cert, Key: key}, ExpectSuccess: true},
+		{TLS: rpc.TLS{CA: ca, Cert: cert, Key: key, ServerName: "example.com"}, ExpectSuccess: true},
+		{TLS: rpc.TLS{CA: ca, Cert: cert, Key: key, ServerName: "node.invalid"}, ExpectSuccess: false},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			client, err := rpc.New(server.URL, credentials, rpc.WithTLS(test.TLS))
+			if err != nil {
+				t.Fatalf("Test case %d failed: unable to create client: %v", i, err)
+			}
+
+			_, err = client.Do(ping)
+			if test.ExpectSuccess && err != nil {
+				t.Errorf("Test case %d failed: expected success but got error: %v", i, err)
+			}
+			if !test.ExpectSuccess && err == nil {
+				t.Errorf("Test case %d failed: expected failure but got success", i)
+			}
+		})
+	}
+}
+
+func Test_TLSMinVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(pong)
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	ca := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	client, err := rpc.New(server.URL, credentials, rpc.WithTLS(rpc.TLS{CA: ca, MinVersion: "1.3"}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Do(ping); err == nil {
+		t.Errorf("Expected failure when the server doesn't support the minimum version")
+	}
+
+	invalid := []rpc.TLS{
+		{MinVersion: "2.0"},
+		{Cert: "client.pem"},
+		{CA: filepath.Join(t.TempDir(), "missing.pem")},
+	}
+	for _, settings := range invalid {
+		if _, err := rpc.New(server.URL, credentials, rpc.WithTLS(settings)); err == nil {
+			t.Errorf("Expected failure for invalid tls settings %+v", settings)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Middleware wraps the transport used to send RPC requests, e.g. to log requests,
+// collect metrics or inject headers. It receives the next transport in the chain.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter that allows the use of ordinary functions as http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// unixEndpoint is the URL requests are sent to when the server is reached through a unix socket.
+const unixEndpoint = "http://localhost/"
+
+// WithTransport replaces the default transport used to send requests.
+// When set, the TLS, Proxy and unix socket settings are ignored, since they only apply to the default transport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *RPCClient) {
+		c.transport = transport
+	}
+}
+
+// WithMiddleware appends middleware to the client's transport chain.
+// The first middleware given is the outermost one, seeing requests first and responses last.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *RPCClient) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// Use appends middleware to the transport chain of an existing client. It's safe to call while
+// requests are sent: requests already in flight keep going through the previous chain.
+func (c *RPCClient) Use(middleware ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.middleware = append(c.middleware, middleware...)
+	c.client = &http.Client{Transport: c.chain()}
+}
+
+// Transport returns the client's base transport, without middleware. It carries the TLS, proxy
+// and unix socket settings, so that other HTTP interfaces of the node (e.g. REST) can be reached the same way.
+func (c *RPCClient) Transport() http.RoundTripper {
+	return c.transport
+}
+
+// chain wraps the base transport with the registered middleware, outermost first, and with the
+// dump of requests and responses at debug level, innermost.
+func (c *RPCClient) chain() http.RoundTripper {
+	transport := c.dump()(c.transport)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		transport = c.middleware[i](transport)
+	}
+	return transport
+}
+
+// endpoint returns the URL requests are sent to.
+func (c *RPCClient) endpoint() string {
+	if parsed, err := url.Parse(c.URL); err == nil && parsed.Scheme == "unix" {
+		return unixEndpoint
+	}
+	return c.URL
+}
+
+// unix returns a dial function that connects to the unix socket at path, whatever the requested address.
+func unix(path string) Dialer {
+	var dialer net.Dialer
+
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
@@ -0,0 +1,153 @@
+package rpc_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+func Test_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bitcoind.sock")
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets aren't available: %v", err)
+	}
+	server := &http.Server{Handler: pong}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client, err := rpc.New("unix://"+path, credentials)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Do(ping); err != nil {
+		t.Errorf("Failed to reach server through unix socket: %v", err)
+	}
+
+	if _, err := rpc.New("unix://", credentials); err == nil {
+		t.Errorf("Expected failure for a unix url without socket path")
+	}
+}
+
+func Test_Transport(t *testing.T) {
+	var method string
+	transport := rpc.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(r.Body)
+		method = string(body)
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"result":"pong","error":null}`)),
+			Header:     http.Header{},
+			Request:    r,
+		}, nil
+	})
+
+	client, err := rpc.New("http://node.invalid:8332", credentials, rpc.WithTransport(transport))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	response, err := client.Do(ping)
+	if err != nil {
+		t.Fatalf("Failed to send request through custom transport: %v", err)
+	}
+	if string(response.Result) != `"pong"` {
+		t.Errorf("Expected result from custom transport, got %s", response.Result)
+	}
+	if !strings.Contains(method, `"method":"ping"`) {
+		t.Errorf("Expected request body to reach the transport, got %s", method)
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Trace")
+		pong(w, r)
+	}))
+	defer server.Close()
+
+	order := []string{}
+	middleware := func(name string) rpc.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return rpc.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				r.Header.Add("X-Trace", name)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+
+	client, err := rpc.New(server.URL, credentials, rpc.WithMiddleware(middleware("outer"), middleware("inner")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.Use(middleware("last"))
+
+	if _, err := client.Do(ping); err != nil {
+		t.Fatalf("Failed to send request through middleware: %v", err)
+	}
+
+	if strings.Join(order, ",") != "outer,inner,last" {
+		t.Errorf("Expected middleware to run in order, got %v", order)
+	}
+	if header != "outer" {
+		t.Errorf("Expected injected header to reach the server, got '%s'", header)
+	}
+}
+
+func Test_MiddlewareConcurrent(t *testing.T) {
+	server := httptest.NewServer(pong)
+	defer server.Close()
+
+	client, err := rpc.New(server.URL, credentials)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var calls atomic.Int64
+	counting := func(next http.RoundTripper) http.RoundTripper {
+		return rpc.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return next.RoundTrip(r)
+		})
+	}
+
+	// Middleware and observers can be added while requests are sent
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				if _, err := client.Do(ping); err != nil {
+					t.Errorf("Failed to send request: %v", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			client.Use(counting)
+			client.Observe(&rpc.SlogObserver{})
+		}()
+	}
+	wg.Wait()
+
+	calls.Store(0)
+	if _, err := client.Do(ping); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("Expected every middleware to run once, got %d calls", calls.Load())
+	}
+}
@@ -0,0 +1,345 @@
+package rpctest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/avila-r/bitclient/wire"
+)
+
+// Regtest consensus parameters used to mine the fixture chain.
+const (
+	// RegtestBits is the compact target of regtest blocks, low enough that a valid nonce is found in a couple of tries.
+	RegtestBits uint32 = 0x207fffff
+
+	// GenesisTime is the timestamp of the fixture chain's first block.
+	GenesisTime uint32 = 1296688602
+
+	// BlockInterval is the number of seconds between fixture blocks.
+	BlockInterval uint32 = 600
+
+	// Subsidy is the coinbase value, in satoshis, of every fixture block.
+	Subsidy int64 = 50_0000_0000
+)
+
+// Block is a block of the fixture chain, kept both decoded and serialized.
+type Block struct {
+	Height       int    // Height of the block
+	Hash         string // Block hash, as displayed by Bitcoin Core (byte-reversed hex)
+	PreviousHash string // Hash of the previous block, empty for the first block
+	MerkleRoot   string // Merkle root of the block's transactions (byte-reversed hex)
+	Version      int32  // Block version
+	Time         uint32 // Block timestamp
+	Bits         uint32 // Compact target
+	Nonce        uint32 // Nonce satisfying the proof-of-work
+
+	Header       []byte   // Serialized 80-byte header
+	Transactions [][]byte // Serialized transactions (coinbase only)
+	TxIDs        []string // Transaction ids (byte-reversed hex)
+}
+
+// Serialize returns the block's consensus serialization: header, transaction count and transactions.
+func (b *Block) Serialize() []byte {
+	var buffer bytes.Buffer
+	buffer.Write(b.Header)
+	buffer.Write(varint(uint64(len(b.Transactions))))
+	for _, tx := range b.Transactions {
+		buffer.Write(tx)
+	}
+	return buffer.Bytes()
+}
+
+// commit computes the ids and merkle root of the block's transactions, returning the root in internal byte order.
+func (b *Block) commit() []byte {
+	b.TxIDs = []string{}
+	hashes := []wire.Hash{}
+	for _, data := range b.Transactions {
+		tx, err := wire.DecodeTx(data)
+		if err != nil {
+			panic(err)
+		}
+		hashes = append(hashes, tx.TxID())
+		b.TxIDs = append(b.TxIDs, tx.TxID().String())
+	}
+
+	root, _ := wire.MerkleRoot(hashes)
+	b.MerkleRoot = root.String()
+	return root[:]
+}
+
+// Size returns the size, in bytes, of the serialized block.
+func (b *Block) Size() int {
+	return len(b.Serialize())
+}
+
+// Chain is an in-memory chain of mined regtest blocks, used as fixture by the fake server.
+type Chain struct {
+	mu     sync.RWMutex
+	blocks []*Block          // Blocks of the active chain, indexed by height
+	hashes map[string]*Block // Every block ever mined (including stale ones), indexed by hash
+	forks  []*Block          // Tips of stale branches, left behind by reorgs
+	reorgs uint32            // Number of reorgs so far, used to make replacement blocks unique
+}
+
+// NewChain mines a chain with n blocks on top of its first block, so its tip is at height n.
+func NewChain(n int) *Chain {
+	chain := &Chain{hashes: map[string]*Block{}}
+	chain.Mine(n + 1)
+	return chain
+}
+
+// Mine appends n blocks to the active chain, returning them.
+func (c *Chain) Mine(n int) []*Block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.mine(n, 0, nil)
+}
+
+// MineWith appends n blocks like Mine, calling modify on each one before searching its nonce,
+// so that its version, timestamp or bits can be changed (e.g. to mine blocks breaking consensus rules),
+// or transactions appended after its coinbase (see Spend).
+func (c *Chain) MineWith(n int, modify func(*Block)) []*Block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.mine(n, 0, modify)
+}
+
+// Reorg disconnects the last depth blocks and mines n replacements, whose timestamps are shifted
+// by a few seconds so that their hashes differ from the disconnected ones. The stale tip is kept as a fork.
+// It returns the disconnected blocks and the newly connected ones.
+func (c *Chain) Reorg(depth, n int) ([]*Block, []*Block) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	depth = min(depth, len(c.blocks)-1)
+	disconnected := slices.Clone(c.blocks[len(c.blocks)-depth:])
+	c.blocks = c.blocks[:len(c.blocks)-depth]
+
+	if depth > 0 {
+		c.forks = append(c.forks, disconnected[len(disconnected)-1])
+	}
+
+	c.reorgs++
+	return disconnected, c.mine(n, c.reorgs, nil)
+}
+
+// mine appends n blocks to the active chain, shifting their timestamps by offset seconds and
+// applying modify, if any, before searching their nonce. The caller must hold the lock.
+func (c *Chain) mine(n int, offset uint32, modify func(*Block)) []*Block {
+	mined := make([]*Block, 0, n)
+
+	for range n {
+		height := len(c.blocks)
+
+		previous := make([]byte, 32)
+		previousHash := ""
+		if height > 0 {
+			parent := c.blocks[height-1]
+			copy(previous, reverse(decode(parent.Hash)))
+			previousHash = parent.Hash
+		}
+
+		coinbase := coinbase(height)
+		txid := hash256(coinbase)
+
+		block := &Block{
+			Height:       height,
+			PreviousHash: previousHash,
+			MerkleRoot:   hex.EncodeToString(reverse(txid)), // The merkle root of a single transaction is its id
+			Version:      0x20000000,
+			Time:         GenesisTime + uint32(height)*BlockInterval + offset,
+			Bits:         RegtestBits,
+			Transactions: [][]byte{coinbase},
+			TxIDs:        []string{hex.EncodeToString(reverse(txid))},
+		}
+
+		if modify != nil {
+			modify(block)
+		}
+
+		// Transactions added by modify change the merkle root
+		root := txid
+		if len(block.Transactions) > 1 {
+			root = block.commit()
+		}
+
+		// Search a nonce satisfying the proof-of-work
+		target := Target(block.Bits)
+		for nonce := uint32(0); ; nonce++ {
+			header := make([]byte, 0, 80)
+			header = binary.LittleEndian.AppendUint32(header, uint32(block.Version))
+			header = append(header, previous...)
+			header = append(header, root...)
+			header = binary.LittleEndian.AppendUint32(header, block.Time)
+			header = binary.LittleEndian.AppendUint32(header, block.Bits)
+			header = binary.LittleEndian.AppendUint32(header, nonce)
+
+			hash := hash256(header)
+			if new(big.Int).SetBytes(reverse(hash)).Cmp(target) <= 0 {
+				block.Nonce, block.Header = nonce, header
+				block.Hash = hex.EncodeToString(reverse(hash))
+				break
+			}
+		}
+
+		c.blocks = append(c.blocks, block)
+		c.hashes[block.Hash] = block
+		mined = append(mined, block)
+	}
+
+	return mined
+}
+
+// Height returns the height of the active chain's tip.
+func (c *Chain) Height() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.blocks) - 1
+}
+
+// Tip returns the active chain's tip.
+func (c *Chain) Tip() *Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.blocks[len(c.blocks)-1]
+}
+
+// Block returns the active chain's block at a height, or nil if it's out of range.
+func (c *Chain) Block(height int) *Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if height < 0 || height >= len(c.blocks) {
+		return nil
+	}
+	return c.blocks[height]
+}
+
+// ByHash returns a block by its hash, including blocks of stale branches, or nil if it's unknown.
+func (c *Chain) ByHash(hash string) *Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.hashes[hash]
+}
+
+// IsActive reports whether a block belongs to the active chain.
+func (c *Chain) IsActive(block *Block) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return block.Height < len(c.blocks) && c.blocks[block.Height] == block
+}
+
+// Forks returns the tips of the stale branches left behind by reorgs.
+func (c *Chain) Forks() []*Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Clone(c.forks)
+}
+
+// MedianTime returns the median timestamp of the 11 blocks ending at a block (median-time-past).
+func (c *Chain) MedianTime(block *Block) uint32 {
+	times := []uint32{}
+	for current := block; current != nil && len(times) < 11; current = c.ByHash(current.PreviousHash) {
+		times = append(times, current.Time)
+	}
+	slices.Sort(times)
+	return times[len(times)/2]
+}
+
+// Work returns the cumulative chainwork up to a block, as computed by Bitcoin Core.
+func (c *Chain) Work(block *Block) *big.Int {
+	work := new(big.Int)
+	for current := block; current != nil; current = c.ByHash(current.PreviousHash) {
+		work.Add(work, Work(current.Bits))
+	}
+	return work
+}
+
+// Target decodes a compact target ('bits') into its full value.
+func Target(bits uint32) *big.Int {
+	exponent, mantissa := uint(bits>>24), big.NewInt(int64(bits&0x007fffff))
+	if exponent <= 3 {
+		return mantissa.Rsh(mantissa, 8*(3-exponent))
+	}
+	return mantissa.Lsh(mantissa, 8*(exponent-3))
+}
+
+// Work returns the expected number of hashes needed to mine a block with the given compact target.
+func Work(bits uint32) *big.Int {
+	target := Target(bits)
+	denominator := new(big.Int).Add(target, big.NewInt(1))
+	return new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), 256), denominator)
+}
+
+// Difficulty returns the difficulty of a compact target, relative to the minimum mainnet difficulty.
+func Difficulty(bits uint32) float64 {
+	difficulty, _ := new(big.Float).Quo(new(big.Float).SetInt(Target(0x1d00ffff)), new(big.Float).SetInt(Target(bits))).Float64()
+	return difficulty
+}
+
+// coinbase serializes the coinbase transaction of a block at the given height, paying the
+// subsidy to an anyone-can-spend (OP_TRUE) output.
+func coinbase(height int) []byte {
+	// BIP34: the coinbase script starts with the block height
+	script := []byte{0x04}
+	script = binary.LittleEndian.AppendUint32(script, uint32(height))
+
+	tx := binary.LittleEndian.AppendUint32(nil, 1) // Version
+	tx = append(tx, 0x01)                          // Input count
+	tx = append(tx, make([]byte, 32)...)           // Null previous output
+	tx = binary.LittleEndian.AppendUint32(tx, 0xffffffff)
+	tx = append(tx, varint(uint64(len(script)))...)
+	tx = append(tx, script...)
+	tx = binary.LittleEndian.AppendUint32(tx, 0xffffffff) // Sequence
+	tx = append(tx, 0x01)                                 // Output count
+	tx = binary.LittleEndian.AppendUint64(tx, uint64(Subsidy))
+	tx = append(tx, 0x01, 0x51)                     // OP_TRUE
+	tx = binary.LittleEndian.AppendUint32(tx, 0x00) // Locktime
+
+	return tx
+}
+
+// varint encodes an integer using Bitcoin's CompactSize encoding.
+func varint(n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return []byte{byte(n)}
+	case n <= 0xffff:
+		return binary.LittleEndian.AppendUint16([]byte{0xfd}, uint16(n))
+	case n <= 0xffffffff:
+		return binary.LittleEndian.AppendUint32([]byte{0xfe}, uint32(n))
+	}
+	return binary.LittleEndian.AppendUint64([]byte{0xff}, n)
+}
+
+// hash256 computes the double SHA-256 of data.
+func hash256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// reverse returns a byte-reversed copy of data, converting between internal and display byte order.
+func reverse(data []byte) []byte {
+	reversed := slices.Clone(data)
+	slices.Reverse(reversed)
+	return reversed
+}
+
+// decode decodes a hex string, returning nil if it's invalid.
+func decode(s string) []byte {
+	data, _ := hex.DecodeString(s)
+	return data
+}
@@ -0,0 +1,178 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Collector is an in-process stand-in for an OpenTelemetry collector, receiving traces over
+// OTLP/HTTP in the JSON encoding at /v1/traces.
+type Collector struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	spans   []Span
+	headers []http.Header
+	status  int
+}
+
+// Span is a span received by a collector.
+type Span struct {
+	Service      string         // service.name of the span's resource
+	TraceID      string         // Hex-encoded trace ID
+	SpanID       string         // Hex-encoded span ID
+	ParentSpanID string         // Hex-encoded ID of the parent span, empty for root spans
+	Name         string         // Name of the span
+	Kind         int            // Kind of the span (1 for internal, 3 for client)
+	Start        time.Time      // Start time of the span
+	End          time.Time      // End time of the span
+	Attributes   map[string]any // Attributes, with integers as int64
+	StatusCode   int            // Status code (0 unset, 1 ok, 2 error)
+	Message      string         // Status message
+}
+
+// NewCollector starts a fake collector. The caller should call Close when finished, to shut it down.
+func NewCollector() *Collector {
+	c := &Collector{status: http.StatusOK}
+	c.Server = httptest.NewServer(http.HandlerFunc(c.serve))
+	return c
+}
+
+// Spans returns the spans received so far, in order.
+func (c *Collector) Spans() []Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Span{}, c.spans...)
+}
+
+// Headers returns the headers of the export requests received so far, in order.
+func (c *Collector) Headers() []http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]http.Header{}, c.headers...)
+}
+
+// Reject makes the collector respond to exports with a status code, e.g. 503, dropping the spans.
+// Status 200 makes it accept them again.
+func (c *Collector) Reject(status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status = status
+}
+
+// serve handles an export request.
+func (c *Collector) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	request := struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []attribute `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string      `json:"traceId"`
+					SpanID       string      `json:"spanId"`
+					ParentSpanID string      `json:"parentSpanId"`
+					Name         string      `json:"name"`
+					Kind         int         `json:"kind"`
+					Start        string      `json:"startTimeUnixNano"`
+					End          string      `json:"endTimeUnixNano"`
+					Attributes   []attribute `json:"attributes"`
+					Status       struct {
+						Code    int    `json:"code"`
+						Message string `json:"message"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.headers = append(c.headers, r.Header.Clone())
+	if c.status != http.StatusOK {
+		http.Error(w, http.StatusText(c.status), c.status)
+		return
+	}
+
+	for _, resource := range request.ResourceSpans {
+		service, _ := attributes(resource.Resource.Attributes)["service.name"].(string)
+		for _, scope := range resource.ScopeSpans {
+			for _, span := range scope.Spans {
+				c.spans = append(c.spans, Span{
+					Service:      service,
+					TraceID:      span.TraceID,
+					SpanID:       span.SpanID,
+					ParentSpanID: span.ParentSpanID,
+					Name:         span.Name,
+					Kind:         span.Kind,
+					Start:        nanoseconds(span.Start),
+					End:          nanoseconds(span.End),
+					Attributes:   attributes(span.Attributes),
+					StatusCode:   span.Status.Code,
+					Message:      span.Status.Message,
+				})
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{}`))
+}
+
+// attribute is an attribute in the OTLP JSON encoding.
+type attribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string  `json:"stringValue"`
+		IntValue    *string  `json:"intValue"`
+		BoolValue   *bool    `json:"boolValue"`
+		DoubleValue *float64 `json:"doubleValue"`
+	} `json:"value"`
+}
+
+// attributes decodes attributes into a map.
+func attributes(list []attribute) map[string]any {
+	decoded := map[string]any{}
+	for _, a := range list {
+		switch {
+		case a.Value.StringValue != nil:
+			decoded[a.Key] = *a.Value.StringValue
+		case a.Value.IntValue != nil:
+			decoded[a.Key], _ = strconv.ParseInt(*a.Value.IntValue, 10, 64)
+		case a.Value.BoolValue != nil:
+			decoded[a.Key] = *a.Value.BoolValue
+		case a.Value.DoubleValue != nil:
+			decoded[a.Key] = *a.Value.DoubleValue
+		}
+	}
+	return decoded
+}
+
+// nanoseconds decodes a time encoded as a string of nanoseconds since the Unix epoch.
+func nanoseconds(s string) time.Time {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return time.Unix(0, n)
+}
@@ -0,0 +1,78 @@
+package rpctest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/avila-r/bitclient/filters"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// WithoutBlockFilterIndex disables the server's basic block filter index, as bitcoind does unless
+// started with -blockfilterindex.
+func WithoutBlockFilterIndex() Option {
+	return func(s *Server) {
+		s.filterless = true
+	}
+}
+
+// filter builds the basic filter of a block, returning it with its filter header. Headers are
+// chained from the first block, following the block's own branch.
+func (s *Server) filter(block *Block) ([]byte, wire.Hash) {
+	branch := []*Block{block}
+	for b := block; b.PreviousHash != ""; branch = append(branch, b) {
+		b = s.Chain.ByHash(b.PreviousHash)
+	}
+
+	var filter []byte
+	header := wire.Hash{}
+	for i := len(branch) - 1; i >= 0; i-- {
+		filter = s.basicFilter(branch[i])
+		header = filters.Header(wire.Hash256(filter), header)
+	}
+	return filter, header
+}
+
+// basicFilter builds the basic filter of a fixture block, from its output scripts and the scripts
+// of the outputs it spends.
+func (s *Server) basicFilter(block *Block) []byte {
+	decoded, err := wire.DecodeBlock(block.Serialize())
+	if err != nil {
+		panic(err)
+	}
+
+	spent := [][]byte{}
+	for _, tx := range decoded.Transactions[1:] {
+		for _, input := range tx.Inputs {
+			if output, _ := s.prevout(input); output != nil {
+				spent = append(spent, output.ScriptPubKey)
+			}
+		}
+	}
+
+	return filters.Build(filters.Basic, decoded.Hash(), filters.BasicElements(decoded, spent))
+}
+
+func (s *Server) getBlockFilter(params []json.RawMessage) (any, error) {
+	block, err := s.lookup(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	filtertype, err := param(params, 1, "basic")
+	if err != nil {
+		return nil, err
+	}
+	if filtertype != filters.Basic.Name {
+		return nil, &Error{Code: RPCInvalidAddressOrKey, Message: "Unknown filtertype"}
+	}
+	if s.filterless {
+		return nil, &Error{Code: RPCMiscError, Message: "Index is not enabled for filtertype basic"}
+	}
+
+	filter, header := s.filter(block)
+	return map[string]any{
+		"filter": hex.EncodeToString(filter),
+		"header": header.String(),
+	}, nil
+}
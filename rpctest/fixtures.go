@@ -0,0 +1,393 @@
+package rpctest
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/avila-r/bitclient/wire"
+)
+
+// Peer is a connected peer, as returned by 'getpeerinfo'.
+type Peer struct {
+	ID              int      `json:"id"`
+	Address         string   `json:"addr"`
+	Network         string   `json:"network"`
+	Services        string   `json:"services"`
+	ServicesNames   []string `json:"servicesnames"`
+	RelayTxes       bool     `json:"relaytxes"`
+	LastSend        int64    `json:"lastsend"`
+	LastRecv        int64    `json:"lastrecv"`
+	LastTransaction int64    `json:"last_transaction"`
+	LastBlock       int64    `json:"last_block"`
+	BytesSent       uint64   `json:"bytessent"`
+	BytesRecv       uint64   `json:"bytesrecv"`
+	ConnTime        int64    `json:"conntime"`
+	TimeOffset      int64    `json:"timeoffset"`
+	PingTime        float64  `json:"pingtime,omitempty"`
+	MinPing         float64  `json:"minping,omitempty"`
+	Version         int      `json:"version"`
+	SubVersion      string   `json:"subver"`
+	Inbound         bool     `json:"inbound"`
+	ConnectionType  string   `json:"connection_type"`
+	StartingHeight  int      `json:"startingheight"`
+	SyncedHeaders   int      `json:"synced_headers"`
+	SyncedBlocks    int      `json:"synced_blocks"`
+	MappedAS        int      `json:"mapped_as,omitempty"`
+}
+
+// Banned is a banned subnet, as returned by 'listbanned'.
+type Banned struct {
+	Address       string `json:"address"`
+	BanCreated    int64  `json:"ban_created"`
+	BannedUntil   int64  `json:"banned_until"`
+	BanDuration   int64  `json:"ban_duration"`
+	TimeRemaining int64  `json:"time_remaining"`
+}
+
+// DefaultPeers returns the peers connected to a fake server by default:
+// two outbound full-relay peers and an inbound one.
+func DefaultPeers() []Peer {
+	return []Peer{
+		{
+			ID: 1, Address: "203.0.113.10:8333", Network: "ipv4", Services: "0000000000000c09",
+			ServicesNames: []string{"NETWORK", "WITNESS", "NETWORK_LIMITED", "P2P_V2"}, RelayTxes: true,
+			LastSend: 1700000600, LastRecv: 1700000600, LastBlock: 1700000500, BytesSent: 262144, BytesRecv: 524288,
+			ConnTime: 1700000000, PingTime: 0.045, MinPing: 0.04, Version: 70016, SubVersion: "/Satoshi:28.0.0/",
+			ConnectionType: "outbound-full-relay", StartingHeight: 200, SyncedHeaders: 200, SyncedBlocks: 200,
+		},
+		{
+			ID: 2, Address: "198.51.100.7:8333", Network: "ipv4", Services: "0000000000000409",
+			ServicesNames: []string{"NETWORK", "WITNESS", "NETWORK_LIMITED"}, RelayTxes: true,
+			LastSend: 1700000600, LastRecv: 1700000590, LastBlock: 1700000400, BytesSent: 131072, BytesRecv: 262144,
+			ConnTime: 1700000100, PingTime: 0.12, MinPing: 0.1, Version: 70016, SubVersion: "/Satoshi:27.1.0/",
+			ConnectionType: "outbound-full-relay", StartingHeight: 199, SyncedHeaders: 200, SyncedBlocks: 200,
+		},
+		{
+			ID: 3, Address: "192.0.2.33:51234", Network: "ipv4", Services: "0000000000000000",
+			ServicesNames: []string{}, RelayTxes: false,
+			LastSend: 1700000600, LastRecv: 1700000300, BytesSent: 4096, BytesRecv: 2048,
+			ConnTime: 1700000200, PingTime: 1.5, MinPing: 1.2, Version: 70015, SubVersion: "/Satoshi:0.20.1/",
+			Inbound: true, ConnectionType: "inbound", StartingHeight: 150, SyncedHeaders: -1, SyncedBlocks: -1,
+		},
+	}
+}
+
+// header builds the verbose 'getblockheader' representation of a block.
+func (s *Server) header(block *Block) map[string]any {
+	tip := s.Chain.Height()
+	confirmations := -1
+	if s.Chain.IsActive(block) {
+		confirmations = tip - block.Height + 1
+	}
+
+	header := map[string]any{
+		"hash":          block.Hash,
+		"confirmations": confirmations,
+		"height":        block.Height,
+		"version":       block.Version,
+		"versionHex":    fmt.Sprintf("%08x", uint32(block.Version)),
+		"merkleroot":    block.MerkleRoot,
+		"time":          block.Time,
+		"mediantime":    s.Chain.MedianTime(block),
+		"nonce":         block.Nonce,
+		"bits":          fmt.Sprintf("%08x", block.Bits),
+		"difficulty":    Difficulty(block.Bits),
+		"chainwork":     fmt.Sprintf("%064x", s.Chain.Work(block)),
+		"nTx":           len(block.Transactions),
+	}
+
+	if block.PreviousHash != "" {
+		header["previousblockhash"] = block.PreviousHash
+	}
+	if next := s.Chain.Block(block.Height + 1); next != nil && s.Chain.IsActive(block) {
+		header["nextblockhash"] = next.Hash
+	}
+
+	return header
+}
+
+// block builds the verbose 'getblock' representation of a block for verbosity 1 to 3.
+func (s *Server) block(block *Block, verbosity int) map[string]any {
+	result := s.header(block)
+
+	size := block.Size()
+	result["size"] = size
+	result["strippedsize"] = size
+	result["weight"] = size * 4
+
+	if verbosity == 1 {
+		result["tx"] = block.TxIDs
+		return result
+	}
+
+	txs := []map[string]any{}
+	for i := range block.Transactions {
+		txs = append(txs, s.transaction(block, i, verbosity))
+	}
+	result["tx"] = txs
+
+	return result
+}
+
+// transaction builds the verbose representation of the block's i-th transaction. At verbosity 3,
+// inputs include the outputs they spend.
+func (s *Server) transaction(block *Block, i int, verbosity int) map[string]any {
+	data := block.Transactions[i]
+	tx, err := wire.DecodeTx(data)
+	if err != nil {
+		panic(err)
+	}
+
+	vin := []map[string]any{}
+	fee, known := int64(0), true
+	for _, input := range tx.Inputs {
+		if tx.IsCoinbase() {
+			vin = append(vin, map[string]any{
+				"coinbase": hex.EncodeToString(input.ScriptSig),
+				"sequence": input.Sequence,
+			})
+			continue
+		}
+
+		entry := map[string]any{
+			"txid":      input.PreviousOutput.Hash.String(),
+			"vout":      input.PreviousOutput.Index,
+			"scriptSig": map[string]any{"asm": "", "hex": hex.EncodeToString(input.ScriptSig)},
+			"sequence":  input.Sequence,
+		}
+
+		output, funding := s.prevout(input)
+		if output == nil {
+			known = false
+		} else {
+			fee += output.Value
+			if verbosity >= 3 {
+				entry["prevout"] = map[string]any{
+					"generated":    funding.TxIDs[0] == input.PreviousOutput.Hash.String(),
+					"height":       funding.Height,
+					"value":        float64(output.Value) / 1e8,
+					"scriptPubKey": script(output.ScriptPubKey),
+				}
+			}
+		}
+		vin = append(vin, entry)
+	}
+
+	vout := []map[string]any{}
+	for n, output := range tx.Outputs {
+		fee -= output.Value
+		vout = append(vout, map[string]any{
+			"value":        float64(output.Value) / 1e8,
+			"n":            n,
+			"scriptPubKey": script(output.ScriptPubKey),
+		})
+	}
+
+	result := map[string]any{
+		"txid":     tx.TxID().String(),
+		"hash":     tx.WTxID().String(),
+		"version":  tx.Version,
+		"size":     tx.Size(),
+		"vsize":    tx.VSize(),
+		"weight":   tx.Weight(),
+		"locktime": tx.LockTime,
+		"vin":      vin,
+		"vout":     vout,
+		"hex":      hex.EncodeToString(data),
+	}
+	if !tx.IsCoinbase() && known {
+		result["fee"] = float64(fee) / 1e8
+	}
+	return result
+}
+
+// script builds the representation of an output script. Fixture coinbases pay to OP_TRUE.
+func script(data []byte) map[string]any {
+	if bytes.Equal(data, []byte{0x51}) {
+		return map[string]any{
+			"asm":  "1",
+			"desc": "raw(51)#8lvh9jxk",
+			"hex":  "51",
+			"type": "nonstandard",
+		}
+	}
+	return map[string]any{
+		"hex":  hex.EncodeToString(data),
+		"type": scriptType(data),
+	}
+}
+
+// scriptType classifies an output script as Bitcoin Core does for standard templates.
+func scriptType(data []byte) string {
+	switch {
+	case len(data) == 25 && data[0] == 0x76 && data[1] == 0xa9 && data[2] == 0x14 && data[23] == 0x88 && data[24] == 0xac:
+		return "pubkeyhash"
+	case len(data) == 23 && data[0] == 0xa9 && data[1] == 0x14 && data[22] == 0x87:
+		return "scripthash"
+	case len(data) == 22 && data[0] == 0x00 && data[1] == 0x14:
+		return "witness_v0_keyhash"
+	case len(data) == 34 && data[0] == 0x00 && data[1] == 0x20:
+		return "witness_v0_scripthash"
+	case len(data) == 34 && data[0] == 0x51 && data[1] == 0x20:
+		return "witness_v1_taproot"
+	case len(data) > 0 && data[0] == 0x6a:
+		return "nulldata"
+	}
+	return "nonstandard"
+}
+
+// stats builds the 'getblockstats' representation of a block, computed from its transactions as
+// Bitcoin Core does: fees, sizes and fee rates exclude the coinbase, and fee rates are in sat/vB.
+func (s *Server) stats(block *Block) map[string]any {
+	decoded, err := wire.DecodeBlock(block.Serialize())
+	if err != nil {
+		panic(err)
+	}
+
+	var ins, outs, totalOut, totalSize, totalWeight, totalFee, swTxs, swSize, swWeight, utxoSize int64
+	fees, feerates, sizes := []int64{}, []int64{}, []int64{}
+	scores := [][2]int64{} // Fee rate and weight of each transaction
+
+	for _, tx := range decoded.Transactions {
+		outs += int64(len(tx.Outputs))
+		for _, output := range tx.Outputs {
+			utxoSize += int64(utxoOverhead + 9 + len(output.ScriptPubKey))
+		}
+		if tx.IsCoinbase() {
+			continue
+		}
+
+		fee := int64(0)
+		for _, input := range tx.Inputs {
+			if output, _ := s.prevout(input); output != nil {
+				fee += output.Value
+				utxoSize -= int64(utxoOverhead + 9 + len(output.ScriptPubKey))
+			}
+		}
+		for _, output := range tx.Outputs {
+			fee -= output.Value
+			totalOut += output.Value
+		}
+
+		size, weight := int64(tx.Size()), int64(tx.Weight())
+		ins += int64(len(tx.Inputs))
+		totalSize += size
+		totalWeight += weight
+		totalFee += fee
+		if tx.HasWitness() {
+			swTxs++
+			swSize += size
+			swWeight += weight
+		}
+
+		feerate := fee * 4 / weight
+		fees, feerates, sizes = append(fees, fee), append(feerates, feerate), append(sizes, size)
+		scores = append(scores, [2]int64{feerate, weight})
+	}
+
+	txs := int64(len(decoded.Transactions))
+	average := func(total int64) int64 {
+		if txs <= 1 {
+			return 0
+		}
+		return total / (txs - 1)
+	}
+	avgFeerate := int64(0)
+	if totalWeight > 0 {
+		avgFeerate = totalFee * 4 / totalWeight
+	}
+
+	return map[string]any{
+		"avgfee":               average(totalFee),
+		"avgfeerate":           avgFeerate,
+		"avgtxsize":            average(totalSize),
+		"blockhash":            block.Hash,
+		"feerate_percentiles":  percentiles(scores, totalWeight),
+		"height":               block.Height,
+		"ins":                  ins,
+		"maxfee":               extreme(fees, slices.Max),
+		"maxfeerate":           extreme(feerates, slices.Max),
+		"maxtxsize":            extreme(sizes, slices.Max),
+		"medianfee":            median(fees),
+		"mediantime":           s.Chain.MedianTime(block),
+		"mediantxsize":         median(sizes),
+		"minfee":               extreme(fees, slices.Min),
+		"minfeerate":           extreme(feerates, slices.Min),
+		"mintxsize":            extreme(sizes, slices.Min),
+		"outs":                 outs,
+		"subsidy":              Subsidy,
+		"swtotal_size":         swSize,
+		"swtotal_weight":       swWeight,
+		"swtxs":                swTxs,
+		"time":                 block.Time,
+		"total_out":            totalOut,
+		"total_size":           totalSize,
+		"total_weight":         totalWeight,
+		"totalfee":             totalFee,
+		"txs":                  txs,
+		"utxo_increase":        outs - ins,
+		"utxo_size_inc":        utxoSize,
+		"utxo_increase_actual": outs - ins,
+		"utxo_size_inc_actual": utxoSize,
+	}
+}
+
+// utxoOverhead is the size Bitcoin Core accounts for each UTXO besides its output (outpoint, height and coinbase flag).
+const utxoOverhead = 41
+
+// percentiles computes the 10th, 25th, 50th, 75th and 90th fee rate percentiles, weighted by transaction weight.
+func percentiles(scores [][2]int64, total int64) []int64 {
+	result := make([]int64, 5)
+	if len(scores) == 0 {
+		return result
+	}
+
+	slices.SortFunc(scores, func(a, b [2]int64) int { return cmp.Compare(a[0], b[0]) })
+	thresholds := []float64{float64(total) / 10, float64(total) / 4, float64(total) / 2, float64(total) * 3 / 4, float64(total) * 9 / 10}
+
+	next, cumulative := 0, int64(0)
+	for _, score := range scores {
+		cumulative += score[1]
+		for ; next < len(thresholds) && float64(cumulative) >= thresholds[next]; next++ {
+			result[next] = score[0]
+		}
+	}
+	for ; next < len(thresholds); next++ {
+		result[next] = scores[len(scores)-1][0]
+	}
+	return result
+}
+
+// median computes the truncated median of values, 0 if there are none.
+func median(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Sorted(slices.Values(values))
+	if len(sorted)%2 == 0 {
+		return (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+	return sorted[len(sorted)/2]
+}
+
+// extreme computes the minimum or maximum of values, 0 if there are none.
+func extreme(values []int64, f func([]int64) int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return f(values)
+}
+
+// chainwork formats the cumulative work of the chain's tip.
+func (s *Server) chainwork() string {
+	return fmt.Sprintf("%064x", s.Chain.Work(s.Chain.Tip()))
+}
+
+// difficulty returns the difficulty of the chain's tip.
+func (s *Server) difficulty() *big.Float {
+	return big.NewFloat(Difficulty(s.Chain.Tip().Bits))
+}
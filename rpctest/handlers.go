@@ -0,0 +1,773 @@
+package rpctest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// register installs the built-in handlers, serving the fixture chain, peers and ban list.
+func (s *Server) register() {
+	builtins := map[rpc.Method]Handler{
+		// Blockchain
+		"getbestblockhash":   s.getBestBlockHash,
+		"getblock":           s.getBlock,
+		"getblockchaininfo":  s.getBlockchainInfo,
+		"getblockcount":      s.getBlockCount,
+		"getblockfilter":     s.getBlockFilter,
+		"getblockhash":       s.getBlockHash,
+		"getblockheader":     s.getBlockHeader,
+		"getblockstats":      s.getBlockStats,
+		"getchaintips":       s.getChainTips,
+		"getchaintxstats":    s.getChainTxStats,
+		"getdifficulty":      s.getDifficulty,
+		"waitforblockheight": s.waitForBlockHeight,
+		"waitfornewblock":    s.waitForNewBlock,
+
+		// Mempool
+		"getmempoolinfo": s.getMempoolInfo,
+
+		// Network
+		"addnode":            s.addNode,
+		"clearbanned":        s.clearBanned,
+		"disconnectnode":     s.disconnectNode,
+		"getaddednodeinfo":   s.getAddedNodeInfo,
+		"getconnectioncount": s.getConnectionCount,
+		"getnettotals":       s.getNetTotals,
+		"getnetworkinfo":     s.getNetworkInfo,
+		"getnodeaddresses":   s.getNodeAddresses,
+		"getpeerinfo":        s.getPeerInfo,
+		"listbanned":         s.listBanned,
+		"ping":               s.ping,
+		"setban":             s.setBan,
+		"setnetworkactive":   s.setNetworkActive,
+
+		// Control
+		"getmemoryinfo": s.getMemoryInfo,
+		"getrpcinfo":    s.getRPCInfo,
+		"help":          s.help,
+		"logging":       s.logging,
+
+		// ZeroMQ (no notifications are enabled by default)
+		"getzmqnotifications": func(params []json.RawMessage) (any, error) { return []any{}, nil },
+	}
+
+	for method, handler := range builtins {
+		if _, ok := s.handlers[method]; !ok {
+			s.handlers[method] = handler
+		}
+	}
+}
+
+// lookup resolves the block hash parameter at index i, failing like Bitcoin Core
+// for malformed or unknown hashes.
+func (s *Server) lookup(params []json.RawMessage, i int) (*Block, error) {
+	hash, err := param(params, i, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+		return nil, &Error{Code: RPCInvalidParameter, Message: fmt.Sprintf("blockhash must be of length 64 (not %d, for '%s')", len(hash), hash)}
+	}
+
+	block := s.Chain.ByHash(hash)
+	if block == nil {
+		return nil, &Error{Code: RPCInvalidAddressOrKey, Message: "Block not found"}
+	}
+	return block, nil
+}
+
+func (s *Server) getBestBlockHash(params []json.RawMessage) (any, error) {
+	return s.Chain.Tip().Hash, nil
+}
+
+func (s *Server) getBlock(params []json.RawMessage) (any, error) {
+	block, err := s.lookup(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verbosity may also be given as a boolean by older clients
+	verbosity, err := param(params, 1, 1)
+	if err != nil {
+		verbose, err := param(params, 1, true)
+		if err != nil {
+			return nil, err
+		}
+		verbosity = 0
+		if verbose {
+			verbosity = 1
+		}
+	}
+
+	if verbosity <= 0 {
+		return hex.EncodeToString(block.Serialize()), nil
+	}
+	return s.block(block, min(verbosity, 3)), nil
+}
+
+func (s *Server) getBlockchainInfo(params []json.RawMessage) (any, error) {
+	tip := s.Chain.Tip()
+
+	return map[string]any{
+		"chain":                "regtest",
+		"blocks":               tip.Height,
+		"headers":              tip.Height,
+		"bestblockhash":        tip.Hash,
+		"bits":                 fmt.Sprintf("%08x", tip.Bits),
+		"target":               fmt.Sprintf("%064x", Target(tip.Bits)),
+		"difficulty":           Difficulty(tip.Bits),
+		"time":                 tip.Time,
+		"mediantime":           s.Chain.MedianTime(tip),
+		"verificationprogress": 1,
+		"initialblockdownload": false,
+		"chainwork":            s.chainwork(),
+		"size_on_disk":         tip.Height * 250,
+		"pruned":               false,
+		"warnings":             []string{},
+	}, nil
+}
+
+func (s *Server) getBlockCount(params []json.RawMessage) (any, error) {
+	return s.Chain.Height(), nil
+}
+
+func (s *Server) getBlockHash(params []json.RawMessage) (any, error) {
+	height, err := param(params, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	block := s.Chain.Block(height)
+	if block == nil {
+		return nil, &Error{Code: RPCInvalidParameter, Message: "Block height out of range"}
+	}
+	return block.Hash, nil
+}
+
+func (s *Server) getBlockHeader(params []json.RawMessage) (any, error) {
+	block, err := s.lookup(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	verbose, err := param(params, 1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !verbose {
+		return hex.EncodeToString(block.Header), nil
+	}
+	return s.header(block), nil
+}
+
+func (s *Server) getBlockStats(params []json.RawMessage) (any, error) {
+	var block *Block
+
+	// The block may be given by height or by hash
+	if height, err := param(params, 0, -1); err == nil {
+		if block = s.Chain.Block(height); block == nil {
+			return nil, &Error{Code: RPCInvalidParameter, Message: fmt.Sprintf("Target block height %d after current tip %d", height, s.Chain.Height())}
+		}
+	} else if block, err = s.lookup(params, 0); err != nil {
+		return nil, err
+	}
+
+	stats := s.stats(block)
+
+	selected, err := param(params, 1, []string{})
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return stats, nil
+	}
+
+	filtered := map[string]any{}
+	for _, stat := range selected {
+		value, ok := stats[stat]
+		if !ok {
+			return nil, &Error{Code: RPCInvalidParameter, Message: fmt.Sprintf("Invalid selected statistic '%s'", stat)}
+		}
+		filtered[stat] = value
+	}
+	return filtered, nil
+}
+
+func (s *Server) getChainTips(params []json.RawMessage) (any, error) {
+	tip := s.Chain.Tip()
+
+	tips := []map[string]any{{
+		"height":    tip.Height,
+		"hash":      tip.Hash,
+		"branchlen": 0,
+		"status":    "active",
+	}}
+
+	for _, fork := range s.Chain.Forks() {
+		// The branch starts right after the last block it shares with the active chain
+		branch := 0
+		for current := fork; current != nil && !s.Chain.IsActive(current); current = s.Chain.ByHash(current.PreviousHash) {
+			branch++
+		}
+
+		tips = append(tips, map[string]any{
+			"height":    fork.Height,
+			"hash":      fork.Hash,
+			"branchlen": branch,
+			"status":    "valid-fork",
+		})
+	}
+
+	return tips, nil
+}
+
+func (s *Server) getChainTxStats(params []json.RawMessage) (any, error) {
+	tip := s.Chain.Tip()
+	if len(params) > 1 {
+		block, err := s.lookup(params, 1)
+		if err != nil {
+			return nil, err
+		}
+		tip = block
+	}
+
+	// One month of blocks by default, as Bitcoin Core does
+	window, err := param(params, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	if window == -1 {
+		window = max(min(tip.Height-1, int(30*24*time.Hour/time.Second)/int(BlockInterval)), 0)
+	}
+	if window < 0 || window > 0 && window >= tip.Height {
+		return nil, &Error{Code: RPCInvalidParameter, Message: "Invalid block count: should be between 0 and the block's height - 1"}
+	}
+
+	stats := map[string]any{
+		"time":                      tip.Time,
+		"txcount":                   tip.Height + 1, // One coinbase per block
+		"window_final_block_hash":   tip.Hash,
+		"window_final_block_height": tip.Height,
+		"window_block_count":        window,
+	}
+
+	if window > 0 {
+		start := s.Chain.Block(tip.Height - window)
+		interval := tip.Time - start.Time
+		stats["window_tx_count"] = window
+		stats["window_interval"] = interval
+		stats["txrate"] = float64(window) / float64(interval)
+	}
+
+	return stats, nil
+}
+
+func (s *Server) getDifficulty(params []json.RawMessage) (any, error) {
+	return s.difficulty(), nil
+}
+
+func (s *Server) waitForBlockHeight(params []json.RawMessage) (any, error) {
+	height, err := param(params, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := param(params, 1, int64(0))
+	if err != nil {
+		return nil, err
+	}
+
+	s.await(timeout, func() bool { return s.Chain.Height() >= height })
+
+	tip := s.Chain.Tip()
+	return map[string]any{"hash": tip.Hash, "height": tip.Height}, nil
+}
+
+func (s *Server) waitForNewBlock(params []json.RawMessage) (any, error) {
+	timeout, err := param(params, 0, int64(0))
+	if err != nil {
+		return nil, err
+	}
+
+	start := s.Chain.Tip()
+	s.await(timeout, func() bool { return s.Chain.Tip() != start })
+
+	tip := s.Chain.Tip()
+	return map[string]any{"hash": tip.Hash, "height": tip.Height}, nil
+}
+
+// await blocks until done reports true, the timeout (in milliseconds, 0 meaning no timeout)
+// expires or the server shuts down.
+func (s *Server) await(timeout int64, done func() bool) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(time.Duration(timeout) * time.Millisecond)
+	}
+
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !done() {
+		select {
+		case <-ticker.C:
+		case <-expired:
+			return
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+func (s *Server) addNode(params []json.RawMessage) (any, error) {
+	node, err := param(params, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	command, err := param(params, 1, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch command {
+	case "onetry":
+		return nil, nil
+	case "add":
+		if slices.Contains(s.state.added, node) {
+			return nil, &Error{Code: RPCClientNodeAlreadyAdded, Message: "Error: Node already added"}
+		}
+		s.state.added = append(s.state.added, node)
+		return nil, nil
+	case "remove":
+		i := slices.Index(s.state.added, node)
+		if i < 0 {
+			return nil, &Error{Code: RPCClientNodeNotAdded, Message: "Error: Node could not be removed. It has not been added previously."}
+		}
+		s.state.added = slices.Delete(s.state.added, i, i+1)
+		return nil, nil
+	}
+
+	return nil, &Error{Code: RPCInvalidParameter, Message: "Error: command must be one of 'add', 'remove' or 'onetry'"}
+}
+
+func (s *Server) clearBanned(params []json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Bans = nil
+	return nil, nil
+}
+
+func (s *Server) disconnectNode(params []json.RawMessage) (any, error) {
+	address, err := param(params, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	id, err := param(params, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	if address != "" && id >= 0 {
+		return nil, &Error{Code: RPCInvalidParameter, Message: "Only one of address and nodeid should be provided."}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.Peers, func(peer Peer) bool {
+		return address != "" && peer.Address == address || address == "" && peer.ID == id
+	})
+	if i < 0 {
+		return nil, &Error{Code: RPCClientNodeNotConnected, Message: "Node not found in connected nodes"}
+	}
+
+	s.Peers = slices.Delete(s.Peers, i, i+1)
+	return nil, nil
+}
+
+func (s *Server) getAddedNodeInfo(params []json.RawMessage) (any, error) {
+	node, err := param(params, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node != "" && !slices.Contains(s.state.added, node) {
+		return nil, &Error{Code: RPCClientNodeNotAdded, Message: "Error: Node has not been added."}
+	}
+
+	nodes := []map[string]any{}
+	for _, added := range s.state.added {
+		if node != "" && added != node {
+			continue
+		}
+
+		connected := slices.ContainsFunc(s.Peers, func(peer Peer) bool { return peer.Address == added })
+		addresses := []map[string]any{}
+		if connected {
+			addresses = append(addresses, map[string]any{"address": added, "connected": "outbound"})
+		}
+
+		nodes = append(nodes, map[string]any{
+			"addednode": added,
+			"connected": connected,
+			"addresses": addresses,
+		})
+	}
+
+	return nodes, nil
+}
+
+func (s *Server) getConnectionCount(params []json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.state.active {
+		return 0, nil
+	}
+	return len(s.Peers), nil
+}
+
+func (s *Server) getNetTotals(params []json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]any{
+		"totalbytesrecv": s.state.totals[0],
+		"totalbytessent": s.state.totals[1],
+		"timemillis":     time.Now().UnixMilli(),
+		"uploadtarget": map[string]any{
+			"timeframe":               86400,
+			"target":                  0,
+			"target_reached":          false,
+			"serve_historical_blocks": true,
+			"bytes_left_in_cycle":     0,
+			"time_left_in_cycle":      0,
+		},
+	}, nil
+}
+
+func (s *Server) getNetworkInfo(params []json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inbound := 0
+	for _, peer := range s.Peers {
+		if peer.Inbound {
+			inbound++
+		}
+	}
+
+	connections := len(s.Peers)
+	if !s.state.active {
+		connections, inbound = 0, 0
+	}
+
+	return map[string]any{
+		"version":            280000,
+		"subversion":         "/Satoshi:28.0.0/",
+		"protocolversion":    70016,
+		"localservices":      "0000000000000c09",
+		"localservicesnames": []string{"NETWORK", "WITNESS", "NETWORK_LIMITED", "P2P_V2"},
+		"localrelay":         true,
+		"timeoffset":         0,
+		"networkactive":      s.state.active,
+		"connections":        connections,
+		"connections_in":     inbound,
+		"connections_out":    connections - inbound,
+		"networks": []map[string]any{
+			{"name": "ipv4", "limited": false, "reachable": true, "proxy": "", "proxy_randomize_credentials": false},
+			{"name": "ipv6", "limited": false, "reachable": true, "proxy": "", "proxy_randomize_credentials": false},
+			{"name": "onion", "limited": true, "reachable": false, "proxy": "", "proxy_randomize_credentials": false},
+		},
+		"relayfee":       0.00001,
+		"incrementalfee": 0.00001,
+		"localaddresses": []map[string]any{},
+		"warnings":       []string{},
+	}, nil
+}
+
+func (s *Server) getNodeAddresses(params []json.RawMessage) (any, error) {
+	count, err := param(params, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, &Error{Code: RPCInvalidParameter, Message: "Address count out of range"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	addresses := []map[string]any{}
+	for _, peer := range s.Peers {
+		if count > 0 && len(addresses) >= count {
+			break
+		}
+
+		host, port, _ := net.SplitHostPort(peer.Address)
+		number, _ := strconv.Atoi(port)
+		addresses = append(addresses, map[string]any{
+			"time":     peer.LastRecv,
+			"services": 3081,
+			"address":  host,
+			"port":     number,
+			"network":  peer.Network,
+		})
+	}
+
+	return addresses, nil
+}
+
+// getMempoolInfo describes an always empty mempool.
+func (s *Server) getMempoolInfo(params []json.RawMessage) (any, error) {
+	return map[string]any{
+		"loaded":              true,
+		"size":                0,
+		"bytes":               0,
+		"usage":               0,
+		"total_fee":           0,
+		"maxmempool":          300_000_000,
+		"mempoolminfee":       0.00001,
+		"minrelaytxfee":       0.00001,
+		"incrementalrelayfee": 0.00001,
+		"unbroadcastcount":    0,
+		"fullrbf":             true,
+	}, nil
+}
+
+func (s *Server) getPeerInfo(params []json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.state.active {
+		return []Peer{}, nil
+	}
+	return slices.Clone(s.Peers), nil
+}
+
+func (s *Server) listBanned(params []json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+	banned := []Banned{}
+	for _, ban := range s.Bans {
+		ban.TimeRemaining = max(ban.BannedUntil-now, 0)
+		banned = append(banned, ban)
+	}
+	return banned, nil
+}
+
+func (s *Server) ping(params []json.RawMessage) (any, error) {
+	return nil, nil
+}
+
+func (s *Server) setBan(params []json.RawMessage) (any, error) {
+	target, err := param(params, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	command, err := param(params, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	duration, err := param(params, 2, int64(0))
+	if err != nil {
+		return nil, err
+	}
+	absolute, err := param(params, 3, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if command != "add" && command != "remove" {
+		return nil, &Error{Code: RPCInvalidParameter, Message: "Error: command must be 'add' or 'remove'"}
+	}
+
+	// Single addresses are stored as /32 (or /128) subnets, as Bitcoin Core does
+	subnet := target
+	if !strings.Contains(subnet, "/") {
+		ip := net.ParseIP(subnet)
+		if ip == nil {
+			return nil, &Error{Code: RPCClientInvalidIPOrSubnet, Message: "Error: Invalid IP/Subnet"}
+		}
+		if ip.To4() != nil {
+			subnet += "/32"
+		} else {
+			subnet += "/128"
+		}
+	} else if _, network, err := net.ParseCIDR(subnet); err != nil {
+		return nil, &Error{Code: RPCClientInvalidIPOrSubnet, Message: "Error: Invalid IP/Subnet"}
+	} else {
+		subnet = network.String()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.Bans, func(ban Banned) bool { return ban.Address == subnet })
+
+	if command == "remove" {
+		if i < 0 {
+			return nil, &Error{Code: RPCClientInvalidIPOrSubnet, Message: "Error: Unban failed. Requested address/subnet was not previously manually banned."}
+		}
+		s.Bans = slices.Delete(s.Bans, i, i+1)
+		return nil, nil
+	}
+
+	if i >= 0 {
+		return nil, &Error{Code: RPCClientNodeAlreadyAdded, Message: "Error: IP/Subnet already banned"}
+	}
+
+	now := time.Now().Unix()
+	if duration == 0 {
+		duration = 24 * 60 * 60
+	}
+	until := now + duration
+	if absolute {
+		until = duration
+	}
+
+	s.Bans = append(s.Bans, Banned{Address: subnet, BanCreated: now, BannedUntil: until, BanDuration: until - now})
+	sort.Slice(s.Bans, func(i, j int) bool { return s.Bans[i].Address < s.Bans[j].Address })
+
+	// Banned peers are disconnected, as Bitcoin Core does
+	_, banned, _ := net.ParseCIDR(subnet)
+	s.Peers = slices.DeleteFunc(s.Peers, func(peer Peer) bool {
+		host, _, err := net.SplitHostPort(peer.Address)
+		return err == nil && banned.Contains(net.ParseIP(host))
+	})
+
+	return nil, nil
+}
+
+func (s *Server) setNetworkActive(params []json.RawMessage) (any, error) {
+	if len(params) == 0 {
+		return nil, &Error{Code: RPCInvalidParameter, Message: "Missing required parameter state"}
+	}
+	active, err := param(params, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.active = active
+	return active, nil
+}
+
+func (s *Server) getMemoryInfo(params []json.RawMessage) (any, error) {
+	mode, err := param(params, 0, "stats")
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case "stats":
+		return map[string]any{
+			"locked": map[string]any{
+				"used":        65536,
+				"free":        196608,
+				"total":       262144,
+				"locked":      262144,
+				"chunks_used": 1,
+				"chunks_free": 2,
+			},
+		}, nil
+	case "mallocinfo":
+		return `<malloc version="1"></malloc>`, nil
+	}
+
+	return nil, &Error{Code: RPCInvalidParameter, Message: fmt.Sprintf("unknown mode %s", mode)}
+}
+
+func (s *Server) getRPCInfo(params []json.RawMessage) (any, error) {
+	return map[string]any{
+		"active_commands": []map[string]any{
+			{"method": "getrpcinfo", "duration": 0},
+		},
+		"logpath": "/root/.bitcoin/regtest/debug.log",
+	}, nil
+}
+
+func (s *Server) help(params []json.RawMessage) (any, error) {
+	command, err := param(params, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	methods := []string{}
+	for method := range s.handlers {
+		methods = append(methods, string(method))
+	}
+	s.mu.Unlock()
+	slices.Sort(methods)
+
+	if command == "" {
+		return strings.Join(methods, "\n"), nil
+	}
+	if !slices.Contains(methods, command) {
+		return "help: unknown command: " + command, nil
+	}
+	return command + "\n\nServed by the bitclient fake bitcoind.", nil
+}
+
+func (s *Server) logging(params []json.RawMessage) (any, error) {
+	include, err := param(params, 0, []string{})
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := param(params, 1, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, categories := range [][]string{include, exclude} {
+		for _, category := range categories {
+			if _, ok := s.state.logs[category]; !ok && category != "all" && category != "1" && category != "none" && category != "0" {
+				return nil, &Error{Code: RPCInvalidParameter, Message: fmt.Sprintf("unknown logging category %s", category)}
+			}
+		}
+	}
+
+	set := func(categories []string, enabled bool) {
+		for _, category := range categories {
+			if category == "all" || category == "1" || category == "none" || category == "0" {
+				for name := range s.state.logs {
+					s.state.logs[name] = enabled
+				}
+				continue
+			}
+			s.state.logs[category] = enabled
+		}
+	}
+	set(include, true)
+	set(exclude, false)
+
+	logs := map[string]any{}
+	for name, enabled := range s.state.logs {
+		logs[name] = enabled
+	}
+	return logs, nil
+}
@@ -0,0 +1,341 @@
+package rpctest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Limits of the REST interface, as defined in Bitcoin Core's src/rest.cpp.
+const (
+	maxHeaders   = 2000 // Maximum number of headers returned by '/rest/headers/'
+	maxOutpoints = 15   // Maximum number of outpoints queried by '/rest/getutxos/'
+)
+
+// WithoutREST disables the server's REST interface, as bitcoind does unless started with -rest.
+func WithoutREST() Option {
+	return func(s *Server) {
+		s.restless = true
+	}
+}
+
+// Paths returns the path of every REST request received so far, in order.
+func (s *Server) Paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.paths...)
+}
+
+// endpoint serves a REST resource in the format requested through the path's extension.
+type endpoint func(resource string, format string, r *http.Request) ([]byte, error)
+
+// restError is a REST failure, written as a plain text body with its status code.
+type restError struct {
+	status  int
+	message string
+}
+
+func (e *restError) Error() string {
+	return e.message
+}
+
+// rest handles an unauthenticated request to the REST interface.
+func (s *Server) rest(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.paths = append(s.paths, r.URL.RequestURI())
+	restless := s.restless
+	s.mu.Unlock()
+
+	// Without -rest, bitcoind doesn't register any REST handler
+	if restless {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	endpoints := []struct {
+		prefix   string
+		formats  []string
+		endpoint endpoint
+	}{
+		{"/rest/block/notxdetails/", []string{"bin", "hex", "json"}, s.restBlock(1)},
+		{"/rest/block/", []string{"bin", "hex", "json"}, s.restBlock(3)},
+		{"/rest/headers/", []string{"bin", "hex", "json"}, s.restHeaders},
+		{"/rest/blockhashbyheight/", []string{"bin", "hex", "json"}, s.restBlockHashByHeight},
+		{"/rest/chaininfo", []string{"json"}, s.restChainInfo},
+		{"/rest/mempool/", []string{"json"}, s.restMempool},
+		{"/rest/getutxos", []string{"bin", "hex", "json"}, s.restUTXOs},
+		{"/rest/tx/", []string{"bin", "hex", "json"}, s.restTx},
+	}
+
+	for _, e := range endpoints {
+		if !strings.HasPrefix(r.URL.Path, e.prefix) {
+			continue
+		}
+
+		resource, format := strings.TrimPrefix(r.URL.Path, e.prefix), ""
+		if i := strings.LastIndex(resource, "."); i >= 0 {
+			resource, format = resource[:i], resource[i+1:]
+		}
+		if !slices.Contains(e.formats, format) {
+			http.Error(w, fmt.Sprintf("output format not found (available: %s)", strings.Join(e.formats, ", ")), http.StatusNotFound)
+			return
+		}
+
+		body, err := e.endpoint(resource, format, r)
+		if err != nil {
+			failure, ok := err.(*restError)
+			if !ok {
+				failure = &restError{http.StatusInternalServerError, err.Error()}
+			}
+			http.Error(w, failure.message, failure.status)
+			return
+		}
+
+		switch format {
+		case "bin":
+			w.Header().Set("Content-Type", "application/octet-stream")
+		case "hex":
+			w.Header().Set("Content-Type", "text/plain")
+			body = []byte(hex.EncodeToString(body) + "\n")
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.Write(body)
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// restBlock serves '/rest/block/' with transaction details for the given 'getblock' verbosity.
+func (s *Server) restBlock(verbosity int) endpoint {
+	return func(resource string, format string, r *http.Request) ([]byte, error) {
+		block, err := s.restLookup(resource)
+		if err != nil {
+			return nil, err
+		}
+
+		if format == "json" {
+			return encode(s.block(block, verbosity))
+		}
+		return block.Serialize(), nil
+	}
+}
+
+// restHeaders serves '/rest/headers/<hash>?count=<count>' and the deprecated '/rest/headers/<count>/<hash>'.
+func (s *Server) restHeaders(resource string, format string, r *http.Request) ([]byte, error) {
+	count := r.URL.Query().Get("count")
+	if parts := strings.Split(resource, "/"); len(parts) == 2 {
+		count, resource = parts[0], parts[1]
+	}
+	if count == "" {
+		count = "5"
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 || n > maxHeaders {
+		return nil, &restError{http.StatusBadRequest, fmt.Sprintf("Header count is invalid or out of acceptable range (1-%d): %s", maxHeaders, count)}
+	}
+
+	block, err := s.restLookup(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	// Headers follow the active chain, starting at the requested block
+	headers := []*Block{}
+	for block != nil && s.Chain.IsActive(block) && len(headers) < n {
+		headers = append(headers, block)
+		block = s.Chain.Block(block.Height + 1)
+	}
+
+	if format == "json" {
+		result := []map[string]any{}
+		for _, header := range headers {
+			result = append(result, s.header(header))
+		}
+		return encode(result)
+	}
+
+	var buffer bytes.Buffer
+	for _, header := range headers {
+		buffer.Write(header.Header)
+	}
+	return buffer.Bytes(), nil
+}
+
+// restBlockHashByHeight serves '/rest/blockhashbyheight/<height>'.
+func (s *Server) restBlockHashByHeight(resource string, format string, r *http.Request) ([]byte, error) {
+	height, err := strconv.Atoi(resource)
+	if err != nil || height < 0 {
+		return nil, &restError{http.StatusBadRequest, fmt.Sprintf("Invalid height: %s", resource)}
+	}
+
+	block := s.Chain.Block(height)
+	if block == nil {
+		return nil, &restError{http.StatusNotFound, "Block height out of range"}
+	}
+
+	switch format {
+	case "json":
+		return encode(map[string]any{"blockhash": block.Hash})
+	case "hex":
+		// The hash is written in display order, rather than as the hex encoding of the binary response
+		return decode(block.Hash), nil
+	}
+	return reverse(decode(block.Hash)), nil
+}
+
+// restChainInfo serves '/rest/chaininfo', the 'getblockchaininfo' result.
+func (s *Server) restChainInfo(resource string, format string, r *http.Request) ([]byte, error) {
+	info, err := s.getBlockchainInfo(nil)
+	if err != nil {
+		return nil, err
+	}
+	return encode(info)
+}
+
+// restMempool serves '/rest/mempool/info' and '/rest/mempool/contents' for an always empty mempool.
+func (s *Server) restMempool(resource string, format string, r *http.Request) ([]byte, error) {
+	switch resource {
+	case "info":
+		info, err := s.getMempoolInfo(nil)
+		if err != nil {
+			return nil, err
+		}
+		return encode(info)
+	case "contents":
+		return encode(map[string]any{})
+	}
+	return nil, &restError{http.StatusBadRequest, "Invalid URI format. Expected /rest/mempool/<info|contents>.json"}
+}
+
+// restUTXOs serves '/rest/getutxos/[checkmempool/]<txid>-<n>/...'. Every fixture output is unspent.
+func (s *Server) restUTXOs(resource string, format string, r *http.Request) ([]byte, error) {
+	parts := strings.Split(strings.Trim(resource, "/"), "/")
+	if len(parts) > 0 && parts[0] == "checkmempool" {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 || parts[0] == "" {
+		return nil, &restError{http.StatusBadRequest, "Error: empty request"}
+	}
+	if len(parts) > maxOutpoints {
+		return nil, &restError{http.StatusBadRequest, fmt.Sprintf("Error: max outpoints exceeded (max: %d, tried: %d)", maxOutpoints, len(parts))}
+	}
+
+	tip := s.Chain.Tip()
+	bitmap := make([]byte, (len(parts)+7)/8)
+	hits, outputs := "", []*Block{}
+
+	for i, part := range parts {
+		txid, n, ok := strings.Cut(part, "-")
+		index, err := strconv.ParseUint(n, 10, 32)
+		if _, hexErr := hex.DecodeString(txid); !ok || err != nil || hexErr != nil || len(txid) != 64 {
+			return nil, &restError{http.StatusBadRequest, "Parse error"}
+		}
+
+		block, _ := s.lookupTx(txid)
+		if block == nil || index != 0 {
+			hits += "0"
+			continue
+		}
+
+		hits += "1"
+		bitmap[i/8] |= 1 << (i % 8)
+		outputs = append(outputs, block)
+	}
+
+	if format == "json" {
+		utxos := []map[string]any{}
+		for _, block := range outputs {
+			utxos = append(utxos, map[string]any{
+				"height":       block.Height,
+				"value":        float64(Subsidy) / 1e8,
+				"scriptPubKey": script([]byte{0x51}),
+			})
+		}
+		return encode(map[string]any{
+			"chainHeight":  tip.Height,
+			"chaintipHash": tip.Hash,
+			"bitmap":       hits,
+			"utxos":        utxos,
+		})
+	}
+
+	data := binary.LittleEndian.AppendUint32(nil, uint32(tip.Height))
+	data = append(data, reverse(decode(tip.Hash))...)
+	data = append(data, varint(uint64(len(bitmap)))...)
+	data = append(data, bitmap...)
+	data = append(data, varint(uint64(len(outputs)))...)
+	for _, block := range outputs {
+		data = binary.LittleEndian.AppendUint32(data, 0) // Unused transaction version
+		data = binary.LittleEndian.AppendUint32(data, uint32(block.Height))
+		data = binary.LittleEndian.AppendUint64(data, uint64(Subsidy))
+		data = append(data, 0x01, 0x51) // OP_TRUE
+	}
+	return data, nil
+}
+
+// restTx serves '/rest/tx/<txid>' for the transactions of the active chain.
+func (s *Server) restTx(resource string, format string, r *http.Request) ([]byte, error) {
+	if _, err := hex.DecodeString(resource); err != nil || len(resource) != 64 {
+		return nil, &restError{http.StatusBadRequest, fmt.Sprintf("Invalid hash: %s", resource)}
+	}
+
+	block, i := s.lookupTx(resource)
+	if block == nil {
+		return nil, &restError{http.StatusNotFound, fmt.Sprintf("%s not found", resource)}
+	}
+
+	if format == "json" {
+		tx := s.transaction(block, i, 2)
+		tx["blockhash"] = block.Hash
+		return encode(tx)
+	}
+	return block.Transactions[i], nil
+}
+
+// restLookup finds a block by the hash given in a REST path.
+func (s *Server) restLookup(hash string) (*Block, error) {
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+		return nil, &restError{http.StatusBadRequest, fmt.Sprintf("Invalid hash: %s", hash)}
+	}
+
+	block := s.Chain.ByHash(hash)
+	if block == nil {
+		return nil, &restError{http.StatusNotFound, fmt.Sprintf("%s not found", hash)}
+	}
+	return block, nil
+}
+
+// lookupTx finds a transaction of the active chain, returning its block and index.
+func (s *Server) lookupTx(txid string) (*Block, int) {
+	for height := s.Chain.Height(); height >= 0; height-- {
+		block := s.Chain.Block(height)
+		if i := slices.Index(block.TxIDs, txid); i >= 0 {
+			return block, i
+		}
+	}
+	return nil, -1
+}
+
+// encode serializes a JSON response body.
+func encode(value any) ([]byte, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
@@ -0,0 +1,352 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// Handler handles a JSON-RPC method, receiving its raw positional parameters.
+// Returning an *Error produces a JSON-RPC error with its code; any other error
+// produces an RPC_MISC_ERROR.
+type Handler func(params []json.RawMessage) (any, error)
+
+// Error is a JSON-RPC error, as returned by Bitcoin Core.
+type Error struct {
+	Code    int    `json:"code"`    // Error code (see the RPC* constants)
+	Message string `json:"message"` // Human-readable message
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Error codes used by Bitcoin Core, as defined in src/rpc/protocol.h.
+const (
+	RPCMiscError               = -1     // Generic error
+	RPCTypeError               = -3     // Unexpected type was passed as parameter
+	RPCInvalidAddressOrKey     = -5     // Invalid address or key (also used for unknown blocks)
+	RPCInvalidParameter        = -8     // Invalid, missing or duplicate parameter
+	RPCClientInInitialDownload = -10    // Still downloading initial blocks
+	RPCClientNodeAlreadyAdded  = -23    // Node is already added
+	RPCClientNodeNotAdded      = -24    // Node has not been added before
+	RPCClientNodeNotConnected  = -29    // Node to disconnect not found in connected nodes
+	RPCClientInvalidIPOrSubnet = -30    // Invalid IP/Subnet
+	RPCInvalidRequest          = -32600 // Invalid JSON-RPC request
+	RPCMethodNotFound          = -32601 // Unknown method
+	RPCParseError              = -32700 // Malformed JSON
+)
+
+// Server is an in-process fake bitcoind, serving JSON-RPC and the REST interface over HTTP
+// from a fixture chain, peers and ban list. Every method can be overridden, and errors or latency can be injected.
+type Server struct {
+	*httptest.Server
+
+	Chain *Chain   // Fixture chain served by the blockchain methods
+	Peers []Peer   // Connected peers served by the network methods
+	Bans  []Banned // Banned subnets served by the network methods
+
+	authentication rpc.Authentication
+	mu             sync.Mutex
+	handlers       map[rpc.Method]Handler
+	failures       map[rpc.Method]*Error
+	latency        map[rpc.Method]time.Duration
+	requests       []rpc.Request
+	paths          []string // Paths of the REST requests received
+	restless       bool     // Whether the REST interface is disabled
+	filterless     bool     // Whether the basic block filter index is disabled
+	state          state
+	closing        chan struct{} // Closed when the server shuts down, ending long-polls
+	once           sync.Once
+}
+
+// state holds the mutable node state changed by RPC calls.
+type state struct {
+	active bool            // Whether network activity is enabled
+	added  []string        // Nodes added through 'addnode add'
+	totals [2]uint64       // Total bytes received and sent
+	logs   map[string]bool // Logging categories
+}
+
+// Option configures the optional settings of a Server created through NewServer.
+type Option func(*Server)
+
+// Credentials accepted by default by the fake server.
+var Credentials = rpc.Authentication{
+	Type:  rpc.AuthenticationTypeCredentials,
+	Label: "bitclient:bitclient",
+}
+
+// WithChain sets the fixture chain served by the server (by default, a chain with 200 blocks).
+func WithChain(chain *Chain) Option {
+	return func(s *Server) {
+		s.Chain = chain
+	}
+}
+
+// WithAuthentication sets the authentication the server expects from clients.
+func WithAuthentication(authentication rpc.Authentication) Option {
+	return func(s *Server) {
+		s.authentication = authentication
+	}
+}
+
+// WithLatency delays every response by d.
+func WithLatency(d time.Duration) Option {
+	return func(s *Server) {
+		s.latency[""] = d
+	}
+}
+
+// NewServer starts a fake bitcoind. The caller should call Close when finished, to shut it down.
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		Peers:          DefaultPeers(),
+		authentication: Credentials,
+		handlers:       map[rpc.Method]Handler{},
+		failures:       map[rpc.Method]*Error{},
+		latency:        map[rpc.Method]time.Duration{},
+		closing:        make(chan struct{}),
+		state: state{
+			active: true,
+			totals: [2]uint64{1_048_576, 524_288},
+			logs:   map[string]bool{"net": false, "rpc": false, "http": false, "mempool": false, "validation": false},
+		},
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.Chain == nil {
+		s.Chain = NewChain(200)
+	}
+
+	s.register()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s
+}
+
+// Close ends pending long-polls and shuts down the server, blocking until all outstanding requests have completed.
+func (s *Server) Close() {
+	s.once.Do(func() { close(s.closing) })
+	s.Server.Close()
+}
+
+// Client returns a new RPCClient connected to the server with the expected authentication.
+func (s *Server) Client() *rpc.RPCClient {
+	client, err := rpc.New(s.URL, s.authentication)
+	if err != nil {
+		panic(fmt.Sprintf("rpctest: failed to create client: %v", err))
+	}
+	return client
+}
+
+// Use points the default rpc.Client to the server for the duration of a test, restoring the
+// previous client when the test ends.
+func (s *Server) Use(t testing.TB) *Server {
+	previous := rpc.Client
+	rpc.Client = s.Client()
+	t.Cleanup(func() { rpc.Client = previous })
+	return s
+}
+
+// Use starts a fake bitcoind and points the default rpc.Client to it for the duration of a test,
+// shutting it down and restoring the previous client when the test ends.
+//
+// Example Usage:
+//
+//	fake := rpctest.Use(t, rpctest.WithChain(rpctest.NewChain(20)))
+//	count, err := blocks.GetBlockCount()
+func Use(t testing.TB, options ...Option) *Server {
+	s := NewServer(options...)
+	t.Cleanup(s.Close)
+	return s.Use(t)
+}
+
+// Handle registers the handler for a method, replacing the built-in one.
+func (s *Server) Handle(method rpc.Method, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[method] = handler
+}
+
+// Fail makes every call to a method fail with the given error, until Recover is called.
+func (s *Server) Fail(method rpc.Method, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[method] = &Error{Code: code, Message: message}
+}
+
+// Recover stops the failures injected into a method through Fail.
+func (s *Server) Recover(method rpc.Method) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, method)
+}
+
+// Delay delays the responses of a method by d. An empty method delays every response.
+func (s *Server) Delay(method rpc.Method, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency[method] = d
+}
+
+// Requests returns every request received so far, in order.
+func (s *Server) Requests() []rpc.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]rpc.Request{}, s.requests...)
+}
+
+// Calls returns how many times a method has been called.
+func (s *Server) Calls(method rpc.Method) int {
+	calls := 0
+	for _, request := range s.Requests() {
+		if request.Method == method {
+			calls++
+		}
+	}
+	return calls
+}
+
+// response is the JSON-RPC response written by the server.
+type response struct {
+	Result any    `json:"result"`
+	Error  *Error `json:"error"`
+	ID     any    `json:"id"`
+}
+
+// request is the JSON-RPC request read by the server, keeping parameters raw.
+type request struct {
+	ID     any               `json:"id"`
+	Method rpc.Method        `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// serve handles an HTTP request, authenticating it and dispatching it to the method's handler.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/rest/") {
+		s.rest(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "JSONRPC server handles only POST requests", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.authenticate(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="jsonrpc"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.write(w, response{Error: &Error{Code: RPCParseError, Message: "Parse error"}})
+		return
+	}
+
+	req := request{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.write(w, response{Error: &Error{Code: RPCParseError, Message: "Parse error"}})
+		return
+	}
+
+	params := make(rpc.Params, len(req.Params))
+	for i, param := range req.Params {
+		params[i] = param
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, rpc.Request{ID: rpc.ID(fmt.Sprint(req.ID)), Method: req.Method, Params: params})
+	handler, failure := s.handlers[req.Method], s.failures[req.Method]
+	delay := s.latency[""] + s.latency[req.Method]
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case failure != nil:
+		s.write(w, response{Error: failure, ID: req.ID})
+	case handler == nil:
+		s.write(w, response{Error: &Error{Code: RPCMethodNotFound, Message: "Method not found"}, ID: req.ID})
+	default:
+		result, err := handler(req.Params)
+		if err != nil {
+			rpcErr, ok := err.(*Error)
+			if !ok {
+				rpcErr = &Error{Code: RPCMiscError, Message: err.Error()}
+			}
+			s.write(w, response{Error: rpcErr, ID: req.ID})
+			return
+		}
+		s.write(w, response{Result: result, ID: req.ID})
+	}
+}
+
+// authenticate checks a request against the authentication expected by the server.
+func (s *Server) authenticate(r *http.Request) bool {
+	expected, _ := http.NewRequest(http.MethodPost, s.URL, nil)
+	if err := s.authentication.Setup(expected); err != nil {
+		return false
+	}
+
+	switch s.authentication.Type {
+	case rpc.AuthenticationTypeCredentials:
+		user, password, ok := r.BasicAuth()
+		expectedUser, expectedPassword, _ := expected.BasicAuth()
+		return ok && user == expectedUser && password == expectedPassword
+	case rpc.AuthenticationTypePath:
+		return r.URL.Path == expected.URL.Path
+	}
+
+	for header := range expected.Header {
+		if r.Header.Get(header) != expected.Header.Get(header) {
+			return false
+		}
+	}
+	return true
+}
+
+// write encodes a response like Bitcoin Core does for JSON-RPC 2.0 requests: always with status 200.
+func (s *Server) write(w http.ResponseWriter, res response) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// param decodes the positional parameter at index i into a value of type T,
+// returning fallback when it's missing or null.
+func param[T any](params []json.RawMessage, i int, fallback T) (T, error) {
+	if i >= len(params) || string(params[i]) == "null" {
+		return fallback, nil
+	}
+
+	var value T
+	if err := json.Unmarshal(params[i], &value); err != nil {
+		return fallback, &Error{Code: RPCTypeError, Message: fmt.Sprintf("JSON value of type %s for parameter %d is not of expected type", params[i], i)}
+	}
+	return value, nil
+}
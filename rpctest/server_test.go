@@ -0,0 +1,225 @@
+package rpctest_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+)
+
+// call sends a request for a method to the server through client.
+func call(client *rpc.RPCClient, method rpc.Method, params ...any) (*rpc.Response, error) {
+	if params == nil {
+		params = rpc.NoParams
+	}
+	return client.Do(rpc.Request{ID: "rpctest", Version: rpc.Version2, Method: method, Params: params})
+}
+
+func Test_Chain(t *testing.T) {
+	chain := rpctest.NewChain(20)
+
+	if chain.Height() != 20 {
+		t.Fatalf("Expected tip at height 20, got %v", chain.Height())
+	}
+
+	for height := 1; height <= chain.Height(); height++ {
+		block := chain.Block(height)
+
+		first := sha256.Sum256(block.Header)
+		second := sha256.Sum256(first[:])
+		hash := hex.EncodeToString(second[:])
+
+		// Compare with the display (byte-reversed) hash
+		reversed := make([]byte, 0, 64)
+		for i := len(hash); i > 0; i -= 2 {
+			reversed = append(reversed, hash[i-2:i]...)
+		}
+		if string(reversed) != block.Hash {
+			t.Errorf("Block %v: header hashes to %s, expected %s", height, reversed, block.Hash)
+		}
+
+		if block.PreviousHash != chain.Block(height-1).Hash {
+			t.Errorf("Block %v doesn't link to its parent", height)
+		}
+	}
+}
+
+func Test_Authentication(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	if _, err := call(server.Client(), "getblockcount"); err != nil {
+		t.Errorf("Failed to call with valid credentials: %v", err)
+	}
+
+	client, err := rpc.New(server.URL, rpc.Authentication{Type: rpc.AuthenticationTypeCredentials, Label: "bitclient:wrong"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := call(client, "getblockcount"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an unauthorized error, got %v", err)
+	}
+}
+
+func Test_Fail(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	client := server.Client()
+
+	server.Fail("getblockcount", rpctest.RPCClientInInitialDownload, "Loading block index...")
+	if _, err := call(client, "getblockcount"); err == nil || !strings.Contains(err.Error(), "Loading block index") {
+		t.Errorf("Expected injected error, got %v", err)
+	}
+
+	server.Recover("getblockcount")
+	if _, err := call(client, "getblockcount"); err != nil {
+		t.Errorf("Failed to call after recovering: %v", err)
+	}
+
+	if _, err := call(client, "getwalletinfo"); err == nil || !strings.Contains(err.Error(), "-32601") {
+		t.Errorf("Expected method not found error, got %v", err)
+	}
+}
+
+func Test_Delay(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	server.Delay("ping", 50*time.Millisecond)
+
+	start := time.Now()
+	if _, err := call(server.Client(), "ping"); err != nil {
+		t.Fatalf("Failed to ping: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected a delayed response, got it after %v", elapsed)
+	}
+}
+
+func Test_Handle(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	server.Handle("getblockcount", func(params []json.RawMessage) (any, error) {
+		return 840000, nil
+	})
+
+	response, err := call(server.Client(), "getblockcount")
+	if err != nil {
+		t.Fatalf("Failed to get block count: %v", err)
+	}
+	if string(response.Result) != "840000" {
+		t.Errorf("Expected overridden result, got %s", response.Result)
+	}
+
+	if calls := server.Calls("getblockcount"); calls != 1 {
+		t.Errorf("Expected 1 recorded call, got %v", calls)
+	}
+}
+
+func Test_PlayTips(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	server.PlayTips(
+		[]rpctest.ChainTip{{Height: 10, Hash: "a", Status: "active"}},
+		[]rpctest.ChainTip{{Height: 11, Hash: "b", Status: "active"}, {Height: 10, Hash: "a", BranchLen: 1, Status: "valid-fork"}},
+	)
+
+	expected := []string{
+		`[{"height":10,"hash":"a","branchlen":0,"status":"active"}]`,
+		`[{"height":11,"hash":"b","branchlen":0,"status":"active"},{"height":10,"hash":"a","branchlen":1,"status":"valid-fork"}]`,
+		`[{"height":11,"hash":"b","branchlen":0,"status":"active"},{"height":10,"hash":"a","branchlen":1,"status":"valid-fork"}]`,
+	}
+	for i, tips := range expected {
+		response, err := call(server.Client(), "getchaintips")
+		if err != nil {
+			t.Fatalf("Failed to get chain tips: %v", err)
+		}
+		if string(response.Result) != tips {
+			t.Errorf("Expected step %d to return %s, got %s", i, tips, response.Result)
+		}
+	}
+}
+
+func Test_Reorg(t *testing.T) {
+	server := rpctest.NewServer(rpctest.WithChain(rpctest.NewChain(10)))
+	defer server.Close()
+
+	disconnected, connected := server.Chain.Reorg(2, 3)
+	if len(disconnected) != 2 || len(connected) != 3 || server.Chain.Height() != 11 {
+		t.Fatalf("Unexpected reorg: %v disconnected, %v connected, tip at %v", len(disconnected), len(connected), server.Chain.Height())
+	}
+
+	response, err := call(server.Client(), "getchaintips")
+	if err != nil {
+		t.Fatalf("Failed to get chain tips: %v", err)
+	}
+	tips, err := response.UnmarshalArray()
+	if err != nil {
+		t.Fatalf("Failed to unmarshal chain tips: %v", err)
+	}
+
+	if len(*tips) != 2 {
+		t.Fatalf("Expected an active tip and a fork, got %v", *tips)
+	}
+	fork := (*tips)[1]
+	if fork["hash"] != disconnected[1].Hash || fork["branchlen"] != float64(2) || fork["status"] != "valid-fork" {
+		t.Errorf("Unexpected fork tip: %v", fork)
+	}
+
+	response, err = call(server.Client(), "getblockheader", disconnected[0].Hash)
+	if err != nil {
+		t.Fatalf("Failed to get stale block header: %v", err)
+	}
+	header, _ := response.UnmarshalResult()
+	if (*header)["confirmations"] != float64(-1) {
+		t.Errorf("Expected -1 confirmations for a stale block, got %v", (*header)["confirmations"])
+	}
+}
+
+func Test_Spend(t *testing.T) {
+	chain := rpctest.NewChain(10)
+	script := []byte{0x00, 0x14, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14}
+	funding := chain.Block(1).TxIDs[0]
+
+	mined := chain.MineWith(1, func(b *rpctest.Block) {
+		b.Transactions = append(b.Transactions, rpctest.Spend(funding, 0, rpctest.Output{Value: rpctest.Subsidy - 1000, Script: script}))
+	})
+	if len(mined[0].TxIDs) != 2 {
+		t.Fatalf("Expected 2 transactions, got %v", len(mined[0].TxIDs))
+	}
+
+	server := rpctest.NewServer(rpctest.WithChain(chain))
+	defer server.Close()
+
+	response, err := call(server.Client(), "getblock", mined[0].Hash, 3)
+	if err != nil {
+		t.Fatalf("Failed to get block: %v", err)
+	}
+	block, _ := response.UnmarshalResult()
+	if (*block)["merkleroot"] != mined[0].MerkleRoot {
+		t.Errorf("Expected merkle root %s, got %v", mined[0].MerkleRoot, (*block)["merkleroot"])
+	}
+
+	txs, _ := (*block)["tx"].([]any)
+	if len(txs) != 2 {
+		t.Fatalf("Expected 2 transactions, got %v", len(txs))
+	}
+	tx := txs[1].(map[string]any)
+	input := tx["vin"].([]any)[0].(map[string]any)
+	prevout := input["prevout"].(map[string]any)
+	if input["txid"] != funding || prevout["height"] != float64(1) || tx["fee"] != 0.00001 {
+		t.Errorf("Unexpected spending transaction: %v", tx)
+	}
+	output := tx["vout"].([]any)[0].(map[string]any)["scriptPubKey"].(map[string]any)
+	if output["hex"] != hex.EncodeToString(script) || output["type"] != "witness_v0_keyhash" {
+		t.Errorf("Unexpected output script: %v", output)
+	}
+}
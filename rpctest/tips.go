@@ -0,0 +1,34 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// ChainTip is a tip of the block tree, as returned by 'getchaintips'.
+type ChainTip struct {
+	Height    int    `json:"height"`
+	Hash      string `json:"hash"`
+	BranchLen int    `json:"branchlen"`
+	Status    string `json:"status"` // "active", "valid-fork", "valid-headers", "headers-only" or "invalid"
+}
+
+// PlayTips makes 'getchaintips' return a sequence of tips, one step per call, so that tip changes
+// (e.g. reorgs or invalid branches) can be simulated without building the blocks behind them.
+// The last step is returned again once the sequence is exhausted.
+func (s *Server) PlayTips(sequence ...[]ChainTip) {
+	var mu sync.Mutex
+	step := 0
+
+	s.Handle("getchaintips", func(params []json.RawMessage) (any, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if len(sequence) == 0 {
+			return []ChainTip{}, nil
+		}
+		tips := sequence[min(step, len(sequence)-1)]
+		step++
+		return tips, nil
+	})
+}
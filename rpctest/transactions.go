@@ -0,0 +1,59 @@
+package rpctest
+
+import (
+	"github.com/avila-r/bitclient/wire"
+)
+
+// Output is an output of a transaction built by Spend.
+type Output struct {
+	Value  int64  // Amount, in satoshis
+	Script []byte // Locking script (scriptPubKey)
+}
+
+// Spend serializes a transaction spending an output of a fixture transaction, to be appended
+// to a block through MineWith. Scripts aren't validated: fixture coinbases pay to OP_TRUE, which
+// is spent with an empty scriptSig, and any other output can be spent the same way.
+//
+// Parameters:
+// - txid (string): The id of the transaction holding the spent output.
+// - vout (uint32): The index of the spent output.
+// - outputs (...Output): The outputs of the transaction. Their values should leave a fee.
+//
+// Example:
+//
+//	chain := rpctest.NewChain(100)
+//	chain.MineWith(1, func(b *rpctest.Block) {
+//		tx := rpctest.Spend(chain.Block(1).TxIDs[0], 0, rpctest.Output{Value: 49_0000_0000, Script: script})
+//		b.Transactions = append(b.Transactions, tx)
+//	})
+func Spend(txid string, vout uint32, outputs ...Output) []byte {
+	hash, err := wire.HashFrom(txid)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := &wire.Tx{
+		Version: 2,
+		Inputs:  []wire.TxIn{{PreviousOutput: wire.OutPoint{Hash: hash, Index: vout}, Sequence: 0xfffffffd}},
+	}
+	for _, output := range outputs {
+		tx.Outputs = append(tx.Outputs, wire.TxOut{Value: output.Value, ScriptPubKey: output.Script})
+	}
+
+	return tx.Serialize(false)
+}
+
+// prevout finds the output spent by an input among the transactions of the active chain,
+// returning it with the block holding it, or nil if it's unknown.
+func (s *Server) prevout(input wire.TxIn) (*wire.TxOut, *Block) {
+	block, i := s.lookupTx(input.PreviousOutput.Hash.String())
+	if block == nil {
+		return nil, nil
+	}
+
+	tx, err := wire.DecodeTx(block.Transactions[i])
+	if err != nil || int(input.PreviousOutput.Index) >= len(tx.Outputs) {
+		return nil, nil
+	}
+	return &tx.Outputs[input.PreviousOutput.Index], block
+}
@@ -0,0 +1,216 @@
+package shell
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// hash matches block hashes, as found in RPC results.
+var hash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Completer completes the lines of a shell session: bitclient subcommands and their flags,
+// RPC methods listed by the node's 'help', and the block hashes and peer addresses seen in
+// the results of earlier requests.
+type Completer struct {
+	root    *cobra.Command
+	methods []string
+
+	mu        sync.Mutex
+	hashes    []string // Block hashes seen, most recent last
+	addresses []string // Peer addresses seen, most recent last
+}
+
+// maxSeen is the number of block hashes and peer addresses remembered for completion.
+const maxSeen = 256
+
+// NewCompleter creates a completer for a command tree.
+//
+// Parameters:
+// - root (*cobra.Command): The root command, whose subcommands are completed. It can be nil.
+//
+// Returns:
+// - *Completer: The completer. RPC methods are added with LoadMethods.
+func NewCompleter(root *cobra.Command) *Completer {
+	return &Completer{root: root}
+}
+
+// LoadMethods loads the RPC methods the node supports from its 'help' output.
+//
+// Returns:
+// - error: An error if 'help' fails.
+func (c *Completer) LoadMethods() error {
+	result, err := rpc.Help()
+	if err != nil {
+		return err
+	}
+
+	// The help text is the JSON string of the result
+	help := result
+	_ = json.Unmarshal([]byte(result), &help)
+
+	methods := []string{}
+	for _, line := range strings.Split(help, "\n") {
+		// Sections are headed by "== Name ==" lines, each method by its own line
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "==") {
+			continue
+		}
+		methods = append(methods, fields[0])
+	}
+	slices.Sort(methods)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.methods = slices.Compact(methods)
+	return nil
+}
+
+// Methods returns the RPC methods loaded from the node's 'help' output.
+func (c *Completer) Methods() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.methods)
+}
+
+// Observe remembers the block hashes and peer addresses found in an RPC result.
+//
+// Parameters:
+// - method (string): The method of the request.
+// - result (json.RawMessage): The result of the request.
+func (c *Completer) Observe(method string, result json.RawMessage) {
+	var value any
+	if err := json.Unmarshal(result, &value); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 'getblockhash' and 'getbestblockhash' return a bare hash, other methods name their fields
+	if s, ok := value.(string); ok && (method == "getblockhash" || method == "getbestblockhash") && hash.MatchString(s) {
+		c.hashes = remember(c.hashes, s)
+		return
+	}
+	c.walk(value)
+}
+
+// walk looks for block hashes and peer addresses in a decoded result.
+func (c *Completer) walk(value any) {
+	switch value := value.(type) {
+	case []any:
+		for _, v := range value {
+			c.walk(v)
+		}
+	case map[string]any:
+		for key, v := range value {
+			s, ok := v.(string)
+			switch {
+			case !ok:
+				c.walk(v)
+			case (key == "hash" || strings.HasSuffix(key, "blockhash")) && hash.MatchString(s):
+				c.hashes = remember(c.hashes, s)
+			case key == "addr" || key == "address" && strings.ContainsAny(s, ".:"):
+				c.addresses = remember(c.addresses, s)
+			}
+		}
+	}
+}
+
+// remember appends a value to a list, moving it last if already there and dropping the oldest
+// values beyond maxSeen.
+func remember(values []string, value string) []string {
+	values = slices.DeleteFunc(values, func(v string) bool { return v == value })
+	values = append(values, value)
+	if len(values) > maxSeen {
+		values = values[len(values)-maxSeen:]
+	}
+	return values
+}
+
+// Complete returns the completions of a line, as whole lines ending with a completed last word.
+// The first word completes to subcommands and RPC methods; following words complete to the
+// subcommands and flags of the command being typed, and to the hashes and addresses seen for the
+// arguments of RPC methods and of commands without subcommands.
+//
+// Parameters:
+// - line (string): The line being typed.
+//
+// Returns:
+// - []string: The completed lines, most relevant first.
+func (c *Completer) Complete(line string) []string {
+	words := strings.Fields(line)
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	prefix, last := line[:len(line)-len(words[len(words)-1])], words[len(words)-1]
+
+	c.mu.Lock()
+	candidates := []string{}
+	if len(words) == 1 {
+		candidates = append(candidates, builtins...)
+		candidates = append(candidates, c.commands(c.root)...)
+		candidates = append(candidates, c.methods...)
+	} else {
+		command := c.command(words[:len(words)-1])
+		if command != nil {
+			candidates = append(candidates, c.commands(command)...)
+			command.Flags().VisitAll(func(flag *pflag.Flag) {
+				if !flag.Hidden {
+					candidates = append(candidates, "--"+flag.Name)
+				}
+			})
+		}
+		// Arguments of RPC methods and of commands without subcommands, most recently seen first
+		if command == nil || len(c.commands(command)) == 0 {
+			for i := len(c.hashes) - 1; i >= 0; i-- {
+				candidates = append(candidates, c.hashes[i])
+			}
+			for i := len(c.addresses) - 1; i >= 0; i-- {
+				candidates = append(candidates, c.addresses[i])
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	completions := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, last) && candidate != last && !slices.Contains(completions, prefix+candidate) {
+			completions = append(completions, prefix+candidate)
+		}
+	}
+	return completions
+}
+
+// command returns the subcommand named by the words of a line, or nil if they don't name one.
+func (c *Completer) command(words []string) *cobra.Command {
+	if c.root == nil {
+		return nil
+	}
+	command, _, err := c.root.Find(words)
+	if err != nil || command == c.root {
+		return nil
+	}
+	return command
+}
+
+// commands returns the names of the subcommands of a command available in the shell.
+func (c *Completer) commands(command *cobra.Command) []string {
+	if command == nil {
+		return nil
+	}
+	names := []string{}
+	for _, children := range command.Commands() {
+		if available(children) {
+			names = append(names, children.Name())
+		}
+	}
+	return names
+}
@@ -0,0 +1,142 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// maxHistory is the number of lines kept in the history.
+const maxHistory = 1000
+
+// History is the history of the lines entered in shell sessions, persisted to a file unless
+// its path is empty.
+type History struct {
+	path  string
+	lines []string // Lines entered, oldest first
+}
+
+// HistoryPath returns the default path of the history file: bitclient/history in
+// $XDG_STATE_HOME, or in ~/.local/state if it isn't set.
+//
+// Returns:
+// - string: The path of the history file.
+// - error: An error if the home directory can't be determined.
+func HistoryPath() (string, error) {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "bitclient", "history"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", failure.Of("failed to locate the home directory: %v", err.Error())
+	}
+	return filepath.Join(home, ".local", "state", "bitclient", "history"), nil
+}
+
+// LoadHistory loads the history from a file. A missing file is an empty history.
+//
+// Parameters:
+// - path (string): The path of the history file.
+//
+// Returns:
+// - *History: The history, to which new lines are appended with Add.
+// - error: An error if the file can't be read.
+func LoadHistory(path string) (*History, error) {
+	history := &History{path: path}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, failure.Of("failed to open history: %v", err.Error())
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			history.lines = append(history.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, failure.Of("failed to read history: %v", err.Error())
+	}
+
+	history.trim()
+	return history, nil
+}
+
+// Lines returns the lines of the history, oldest first.
+func (h *History) Lines() []string {
+	return h.lines
+}
+
+// Add appends a line to the history and saves it, unless it repeats the previous line.
+//
+// Parameters:
+// - line (string): The line entered.
+//
+// Returns:
+// - error: An error if the history can't be saved.
+func (h *History) Add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return nil
+	}
+
+	h.lines = append(h.lines, line)
+	if h.path == "" {
+		h.trim()
+		return nil
+	}
+	if len(h.lines) <= maxHistory {
+		return h.append(line)
+	}
+
+	// Rewrite the file once the history overflows, dropping the oldest lines
+	h.trim()
+	return h.save()
+}
+
+// trim drops the oldest lines beyond maxHistory.
+func (h *History) trim() {
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+	}
+}
+
+// append appends a line to the history file.
+func (h *History) append(line string) error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return failure.Of("failed to create history directory: %v", err.Error())
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return failure.Of("failed to open history: %v", err.Error())
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		return failure.Of("failed to save history: %v", err.Error())
+	}
+	return nil
+}
+
+// save rewrites the history file with the lines of the history.
+func (h *History) save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return failure.Of("failed to create history directory: %v", err.Error())
+	}
+
+	if err := os.WriteFile(h.path, []byte(strings.Join(h.lines, "\n")+"\n"), 0o600); err != nil {
+		return failure.Of("failed to save history: %v", err.Error())
+	}
+	return nil
+}
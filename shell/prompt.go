@@ -0,0 +1,174 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/avila-r/bitclient/assets"
+	"github.com/avila-r/bitclient/failure"
+)
+
+var (
+	promptStyle = lipgloss.NewStyle().Foreground(assets.LightOrange).Bold(true)
+	hintStyle   = lipgloss.NewStyle().Foreground(assets.Gray)
+)
+
+// maxHints is the number of completions listed under the prompt.
+const maxHints = 8
+
+// prompt is the bubbletea model reading a line, with completion and history.
+type prompt struct {
+	input     textinput.Model
+	completer *Completer
+	history   []string
+	index     int    // Index of the history line shown, len(history) while typing a new line
+	draft     string // New line being typed, kept while browsing the history
+	done      bool   // Whether the line was entered
+	quit      bool   // Whether the user left the session
+}
+
+// newPrompt creates a prompt reading a line.
+func newPrompt(completer *Completer, history []string) prompt {
+	input := textinput.New()
+	input.Prompt = promptStyle.Render("bitclient> ")
+	input.ShowSuggestions = true
+	// Up and down browse the history, completions are cycled through with ctrl+n and ctrl+p
+	input.KeyMap.NextSuggestion = key.NewBinding(key.WithKeys("ctrl+n"))
+	input.KeyMap.PrevSuggestion = key.NewBinding(key.WithKeys("ctrl+p"))
+	input.Focus()
+
+	return prompt{input: input, completer: completer, history: history, index: len(history)}
+}
+
+// Init starts the cursor blinking.
+func (p prompt) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles key presses.
+func (p prompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			p.done = true
+			return p, tea.Quit
+		case "ctrl+c":
+			// Discard the line
+			p.input.SetValue("")
+			p.done = true
+			return p, tea.Quit
+		case "ctrl+d":
+			if p.input.Value() == "" {
+				p.quit = true
+				return p, tea.Quit
+			}
+		case "up", "down":
+			p.browse(msg.String() == "up")
+			return p, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	p.input.SetSuggestions(p.completer.Complete(p.input.Value()))
+	return p, cmd
+}
+
+// browse shows the previous or next line of the history.
+func (p *prompt) browse(previous bool) {
+	if p.index == len(p.history) {
+		p.draft = p.input.Value()
+	}
+
+	switch {
+	case previous && p.index > 0:
+		p.index--
+	case !previous && p.index < len(p.history):
+		p.index++
+	default:
+		return
+	}
+
+	if p.index == len(p.history) {
+		p.input.SetValue(p.draft)
+	} else {
+		p.input.SetValue(p.history[p.index])
+	}
+	p.input.CursorEnd()
+	p.input.SetSuggestions(nil)
+}
+
+// View renders the prompt, with the completions of the line under it.
+func (p prompt) View() string {
+	if p.done || p.quit {
+		return p.input.Prompt + p.input.Value() + "\n"
+	}
+	if p.input.Value() == "" {
+		return p.input.View()
+	}
+
+	suggestions := p.input.AvailableSuggestions()
+	value := p.input.Value()
+	hints := []string{}
+	for _, suggestion := range suggestions {
+		if !strings.HasPrefix(suggestion, value) {
+			continue
+		}
+		if len(hints) == maxHints {
+			hints = append(hints, "…")
+			break
+		}
+		// Only the completed word is listed
+		hints = append(hints, suggestion[strings.LastIndex(suggestion, " ")+1:])
+	}
+	if len(hints) < 2 {
+		return p.input.View()
+	}
+	return p.input.View() + "\n" + hintStyle.Render(strings.Join(hints, "  "))
+}
+
+// interactive reads lines through a prompt until the user leaves the session.
+func (s *Shell) interactive() error {
+	// Interrupts stop the running command, if it handles them, rather than the session
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	fmt.Fprintln(s.out, hintStyle.Render("Type help for usage, exit or ctrl+d to leave."))
+	for {
+		model, err := tea.NewProgram(newPrompt(s.completer, s.history.Lines()), tea.WithInput(s.in), tea.WithOutput(s.out)).Run()
+		if err != nil {
+			return failure.Of("failed to read line: %v", err.Error())
+		}
+
+		line := model.(prompt)
+		if line.quit {
+			return nil
+		}
+
+		if err := s.Execute(line.input.Value()); errors.Is(err, ErrExit) {
+			return nil
+		} else if err != nil {
+			fmt.Fprintln(s.out, "error:", err.Error())
+		}
+
+		// Drain interrupts received while the command ran
+		for len(interrupts) > 0 {
+			<-interrupts
+		}
+	}
+}
+
+// terminal reports whether a file is a terminal.
+func terminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,366 @@
+// Package shell implements an interactive session sending bitclient subcommands and raw RPC
+// requests through a single client, with a persistent history and completion fed by the node's
+// 'help' output and by the block hashes and peer addresses seen during the session.
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// builtins are the commands of the shell itself.
+var builtins = []string{"help", "exit", "quit"}
+
+// secrets are the RPC methods whose parameters hold private keys or passphrases. Their lines are
+// kept out of the history, as in Bitcoin Core's console.
+var secrets = []string{
+	"createwallet", "encryptwallet", "importdescriptors", "importmulti", "importprivkey", "sethdseed",
+	"signmessagewithprivkey", "signrawtransactionwithkey", "walletpassphrase", "walletpassphrasechange",
+}
+
+// ErrExit is returned by Execute when the line ends the session.
+var ErrExit = errors.New("exit")
+
+// Shell is an interactive session.
+type Shell struct {
+	root      *cobra.Command
+	history   *History
+	completer *Completer
+
+	in  io.Reader
+	out io.Writer
+}
+
+// Options configures a shell session.
+type Options struct {
+	// Root is the command tree whose subcommands can be run, e.g. "blocks get 100". It can be nil,
+	// in which case every line is sent as a raw RPC request.
+	Root *cobra.Command
+
+	// History is the path of the history file. The history isn't persisted if empty.
+	History string
+
+	// In and Out are the input lines are read from and the output RPC results are written to
+	// (default: os.Stdin and os.Stdout). Lines are read through an interactive prompt if In is a terminal.
+	In  io.Reader
+	Out io.Writer
+}
+
+// New creates a shell session on the default rpc.Client, whose responses are observed for completion.
+//
+// Parameters:
+// - options (Options): The command tree, history file, input and output of the session.
+//
+// Returns:
+// - *Shell: The session, to be run with Run or fed lines with Execute.
+// - error: An error if there is no client or the history can't be loaded.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient shell
+//     bitclient> getblockcount
+//     bitclient> blocks get 000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f
+//
+//   - Using Go:
+//     session, err := shell.New(shell.Options{Root: cmd.Root})
+//     err = session.Execute("getblockhash 0")
+func New(options Options) (*Shell, error) {
+	if rpc.Client == nil {
+		return nil, failure.Of("no rpc.Client configured")
+	}
+
+	if options.In == nil {
+		options.In = os.Stdin
+	}
+	if options.Out == nil {
+		options.Out = os.Stdout
+	}
+
+	s := &Shell{root: options.Root, completer: NewCompleter(options.Root), in: options.In, out: options.Out, history: &History{}}
+	if options.History != "" {
+		history, err := LoadHistory(options.History)
+		if err != nil {
+			return nil, err
+		}
+		s.history = history
+	}
+
+	// Methods are only needed to complete lines, a node refusing 'help' doesn't prevent the session
+	_ = s.completer.LoadMethods()
+	rpc.Client.Use(s.observe)
+
+	return s, nil
+}
+
+// Completer returns the completer of the session.
+func (s *Shell) Completer() *Completer {
+	return s.completer
+}
+
+// Run reads lines and executes them until the input ends or the user exits. Errors of a line are
+// reported without ending the session.
+//
+// Returns:
+// - error: An error if the input can't be read.
+func (s *Shell) Run() error {
+	if file, ok := s.in.(*os.File); ok && terminal(file) {
+		return s.interactive()
+	}
+
+	scanner := bufio.NewScanner(s.in)
+	for scanner.Scan() {
+		if err := s.Execute(scanner.Text()); errors.Is(err, ErrExit) {
+			return nil
+		} else if err != nil {
+			fmt.Fprintln(s.out, "error:", err.Error())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return failure.Of("failed to read input: %v", err.Error())
+	}
+	return nil
+}
+
+// Execute executes a line: a builtin, a subcommand with its flags and arguments, or an RPC method
+// with its parameters. Lines are added to the history, unless they call a method handling secrets
+// (e.g. walletpassphrase). Parameters are sent as JSON values if they're valid JSON, and as strings
+// otherwise, as bitcoin-cli does; quote them to include spaces (e.g. getblockstats 100 '["avgfee"]').
+//
+// Parameters:
+// - line (string): The line to execute.
+//
+// Returns:
+// - error: ErrExit if the line ends the session, or an error if it fails.
+func (s *Shell) Execute(line string) error {
+	words, err := split(line)
+	if err != nil || len(words) == 0 {
+		return err
+	}
+	if !slices.Contains(secrets, strings.ToLower(words[0])) {
+		if err := s.history.Add(line); err != nil {
+			fmt.Fprintln(s.out, "warning:", err.Error())
+		}
+	}
+
+	switch words[0] {
+	case "exit", "quit":
+		return ErrExit
+	case "help":
+		if len(words) == 1 {
+			s.help()
+			return nil
+		}
+		// The node's help of a method, rather than bitclient's help command
+		return s.call(words[0], words[1:])
+	}
+
+	if s.root != nil {
+		if command, args, err := s.root.Find(words); err == nil && command != s.root {
+			return s.run(command, args)
+		}
+	}
+
+	return s.call(words[0], words[1:])
+}
+
+// help writes the usage of the shell.
+func (s *Shell) help() {
+	fmt.Fprintln(s.out, "Enter bitclient subcommands (e.g. blocks get 100) or RPC methods with their parameters (e.g. getblockhash 100).")
+	fmt.Fprintln(s.out, "Press tab to complete, up and down to browse the history, and type exit or press ctrl+d to leave.")
+	if s.root != nil {
+		fmt.Fprintln(s.out, "\nSubcommands:")
+		for _, command := range s.root.Commands() {
+			if available(command) {
+				fmt.Fprintf(s.out, "  %-12s %s\n", command.Name(), command.Short)
+			}
+		}
+	}
+	fmt.Fprintln(s.out, "\nRun 'help <method>' for the node's help of an RPC method.")
+}
+
+// run runs a subcommand with the flags and arguments of a line. The persistent hooks of the
+// command tree aren't run again: connection settings are those the session was started with.
+// Subcommands report their failures through the logger, without ending the session.
+func (s *Shell) run(command *cobra.Command, args []string) error {
+	if !available(command) {
+		return failure.Of("%s can't be run from the shell", command.CommandPath())
+	}
+
+	command.InitDefaultHelpFlag()
+	command.InheritedFlags() // Merges the persistent flags, so that they're reset too
+	reset(command.Flags())
+
+	if err := command.ParseFlags(args); err != nil {
+		return err
+	}
+	if help, _ := command.Flags().GetBool("help"); help || command.Run == nil {
+		return command.Help()
+	}
+
+	args = command.Flags().Args()
+	if err := command.ValidateArgs(args); err != nil {
+		return err
+	}
+	if err := command.ValidateRequiredFlags(); err != nil {
+		return err
+	}
+	if err := command.ValidateFlagGroups(); err != nil {
+		return err
+	}
+
+	command.Run(command, args)
+	return nil
+}
+
+// call sends an RPC request, writing its result.
+func (s *Shell) call(method string, args []string) error {
+	params := rpc.Params{}
+	for _, arg := range args {
+		if json.Valid([]byte(arg)) {
+			params = append(params, json.RawMessage(arg))
+		} else {
+			params = append(params, arg)
+		}
+	}
+
+	response, err := rpc.Client.Do(rpc.Request{ID: rpc.Identifier, Version: rpc.Version2, Method: rpc.Method(method), Params: params})
+	if err != nil {
+		return err
+	}
+
+	// Strings (e.g. hashes or help texts) are written as is, other results indented
+	var text string
+	if err := json.Unmarshal(response.Result, &text); err == nil {
+		fmt.Fprintln(s.out, text)
+		return nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, response.Result, "", "  "); err != nil {
+		fmt.Fprintln(s.out, string(response.Result))
+		return nil
+	}
+	fmt.Fprintln(s.out, indented.String())
+	return nil
+}
+
+// observe is the middleware feeding the results of every request of the session to the completer,
+// including those sent by subcommands.
+func (s *Shell) observe(next http.RoundTripper) http.RoundTripper {
+	return rpc.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		request := struct {
+			Method string `json:"method"`
+		}{}
+		if req.Body != nil {
+			body, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, failure.Of("failed to read request: %v", err.Error())
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			_ = json.Unmarshal(body, &request)
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, failure.Of("failed to read response: %v", err.Error())
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		response := rpc.Response{}
+		if json.Unmarshal(body, &response) == nil && response.Error == nil {
+			s.completer.Observe(request.Method, response.Result)
+		}
+		return resp, nil
+	})
+}
+
+// available reports whether a command can be run from the shell.
+func available(command *cobra.Command) bool {
+	return command.IsAvailableCommand() && !slices.Contains([]string{"help", "completion", "shell"}, command.Name())
+}
+
+// reset sets the flags of a command back to their defaults, so that flags given on a line don't
+// carry over to the next.
+func reset(flags *pflag.FlagSet) {
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			defaults := []string{}
+			if value := strings.Trim(flag.DefValue, "[]"); value != "" {
+				defaults = strings.Split(value, ",")
+			}
+			_ = slice.Replace(defaults)
+		} else {
+			_ = flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	})
+}
+
+// split splits a line into words, as a POSIX shell does: on whitespace, unless quoted with single
+// or double quotes, and with backslashes escaping the next character outside of single quotes.
+func split(line string) ([]string, error) {
+	words := []string{}
+	var (
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, failure.Of("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
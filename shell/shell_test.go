@@ -0,0 +1,230 @@
+package shell_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avila-r/bitclient/rpctest"
+	"github.com/avila-r/bitclient/shell"
+)
+
+// tree builds a command tree recording the runs of its 'blocks get' command.
+func tree(runs *[]string) *cobra.Command {
+	root := &cobra.Command{Use: "bitclient"}
+	blocks := &cobra.Command{Use: "blocks"}
+	get := &cobra.Command{
+		Use:  "get [block]",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			verbosity, _ := cmd.Flags().GetInt("verbosity")
+			*runs = append(*runs, fmt.Sprintf("%s %d", args[0], verbosity))
+		},
+	}
+	get.Flags().Int("verbosity", 1, "")
+	blocks.AddCommand(get)
+	root.AddCommand(blocks)
+	return root
+}
+
+func Test_Execute(t *testing.T) {
+	fake := rpctest.Use(t)
+
+	runs := []string{}
+	out := &bytes.Buffer{}
+	session, err := shell.New(shell.Options{Root: tree(&runs), Out: out})
+	if err != nil {
+		t.Fatalf("Failed to start shell: %v", err)
+	}
+
+	cases := []struct {
+		Line     string
+		Expected string
+	}{
+		{Line: "getblockcount", Expected: "200\n"},
+		{Line: "getblockhash 5", Expected: fake.Chain.Block(5).Hash + "\n"},
+		{Line: `getblockstats 10 '["height", "txs"]'`, Expected: "{\n  \"height\": 10,\n  \"txs\": 1\n}\n"},
+		{Line: "help getblock", Expected: "getblock\n\nServed by the bitclient fake bitcoind.\n"},
+		{Line: "   ", Expected: ""},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			out.Reset()
+			if err := session.Execute(test.Line); err != nil {
+				t.Fatalf("Failed to execute %q: %v", test.Line, err)
+			}
+			if out.String() != test.Expected {
+				t.Errorf("Expected %q, got %q", test.Expected, out.String())
+			}
+		})
+	}
+
+	// Flags given on a line don't carry over to the next
+	for _, line := range []string{"blocks get 7 --verbosity 2", "blocks get 8"} {
+		if err := session.Execute(line); err != nil {
+			t.Fatalf("Failed to execute %q: %v", line, err)
+		}
+	}
+	if fmt.Sprint(runs) != "[7 2 8 1]" {
+		t.Errorf("Expected runs [7 2 8 1], got %v", runs)
+	}
+
+	for _, line := range []string{"unknownmethod", "blocks get", `getblockhash "5`} {
+		if err := session.Execute(line); err == nil {
+			t.Errorf("Expected %q to fail", line)
+		}
+	}
+	if err := session.Execute("exit"); !errors.Is(err, shell.ErrExit) {
+		t.Errorf("Expected exit to end the session, got %v", err)
+	}
+}
+
+func Test_Run(t *testing.T) {
+	rpctest.Use(t)
+
+	out := &bytes.Buffer{}
+	in := strings.NewReader("getblockcount\nunknownmethod\nquit\ngetblockcount\n")
+	session, err := shell.New(shell.Options{In: in, Out: out})
+	if err != nil {
+		t.Fatalf("Failed to start shell: %v", err)
+	}
+
+	if err := session.Run(); err != nil {
+		t.Fatalf("Failed to run shell: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || lines[0] != "200" || !strings.HasPrefix(lines[1], "error:") {
+		t.Errorf("Expected a result and an error before quitting, got %q", out.String())
+	}
+}
+
+func Test_Complete(t *testing.T) {
+	fake := rpctest.Use(t)
+
+	runs := []string{}
+	session, err := shell.New(shell.Options{Root: tree(&runs), Out: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatalf("Failed to start shell: %v", err)
+	}
+	completer := session.Completer()
+
+	if methods := completer.Methods(); !slices.Contains(methods, "getblockhash") || !slices.Contains(methods, "getpeerinfo") {
+		t.Errorf("Expected the methods of the node's help, got %v", methods)
+	}
+
+	// Results of earlier requests feed completions
+	for _, line := range []string{"getblockhash 5", "getblockheader " + fake.Chain.Block(7).Hash, "getpeerinfo"} {
+		if err := session.Execute(line); err != nil {
+			t.Fatalf("Failed to execute %q: %v", line, err)
+		}
+	}
+
+	block5, block6, block7 := fake.Chain.Block(5).Hash, fake.Chain.Block(6).Hash, fake.Chain.Block(7).Hash
+	cases := []struct {
+		Line     string
+		Expected []string
+	}{
+		{Line: "getblockh", Expected: []string{"getblockhash", "getblockheader"}},
+		{Line: "blo", Expected: []string{"blocks"}},
+		{Line: "blocks ", Expected: []string{"blocks get"}},
+		{Line: "blocks get --v", Expected: []string{"blocks get --verbosity"}},
+		{Line: "blocks get " + block5[:4], Expected: []string{"blocks get " + block5}},
+		{Line: "getblock " + block7[:6], Expected: []string{"getblock " + block7}},
+		{Line: "getblock " + block6[:6], Expected: []string{"getblock " + block6}},
+		{Line: "disconnectnode 198.", Expected: []string{"disconnectnode 198.51.100.7:8333"}},
+		{Line: "getblockhash", Expected: []string{}},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			completions := completer.Complete(test.Line)
+			if !slices.Equal(completions, test.Expected) {
+				t.Errorf("Expected completions %q for %q, got %q", test.Expected, test.Line, completions)
+			}
+		})
+	}
+}
+
+func Test_History(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bitclient", "history")
+
+	history, err := shell.LoadHistory(path)
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if len(history.Lines()) != 0 {
+		t.Errorf("Expected a missing history to be empty, got %v", history.Lines())
+	}
+
+	for _, line := range []string{"getblockcount", "getblockcount", " ", "getblockhash 5", "getblockcount"} {
+		if err := history.Add(line); err != nil {
+			t.Fatalf("Failed to add %q to history: %v", line, err)
+		}
+	}
+
+	loaded, err := shell.LoadHistory(path)
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if expected := []string{"getblockcount", "getblockhash 5", "getblockcount"}; !slices.Equal(loaded.Lines(), expected) {
+		t.Errorf("Expected history %q, got %q", expected, loaded.Lines())
+	}
+
+	// The history is capped, dropping the oldest lines
+	for i := 0; i < 1200; i++ {
+		if err := loaded.Add(fmt.Sprintf("getblockhash %d", i)); err != nil {
+			t.Fatalf("Failed to add to history: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) > 1000 || lines[len(lines)-1] != "getblockhash 1199" {
+		t.Errorf("Expected at most 1000 lines ending with the latest, got %d ending with %q", len(lines), lines[len(lines)-1])
+	}
+}
+
+func Test_HistorySecrets(t *testing.T) {
+	rpctest.Use(t)
+
+	path := filepath.Join(t.TempDir(), "history")
+	session, err := shell.New(shell.Options{History: path, Out: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatalf("Failed to start shell: %v", err)
+	}
+
+	// Lines calling methods handling secrets are executed, but kept out of the history
+	for _, line := range []string{"getblockcount", "walletpassphrase hunter2 60", "importprivkey L1aW4aubDFB7yfras2S1mN3bqg9nwySY8nkoLmJebSLD5BWv3ENZ", "help walletpassphrase"} {
+		_ = session.Execute(line)
+	}
+
+	history, err := shell.LoadHistory(path)
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if expected := []string{"getblockcount", "help walletpassphrase"}; !slices.Equal(history.Lines(), expected) {
+		t.Errorf("Expected history %q, got %q", expected, history.Lines())
+	}
+}
+
+func Test_HistoryPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	if path, err := shell.HistoryPath(); err != nil || path != "/tmp/state/bitclient/history" {
+		t.Errorf("Expected the history in $XDG_STATE_HOME, got %q (%v)", path, err)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/satoshi")
+	if path, err := shell.HistoryPath(); err != nil || path != "/home/satoshi/.local/state/bitclient/history" {
+		t.Errorf("Expected the history in ~/.local/state, got %q (%v)", path, err)
+	}
+}
@@ -0,0 +1,321 @@
+// Package tracing implements observers of RPC requests: an exporter of traces to an OpenTelemetry
+// collector over OTLP/HTTP, and a summary of the time spent per method.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// Span kinds and status codes, as defined by the OTLP trace protocol.
+const (
+	kindInternal = 1
+	kindClient   = 3
+
+	statusOK    = 1
+	statusError = 2
+)
+
+// Options configures the OTLP exporter.
+type Options struct {
+	// Endpoint is the URL of the collector (e.g. http://localhost:4318). When it has no path,
+	// spans are posted to its /v1/traces path, as OTEL_EXPORTER_OTLP_ENDPOINT does.
+	Endpoint string
+
+	// Service is the service.name of the exported spans (default: "bitclient").
+	Service string
+
+	// Headers are sent with every export, e.g. to authenticate to the collector.
+	Headers map[string]string
+
+	// Timeout is the timeout of an export (default: 10s).
+	Timeout time.Duration
+
+	// BatchSize is the number of ended spans that triggers an export (default: 512).
+	BatchSize int
+
+	// OnError is called with the errors of the exports triggered by full batches. It can be nil.
+	OnError func(error)
+}
+
+// Exporter is an rpc.Observer tracing RPC requests as client spans, exported to an OpenTelemetry
+// collector in the OTLP/HTTP JSON encoding. Spans are exported by batches, and when flushed.
+type Exporter struct {
+	options Options
+	url     string
+	client  *http.Client
+
+	mu    sync.Mutex
+	spans []*span // Ended spans, not exported yet
+	root  *span   // Span started by Begin, parent of the requests' spans
+}
+
+// span is a span being recorded.
+type span struct {
+	traceID    [16]byte
+	spanID     [8]byte
+	parentID   [8]byte // Zero for root spans
+	name       string
+	kind       int
+	start      time.Time
+	end        time.Time
+	attributes []keyValue
+	err        error
+}
+
+// spanKey is the key of the current span in a context.
+type spanKey struct{}
+
+// NewExporter creates an exporter of traces to an OTLP/HTTP collector.
+//
+// Parameters:
+// - options (Options): The collector's endpoint, and the export settings.
+//
+// Returns:
+// - *Exporter: The exporter, to register as an observer of RPC clients.
+// - error: An error if the endpoint isn't a valid HTTP(S) URL.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient blocks get 100 --otlp-endpoint http://localhost:4318
+//
+//   - Using Go:
+//     exporter, err := tracing.NewExporter(tracing.Options{Endpoint: "http://localhost:4318"})
+//     rpc.Client.Observe(exporter)
+//     defer exporter.Flush(context.Background())
+func NewExporter(options Options) (*Exporter, error) {
+	endpoint, err := url.Parse(options.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, failure.Of("invalid OTLP endpoint %q: must be an HTTP/HTTPS URL", options.Endpoint)
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = "/v1/traces"
+	}
+
+	if options.Service == "" {
+		options.Service = "bitclient"
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 512
+	}
+
+	return &Exporter{options: options, url: endpoint.String(), client: &http.Client{Timeout: options.Timeout}}, nil
+}
+
+// Begin starts a span wrapping the requests sent until the returned function is called, e.g. to
+// trace a command as a whole. Requests started with a span in their context keep it as parent.
+//
+// Parameters:
+// - name (string): The name of the span.
+//
+// Returns:
+// - func(error): Ends the span, failed if the error isn't nil.
+func (e *Exporter) Begin(name string) func(err error) {
+	root := e.span(nil, name, kindInternal)
+
+	e.mu.Lock()
+	e.root = root
+	e.mu.Unlock()
+
+	return func(err error) {
+		root.err = err
+		e.mu.Lock()
+		if e.root == root {
+			e.root = nil
+		}
+		e.mu.Unlock()
+		e.end(root)
+	}
+}
+
+// Start starts the span of a request, a child of the span of the context or of the one started by Begin.
+func (e *Exporter) Start(ctx context.Context, call *rpc.Call) context.Context {
+	parent, _ := ctx.Value(spanKey{}).(*span)
+	if parent == nil {
+		e.mu.Lock()
+		parent = e.root
+		e.mu.Unlock()
+	}
+
+	s := e.span(parent, string(call.Method), kindClient)
+	s.start = call.Start
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// End ends the span of a request, with its outcome.
+func (e *Exporter) End(ctx context.Context, call *rpc.Call) {
+	s, ok := ctx.Value(spanKey{}).(*span)
+	if !ok {
+		return
+	}
+
+	s.attributes = append(s.attributes,
+		str("rpc.system", "jsonrpc"),
+		str("rpc.method", string(call.Method)),
+		integer("rpc.request.params_size", call.ParamsSize),
+	)
+	if call.Status != 0 {
+		s.attributes = append(s.attributes, integer("http.response.status_code", call.Status))
+	}
+	if call.ErrorCode != 0 {
+		s.attributes = append(s.attributes, integer("rpc.jsonrpc.error_code", call.ErrorCode))
+	}
+	s.err = call.Err
+	s.end = call.Start.Add(call.Duration)
+
+	e.end(s)
+}
+
+// span creates a span, a child of parent if it isn't nil.
+func (e *Exporter) span(parent *span, name string, kind int) *span {
+	s := &span{name: name, kind: kind, start: time.Now()}
+	_, _ = rand.Read(s.spanID[:])
+	if parent != nil {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else {
+		_, _ = rand.Read(s.traceID[:])
+	}
+	return s
+}
+
+// end queues an ended span for export, exporting the batch in the background once it's full.
+func (e *Exporter) end(s *span) {
+	if s.end.IsZero() {
+		s.end = time.Now()
+	}
+
+	e.mu.Lock()
+	e.spans = append(e.spans, s)
+	full := len(e.spans) >= e.options.BatchSize
+	e.mu.Unlock()
+
+	if full {
+		go func() {
+			if err := e.Flush(context.Background()); err != nil && e.options.OnError != nil {
+				e.options.OnError(err)
+			}
+		}()
+	}
+}
+
+// Flush exports the spans ended so far.
+//
+// Parameters:
+// - ctx (context.Context): Aborts the export when done.
+//
+// Returns:
+// - error: An error if the collector can't be reached or rejects the spans, which are then dropped.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	e.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return failure.Of("failed to serialize spans: %v", err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return failure.Of("failed to set up OTLP request: %v", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for header, value := range e.options.Headers {
+		req.Header.Set(header, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return failure.Of("failed to export spans: %v", err.Error())
+	}
+	defer resp.Body.Close()
+	payload, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return failure.Of("collector responded with status %s: %s", resp.Status, bytes.TrimSpace(payload))
+	}
+	return nil
+}
+
+// keyValue is an attribute, in the OTLP JSON encoding.
+type keyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"` // 64-bit integers are encoded as strings
+	} `json:"value"`
+}
+
+// str returns a string attribute.
+func str(key, value string) keyValue {
+	kv := keyValue{Key: key}
+	kv.Value.StringValue = &value
+	return kv
+}
+
+// integer returns an integer attribute.
+func integer(key string, value int) keyValue {
+	kv := keyValue{Key: key}
+	s := strconv.Itoa(value)
+	kv.Value.IntValue = &s
+	return kv
+}
+
+// encode builds an export request of spans, in the OTLP JSON encoding.
+func (e *Exporter) encode(spans []*span) map[string]any {
+	encoded := []map[string]any{}
+	for _, s := range spans {
+		span := map[string]any{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        s.attributes,
+			"status":            map[string]any{"code": statusOK},
+		}
+		if s.parentID != ([8]byte{}) {
+			span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+		}
+		if s.attributes == nil {
+			span["attributes"] = []keyValue{}
+		}
+		if s.err != nil {
+			span["status"] = map[string]any{"code": statusError, "message": s.err.Error()}
+		}
+		encoded = append(encoded, span)
+	}
+
+	return map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{"attributes": []keyValue{str("service.name", e.options.Service)}},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]any{"name": "github.com/avila-r/bitclient/rpc"},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
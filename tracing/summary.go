@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// Summary is an rpc.Observer recording the number, errors and duration of RPC requests per method,
+// to tell which of them a command spent its time on.
+type Summary struct {
+	mu      sync.Mutex
+	started time.Time
+	methods map[rpc.Method]*timing
+}
+
+// timing is the record of the requests to a method.
+type timing struct {
+	calls  int
+	errors int
+	total  time.Duration
+	max    time.Duration
+}
+
+// NewSummary creates an empty summary, timing the command from now on.
+func NewSummary() *Summary {
+	return &Summary{started: time.Now(), methods: map[rpc.Method]*timing{}}
+}
+
+// Start does nothing: requests are recorded once they end.
+func (s *Summary) Start(ctx context.Context, call *rpc.Call) context.Context {
+	return ctx
+}
+
+// End records a request.
+func (s *Summary) End(ctx context.Context, call *rpc.Call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.methods[call.Method]
+	if !ok {
+		t = &timing{}
+		s.methods[call.Method] = t
+	}
+	t.calls++
+	t.total += call.Duration
+	t.max = max(t.max, call.Duration)
+	if call.Err != nil {
+		t.errors++
+	}
+}
+
+// Write writes the summary as a table, the methods that took the longest first.
+//
+// Parameters:
+// - w (io.Writer): The writer the summary is written to.
+//
+// Example Output:
+//
+//	method               calls  errors       total         avg         max
+//	getblock                 2       0      3.12ms      1.56ms       2.4ms
+//	getblockhash             2       1       410µs       205µs       302µs
+//	2 methods, 4 calls, 1 error, 3.53ms of 15.2ms
+func (s *Summary) Write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	methods := []rpc.Method{}
+	for method := range s.methods {
+		methods = append(methods, method)
+	}
+	slices.SortFunc(methods, func(a, b rpc.Method) int {
+		return cmp.Or(cmp.Compare(s.methods[b].total, s.methods[a].total), cmp.Compare(a, b))
+	})
+
+	calls, errors, total := 0, 0, time.Duration(0)
+	fmt.Fprintf(w, "%-20s %6s %7s %11s %11s %11s\n", "method", "calls", "errors", "total", "avg", "max")
+	for _, method := range methods {
+		t := s.methods[method]
+		fmt.Fprintf(w, "%-20s %6d %7d %11v %11v %11v\n", method, t.calls, t.errors, round(t.total), round(t.total/time.Duration(t.calls)), round(t.max))
+		calls, errors, total = calls+t.calls, errors+t.errors, total+t.total
+	}
+	fmt.Fprintf(w, "%s, %s, %s, %v of %v\n", plural(len(methods), "method"), plural(calls, "call"), plural(errors, "error"), round(total), round(time.Since(s.started)))
+}
+
+// round rounds a duration to a readable precision.
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// plural formats a count of things, e.g. "1 call" or "2 calls".
+func plural(n int, thing string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, thing)
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
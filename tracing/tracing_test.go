@@ -0,0 +1,190 @@
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/rpc"
+	"github.com/avila-r/bitclient/rpctest"
+	"github.com/avila-r/bitclient/tracing"
+)
+
+// request builds a request to a method of the fake server.
+func request(method rpc.Method, params ...any) rpc.Request {
+	return rpc.Request{ID: "1", Version: rpc.Version2, Method: method, Params: append(rpc.Params{}, params...)}
+}
+
+func Test_NewExporter(t *testing.T) {
+	cases := []struct {
+		Endpoint string
+		Valid    bool
+	}{
+		{Endpoint: "http://localhost:4318", Valid: true},
+		{Endpoint: "https://collector.example.com/custom/traces", Valid: true},
+		{Endpoint: "localhost:4318", Valid: false},
+		{Endpoint: "grpc://localhost:4317", Valid: false},
+		{Endpoint: "", Valid: false},
+	}
+
+	for i, test := range cases {
+		t.Run(fmt.Sprintf("case %v", i), func(t *testing.T) {
+			_, err := tracing.NewExporter(tracing.Options{Endpoint: test.Endpoint})
+			if (err == nil) != test.Valid {
+				t.Errorf("Expected endpoint %q to be valid: %v, got error %v", test.Endpoint, test.Valid, err)
+			}
+		})
+	}
+}
+
+func Test_Exporter(t *testing.T) {
+	fake := rpctest.NewServer()
+	defer fake.Close()
+	collector := rpctest.NewCollector()
+	defer collector.Close()
+
+	exporter, err := tracing.NewExporter(tracing.Options{Endpoint: collector.URL, Service: "test", Headers: map[string]string{"Authorization": "Bearer token"}})
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+	client, _ := rpc.New(fake.URL, rpctest.Credentials, rpc.WithObserver(exporter))
+
+	end := exporter.Begin("bitclient blocks get")
+	if _, err := client.Do(request("getblockhash", 1)); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	fake.Fail("getblock", rpctest.RPCInvalidAddressOrKey, "Block not found")
+	if _, err := client.Do(request("getblock", "00")); err == nil {
+		t.Errorf("Expected request to fail")
+	}
+	end(nil)
+
+	// Spans aren't exported until flushed
+	if len(collector.Spans()) != 0 {
+		t.Errorf("Expected no span before flushing, got %+v", collector.Spans())
+	}
+	if err := exporter.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush spans: %v", err)
+	}
+
+	spans := collector.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %+v", spans)
+	}
+	hash, block, root := spans[0], spans[1], spans[2]
+
+	if root.Name != "bitclient blocks get" || root.Kind != 1 || root.ParentSpanID != "" || root.StatusCode != 1 || root.Service != "test" {
+		t.Errorf("Unexpected root span: %+v", root)
+	}
+	for _, span := range []rpctest.Span{hash, block} {
+		if span.TraceID != root.TraceID || span.ParentSpanID != root.SpanID || span.Kind != 3 || len(span.SpanID) != 16 || len(span.TraceID) != 32 {
+			t.Errorf("Expected a client span in the root span's trace, got %+v", span)
+		}
+		if span.Start.Before(root.Start) || span.End.After(root.End) || !span.End.After(span.Start) {
+			t.Errorf("Expected span to be timed within the root span, got %+v", span)
+		}
+	}
+
+	if hash.Name != "getblockhash" || hash.StatusCode != 1 || hash.Attributes["rpc.method"] != "getblockhash" || hash.Attributes["rpc.system"] != "jsonrpc" ||
+		hash.Attributes["rpc.request.params_size"] != int64(3) || hash.Attributes["http.response.status_code"] != int64(200) {
+		t.Errorf("Unexpected span of a successful request: %+v", hash)
+	}
+	if _, ok := hash.Attributes["rpc.jsonrpc.error_code"]; ok {
+		t.Errorf("Expected no error code on a successful request, got %+v", hash)
+	}
+	if block.StatusCode != 2 || !strings.Contains(block.Message, "Block not found") || block.Attributes["rpc.jsonrpc.error_code"] != int64(rpctest.RPCInvalidAddressOrKey) {
+		t.Errorf("Unexpected span of a failed request: %+v", block)
+	}
+
+	if headers := collector.Headers(); len(headers) != 1 || headers[0].Get("Authorization") != "Bearer token" {
+		t.Errorf("Expected the configured headers to be sent, got %v", headers)
+	}
+
+	// Spans started without Begin are roots of their own traces
+	client.Do(request("getblockcount"))
+	exporter.Flush(context.Background())
+	if spans := collector.Spans(); len(spans) != 4 || spans[3].ParentSpanID != "" || spans[3].TraceID == root.TraceID {
+		t.Errorf("Expected a span in a new trace, got %+v", spans[3:])
+	}
+
+	// Nothing to flush
+	if err := exporter.Flush(context.Background()); err != nil || len(collector.Headers()) != 2 {
+		t.Errorf("Expected no export without spans, got %v", err)
+	}
+}
+
+func Test_ExporterErrors(t *testing.T) {
+	fake := rpctest.NewServer()
+	defer fake.Close()
+	collector := rpctest.NewCollector()
+	defer collector.Close()
+
+	errors := make(chan error, 1)
+	exporter, _ := tracing.NewExporter(tracing.Options{Endpoint: collector.URL, BatchSize: 2, OnError: func(err error) { errors <- err }})
+	client, _ := rpc.New(fake.URL, rpctest.Credentials, rpc.WithObserver(exporter))
+
+	// Full batches are exported in the background
+	client.Do(request("getblockcount"))
+	client.Do(request("getblockcount"))
+	for deadline := time.Now().Add(5 * time.Second); len(collector.Spans()) < 2; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the batch to be exported")
+		}
+	}
+
+	// Failed exports are reported
+	collector.Reject(http.StatusServiceUnavailable)
+	client.Do(request("getblockcount"))
+	client.Do(request("getblockcount"))
+	select {
+	case err := <-errors:
+		if !strings.Contains(err.Error(), "503") {
+			t.Errorf("Expected the collector's status in the error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the export to fail")
+	}
+
+	client.Do(request("getblockcount"))
+	collector.Close()
+	if err := exporter.Flush(context.Background()); err == nil {
+		t.Errorf("Expected flush to fail when the collector can't be reached")
+	}
+}
+
+func Test_Summary(t *testing.T) {
+	summary := tracing.NewSummary()
+
+	for _, call := range []rpc.Call{
+		{Method: "getblockhash", Duration: 2 * time.Millisecond},
+		{Method: "getblock", Duration: 30 * time.Millisecond},
+		{Method: "getblockhash", Duration: 4 * time.Millisecond, Err: fmt.Errorf("not found")},
+	} {
+		ctx := summary.Start(context.Background(), &call)
+		summary.End(ctx, &call)
+	}
+
+	var b strings.Builder
+	summary.Write(&b)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+
+	expected := [][]string{
+		{"method", "calls", "errors", "total", "avg", "max"},
+		{"getblock", "1", "0", "30ms", "30ms", "30ms"},
+		{"getblockhash", "2", "1", "6ms", "3ms", "4ms"},
+	}
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header, 2 methods and a total, got %q", lines)
+	}
+	for i, fields := range expected {
+		if fmt.Sprint(strings.Fields(lines[i])) != fmt.Sprint(fields) {
+			t.Errorf("Expected line %v to be %v, got %q", i, fields, lines[i])
+		}
+	}
+	if !strings.HasPrefix(lines[3], "2 methods, 3 calls, 1 error, 36ms of ") {
+		t.Errorf("Unexpected total: %q", lines[3])
+	}
+}
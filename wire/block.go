@@ -0,0 +1,149 @@
+package wire
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// HeaderSize is the size, in bytes, of a serialized block header.
+const HeaderSize = 80
+
+// Header is a block header.
+type Header struct {
+	Version       int32  `json:"version"`           // Block version (and BIP9 version bits)
+	PreviousBlock Hash   `json:"previousblockhash"` // Hash of the previous block
+	MerkleRoot    Hash   `json:"merkleroot"`        // Merkle root of the block's transactions
+	Time          uint32 `json:"time"`              // Block timestamp
+	Bits          uint32 `json:"bits"`              // Compact proof-of-work target
+	Nonce         uint32 `json:"nonce"`             // Nonce satisfying the proof-of-work
+}
+
+// Block is a block: its header and transactions.
+type Block struct {
+	Header
+	Transactions []*Tx `json:"tx"`
+}
+
+// DecodeHeader decodes a serialized 80-byte block header.
+func DecodeHeader(data []byte) (*Header, error) {
+	return decode(data, "block header", readHeader)
+}
+
+// DecodeHeaderHex decodes a hex-encoded block header, such as 'getblockheader' results with verbose=false.
+func DecodeHeaderHex(s string) (*Header, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, failure.Of("invalid block header hex: %v", err.Error())
+	}
+	return DecodeHeader(data)
+}
+
+// DecodeBlock decodes a serialized block.
+func DecodeBlock(data []byte) (*Block, error) {
+	return decode(data, "block", readBlock)
+}
+
+// DecodeBlockHex decodes a hex-encoded block, such as 'getblock' results with verbosity 0.
+func DecodeBlockHex(s string) (*Block, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, failure.Of("invalid block hex: %v", err.Error())
+	}
+	return DecodeBlock(data)
+}
+
+// readHeader reads a block header.
+func readHeader(r *reader) *Header {
+	return &Header{
+		Version:       int32(r.uint32()),
+		PreviousBlock: r.hash(),
+		MerkleRoot:    r.hash(),
+		Time:          r.uint32(),
+		Bits:          r.uint32(),
+		Nonce:         r.uint32(),
+	}
+}
+
+// readBlock reads a block.
+func readBlock(r *reader) *Block {
+	block := &Block{Header: *readHeader(r)}
+
+	// Transactions take at least 60 bytes
+	block.Transactions = make([]*Tx, r.count(60))
+	for i := range block.Transactions {
+		block.Transactions[i] = readTx(r)
+	}
+
+	return block
+}
+
+// Serialize returns the header's 80-byte serialization.
+func (h *Header) Serialize() []byte {
+	w := &writer{}
+	w.uint32(uint32(h.Version))
+	w.Write(h.PreviousBlock[:])
+	w.Write(h.MerkleRoot[:])
+	w.uint32(h.Time)
+	w.uint32(h.Bits)
+	w.uint32(h.Nonce)
+	return w.Bytes()
+}
+
+// Hash returns the block hash: the double SHA-256 of the serialized header.
+func (h *Header) Hash() Hash {
+	return Hash256(h.Serialize())
+}
+
+// Serialize returns the block's serialization, including witness data.
+func (b *Block) Serialize() []byte {
+	w := &writer{}
+	w.Write(b.Header.Serialize())
+	w.varint(uint64(len(b.Transactions)))
+	for _, tx := range b.Transactions {
+		w.Write(tx.Serialize(true))
+	}
+	return w.Bytes()
+}
+
+// Size returns the size, in bytes, of the block's serialization with witness data.
+func (b *Block) Size() int {
+	return len(b.Serialize())
+}
+
+// StrippedSize returns the size, in bytes, of the block's serialization without witness data.
+func (b *Block) StrippedSize() int {
+	size := b.Size()
+	for _, tx := range b.Transactions {
+		size -= tx.Size() - tx.StrippedSize()
+	}
+	return size
+}
+
+// Weight returns the block's weight (BIP141), limited to 4M by consensus rules.
+func (b *Block) Weight() int {
+	return b.StrippedSize()*3 + b.Size()
+}
+
+// MarshalJSON encodes the header along with its hash.
+func (h *Header) MarshalJSON() ([]byte, error) {
+	type fields Header
+	return json.Marshal(struct {
+		Hash Hash `json:"hash"`
+		*fields
+	}{h.Hash(), (*fields)(h)})
+}
+
+// MarshalJSON encodes the block along with its hash and sizes, named like in Bitcoin Core's results.
+func (b *Block) MarshalJSON() ([]byte, error) {
+	type fields Header
+	return json.Marshal(struct {
+		Hash Hash `json:"hash"`
+		*fields
+		Size         int   `json:"size"`
+		StrippedSize int   `json:"strippedsize"`
+		Weight       int   `json:"weight"`
+		Transactions []*Tx `json:"tx"`
+	}{b.Hash(), (*fields)(&b.Header), b.Size(), b.StrippedSize(), b.Weight(), b.Transactions})
+}
@@ -0,0 +1,135 @@
+package wire
+
+import (
+	"bytes"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// witnessCommitmentHeader prefixes the coinbase output committing to the witness merkle root (BIP141):
+// OP_RETURN, a 36-byte push and the 0xaa21a9ed tag.
+var witnessCommitmentHeader = []byte{0x6a, 0x24, 0xaa, 0x21, 0xa9, 0xed}
+
+// MerkleRoot computes the merkle root of hashes, as Bitcoin Core does: levels with an odd number
+// of hashes duplicate their last one.
+//
+// Parameters:
+// - hashes ([]Hash): The leaves, in order (e.g. the txids of a block's transactions).
+//
+// Returns:
+//   - Hash: The merkle root. It's zero when there are no hashes.
+//   - bool: Whether two identical hashes were paired. Such trees are mutated (CVE-2012-2459): a list
+//     ending with duplicated hashes would produce the same root, so blocks matching them are invalid.
+func MerkleRoot(hashes []Hash) (Hash, bool) {
+	if len(hashes) == 0 {
+		return Hash{}, false
+	}
+
+	level := append([]Hash{}, hashes...)
+	mutated := false
+
+	for len(level) > 1 {
+		for i := 0; i+1 < len(level); i += 2 {
+			if level[i] == level[i+1] {
+				mutated = true
+			}
+		}
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+
+		next := make([]Hash, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, Hash256(append(level[i][:], level[i+1][:]...)))
+		}
+		level = next
+	}
+
+	return level[0], mutated
+}
+
+// ComputeMerkleRoot computes the merkle root of the block's transaction ids, reporting whether the tree is mutated.
+func (b *Block) ComputeMerkleRoot() (Hash, bool) {
+	hashes := make([]Hash, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		hashes[i] = tx.TxID()
+	}
+	return MerkleRoot(hashes)
+}
+
+// WitnessMerkleRoot computes the merkle root of the block's witness transaction ids, in which
+// the coinbase is replaced by zero, reporting whether the tree is mutated.
+func (b *Block) WitnessMerkleRoot() (Hash, bool) {
+	hashes := make([]Hash, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		if i > 0 {
+			hashes[i] = tx.WTxID()
+		}
+	}
+	return MerkleRoot(hashes)
+}
+
+// WitnessCommitment returns the commitment to the witness merkle root found in the block's
+// coinbase (its last output matching the BIP141 format), and whether one was found.
+func (b *Block) WitnessCommitment() (Hash, bool) {
+	if len(b.Transactions) == 0 || !b.Transactions[0].IsCoinbase() {
+		return Hash{}, false
+	}
+
+	outputs := b.Transactions[0].Outputs
+	for i := len(outputs) - 1; i >= 0; i-- {
+		script := outputs[i].ScriptPubKey
+		if len(script) >= 38 && bytes.HasPrefix(script, witnessCommitmentHeader) {
+			commitment := Hash{}
+			copy(commitment[:], script[len(witnessCommitmentHeader):38])
+			return commitment, true
+		}
+	}
+	return Hash{}, false
+}
+
+// CheckMerkleRoot verifies that the block's header commits to its transactions: the merkle root
+// must match the transaction ids without mutation, and blocks with witness data must commit to
+// the witness merkle root in their coinbase (BIP141).
+func (b *Block) CheckMerkleRoot() error {
+	if len(b.Transactions) == 0 {
+		return failure.Of("block %s has no transactions", b.Hash())
+	}
+
+	root, mutated := b.ComputeMerkleRoot()
+	if root != b.MerkleRoot {
+		return failure.Of("merkle root mismatch in block %s: header commits to %s, transactions hash to %s", b.Hash(), b.MerkleRoot, root)
+	}
+	if mutated {
+		return failure.Of("block %s has duplicate transactions in its merkle tree", b.Hash())
+	}
+
+	witness := false
+	for _, tx := range b.Transactions {
+		witness = witness || tx.HasWitness()
+	}
+
+	commitment, committed := b.WitnessCommitment()
+	if !committed {
+		if witness {
+			return failure.Of("block %s has witness data but no witness commitment", b.Hash())
+		}
+		return nil
+	}
+
+	// The coinbase witness holds a single 32-byte reserved value, hashed along the witness root
+	reserved := b.Transactions[0].Inputs[0].Witness
+	if len(reserved) != 1 || len(reserved[0]) != 32 {
+		return failure.Of("block %s has an invalid witness reserved value in its coinbase", b.Hash())
+	}
+
+	root, mutated = b.WitnessMerkleRoot()
+	if expected := Hash256(append(root[:], reserved[0]...)); expected != commitment {
+		return failure.Of("witness commitment mismatch in block %s: coinbase commits to %x, witnesses hash to %x", b.Hash(), commitment[:], expected[:])
+	}
+	if mutated {
+		return failure.Of("block %s has duplicate witness transactions in its witness merkle tree", b.Hash())
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package wire
+
+import (
+	"math/big"
+	"slices"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// CompactToTarget decodes a compact proof-of-work target (the header's bits), as Bitcoin Core's
+// arith_uint256::SetCompact does. Negative targets, and targets overflowing 256 bits, are rejected.
+//
+// Parameters:
+// - bits (uint32): The compact target: a 1-byte exponent and a 3-byte signed mantissa.
+//
+// Returns:
+// - *big.Int: The target, which block hashes must not exceed.
+// - error: An error if the target is negative, zero or overflows.
+func CompactToTarget(bits uint32) (*big.Int, error) {
+	size := bits >> 24
+	word := bits & 0x007fffff
+
+	target := big.NewInt(int64(word))
+	if size <= 3 {
+		target.Rsh(target, uint(8*(3-size)))
+	} else {
+		target.Lsh(target, uint(8*(size-3)))
+	}
+
+	switch {
+	case word != 0 && bits&0x00800000 != 0:
+		return nil, failure.Of("negative target in bits %08x", bits)
+	case word != 0 && (size > 34 || word > 0xff && size > 33 || word > 0xffff && size > 32):
+		return nil, failure.Of("target overflow in bits %08x", bits)
+	case target.Sign() == 0:
+		return nil, failure.Of("zero target in bits %08x", bits)
+	}
+
+	return target, nil
+}
+
+// TargetToCompact encodes a target in compact form, as Bitcoin Core's arith_uint256::GetCompact does.
+// Precision beyond the 3-byte mantissa is truncated.
+func TargetToCompact(target *big.Int) uint32 {
+	size := uint32((target.BitLen() + 7) / 8)
+
+	var word uint32
+	if size <= 3 {
+		word = uint32(target.Uint64()) << (8 * (3 - size))
+	} else {
+		word = uint32(new(big.Int).Rsh(target, uint(8*(size-3))).Uint64())
+	}
+
+	// The mantissa is signed: shift it when its sign bit would be set
+	if word&0x00800000 != 0 {
+		word >>= 8
+		size++
+	}
+
+	return size<<24 | word
+}
+
+// Big returns the hash as a 256-bit number, for comparisons against proof-of-work targets.
+func (h Hash) Big() *big.Int {
+	reversed := h
+	slices.Reverse(reversed[:])
+	return new(big.Int).SetBytes(reversed[:])
+}
+
+// CheckProofOfWork verifies that the header's hash doesn't exceed the target encoded in its bits,
+// and that the target doesn't exceed limit (the chain's easiest target), when given.
+func (h *Header) CheckProofOfWork(limit *big.Int) error {
+	target, err := CompactToTarget(h.Bits)
+	if err != nil {
+		return err
+	}
+	if limit != nil && target.Cmp(limit) > 0 {
+		return failure.Of("target of bits %08x is easier than the proof-of-work limit", h.Bits)
+	}
+
+	if hash := h.Hash(); hash.Big().Cmp(target) > 0 {
+		return failure.Of("hash %s doesn't meet the target of bits %08x", hash, h.Bits)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package wire
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// OutPoint references an output of a previous transaction.
+type OutPoint struct {
+	Hash  Hash   `json:"txid"` // Id of the transaction holding the output
+	Index uint32 `json:"vout"` // Index of the output in the transaction
+}
+
+// TxIn is a transaction input.
+type TxIn struct {
+	PreviousOutput OutPoint   `json:"prevout"`           // Output being spent
+	ScriptSig      HexBytes   `json:"scriptsig"`         // Unlocking script
+	Sequence       uint32     `json:"sequence"`          // Sequence number (relative locktime, RBF signaling)
+	Witness        []HexBytes `json:"witness,omitempty"` // Segregated witness stack
+}
+
+// TxOut is a transaction output.
+type TxOut struct {
+	Value        int64    `json:"value"`        // Amount, in satoshis
+	ScriptPubKey HexBytes `json:"scriptpubkey"` // Locking script
+}
+
+// Tx is a transaction.
+type Tx struct {
+	Version  int32   `json:"version"`  // Transaction version
+	Inputs   []TxIn  `json:"vin"`      // Inputs
+	Outputs  []TxOut `json:"vout"`     // Outputs
+	LockTime uint32  `json:"locktime"` // Earliest time or height the transaction can be mined at
+}
+
+// HexBytes is a byte string encoded as hex in JSON.
+type HexBytes []byte
+
+// MarshalText encodes the bytes as hex.
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+// UnmarshalText decodes the bytes from hex.
+func (b *HexBytes) UnmarshalText(text []byte) error {
+	data, err := hex.DecodeString(string(text))
+	if err != nil {
+		return failure.Of("invalid hex: %v", err.Error())
+	}
+	*b = data
+	return nil
+}
+
+// Segwit serialization marker and flag, following the version of witness transactions (BIP144).
+const (
+	witnessMarker = 0x00
+	witnessFlag   = 0x01
+)
+
+// DecodeTx decodes a serialized transaction, with or without witness data.
+func DecodeTx(data []byte) (*Tx, error) {
+	return decode(data, "transaction", readTx)
+}
+
+// DecodeTxHex decodes a hex-encoded serialized transaction, such as 'getrawtransaction' results.
+func DecodeTxHex(s string) (*Tx, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, failure.Of("invalid transaction hex: %v", err.Error())
+	}
+	return DecodeTx(data)
+}
+
+// readTx reads a transaction.
+func readTx(r *reader) *Tx {
+	tx := &Tx{Version: int32(r.uint32())}
+
+	// A zero input count is the segwit marker, as transactions without inputs are invalid
+	witness := false
+	if r.peek() == witnessMarker {
+		r.uint8()
+		if flag := r.uint8(); r.err == nil && flag != witnessFlag {
+			r.err = failure.Of("unsupported witness flag %#x", flag)
+			return nil
+		}
+		witness = true
+	}
+
+	// Inputs take at least 41 bytes (outpoint, empty script and sequence)
+	tx.Inputs = make([]TxIn, r.count(41))
+	for i := range tx.Inputs {
+		tx.Inputs[i] = TxIn{
+			PreviousOutput: OutPoint{Hash: r.hash(), Index: r.uint32()},
+			ScriptSig:      r.script(),
+			Sequence:       r.uint32(),
+		}
+	}
+
+	// Outputs take at least 9 bytes (value and empty script)
+	tx.Outputs = make([]TxOut, r.count(9))
+	for i := range tx.Outputs {
+		tx.Outputs[i] = TxOut{Value: int64(r.uint64()), ScriptPubKey: r.script()}
+	}
+
+	if witness {
+		hasWitness := false
+		for i := range tx.Inputs {
+			items := r.count(1)
+			stack := make([]HexBytes, items)
+			for j := range stack {
+				stack[j] = r.script()
+			}
+			tx.Inputs[i].Witness = stack
+			hasWitness = hasWitness || items > 0
+		}
+
+		if r.err == nil && !hasWitness {
+			r.err = failure.Of("superfluous witness record")
+		}
+	}
+
+	tx.LockTime = r.uint32()
+
+	return tx
+}
+
+// HasWitness reports whether any input of the transaction has witness data.
+func (tx *Tx) HasWitness() bool {
+	for _, input := range tx.Inputs {
+		if len(input.Witness) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCoinbase reports whether the transaction is a coinbase, spending no previous output.
+func (tx *Tx) IsCoinbase() bool {
+	return len(tx.Inputs) == 1 && tx.Inputs[0].PreviousOutput.Hash.IsZero() && tx.Inputs[0].PreviousOutput.Index == 0xffffffff
+}
+
+// Serialize returns the transaction's serialization, including witness data if witness is true and it has any.
+func (tx *Tx) Serialize(witness bool) []byte {
+	witness = witness && tx.HasWitness()
+
+	w := &writer{}
+	w.uint32(uint32(tx.Version))
+	if witness {
+		w.WriteByte(witnessMarker)
+		w.WriteByte(witnessFlag)
+	}
+
+	w.varint(uint64(len(tx.Inputs)))
+	for _, input := range tx.Inputs {
+		w.Write(input.PreviousOutput.Hash[:])
+		w.uint32(input.PreviousOutput.Index)
+		w.script(input.ScriptSig)
+		w.uint32(input.Sequence)
+	}
+
+	w.varint(uint64(len(tx.Outputs)))
+	for _, output := range tx.Outputs {
+		w.uint64(uint64(output.Value))
+		w.script(output.ScriptPubKey)
+	}
+
+	if witness {
+		for _, input := range tx.Inputs {
+			w.varint(uint64(len(input.Witness)))
+			for _, item := range input.Witness {
+				w.script(item)
+			}
+		}
+	}
+
+	w.uint32(tx.LockTime)
+
+	return w.Bytes()
+}
+
+// TxID returns the transaction id: the double SHA-256 of its serialization without witness data.
+func (tx *Tx) TxID() Hash {
+	return Hash256(tx.Serialize(false))
+}
+
+// WTxID returns the witness transaction id: the double SHA-256 of its serialization with witness
+// data. It's equal to the txid when the transaction has no witness data.
+func (tx *Tx) WTxID() Hash {
+	return Hash256(tx.Serialize(true))
+}
+
+// Size returns the size, in bytes, of the transaction's serialization with witness data.
+func (tx *Tx) Size() int {
+	return len(tx.Serialize(true))
+}
+
+// StrippedSize returns the size, in bytes, of the transaction's serialization without witness data.
+func (tx *Tx) StrippedSize() int {
+	return len(tx.Serialize(false))
+}
+
+// Weight returns the transaction's weight (BIP141): its stripped size counts 4 times and its witness data once.
+func (tx *Tx) Weight() int {
+	return tx.StrippedSize()*3 + tx.Size()
+}
+
+// VSize returns the transaction's virtual size: its weight divided by 4, rounded up.
+func (tx *Tx) VSize() int {
+	return (tx.Weight() + 3) / 4
+}
+
+// MarshalJSON encodes the transaction along with its computed ids and sizes, named like in Bitcoin Core's results.
+func (tx *Tx) MarshalJSON() ([]byte, error) {
+	type fields Tx
+	return json.Marshal(struct {
+		TxID   Hash `json:"txid"`
+		WTxID  Hash `json:"hash"`
+		Size   int  `json:"size"`
+		VSize  int  `json:"vsize"`
+		Weight int  `json:"weight"`
+		*fields
+	}{tx.TxID(), tx.WTxID(), tx.Size(), tx.VSize(), tx.Weight(), (*fields)(tx)})
+}
@@ -0,0 +1,219 @@
+// Package wire decodes and encodes Bitcoin's consensus serialization of block headers,
+// blocks and transactions, as found in Core's hex RPC results, REST binary responses
+// and ZMQ notifications.
+package wire
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"slices"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Hash is a double SHA-256 hash, kept in internal byte order. It's displayed byte-reversed,
+// as Bitcoin Core does for block hashes and transaction ids.
+type Hash [32]byte
+
+// Hash256 computes the double SHA-256 of data.
+func Hash256(data []byte) Hash {
+	first := sha256.Sum256(data)
+	return sha256.Sum256(first[:])
+}
+
+// HashFrom parses a hash displayed byte-reversed, as returned by Bitcoin Core.
+func HashFrom(s string) (Hash, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) != 32 {
+		return Hash{}, failure.Of("invalid hash '%s': must be 64 hex characters", s)
+	}
+
+	hash := Hash{}
+	copy(hash[:], data)
+	slices.Reverse(hash[:])
+	return hash, nil
+}
+
+// String returns the byte-reversed hex encoding of the hash.
+func (h Hash) String() string {
+	reversed := h
+	slices.Reverse(reversed[:])
+	return hex.EncodeToString(reversed[:])
+}
+
+// IsZero reports whether every byte of the hash is zero, as in the previous output of coinbase inputs.
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+// MarshalText encodes the hash as its displayed string, so it's readable in JSON.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText decodes a hash from its displayed string.
+func (h *Hash) UnmarshalText(text []byte) error {
+	hash, err := HashFrom(string(text))
+	if err != nil {
+		return err
+	}
+	*h = hash
+	return nil
+}
+
+// reader reads consensus-serialized fields, keeping the first error found so that
+// decoders can check it once, after reading every field.
+type reader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+// remaining returns the number of unread bytes.
+func (r *reader) remaining() int {
+	return len(r.data) - r.offset
+}
+
+// bytes reads the next n bytes.
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > r.remaining() {
+		r.err = failure.Of("unexpected end of data: %d bytes needed at offset %d, %d left", n, r.offset, r.remaining())
+		return nil
+	}
+
+	data := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return data
+}
+
+// peek returns the next byte without consuming it.
+func (r *reader) peek() byte {
+	if r.err != nil || r.remaining() == 0 {
+		return 0
+	}
+	return r.data[r.offset]
+}
+
+func (r *reader) uint8() uint8 {
+	if data := r.bytes(1); data != nil {
+		return data[0]
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if data := r.bytes(4); data != nil {
+		return binary.LittleEndian.Uint32(data)
+	}
+	return 0
+}
+
+func (r *reader) uint64() uint64 {
+	if data := r.bytes(8); data != nil {
+		return binary.LittleEndian.Uint64(data)
+	}
+	return 0
+}
+
+func (r *reader) hash() Hash {
+	hash := Hash{}
+	copy(hash[:], r.bytes(32))
+	return hash
+}
+
+// varint reads a CompactSize integer, rejecting non-canonical encodings as Core does.
+func (r *reader) varint() uint64 {
+	prefix := r.uint8()
+
+	var n, minimum uint64
+	switch prefix {
+	case 0xfd:
+		if data := r.bytes(2); data != nil {
+			n = uint64(binary.LittleEndian.Uint16(data))
+		}
+		minimum = 0xfd
+	case 0xfe:
+		n, minimum = uint64(r.uint32()), 0x10000
+	case 0xff:
+		n, minimum = r.uint64(), 0x100000000
+	default:
+		return uint64(prefix)
+	}
+
+	if r.err == nil && n < minimum {
+		r.err = failure.Of("non-canonical varint at offset %d", r.offset)
+	}
+	return n
+}
+
+// count reads a varint used as the number of items that follow, each taking at least size bytes.
+// Counts that can't fit in the remaining data are rejected before anything is allocated.
+func (r *reader) count(size int) int {
+	n := r.varint()
+	if r.err == nil && n > uint64(r.remaining()/size) {
+		r.err = failure.Of("item count %d at offset %d exceeds the remaining data", n, r.offset)
+		return 0
+	}
+	return int(n)
+}
+
+// script reads a length-prefixed byte string.
+func (r *reader) script() []byte {
+	return bytes.Clone(r.bytes(r.count(1)))
+}
+
+// writer writes consensus-serialized fields.
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) uint32(n uint32) {
+	w.Write(binary.LittleEndian.AppendUint32(nil, n))
+}
+
+func (w *writer) uint64(n uint64) {
+	w.Write(binary.LittleEndian.AppendUint64(nil, n))
+}
+
+// varint writes a CompactSize integer.
+func (w *writer) varint(n uint64) {
+	switch {
+	case n < 0xfd:
+		w.WriteByte(byte(n))
+	case n <= 0xffff:
+		w.WriteByte(0xfd)
+		w.Write(binary.LittleEndian.AppendUint16(nil, uint16(n)))
+	case n <= 0xffffffff:
+		w.WriteByte(0xfe)
+		w.uint32(uint32(n))
+	default:
+		w.WriteByte(0xff)
+		w.uint64(n)
+	}
+}
+
+// script writes a length-prefixed byte string.
+func (w *writer) script(data []byte) {
+	w.varint(uint64(len(data)))
+	w.Write(data)
+}
+
+// decode runs a decoder over data, failing if it doesn't consume it entirely.
+func decode[T any](data []byte, what string, read func(*reader) T) (T, error) {
+	r := &reader{data: data}
+	value := read(r)
+
+	if r.err == nil && r.remaining() > 0 {
+		r.err = failure.Of("%d unexpected trailing bytes", r.remaining())
+	}
+	if r.err != nil {
+		var zero T
+		return zero, failure.Of("failed to decode %s: %v", what, r.err.Error())
+	}
+	return value, nil
+}
@@ -0,0 +1,328 @@
+package wire_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/avila-r/bitclient/rpctest"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// genesis is the serialized mainnet genesis block.
+const genesis = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c" +
+	"0101000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+func Test_DecodeBlock(t *testing.T) {
+	block, err := wire.DecodeBlockHex(genesis)
+	if err != nil {
+		t.Fatalf("Failed to decode genesis block: %v", err)
+	}
+
+	if hash := block.Hash().String(); hash != "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f" {
+		t.Errorf("Unexpected genesis block hash %s", hash)
+	}
+	if len(block.Transactions) != 1 || !block.Transactions[0].IsCoinbase() {
+		t.Fatalf("Expected a single coinbase transaction, got %v", len(block.Transactions))
+	}
+	if txid := block.Transactions[0].TxID(); txid != block.MerkleRoot {
+		t.Errorf("Expected the coinbase txid %s to be the merkle root %s", txid, block.MerkleRoot)
+	}
+	if value := block.Transactions[0].Outputs[0].Value; value != 50_0000_0000 {
+		t.Errorf("Expected a 50 BTC output, got %v", value)
+	}
+	if serialized := hex.EncodeToString(block.Serialize()); serialized != genesis {
+		t.Errorf("Expected the block to serialize back to its original encoding")
+	}
+
+	chain := rpctest.NewChain(5)
+	for height := 0; height <= chain.Height(); height++ {
+		fixture := chain.Block(height)
+
+		block, err := wire.DecodeBlock(fixture.Serialize())
+		if err != nil {
+			t.Fatalf("Failed to decode fixture block %v: %v", height, err)
+		}
+		if block.Hash().String() != fixture.Hash {
+			t.Errorf("Expected fixture block hash %s, got %s", fixture.Hash, block.Hash())
+		}
+	}
+}
+
+func Test_DecodeTx(t *testing.T) {
+	tx := &wire.Tx{
+		Version: 2,
+		Inputs: []wire.TxIn{{
+			PreviousOutput: wire.OutPoint{Hash: wire.Hash256([]byte("previous")), Index: 1},
+			Sequence:       0xfffffffd,
+			Witness:        []wire.HexBytes{bytes.Repeat([]byte{0x30}, 71), bytes.Repeat([]byte{0x02}, 33)},
+		}},
+		Outputs: []wire.TxOut{
+			{Value: 12_345, ScriptPubKey: append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0xab}, 20)...)},
+		},
+		LockTime: 840_000,
+	}
+
+	cases := []struct {
+		Witness bool
+	}{
+		{Witness: true},
+		{Witness: false},
+	}
+
+	for i, test := range cases {
+		name := fmt.Sprintf("case %v", i)
+		t.Run(name, func(t *testing.T) {
+			decoded, err := wire.DecodeTx(tx.Serialize(test.Witness))
+			if err != nil {
+				t.Fatalf("Failed to decode transaction: %v", err)
+			}
+			if decoded.HasWitness() != test.Witness {
+				t.Errorf("Expected witness presence to be %v", test.Witness)
+			}
+			if decoded.TxID() != tx.TxID() {
+				t.Errorf("Expected txid %s, got %s", tx.TxID(), decoded.TxID())
+			}
+			if !bytes.Equal(decoded.Serialize(true), tx.Serialize(test.Witness)) {
+				t.Errorf("Expected the transaction to serialize back to its original encoding")
+			}
+		})
+	}
+}
+
+func Test_DecodeInvalid(t *testing.T) {
+	cases := []struct {
+		Data string
+	}{
+		{Data: ""},
+		{Data: genesis[:160]},  // Header only
+		{Data: genesis + "00"}, // Trailing data
+		{Data: genesis[:160] + "ffffffffffffffffff"}, // Huge transaction count
+		{Data: genesis[:160] + "fd0100"},             // Non-canonical varint
+	}
+
+	for i, test := range cases {
+		name := fmt.Sprintf("case %v", i)
+		t.Run(name, func(t *testing.T) {
+			if _, err := wire.DecodeBlockHex(test.Data); err == nil {
+				t.Errorf("Expected failure decoding invalid block")
+			}
+		})
+	}
+}
+
+func Test_MerkleRoot(t *testing.T) {
+	a, b, c := wire.Hash256([]byte("a")), wire.Hash256([]byte("b")), wire.Hash256([]byte("c"))
+	pair := func(x, y wire.Hash) wire.Hash { return wire.Hash256(append(x[:], y[:]...)) }
+
+	cases := []struct {
+		Hashes   []wire.Hash
+		Expected wire.Hash
+		Mutated  bool
+	}{
+		{Hashes: nil, Expected: wire.Hash{}},
+		{Hashes: []wire.Hash{a}, Expected: a},
+		{Hashes: []wire.Hash{a, b}, Expected: pair(a, b)},
+		{Hashes: []wire.Hash{a, b, c}, Expected: pair(pair(a, b), pair(c, c))},
+		{Hashes: []wire.Hash{a, b, c, c}, Expected: pair(pair(a, b), pair(c, c)), Mutated: true},
+	}
+
+	for i, test := range cases {
+		name := fmt.Sprintf("case %v", i)
+		t.Run(name, func(t *testing.T) {
+			root, mutated := wire.MerkleRoot(test.Hashes)
+			if root != test.Expected {
+				t.Errorf("Expected merkle root %s, got %s", test.Expected, root)
+			}
+			if mutated != test.Mutated {
+				t.Errorf("Expected mutation to be %v", test.Mutated)
+			}
+		})
+	}
+}
+
+// segwitBlock builds a block with a coinbase committing to the witness of a segwit transaction.
+func segwitBlock() *wire.Block {
+	spend := &wire.Tx{
+		Version: 2,
+		Inputs: []wire.TxIn{{
+			PreviousOutput: wire.OutPoint{Hash: wire.Hash256([]byte("previous")), Index: 0},
+			Sequence:       0xffffffff,
+			Witness:        []wire.HexBytes{bytes.Repeat([]byte{0x30}, 72), bytes.Repeat([]byte{0x03}, 33)},
+		}},
+		Outputs: []wire.TxOut{{Value: 90_000, ScriptPubKey: append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0xcd}, 20)...)}},
+	}
+
+	coinbase := &wire.Tx{
+		Version: 2,
+		Inputs: []wire.TxIn{{
+			PreviousOutput: wire.OutPoint{Index: 0xffffffff},
+			ScriptSig:      []byte{0x03, 0x40, 0x0d, 0x03},
+			Sequence:       0xffffffff,
+			Witness:        []wire.HexBytes{make([]byte, 32)},
+		}},
+		Outputs: []wire.TxOut{{Value: 312_500_000, ScriptPubKey: []byte{0x51}}},
+	}
+
+	block := &wire.Block{
+		Header:       wire.Header{Version: 0x20000000, Time: 1_700_000_000, Bits: 0x207fffff},
+		Transactions: []*wire.Tx{coinbase, spend},
+	}
+
+	root, _ := block.WitnessMerkleRoot()
+	commitment := wire.Hash256(append(root[:], make([]byte, 32)...))
+	coinbase.Outputs = append(coinbase.Outputs, wire.TxOut{ScriptPubKey: append([]byte{0x6a, 0x24, 0xaa, 0x21, 0xa9, 0xed}, commitment[:]...)})
+
+	block.MerkleRoot, _ = block.ComputeMerkleRoot()
+	return block
+}
+
+func Test_CheckMerkleRoot(t *testing.T) {
+	genesisBlock, _ := wire.DecodeBlockHex(genesis)
+	if err := genesisBlock.CheckMerkleRoot(); err != nil {
+		t.Errorf("Failed to verify genesis merkle root: %v", err)
+	}
+
+	block, err := wire.DecodeBlock(segwitBlock().Serialize())
+	if err != nil {
+		t.Fatalf("Failed to decode segwit block: %v", err)
+	}
+	if err := block.CheckMerkleRoot(); err != nil {
+		t.Errorf("Failed to verify segwit block: %v", err)
+	}
+
+	spend := block.Transactions[1]
+	if spend.TxID() == spend.WTxID() {
+		t.Errorf("Expected the wtxid of a segwit transaction to differ from its txid")
+	}
+	if witness := spend.Size() - spend.StrippedSize(); spend.Weight() != spend.StrippedSize()*4+witness {
+		t.Errorf("Unexpected weight %v for stripped size %v and %v witness bytes", spend.Weight(), spend.StrippedSize(), witness)
+	}
+	if block.Weight() != block.StrippedSize()*4+(block.Size()-block.StrippedSize()) {
+		t.Errorf("Unexpected block weight %v", block.Weight())
+	}
+
+	cases := []struct {
+		Tamper func(*wire.Block)
+	}{
+		{Tamper: func(b *wire.Block) { b.Transactions[1].Outputs[0].Value++ }},                       // Changes the txid
+		{Tamper: func(b *wire.Block) { b.Transactions[1].Inputs[0].Witness[0][0] = 0x31 }},           // Changes the wtxid only
+		{Tamper: func(b *wire.Block) { b.Transactions = append(b.Transactions, b.Transactions[1]) }}, // Duplicates a transaction
+		{Tamper: func(b *wire.Block) { b.Transactions[0].Outputs = b.Transactions[0].Outputs[:1] }},  // Drops the commitment
+	}
+
+	for i, test := range cases {
+		name := fmt.Sprintf("case %v", i)
+		t.Run(name, func(t *testing.T) {
+			tampered := segwitBlock()
+			test.Tamper(tampered)
+			if err := tampered.CheckMerkleRoot(); err == nil {
+				t.Errorf("Expected verification of a tampered block to fail")
+			}
+		})
+	}
+}
+
+func Test_MarshalJSON(t *testing.T) {
+	block := segwitBlock()
+
+	data, err := json.Marshal(block)
+	if err != nil {
+		t.Fatalf("Failed to marshal block: %v", err)
+	}
+
+	result := struct {
+		Hash       string `json:"hash"`
+		MerkleRoot string `json:"merkleroot"`
+		Weight     int    `json:"weight"`
+		Tx         []struct {
+			TxID string `json:"txid"`
+			Hash string `json:"hash"`
+		} `json:"tx"`
+	}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to unmarshal block: %v", err)
+	}
+
+	if result.Hash != block.Hash().String() || result.MerkleRoot != block.MerkleRoot.String() || result.Weight != block.Weight() {
+		t.Errorf("Unexpected block fields: %s", data)
+	}
+	if len(result.Tx) != 2 || result.Tx[1].TxID != block.Transactions[1].TxID().String() || result.Tx[1].Hash != block.Transactions[1].WTxID().String() {
+		t.Errorf("Unexpected transaction ids: %s", data)
+	}
+
+	decoded := &wire.Tx{}
+	if err := json.Unmarshal(mustMarshal(t, block.Transactions[1]), decoded); err != nil || decoded.TxID() != block.Transactions[1].TxID() {
+		t.Errorf("Expected a transaction to round-trip through json (%v)", err)
+	}
+}
+
+// mustMarshal encodes a value as JSON, failing the test on errors.
+func mustMarshal(t *testing.T, value any) []byte {
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	return data
+}
+
+func Test_CompactTarget(t *testing.T) {
+	cases := []struct {
+		Bits   uint32
+		Target string
+		Valid  bool
+	}{
+		{Bits: 0x1d00ffff, Target: "ffff0000000000000000000000000000000000000000000000000000", Valid: true},
+		{Bits: 0x207fffff, Target: "7fffff0000000000000000000000000000000000000000000000000000000000", Valid: true},
+		{Bits: 0x17034219, Target: "342190000000000000000000000000000000000000000", Valid: true},
+		{Bits: 0x03123456, Target: "123456", Valid: true},
+		{Bits: 0x1d80ffff, Valid: false}, // Negative
+		{Bits: 0x23123456, Valid: false}, // Overflow
+		{Bits: 0x1d000000, Valid: false}, // Zero
+	}
+
+	for i, test := range cases {
+		name := fmt.Sprintf("case %v", i)
+		t.Run(name, func(t *testing.T) {
+			target, err := wire.CompactToTarget(test.Bits)
+			if !test.Valid {
+				if err == nil {
+					t.Errorf("Expected bits %08x to be rejected", test.Bits)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to decode bits %08x: %v", test.Bits, err)
+			}
+			if target.Text(16) != test.Target {
+				t.Errorf("Expected target %s, got %s", test.Target, target.Text(16))
+			}
+			if bits := wire.TargetToCompact(target); bits != test.Bits {
+				t.Errorf("Expected target to encode back to %08x, got %08x", test.Bits, bits)
+			}
+		})
+	}
+}
+
+func Test_CheckProofOfWork(t *testing.T) {
+	block, _ := wire.DecodeBlockHex(genesis)
+	limit, _ := wire.CompactToTarget(0x1d00ffff)
+
+	if err := block.CheckProofOfWork(limit); err != nil {
+		t.Errorf("Failed to verify genesis proof-of-work: %v", err)
+	}
+
+	block.Nonce++
+	if err := block.CheckProofOfWork(limit); err == nil {
+		t.Errorf("Expected a header with a wrong nonce to fail")
+	}
+
+	block.Nonce--
+	block.Bits = 0x1d01ffff
+	if err := block.CheckProofOfWork(limit); err == nil {
+		t.Errorf("Expected a target above the limit to fail")
+	}
+}
@@ -0,0 +1,147 @@
+package zmq
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Notification is a message received by Listen, or a failure to receive one.
+type Notification struct {
+	*Message
+
+	Endpoint string // Endpoint the message was received from
+	Missed   uint32 // Number of notifications of the topic missed right before this one, since connecting
+	Err      error  // Failure to connect or receive, after which Listen reconnects
+}
+
+// ListenOptions configures how Listen subscribes to endpoints.
+type ListenOptions struct {
+	// Retry is the delay before reconnecting after a failure (default: 1s).
+	Retry time.Duration
+}
+
+// Listen subscribes to notification endpoints, such as the ones returned by Notifications,
+// merging their messages into a single channel until ctx is done.
+//
+// Sequence numbers are validated per endpoint and topic: when notifications are missed (e.g. because
+// the publisher's high water mark was reached, or while reconnecting), the next notification reports
+// how many were missed. Connection failures are sent as notifications with Err set, and followed by
+// reconnection attempts.
+//
+// Parameters:
+// - ctx (context.Context): Stops listening when done, closing the channel.
+// - endpoints ([]Endpoint): Topics to subscribe to, and the addresses they're published on.
+// - options (ListenOptions): Reconnection settings; zero values fall back to the defaults.
+//
+// Returns:
+// - <-chan Notification: The notifications of every endpoint.
+// - error: An error if no endpoint is given.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient zmq listen --topic rawtx
+//
+//   - Using Go:
+//     endpoints, err := zmq.Notifications()
+//     notifications, err := zmq.Listen(ctx, endpoints, zmq.ListenOptions{})
+//     for notification := range notifications {
+//     tx, err := notification.Tx()
+//     }
+func Listen(ctx context.Context, endpoints []Endpoint, options ListenOptions) (<-chan Notification, error) {
+	if len(endpoints) == 0 {
+		return nil, failure.Of("at least one endpoint must be provided")
+	}
+	if options.Retry <= 0 {
+		options.Retry = time.Second
+	}
+
+	// Subscribe once per address, to every topic it publishes
+	addresses := []string{}
+	topics := map[string][]Topic{}
+	for _, endpoint := range endpoints {
+		if _, _, err := parse(endpoint.Address); err != nil {
+			return nil, err
+		}
+		if _, ok := topics[endpoint.Address]; !ok {
+			addresses = append(addresses, endpoint.Address)
+		}
+		topics[endpoint.Address] = append(topics[endpoint.Address], endpoint.Topic)
+	}
+
+	notifications := make(chan Notification)
+
+	var wg sync.WaitGroup
+	for _, address := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listen(ctx, address, topics[address], options, notifications)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(notifications)
+	}()
+
+	return notifications, nil
+}
+
+// listen receives the notifications of a single address, reconnecting after failures.
+func listen(ctx context.Context, address string, topics []Topic, options ListenOptions, notifications chan<- Notification) {
+	send := func(notification Notification) bool {
+		select {
+		case notifications <- notification:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for ctx.Err() == nil {
+		subscriber, err := Dial(ctx, address, topics...)
+		if err != nil {
+			if ctx.Err() != nil || !send(Notification{Endpoint: address, Err: err}) {
+				return
+			}
+			select {
+			case <-time.After(options.Retry):
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		// Sequence numbers start over when the node restarts, so gaps are only tracked within a connection
+		next := map[Topic]uint32{} // Expected sequence number of each topic
+
+		// Unblock Receive as soon as the context is done
+		stop := context.AfterFunc(ctx, func() { subscriber.Close() })
+
+		for {
+			message, err := subscriber.Receive()
+			if err != nil {
+				if ctx.Err() == nil {
+					send(Notification{Endpoint: address, Err: err})
+				}
+				break
+			}
+
+			missed := uint32(0)
+			if expected, ok := next[message.Topic]; ok {
+				missed = message.Sequence - expected // Sequence numbers wrap around
+			}
+			next[message.Topic] = message.Sequence + 1
+
+			if !send(Notification{Message: message, Endpoint: address, Missed: missed}) {
+				break
+			}
+		}
+
+		stop()
+		subscriber.Close()
+	}
+}
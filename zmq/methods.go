@@ -0,0 +1,7 @@
+package zmq
+
+import "github.com/avila-r/bitclient/rpc"
+
+const (
+	MethodGetZmqNotifications rpc.Method = "getzmqnotifications" // Method to get the active ZeroMQ notifications
+)
@@ -0,0 +1,104 @@
+package zmq
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/rpc"
+)
+
+// Endpoint is a topic published by Bitcoin Core, and the address it's published on.
+type Endpoint struct {
+	Topic         Topic  `json:"topic"`   // Notification topic
+	Address       string `json:"address"` // Publisher address (e.g. tcp://127.0.0.1:28332)
+	HighWaterMark int    `json:"hwm"`     // Outbound message high water mark
+}
+
+// Notifications retrieves the ZeroMQ notifications enabled on the node.
+//
+// This function sends a JSON-RPC request to the Bitcoin client using the "getzmqnotifications" procedure call.
+// Publishers bound to every interface (e.g. tcp://0.0.0.0:28332) are reached through the RPC server's host.
+//
+// Returns:
+// - []Endpoint: The enabled topics and their addresses.
+// - error: An error if the request fails or if there is an issue with the response.
+//
+// Example Usage:
+//
+//   - Using Bitclient:
+//     $ bitclient zmq listen
+//
+//   - Using the Bitcoin CLI:
+//     $ bitcoin-cli getzmqnotifications
+//
+//   - Using cURL:
+//     $ curl --user {username} --data-binary '{"jsonrpc": "1.0", "id": "curltest", "method": "getzmqnotifications", "params": []}' \
+//     -H 'content-type: text/plain;' {url}
+//
+// JSON Response Example:
+//
+//	[
+//	  {
+//	    "type": "pubrawblock",
+//	    "address": "tcp://127.0.0.1:28332",
+//	    "hwm": 1000
+//	  }
+//	]
+func Notifications() ([]Endpoint, error) {
+	if rpc.Client == nil {
+		return nil, failure.Of("rpc client isn't available")
+	}
+
+	request := rpc.Request{
+		ID:      rpc.Identifier,
+		Version: rpc.Version2,
+		Method:  MethodGetZmqNotifications,
+		Params:  rpc.NoParams,
+	}
+
+	response, err := rpc.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []struct {
+		Type          string `json:"type"`
+		Address       string `json:"address"`
+		HighWaterMark int    `json:"hwm"`
+	}{}
+	if err := response.Bind(&result); err != nil {
+		return nil, failure.Of("failed to parse zmq notifications: %v", err.Error())
+	}
+
+	endpoints := []Endpoint{}
+	for _, notification := range result {
+		endpoints = append(endpoints, Endpoint{
+			Topic:         Topic(strings.TrimPrefix(notification.Type, "pub")),
+			Address:       reachable(notification.Address, rpc.Client.URL),
+			HighWaterMark: notification.HighWaterMark,
+		})
+	}
+
+	return endpoints, nil
+}
+
+// reachable replaces the wildcard host of a publisher address with the host of the RPC server.
+func reachable(address, server string) string {
+	parsed, err := url.Parse(address)
+	if err != nil || parsed.Scheme != "tcp" {
+		return address
+	}
+
+	host, port, err := net.SplitHostPort(parsed.Host)
+	if err != nil || host != "0.0.0.0" && host != "*" && host != "::" {
+		return address
+	}
+
+	rpcURL, err := url.Parse(server)
+	if err != nil || rpcURL.Hostname() == "" || rpcURL.Scheme == "unix" {
+		return "tcp://" + net.JoinHostPort("127.0.0.1", port)
+	}
+	return "tcp://" + net.JoinHostPort(rpcURL.Hostname(), port)
+}
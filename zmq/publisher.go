@@ -0,0 +1,162 @@
+package zmq
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// Publisher is a ZMTP PUB socket publishing notifications the way Bitcoin Core does:
+// as [topic, payload, sequence] messages, with a counter per topic. It's meant for tests
+// and for relaying notifications.
+type Publisher struct {
+	listener net.Listener
+
+	mu          sync.Mutex
+	subscribers map[*conn][][]byte // Subscribed topic prefixes of each connection
+	sequences   map[Topic]uint32   // Next sequence number of each topic
+	subscribed  *sync.Cond         // Signaled whenever a subscription is received
+}
+
+// NewPublisher listens on a TCP address (e.g. "127.0.0.1:0") for subscribers.
+func NewPublisher(address string) (*Publisher, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, failure.Of("failed to listen on %s: %v", address, err.Error())
+	}
+
+	p := &Publisher{
+		listener:    listener,
+		subscribers: map[*conn][][]byte{},
+		sequences:   map[Topic]uint32{},
+	}
+	p.subscribed = sync.NewCond(&p.mu)
+
+	go p.accept()
+
+	return p, nil
+}
+
+// Endpoint returns the endpoint subscribers should connect to.
+func (p *Publisher) Endpoint() string {
+	return "tcp://" + p.listener.Addr().String()
+}
+
+// Publish sends a notification to every subscriber of the topic, returning its sequence number.
+func (p *Publisher) Publish(topic Topic, body []byte) uint32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sequence := p.sequences[topic]
+	p.sequences[topic]++
+
+	message := [][]byte{[]byte(topic), body, binary.LittleEndian.AppendUint32(nil, sequence)}
+	for subscriber, prefixes := range p.subscribers {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(string(topic), string(prefix)) {
+				if err := subscriber.send(message...); err != nil {
+					subscriber.Close()
+					delete(p.subscribers, subscriber)
+				}
+				break
+			}
+		}
+	}
+
+	return sequence
+}
+
+// Skip advances the sequence number of a topic without publishing, simulating lost notifications.
+func (p *Publisher) Skip(topic Topic, n uint32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sequences[topic] += n
+}
+
+// WaitForSubscribers blocks until n subscriptions to the topic have been received.
+func (p *Publisher) WaitForSubscribers(topic Topic, n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for p.count(topic) < n {
+		p.subscribed.Wait()
+	}
+}
+
+// Close stops listening and disconnects every subscriber.
+func (p *Publisher) Close() error {
+	err := p.listener.Close()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for subscriber := range p.subscribers {
+		subscriber.Close()
+	}
+	p.subscribers = map[*conn][][]byte{}
+
+	return err
+}
+
+// count returns the number of subscriptions matching a topic. The caller must hold the lock.
+func (p *Publisher) count(topic Topic) int {
+	n := 0
+	for _, prefixes := range p.subscribers {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(string(topic), string(prefix)) {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// accept handles incoming connections until the listener is closed.
+func (p *Publisher) accept() {
+	for {
+		c, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.serve(c)
+	}
+}
+
+// serve completes the handshake with a subscriber and records its subscriptions.
+func (p *Publisher) serve(c net.Conn) {
+	z, err := handshake(c, "PUB", "SUB", "XSUB")
+	if err != nil {
+		c.Close()
+		return
+	}
+
+	p.mu.Lock()
+	p.subscribers[z] = nil
+	p.mu.Unlock()
+
+	for {
+		parts, err := z.receive()
+		if err != nil {
+			p.mu.Lock()
+			delete(p.subscribers, z)
+			p.mu.Unlock()
+			return
+		}
+
+		if len(parts) != 1 || len(parts[0]) == 0 || parts[0][0] != subscribe {
+			continue // Only ZMTP 3.0 subscriptions are supported
+		}
+
+		p.mu.Lock()
+		if _, ok := p.subscribers[z]; ok {
+			p.subscribers[z] = append(p.subscribers[z], parts[0][1:])
+		}
+		p.subscribed.Broadcast()
+		p.mu.Unlock()
+	}
+}
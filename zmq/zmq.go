@@ -0,0 +1,190 @@
+// Package zmq subscribes to Bitcoin Core's ZeroMQ notifications (-zmqpub* options), implementing
+// the ZMTP 3.0 SUB socket in pure Go, so that no libzmq (nor cgo) is needed.
+package zmq
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/avila-r/bitclient/failure"
+	"github.com/avila-r/bitclient/wire"
+)
+
+// Topic is a notification topic published by Bitcoin Core.
+type Topic string
+
+const (
+	TopicHashBlock Topic = "hashblock" // Hash of every new block (-zmqpubhashblock)
+	TopicHashTx    Topic = "hashtx"    // Id of every new transaction, in blocks or the mempool (-zmqpubhashtx)
+	TopicRawBlock  Topic = "rawblock"  // Serialized new blocks (-zmqpubrawblock)
+	TopicRawTx     Topic = "rawtx"     // Serialized new transactions (-zmqpubrawtx)
+	TopicSequence  Topic = "sequence"  // Block (dis)connections and mempool additions/removals (-zmqpubsequence)
+)
+
+// Topics lists every topic published by Bitcoin Core.
+var Topics = []Topic{TopicHashBlock, TopicHashTx, TopicRawBlock, TopicRawTx, TopicSequence}
+
+// Message is a notification received from Bitcoin Core.
+type Message struct {
+	Topic    Topic  // Notification topic
+	Body     []byte // Notification payload
+	Sequence uint32 // Per-topic message counter, used to detect missed notifications
+}
+
+// SequenceLabel is the kind of change reported by 'sequence' notifications.
+type SequenceLabel string
+
+const (
+	SequenceBlockConnected    SequenceLabel = "C" // Block connected to the active chain
+	SequenceBlockDisconnected SequenceLabel = "D" // Block disconnected from the active chain
+	SequenceTxAdded           SequenceLabel = "A" // Transaction added to the mempool
+	SequenceTxRemoved         SequenceLabel = "R" // Transaction removed from the mempool
+)
+
+// SequenceEvent is the payload of a 'sequence' notification.
+type SequenceEvent struct {
+	Hash            string        `json:"hash"`                       // Block hash or transaction id
+	Label           SequenceLabel `json:"label"`                      // Kind of change
+	MempoolSequence uint64        `json:"mempool_sequence,omitempty"` // Mempool sequence, for mempool changes
+}
+
+// Hash returns the block hash or transaction id of 'hashblock' and 'hashtx' notifications.
+func (m *Message) Hash() (string, error) {
+	if len(m.Body) != 32 {
+		return "", failure.Of("%s payload must be 32 bytes long, got %d", m.Topic, len(m.Body))
+	}
+	// Core publishes hashes in display order
+	return hex.EncodeToString(m.Body), nil
+}
+
+// Block decodes the block of 'rawblock' notifications.
+func (m *Message) Block() (*wire.Block, error) {
+	return wire.DecodeBlock(m.Body)
+}
+
+// Tx decodes the transaction of 'rawtx' notifications.
+func (m *Message) Tx() (*wire.Tx, error) {
+	return wire.DecodeTx(m.Body)
+}
+
+// Event decodes the payload of 'sequence' notifications.
+func (m *Message) Event() (*SequenceEvent, error) {
+	if len(m.Body) != 33 && len(m.Body) != 41 {
+		return nil, failure.Of("sequence payload must be 33 or 41 bytes long, got %d", len(m.Body))
+	}
+
+	event := &SequenceEvent{Hash: hex.EncodeToString(m.Body[:32]), Label: SequenceLabel(m.Body[32])}
+
+	switch event.Label {
+	case SequenceTxAdded, SequenceTxRemoved:
+		if len(m.Body) != 41 {
+			return nil, failure.Of("sequence payload of mempool changes must be 41 bytes long")
+		}
+		event.MempoolSequence = binary.LittleEndian.Uint64(m.Body[33:])
+	case SequenceBlockConnected, SequenceBlockDisconnected:
+	default:
+		return nil, failure.Of("unknown sequence label '%s'", event.Label)
+	}
+
+	return event, nil
+}
+
+// Subscriber is a ZMTP SUB socket connected to a single endpoint.
+type Subscriber struct {
+	Endpoint string // Endpoint the subscriber is connected to
+	conn     *conn
+}
+
+// Dial connects to an endpoint ('tcp://host:port' or 'ipc:///path/to/socket'), subscribing to the given topics.
+// With no topics, every notification published on the endpoint is received.
+func Dial(ctx context.Context, endpoint string, topics ...Topic) (*Subscriber, error) {
+	network, address, err := parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	c, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, failure.Of("failed to connect to %s: %v", endpoint, err.Error())
+	}
+
+	// Abort the handshake as soon as the context is done
+	if deadline, ok := ctx.Deadline(); ok {
+		c.SetDeadline(deadline)
+		defer c.SetDeadline(time.Time{})
+	}
+
+	z, err := handshake(c, "SUB", "PUB", "XPUB")
+	if err != nil {
+		c.Close()
+		return nil, failure.Of("failed to connect to %s: %v", endpoint, err.Error())
+	}
+
+	if len(topics) == 0 {
+		topics = []Topic{""}
+	}
+	for _, topic := range topics {
+		if err := z.send(append([]byte{subscribe}, topic...)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return &Subscriber{Endpoint: endpoint, conn: z}, nil
+}
+
+// Receive blocks until the next notification is received.
+func (s *Subscriber) Receive() (*Message, error) {
+	for {
+		parts, err := s.conn.receive()
+		if err != nil {
+			return nil, err
+		}
+
+		// Core publishes three parts: topic, payload and a 4-byte little-endian sequence number
+		if len(parts) != 3 || len(parts[2]) != 4 {
+			continue
+		}
+
+		return &Message{
+			Topic:    Topic(parts[0]),
+			Body:     parts[1],
+			Sequence: binary.LittleEndian.Uint32(parts[2]),
+		}, nil
+	}
+}
+
+// Close closes the connection.
+func (s *Subscriber) Close() error {
+	return s.conn.Close()
+}
+
+// parse splits an endpoint into the network and address to dial.
+func parse(endpoint string) (string, string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", failure.Of("invalid endpoint '%s'", endpoint)
+	}
+
+	switch parsed.Scheme {
+	case "tcp":
+		if parsed.Host == "" {
+			return "", "", failure.Of("invalid endpoint '%s': missing host", endpoint)
+		}
+		return "tcp", parsed.Host, nil
+	case "ipc":
+		path := strings.TrimPrefix(endpoint, "ipc://")
+		if path == "" {
+			return "", "", failure.Of("invalid endpoint '%s': missing path", endpoint)
+		}
+		return "unix", path, nil
+	}
+
+	return "", "", failure.Of("unsupported endpoint '%s' (must be tcp:// or ipc://)", endpoint)
+}
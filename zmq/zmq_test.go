@@ -0,0 +1,217 @@
+package zmq_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avila-r/bitclient/rpctest"
+	"github.com/avila-r/bitclient/zmq"
+)
+
+// receive returns the next notification, failing the test if none arrives in time.
+func receive(t *testing.T, notifications <-chan zmq.Notification) zmq.Notification {
+	t.Helper()
+
+	select {
+	case notification := <-notifications:
+		if notification.Err != nil {
+			t.Fatalf("Failed to receive notification: %v", notification.Err)
+		}
+		return notification
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for a notification")
+	}
+	return zmq.Notification{}
+}
+
+func Test_Listen(t *testing.T) {
+	publisher, err := zmq.NewPublisher("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	endpoints := []zmq.Endpoint{}
+	for _, topic := range []zmq.Topic{zmq.TopicRawBlock, zmq.TopicRawTx, zmq.TopicHashBlock} {
+		endpoints = append(endpoints, zmq.Endpoint{Topic: topic, Address: publisher.Endpoint()})
+	}
+
+	notifications, err := zmq.Listen(ctx, endpoints, zmq.ListenOptions{})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	publisher.WaitForSubscribers(zmq.TopicHashBlock, 1)
+
+	// Topics that aren't subscribed to aren't received
+	publisher.Publish(zmq.TopicHashTx, make([]byte, 32))
+
+	chain := rpctest.NewChain(3)
+	block := chain.Tip()
+
+	publisher.Publish(zmq.TopicRawBlock, block.Serialize())
+	notification := receive(t, notifications)
+	decoded, err := notification.Block()
+	if err != nil {
+		t.Fatalf("Failed to decode rawblock: %v", err)
+	}
+	if notification.Topic != zmq.TopicRawBlock || decoded.Hash().String() != block.Hash {
+		t.Errorf("Expected rawblock %s, got %s %s", block.Hash, notification.Topic, decoded.Hash())
+	}
+
+	publisher.Publish(zmq.TopicRawTx, block.Transactions[0])
+	notification = receive(t, notifications)
+	tx, err := notification.Tx()
+	if err != nil {
+		t.Fatalf("Failed to decode rawtx: %v", err)
+	}
+	if tx.TxID().String() != block.TxIDs[0] {
+		t.Errorf("Expected rawtx %s, got %s", block.TxIDs[0], tx.TxID())
+	}
+
+	// Gaps in sequence numbers are reported
+	hash, _ := hex.DecodeString(block.Hash)
+	publisher.Publish(zmq.TopicHashBlock, hash)
+	publisher.Skip(zmq.TopicHashBlock, 2)
+	publisher.Publish(zmq.TopicHashBlock, hash)
+
+	for i, missed := range []uint32{0, 2} {
+		notification = receive(t, notifications)
+		if notification.Missed != missed {
+			t.Errorf("Notification %v: expected %v missed notifications, got %v", i, missed, notification.Missed)
+		}
+		if received, err := notification.Hash(); err != nil || received != block.Hash {
+			t.Errorf("Expected hashblock %s, got %s (%v)", block.Hash, received, err)
+		}
+	}
+}
+
+func Test_ListenReconnect(t *testing.T) {
+	publisher, err := zmq.NewPublisher("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start publisher: %v", err)
+	}
+	address := strings.TrimPrefix(publisher.Endpoint(), "tcp://")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	endpoints := []zmq.Endpoint{{Topic: zmq.TopicHashBlock, Address: publisher.Endpoint()}}
+	notifications, err := zmq.Listen(ctx, endpoints, zmq.ListenOptions{Retry: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	publisher.WaitForSubscribers(zmq.TopicHashBlock, 1)
+	hash := make([]byte, 32)
+	publisher.Skip(zmq.TopicHashBlock, 5)
+	publisher.Publish(zmq.TopicHashBlock, hash)
+	receive(t, notifications)
+
+	// The node restarts, its sequence numbers starting over
+	publisher.Close()
+	if publisher, err = zmq.NewPublisher(address); err != nil {
+		t.Fatalf("Failed to restart publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	for notification := range notifications {
+		if notification.Err == nil {
+			t.Fatalf("Expected the disconnection to be reported, got %+v", notification)
+		}
+		break
+	}
+	publisher.WaitForSubscribers(zmq.TopicHashBlock, 1)
+	publisher.Publish(zmq.TopicHashBlock, hash)
+
+	for notification := range notifications {
+		if notification.Err != nil {
+			continue // Failed reconnection attempts
+		}
+		if notification.Sequence != 0 || notification.Missed != 0 {
+			t.Errorf("Expected sequence 0 with no missed notification after reconnecting, got %v (%v missed)", notification.Sequence, notification.Missed)
+		}
+		break
+	}
+}
+
+func Test_Event(t *testing.T) {
+	hash := make([]byte, 32)
+	hash[0] = 0xab
+
+	cases := []struct {
+		Body     []byte
+		Expected *zmq.SequenceEvent
+	}{
+		{Body: append(hash, 'C'), Expected: &zmq.SequenceEvent{Hash: hex.EncodeToString(hash), Label: zmq.SequenceBlockConnected}},
+		{Body: binary.LittleEndian.AppendUint64(append(hash, 'A'), 42), Expected: &zmq.SequenceEvent{Hash: hex.EncodeToString(hash), Label: zmq.SequenceTxAdded, MempoolSequence: 42}},
+		{Body: append(hash, 'A'), Expected: nil},
+		{Body: append(hash, 'X'), Expected: nil},
+	}
+
+	for i, test := range cases {
+		name := fmt.Sprintf("case %v", i)
+		t.Run(name, func(t *testing.T) {
+			message := zmq.Message{Topic: zmq.TopicSequence, Body: test.Body}
+			event, err := message.Event()
+
+			if test.Expected == nil {
+				if err == nil {
+					t.Errorf("Expected failure decoding %x", test.Body)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to decode sequence event: %v", err)
+			}
+			if *event != *test.Expected {
+				t.Errorf("Expected %+v, got %+v", *test.Expected, *event)
+			}
+		})
+	}
+}
+
+func Test_Notifications(t *testing.T) {
+	server := rpctest.Use(t)
+
+	server.Handle(zmq.MethodGetZmqNotifications, func(params []json.RawMessage) (any, error) {
+		return []map[string]any{
+			{"type": "pubrawtx", "address": "tcp://0.0.0.0:28333", "hwm": 1000},
+			{"type": "pubhashblock", "address": "tcp://10.0.0.5:28332", "hwm": 1000},
+		}, nil
+	})
+
+	endpoints, err := zmq.Notifications()
+	if err != nil {
+		t.Fatalf("Failed to get zmq notifications: %v", err)
+	}
+
+	host := "127.0.0.1"
+	if parsed, err := url.Parse(server.URL); err == nil {
+		host = parsed.Hostname()
+	}
+
+	expected := []zmq.Endpoint{
+		{Topic: zmq.TopicRawTx, Address: "tcp://" + host + ":28333", HighWaterMark: 1000},
+		{Topic: zmq.TopicHashBlock, Address: "tcp://10.0.0.5:28332", HighWaterMark: 1000},
+	}
+	if len(endpoints) != len(expected) {
+		t.Fatalf("Expected %v endpoints, got %v", len(expected), endpoints)
+	}
+	for i := range expected {
+		if endpoints[i] != expected[i] {
+			t.Errorf("Expected endpoint %+v, got %+v", expected[i], endpoints[i])
+		}
+	}
+}
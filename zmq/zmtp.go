@@ -0,0 +1,231 @@
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+
+	"github.com/avila-r/bitclient/failure"
+)
+
+// ZMTP 3.0 constants, as defined in https://rfc.zeromq.org/spec/23/.
+const (
+	greetingSize = 64
+
+	flagMore    = 0x01 // More frames of the same message follow
+	flagLong    = 0x02 // The frame size takes 8 bytes instead of 1
+	flagCommand = 0x04 // The frame is a command, rather than a message frame
+
+	subscribe = 0x01 // First byte of ZMTP 3.0 subscription messages
+
+	// MaxFrameSize is the largest frame accepted, well above the largest block Core can publish.
+	MaxFrameSize = 64 << 20
+)
+
+// greeting is sent first by both peers: signature, version 3.0, NULL mechanism and as-server flag (unset).
+var greeting = func() []byte {
+	g := make([]byte, greetingSize)
+	g[0], g[9] = 0xff, 0x7f // Signature
+	g[10], g[11] = 3, 0     // Version
+	copy(g[12:32], "NULL")  // Mechanism
+	return g
+}()
+
+// conn is a ZMTP connection, after the handshake.
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// handshake exchanges greetings and READY commands with the peer, announcing socketType
+// and checking the peer's socket type is one of expected.
+func handshake(c net.Conn, socketType string, expected ...string) (*conn, error) {
+	if _, err := c.Write(greeting); err != nil {
+		return nil, failure.Of("failed to send greeting: %v", err.Error())
+	}
+
+	z := &conn{Conn: c, reader: bufio.NewReader(c)}
+
+	peer := make([]byte, greetingSize)
+	if _, err := io.ReadFull(z.reader, peer); err != nil {
+		return nil, failure.Of("failed to read greeting: %v", err.Error())
+	}
+	if peer[0] != 0xff || peer[9] != 0x7f {
+		return nil, failure.Of("peer isn't a ZMTP endpoint")
+	}
+	if peer[10] < 3 {
+		return nil, failure.Of("unsupported ZMTP version %d.%d", peer[10], peer[11])
+	}
+	if mechanism := string(bytes.TrimRight(peer[12:32], "\x00")); mechanism != "NULL" {
+		return nil, failure.Of("unsupported security mechanism '%s'", mechanism)
+	}
+
+	if err := z.command("READY", map[string]string{"Socket-Type": socketType}); err != nil {
+		return nil, err
+	}
+
+	// Wait for the peer's READY, failing on ERROR
+	for {
+		body, command, _, err := z.frame()
+		if err != nil {
+			return nil, err
+		}
+		if !command {
+			return nil, failure.Of("unexpected message before handshake completion")
+		}
+
+		name, properties, err := parseCommand(body)
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "READY":
+			peerType := properties["Socket-Type"]
+			for _, t := range expected {
+				if peerType == t {
+					return z, nil
+				}
+			}
+			return nil, failure.Of("incompatible peer socket type '%s'", peerType)
+		case "ERROR":
+			reason := ""
+			if len(body) > 7 {
+				reason = string(body[7:])
+			}
+			return nil, failure.Of("peer rejected the handshake: %s", reason)
+		}
+	}
+}
+
+// command sends a command frame with the given properties.
+func (z *conn) command(name string, properties map[string]string) error {
+	body := []byte{byte(len(name))}
+	body = append(body, name...)
+	for key, value := range properties {
+		body = append(body, byte(len(key)))
+		body = append(body, key...)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(value)))
+		body = append(body, value...)
+	}
+
+	if err := z.write(flagCommand, body); err != nil {
+		return failure.Of("failed to send %s command: %v", name, err.Error())
+	}
+	return nil
+}
+
+// send sends a multipart message.
+func (z *conn) send(parts ...[]byte) error {
+	for i, part := range parts {
+		flags := byte(0)
+		if i < len(parts)-1 {
+			flags |= flagMore
+		}
+		if err := z.write(flags, part); err != nil {
+			return failure.Of("failed to send message: %v", err.Error())
+		}
+	}
+	return nil
+}
+
+// receive reads the next multipart message, skipping commands.
+func (z *conn) receive() ([][]byte, error) {
+	parts := [][]byte{}
+	for {
+		body, command, more, err := z.frame()
+		if err != nil {
+			return nil, err
+		}
+		if command {
+			continue
+		}
+
+		parts = append(parts, body)
+		if !more {
+			return parts, nil
+		}
+	}
+}
+
+// write writes a single frame.
+func (z *conn) write(flags byte, body []byte) error {
+	header := []byte{flags}
+	if len(body) > 255 {
+		header[0] |= flagLong
+		header = binary.BigEndian.AppendUint64(header, uint64(len(body)))
+	} else {
+		header = append(header, byte(len(body)))
+	}
+
+	_, err := z.Write(append(header, body...))
+	return err
+}
+
+// frame reads a single frame.
+func (z *conn) frame() (body []byte, command bool, more bool, err error) {
+	flags, err := z.reader.ReadByte()
+	if err != nil {
+		return nil, false, false, failure.Of("failed to read frame: %v", err.Error())
+	}
+
+	size := uint64(0)
+	if flags&flagLong != 0 {
+		data := make([]byte, 8)
+		if _, err := io.ReadFull(z.reader, data); err != nil {
+			return nil, false, false, failure.Of("failed to read frame size: %v", err.Error())
+		}
+		size = binary.BigEndian.Uint64(data)
+	} else {
+		short, err := z.reader.ReadByte()
+		if err != nil {
+			return nil, false, false, failure.Of("failed to read frame size: %v", err.Error())
+		}
+		size = uint64(short)
+	}
+
+	if size > MaxFrameSize {
+		return nil, false, false, failure.Of("frame of %d bytes exceeds the %d bytes limit", size, MaxFrameSize)
+	}
+
+	body = make([]byte, size)
+	if _, err := io.ReadFull(z.reader, body); err != nil {
+		return nil, false, false, failure.Of("failed to read frame: %v", err.Error())
+	}
+
+	return body, flags&flagCommand != 0, flags&flagMore != 0, nil
+}
+
+// parseCommand splits a command body into its name and properties.
+func parseCommand(body []byte) (string, map[string]string, error) {
+	if len(body) == 0 || len(body) < 1+int(body[0]) {
+		return "", nil, failure.Of("malformed command")
+	}
+
+	size := int(body[0])
+	name, rest := string(body[1:1+size]), body[1+size:]
+
+	properties := map[string]string{}
+	if name != "READY" {
+		return name, properties, nil
+	}
+
+	for len(rest) > 0 {
+		keySize := int(rest[0])
+		if len(rest) < 1+keySize+4 {
+			return "", nil, failure.Of("malformed %s property", name)
+		}
+		key := string(rest[1 : 1+keySize])
+		valueSize := int(binary.BigEndian.Uint32(rest[1+keySize:]))
+		rest = rest[1+keySize+4:]
+		if len(rest) < valueSize {
+			return "", nil, failure.Of("malformed %s property", name)
+		}
+		properties[key] = string(rest[:valueSize])
+		rest = rest[valueSize:]
+	}
+
+	return name, properties, nil
+}